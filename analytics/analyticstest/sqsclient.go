@@ -18,6 +18,9 @@ import (
 //             SendMessageFunc: func(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
 // 	               panic("mock out the SendMessage method")
 //             },
+//             SendMessageBatchFunc: func(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+// 	               panic("mock out the SendMessageBatch method")
+//             },
 //         }
 //
 //         // use mockedSQSClient in code that requires analytics.SQSClient
@@ -28,6 +31,9 @@ type SQSClientMock struct {
 	// SendMessageFunc mocks the SendMessage method.
 	SendMessageFunc func(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
 
+	// SendMessageBatchFunc mocks the SendMessageBatch method.
+	SendMessageBatchFunc func(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// SendMessage holds details about calls to the SendMessage method.
@@ -39,8 +45,18 @@ type SQSClientMock struct {
 			// OptFns is the optFns argument value.
 			OptFns []func(*sqs.Options)
 		}
+		// SendMessageBatch holds details about calls to the SendMessageBatch method.
+		SendMessageBatch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params *sqs.SendMessageBatchInput
+			// OptFns is the optFns argument value.
+			OptFns []func(*sqs.Options)
+		}
 	}
-	lockSendMessage sync.RWMutex
+	lockSendMessage      sync.RWMutex
+	lockSendMessageBatch sync.RWMutex
 }
 
 // SendMessage calls SendMessageFunc.
@@ -81,3 +97,42 @@ func (mock *SQSClientMock) SendMessageCalls() []struct {
 	mock.lockSendMessage.RUnlock()
 	return calls
 }
+
+// SendMessageBatch calls SendMessageBatchFunc.
+func (mock *SQSClientMock) SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	if mock.SendMessageBatchFunc == nil {
+		panic("SQSClientMock.SendMessageBatchFunc: method is nil but SQSClient.SendMessageBatch was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params *sqs.SendMessageBatchInput
+		OptFns []func(*sqs.Options)
+	}{
+		Ctx:    ctx,
+		Params: params,
+		OptFns: optFns,
+	}
+	mock.lockSendMessageBatch.Lock()
+	mock.calls.SendMessageBatch = append(mock.calls.SendMessageBatch, callInfo)
+	mock.lockSendMessageBatch.Unlock()
+	return mock.SendMessageBatchFunc(ctx, params, optFns...)
+}
+
+// SendMessageBatchCalls gets all the calls that were made to SendMessageBatch.
+// Check the length with:
+//     len(mockedSQSClient.SendMessageBatchCalls())
+func (mock *SQSClientMock) SendMessageBatchCalls() []struct {
+	Ctx    context.Context
+	Params *sqs.SendMessageBatchInput
+	OptFns []func(*sqs.Options)
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params *sqs.SendMessageBatchInput
+		OptFns []func(*sqs.Options)
+	}
+	mock.lockSendMessageBatch.RLock()
+	calls = mock.calls.SendMessageBatch
+	mock.lockSendMessageBatch.RUnlock()
+	return calls
+}
@@ -0,0 +1,230 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+var _ ServiceBackend = &AsyncBackend{}
+
+// Reasons that analytics data is dropped by AsyncBackend
+const (
+	dropQueueFull = "queue_full"
+	dropClosed    = "closed"
+	dropFailed    = "failed"
+)
+
+// AsyncMetrics counts the analytics data dropped by each AsyncBackend. One AsyncMetrics is shared by all async
+// backends, which label their counts with the name of the backend they store data in.
+type AsyncMetrics struct {
+	dropped *metrics.CounterVec
+}
+
+// NewAsyncMetrics creates AsyncMetrics, registering its counters with registry if it is not nil
+func NewAsyncMetrics(registry *metrics.Registry) *AsyncMetrics {
+	m := &AsyncMetrics{
+		dropped: metrics.NewCounterVec("dp_frontend_router_analytics_dropped_total",
+			"Analytics events that were not stored, as the queue was full or closed, or they failed to store after retrying.",
+			"backend", "reason"),
+	}
+	if registry != nil {
+		registry.Register(m.dropped)
+	}
+	return m
+}
+
+// AsyncBackend stores analytics data on a pool of background workers, so that the request is not held up waiting on the
+// backend. Data is queued for the workers, and dropped if the queue is full. Each store is detached from the
+// cancellation of the request, has its own timeout, and is retried on failure.
+type AsyncBackend struct {
+	backend      ServiceBackend
+	queue        chan asyncStore
+	maxRetries   int
+	timeout      time.Duration
+	retryBackoff time.Duration
+	dedup        *DedupCache
+	spool        *Spool
+	metrics      *AsyncMetrics
+	name         string
+
+	// mu guards closed, so that data is not queued once the queue has been closed
+	mu      sync.RWMutex
+	closed  bool
+	pending sync.WaitGroup
+	workers sync.WaitGroup
+}
+
+// asyncStore is an event queued to be stored
+type asyncStore struct {
+	ctx   context.Context
+	key   string
+	event Event
+}
+
+// NewAsyncBackend creates an AsyncBackend with workers storing data at once, queueing up to queueSize stores for them.
+// Data that arrives while the queue is full is dropped.
+func NewAsyncBackend(backend ServiceBackend, workers, queueSize, maxRetries int, timeout time.Duration) *AsyncBackend {
+	b := &AsyncBackend{
+		backend:      backend,
+		queue:        make(chan asyncStore, queueSize),
+		maxRetries:   maxRetries,
+		timeout:      timeout,
+		retryBackoff: 100 * time.Millisecond,
+		metrics:      NewAsyncMetrics(nil),
+	}
+
+	b.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go b.work()
+	}
+	return b
+}
+
+// WithDedup skips storing events that have already been sent within the TTL of dedup, which may be shared with other
+// backends
+func (b *AsyncBackend) WithDedup(dedup *DedupCache) *AsyncBackend {
+	b.dedup = dedup
+	return b
+}
+
+// WithSpool keeps data that failed to store after retrying in spool, which sends it again later, rather than dropping
+// it. Data is still dropped if the spool is full. The spool is closed when the backend is.
+func (b *AsyncBackend) WithSpool(spool *Spool) *AsyncBackend {
+	b.spool = spool
+	return b
+}
+
+// WithMetrics counts the analytics events dropped by the backend in m, labelled with name
+func (b *AsyncBackend) WithMetrics(m *AsyncMetrics, name string) *AsyncBackend {
+	b.metrics = m
+	b.name = name
+	return b
+}
+
+// Store queues the event to be stored in the background and returns immediately, returning an error if it was dropped
+// as the queue is full or closed. Errors storing the event in the background are retried, and logged if it still fails.
+func (b *AsyncBackend) Store(ctx context.Context, event Event) error {
+	var key string
+	if b.dedup != nil {
+		key = idempotencyKey(ctx, event)
+		if !b.dedup.claim(key) {
+			log.Info(ctx, "skipping duplicate analytics data", log.Data{"url": event.URL})
+			return nil
+		}
+	}
+
+	// keep the request values, such as the request id and trace, but not its cancellation, and when the event was
+	// queued rather than when a worker gets to it
+	if event.Created == "" {
+		event.Created = time.Now().Format(time.RFC3339)
+	}
+	s := asyncStore{ctx: context.WithoutCancel(ctx), key: key, event: event}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		b.drop(dropClosed, key)
+		return errors.New("dropping analytics data as the router is shutting down")
+	}
+
+	b.pending.Add(1)
+	select {
+	case b.queue <- s:
+		return nil
+	default:
+		b.pending.Done()
+		b.drop(dropQueueFull, key)
+		return errors.New("dropping analytics data as the queue is full")
+	}
+}
+
+// Wait blocks until all queued stores have completed
+func (b *AsyncBackend) Wait() {
+	b.pending.Wait()
+}
+
+// Close stops queueing data and blocks until the workers have stored the data already queued, then closes the spool
+// if there is one. Data the workers spooled is left in the spool's file, to be sent once the router starts again.
+func (b *AsyncBackend) Close() error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	b.workers.Wait()
+	if b.spool != nil {
+		return b.spool.Close()
+	}
+	return nil
+}
+
+// work stores queued data until the queue is closed
+func (b *AsyncBackend) work() {
+	defer b.workers.Done()
+	for s := range b.queue {
+		ctx, cancel := context.WithTimeout(s.ctx, b.timeout)
+		if !b.storeWithRetries(ctx, s.event) && !b.spoolStore(s) {
+			b.drop(dropFailed, s.key)
+		}
+		cancel()
+		b.pending.Done()
+	}
+}
+
+// spoolStore keeps data that failed to store in the spool, if there is one, returning whether it was spooled
+func (b *AsyncBackend) spoolStore(s asyncStore) bool {
+	if b.spool == nil {
+		return false
+	}
+	spooled := b.spool.add(s.ctx, s.event)
+	if spooled {
+		log.Info(s.ctx, "spooled analytics data to send again later", log.Data{"url": s.event.URL})
+	}
+	return spooled
+}
+
+// drop counts an event that was not stored and releases its claim
+func (b *AsyncBackend) drop(reason, key string) {
+	b.metrics.dropped.Inc(b.name, reason)
+	b.release(key)
+}
+
+// release forgets a claimed event that was not stored, so that it can be sent again
+func (b *AsyncBackend) release(key string) {
+	if b.dedup != nil {
+		b.dedup.release(key)
+	}
+}
+
+// storeWithRetries stores the event, retrying on failure, and returns whether it was stored
+func (b *AsyncBackend) storeWithRetries(ctx context.Context, event Event) bool {
+	backoff := b.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := b.backend.Store(ctx, event)
+		if err == nil {
+			return true
+		}
+
+		logData := log.Data{"attempt": attempt + 1, "url": event.URL}
+		if attempt >= b.maxRetries {
+			log.Error(ctx, "failed to store analytics data, giving up", err, logData)
+			return false
+		}
+		log.Warn(ctx, "failed to store analytics data, retrying", logData)
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			log.Error(ctx, "timed out storing analytics data", ctx.Err(), logData)
+			return false
+		}
+	}
+}
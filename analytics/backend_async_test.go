@@ -0,0 +1,191 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeFlakyBackend fails the first failures attempts, and blocks each attempt until release is closed, signalling
+// started, if set, as each attempt begins
+type fakeFlakyBackend struct {
+	mu       sync.Mutex
+	attempts int
+	failures int
+	started  chan struct{}
+	release  chan struct{}
+}
+
+func (f *fakeFlakyBackend) Store(ctx context.Context, event Event) error {
+	if f.started != nil {
+		f.started <- struct{}{}
+	}
+	<-f.release
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("sqs unavailable")
+	}
+	return nil
+}
+
+func (f *fakeFlakyBackend) Attempts() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attempts
+}
+
+func TestAsyncBackend(t *testing.T) {
+	Convey("Given an async backend wrapping a backend that fails once", t, func() {
+		inner := &fakeFlakyBackend{failures: 1, started: make(chan struct{}, 10), release: make(chan struct{})}
+		backend := NewAsyncBackend(inner, 1, 1, 3, time.Second).WithMetrics(NewAsyncMetrics(nil), "sqs")
+		backend.retryBackoff = time.Millisecond
+
+		Convey("When data is stored for a request that is then cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			req := httptest.NewRequest(http.MethodGet, "/redir/abc", http.NoBody).WithContext(ctx)
+
+			returned := make(chan struct{})
+			go func() {
+				backend.Store(req.Context(), gdpEvent)
+				close(returned)
+			}()
+
+			Convey("Then Store returns before the backend has completed", func() {
+				select {
+				case <-returned:
+				case <-time.After(time.Second):
+					t.Fatal("Store blocked waiting on the backend")
+				}
+				So(inner.Attempts(), ShouldEqual, 0)
+
+				Convey("And the store is retried until it succeeds, despite the request being cancelled", func() {
+					cancel()
+					close(inner.release)
+					backend.Wait()
+					So(inner.Attempts(), ShouldEqual, 2)
+				})
+			})
+		})
+
+		Convey("When more stores are made than can be queued while the worker is busy", func() {
+			req := httptest.NewRequest(http.MethodGet, "/redir/abc", http.NoBody)
+			backend.Store(req.Context(), Event{URL: "/first"})
+			<-inner.started
+			backend.Store(req.Context(), Event{URL: "/second"})
+			So(backend.Store(req.Context(), Event{URL: "/third"}), ShouldBeError, "dropping analytics data as the queue is full")
+			close(inner.release)
+			backend.Wait()
+
+			Convey("Then the excess data is dropped and counted", func() {
+				So(inner.Attempts(), ShouldEqual, 3) // the first store, failing once and then retried, and the second
+				So(backend.metrics.dropped.Value("sqs", dropQueueFull), ShouldEqual, 1)
+				So(backend.metrics.dropped.Value("sqs", dropFailed), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the backend is closed with data queued", func() {
+			req := httptest.NewRequest(http.MethodGet, "/redir/abc", http.NoBody)
+			backend.Store(req.Context(), Event{URL: "/first"})
+			close(inner.release)
+			So(backend.Close(), ShouldBeNil)
+
+			Convey("Then the queued data is stored before Close returns", func() {
+				So(inner.Attempts(), ShouldEqual, 2)
+			})
+
+			Convey("Then data stored afterwards is dropped", func() {
+				backend.Store(req.Context(), Event{URL: "/second"})
+				So(inner.Attempts(), ShouldEqual, 2)
+				So(backend.metrics.dropped.Value("sqs", dropClosed), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When data cannot be stored within the retries", func() {
+			inner.failures = 10
+			req := httptest.NewRequest(http.MethodGet, "/redir/abc", http.NoBody)
+			backend.Store(req.Context(), Event{URL: "/first"})
+			close(inner.release)
+			backend.Wait()
+
+			Convey("Then it is counted as dropped", func() {
+				So(inner.Attempts(), ShouldEqual, 4)
+				So(backend.metrics.dropped.Value("sqs", dropFailed), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestAsyncBackendDedup(t *testing.T) {
+	Convey("Given a pool of async backends sharing a dedup cache", t, func() {
+		inner := &fakeFlakyBackend{release: make(chan struct{})}
+		close(inner.release)
+		dedup := NewDedupCache(100, time.Minute)
+		pool := []*AsyncBackend{
+			NewAsyncBackend(inner, 10, 100, 0, time.Second).WithDedup(dedup),
+			NewAsyncBackend(inner, 10, 100, 0, time.Second).WithDedup(dedup),
+			NewAsyncBackend(inner, 10, 100, 0, time.Second).WithDedup(dedup),
+		}
+
+		Convey("When workers concurrently store the same event from the same client", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 30; i++ {
+				wg.Add(1)
+				go func(backend *AsyncBackend) {
+					defer wg.Done()
+					backend.Store(withClientIP(context.Background(), "192.0.2.1"), gdpEvent)
+				}(pool[i%len(pool)])
+			}
+			wg.Wait()
+			for _, backend := range pool {
+				backend.Wait()
+			}
+
+			Convey("Then the event is stored once", func() {
+				So(inner.Attempts(), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When events differ in their data or client", func() {
+			ctx, other := withClientIP(context.Background(), "192.0.2.1"), withClientIP(context.Background(), "10.0.0.2")
+			differentData := gdpEvent
+			differentData.LinkIndex = 3
+			pool[0].Store(ctx, gdpEvent)
+			pool[1].Store(ctx, differentData)
+			pool[2].Store(other, gdpEvent)
+			for _, backend := range pool {
+				backend.Wait()
+			}
+
+			Convey("Then each event is stored", func() {
+				So(inner.Attempts(), ShouldEqual, 3)
+			})
+		})
+	})
+
+	Convey("Given an async backend with a dedup cache, wrapping a backend that fails once", t, func() {
+		inner := &fakeFlakyBackend{failures: 1, release: make(chan struct{})}
+		close(inner.release)
+		backend := NewAsyncBackend(inner, 10, 100, 0, time.Second).WithDedup(NewDedupCache(100, time.Minute))
+		req := httptest.NewRequest(http.MethodGet, "/redir/abc", http.NoBody)
+
+		Convey("When an event that failed to store is sent again", func() {
+			backend.Store(req.Context(), gdpEvent)
+			backend.Wait()
+			backend.Store(req.Context(), gdpEvent)
+			backend.Wait()
+
+			Convey("Then it is not treated as a duplicate", func() {
+				So(inner.Attempts(), ShouldEqual, 2)
+			})
+		})
+	})
+}
@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var _ ServiceBackend = &FanOutBackend{}
+
+// FanOutBackend stores analytics data in each of a number of backends, such as both SQS and Kafka while migrating
+// between them. A failing backend does not stop the data reaching the others. The backends are called in turn, so
+// should be async backends if one may be slow.
+type FanOutBackend struct {
+	backends []ServiceBackend
+}
+
+// NewFanOutBackend creates a FanOutBackend storing analytics data in every one of backends
+func NewFanOutBackend(backends ...ServiceBackend) *FanOutBackend {
+	return &FanOutBackend{backends: backends}
+}
+
+// Store stores the event in each backend, returning the errors from any that failed
+func (b *FanOutBackend) Store(ctx context.Context, event Event) error {
+	var errs []error
+	for _, backend := range b.backends {
+		errs = append(errs, storeIsolated(ctx, backend, event))
+	}
+	return errors.Join(errs...)
+}
+
+// storeIsolated stores the event in backend, recovering from any panic as an error, so that the other backends still get
+// the event
+func storeIsolated(ctx context.Context, backend ServiceBackend, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("analytics backend %T panicked: %v", backend, r)
+		}
+	}()
+	return backend.Store(ctx, event)
+}
+
+// Close closes each backend that holds data to store later, returning any errors closing them
+func (b *FanOutBackend) Close() error {
+	var errs []error
+	for _, backend := range b.backends {
+		if closer, ok := backend.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeBackend records the URLs stored in it, panicking instead if panics is set, and returns closeErr when closed
+type fakeBackend struct {
+	urls     []string
+	panics   bool
+	closed   bool
+	closeErr error
+}
+
+func (f *fakeBackend) Store(ctx context.Context, event Event) error {
+	if f.panics {
+		panic("sink unavailable")
+	}
+	f.urls = append(f.urls, event.URL)
+	return nil
+}
+
+func (f *fakeBackend) Close() error {
+	f.closed = true
+	return f.closeErr
+}
+
+func TestFanOutBackend(t *testing.T) {
+	Convey("Given a fan-out backend over several backends", t, func() {
+		sqs, kafka, firehose := &fakeBackend{}, &fakeBackend{}, &fakeBackend{}
+		backend := NewFanOutBackend(sqs, kafka, firehose)
+		req := httptest.NewRequest(http.MethodGet, "/redir/abc", http.NoBody)
+
+		Convey("When data is stored", func() {
+			backend.Store(req.Context(), gdpEvent)
+
+			Convey("Then it is stored in every backend", func() {
+				So(sqs.urls, ShouldResemble, []string{"/economy"})
+				So(kafka.urls, ShouldResemble, []string{"/economy"})
+				So(firehose.urls, ShouldResemble, []string{"/economy"})
+			})
+		})
+
+		Convey("When one of the backends panics", func() {
+			kafka.panics = true
+			var err error
+			So(func() { err = backend.Store(req.Context(), gdpEvent) }, ShouldNotPanic)
+
+			Convey("Then the panic is returned as an error, and the data is still stored in the others", func() {
+				So(err, ShouldNotBeNil)
+				So(sqs.urls, ShouldResemble, []string{"/economy"})
+				So(firehose.urls, ShouldResemble, []string{"/economy"})
+			})
+		})
+
+		Convey("When it is closed", func() {
+			kafka.closeErr = errors.New("flush failed")
+			err := backend.Close()
+
+			Convey("Then every backend is closed, and errors closing them returned", func() {
+				So(sqs.closed, ShouldBeTrue)
+				So(kafka.closed, ShouldBeTrue)
+				So(firehose.closed, ShouldBeTrue)
+				So(err, ShouldBeError, "flush failed")
+			})
+		})
+	})
+}
@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var _ ServiceBackend = &fileBackend{}
+
+// StdoutPath is the path given to NewFileBackend to write analytics data to stdout rather than a file
+const StdoutPath = "-"
+
+// fileBackend writes analytics data as JSON lines, for developing and testing the analytics flow without any cloud
+// services
+type fileBackend struct {
+	mu          sync.Mutex
+	w           io.Writer
+	fieldLimits FieldLimits
+}
+
+// NewFileBackend creates a new backend that appends analytics data to the file at path as JSON lines, or writes it to
+// stdout if path is StdoutPath, truncating string fields to fieldLimits
+func NewFileBackend(path string, fieldLimits FieldLimits) (ServiceBackend, error) {
+	if path == StdoutPath {
+		return &fileBackend{w: os.Stdout, fieldLimits: fieldLimits}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening analytics file")
+	}
+	return &fileBackend{w: f, fieldLimits: fieldLimits}, nil
+}
+
+// Store writes the event as a single line, returning any error so that the write can be retried
+func (b *fileBackend) Store(ctx context.Context, event Event) error {
+	jb, err := marshalMessage(ctx, b.fieldLimits, event)
+	if err != nil {
+		return err
+	}
+
+	// lines are written whole, so that concurrent requests do not interleave
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, err := b.w.Write(append(jb, '\n')); err != nil {
+		return errors.Wrap(err, "error writing analytics data")
+	}
+	return nil
+}
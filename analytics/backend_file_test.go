@@ -0,0 +1,78 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFileBackend(t *testing.T) {
+	Convey("File backend initialises without error", t, func() {
+		backend, err := NewFileBackend(filepath.Join(t.TempDir(), "analytics.jsonl"), FieldLimits{})
+		So(err, ShouldBeNil)
+		So(backend, ShouldNotBeNil)
+
+		backend, err = NewFileBackend(StdoutPath, FieldLimits{})
+		So(err, ShouldBeNil)
+		So(backend, ShouldNotBeNil)
+	})
+
+	Convey("File backend returns an error if the file cannot be opened", t, func() {
+		_, err := NewFileBackend(filepath.Join(t.TempDir(), "missing", "analytics.jsonl"), FieldLimits{})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("File backend should write the right data as JSON lines", t, func() {
+		var buf bytes.Buffer
+		fileBackend := &fileBackend{w: &buf, fieldLimits: FieldLimits{Term: 4}}
+
+		fakeReq, err := http.NewRequest("GET", "/", http.NoBody)
+		So(err, ShouldBeNil)
+
+		fileBackend.Store(fakeReq.Context(), testEvent)
+		fileBackend.Store(fakeReq.Context(), Event{
+			URL: "/other/url", Term: "term", ListType: "list type", GAID: "gaID", GID: "gID", PageIndex: 1, LinkIndex: 2, PageSize: 3,
+		})
+
+		lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+		So(lines, ShouldHaveLength, 2)
+
+		var input map[string]interface{}
+		err = json.Unmarshal(lines[0], &input)
+		So(err, ShouldBeNil)
+		So(input["url"], ShouldEqual, "/some/url")
+		So(input["term"], ShouldEqual, "some")
+		So(input["listType"], ShouldEqual, "list type")
+		So(input["gaID"], ShouldEqual, "gaID")
+		So(input["gID"], ShouldEqual, "gID")
+		So(input["pageIndex"], ShouldEqual, 10)
+		So(input["linkIndex"], ShouldEqual, 20)
+		So(input["pageSize"], ShouldEqual, 30)
+		So(input[truncatedField], ShouldResemble, []interface{}{"term"})
+
+		err = json.Unmarshal(lines[1], &input)
+		So(err, ShouldBeNil)
+		So(input["url"], ShouldEqual, "/other/url")
+	})
+
+	Convey("File backend appends to an existing file", t, func() {
+		path := filepath.Join(t.TempDir(), "analytics.jsonl")
+		So(os.WriteFile(path, []byte("{}\n"), 0o600), ShouldBeNil)
+
+		backend, err := NewFileBackend(path, FieldLimits{})
+		So(err, ShouldBeNil)
+		err = backend.Store(context.Background(), testEvent)
+		So(err, ShouldBeNil)
+
+		b, err := os.ReadFile(path)
+		So(err, ShouldBeNil)
+		So(bytes.Count(b, []byte("\n")), ShouldEqual, 2)
+		So(string(b), ShouldStartWith, "{}\n")
+	})
+}
@@ -0,0 +1,61 @@
+package analytics
+
+import (
+	"context"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	"github.com/pkg/errors"
+)
+
+var _ ServiceBackend = &firehoseBackend{}
+
+//go:generate moq -out analyticstest/firehoseclient.go -pkg analyticstest . FirehoseClient
+type FirehoseClient interface {
+	PutRecord(ctx context.Context, params *firehose.PutRecordInput, optFns ...func(*firehose.Options)) (*firehose.PutRecordOutput, error)
+}
+
+type firehoseBackend struct {
+	firehoseClient FirehoseClient
+	streamName     string
+	fieldLimits    FieldLimits
+}
+
+// NewFirehoseBackend creates a new Kinesis Firehose backend for storing analytics data, truncating string fields to
+// fieldLimits
+func NewFirehoseBackend(ctx context.Context, streamName string, fieldLimits FieldLimits) (ServiceBackend, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &firehoseBackend{
+		firehoseClient: firehose.NewFromConfig(cfg),
+		streamName:     streamName,
+		fieldLimits:    fieldLimits,
+	}, nil
+}
+
+// Store puts the event on the Firehose delivery stream, returning any error so that the put can be retried. Each record
+// ends in a newline, as Firehose concatenates records into the objects it delivers to S3.
+func (b *firehoseBackend) Store(ctx context.Context, event Event) error {
+	jb, err := marshalMessage(ctx, b.fieldLimits, event)
+	if err != nil {
+		return err
+	}
+
+	pri := &firehose.PutRecordInput{
+		DeliveryStreamName: &b.streamName,
+		Record:             &types.Record{Data: append(jb, '\n')},
+	}
+
+	pro, err := b.firehoseClient.PutRecord(ctx, pri)
+	if err != nil {
+		return errors.Wrap(err, "error putting firehose record")
+	}
+
+	log.Info(ctx, "stored analytics data in Firehose", log.Data{"record_id": *pro.RecordId})
+	return nil
+}
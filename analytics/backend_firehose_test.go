@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/analytics/analyticstest"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFirehoseBackend(t *testing.T) {
+	Convey("Firehose backend initialises without error", t, func() {
+		backend, err := NewFirehoseBackend(context.Background(), "search-analytics", FieldLimits{})
+		So(err, ShouldBeNil)
+		So(backend, ShouldNotBeNil)
+	})
+
+	Convey("Firehose backend should put the right data", t, func() {
+		mockFirehoseClient := &analyticstest.FirehoseClientMock{
+			PutRecordFunc: func(ctx context.Context, params *firehose.PutRecordInput, optFns ...func(*firehose.Options)) (*firehose.PutRecordOutput, error) {
+				recordID := "test-record-id"
+				return &firehose.PutRecordOutput{RecordId: &recordID}, nil
+			},
+		}
+		firehoseBackend := &firehoseBackend{
+			firehoseClient: mockFirehoseClient,
+			streamName:     "search-analytics",
+			fieldLimits:    FieldLimits{Term: 4},
+		}
+
+		fakeReq, err := http.NewRequest("GET", "/", http.NoBody)
+		So(err, ShouldBeNil)
+
+		firehoseBackend.Store(fakeReq.Context(), testEvent)
+		So(mockFirehoseClient.PutRecordCalls(), ShouldHaveLength, 1)
+		requestParams := mockFirehoseClient.PutRecordCalls()[0].Params
+		So(*requestParams.DeliveryStreamName, ShouldEqual, "search-analytics")
+
+		data := requestParams.Record.Data
+		So(bytes.HasSuffix(data, []byte("\n")), ShouldBeTrue)
+
+		var input map[string]interface{}
+		err = json.Unmarshal(data, &input)
+		So(err, ShouldBeNil)
+		So(input["url"], ShouldEqual, "/some/url")
+		So(input["term"], ShouldEqual, "some")
+		So(input["listType"], ShouldEqual, "list type")
+		So(input["gaID"], ShouldEqual, "gaID")
+		So(input["gID"], ShouldEqual, "gID")
+		So(input["pageIndex"], ShouldEqual, 10)
+		So(input["linkIndex"], ShouldEqual, 20)
+		So(input["pageSize"], ShouldEqual, 30)
+		So(input[truncatedField], ShouldResemble, []interface{}{"term"})
+	})
+
+	Convey("Firehose backend returns errors putting data so that they can be retried", t, func() {
+		mockFirehoseClient := &analyticstest.FirehoseClientMock{
+			PutRecordFunc: func(ctx context.Context, params *firehose.PutRecordInput, optFns ...func(*firehose.Options)) (*firehose.PutRecordOutput, error) {
+				return nil, errors.New("service unavailable")
+			},
+		}
+		firehoseBackend := &firehoseBackend{firehoseClient: mockFirehoseClient, streamName: "search-analytics"}
+
+		err := firehoseBackend.Store(context.Background(), testEvent)
+		So(err, ShouldNotBeNil)
+	})
+}
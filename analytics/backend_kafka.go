@@ -0,0 +1,102 @@
+package analytics
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+var _ ServiceBackend = &kafkaBackend{}
+
+// kafkaBatchTimeout is how long a write waits for other messages to batch with. Writes are synchronous, so it is kept
+// short rather than the kafka-go default of a second, to not hold up the request or async worker storing the data.
+const kafkaBatchTimeout = 10 * time.Millisecond
+
+//go:generate moq -out analyticstest/kafkawriter.go -pkg analyticstest . KafkaWriter
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// KafkaConfig is the Kafka cluster and topic that analytics data is produced to
+type KafkaConfig struct {
+	Brokers    []string
+	Topic      string
+	TLSEnabled bool
+	// SASLMechanism is PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, or empty if the cluster does not authenticate clients
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+}
+
+type kafkaBackend struct {
+	writer      KafkaWriter
+	topic       string
+	fieldLimits FieldLimits
+}
+
+// NewKafkaBackend creates a new Kafka backend for storing analytics data, truncating string fields to fieldLimits
+func NewKafkaBackend(cfg KafkaConfig, fieldLimits FieldLimits) (ServiceBackend, error) {
+	if len(cfg.Brokers) == 0 || cfg.Topic == "" {
+		return nil, errors.New("kafka brokers and topic are required")
+	}
+
+	mechanism, err := saslMechanism(cfg.SASLMechanism, cfg.SASLUsername, cfg.SASLPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &kafka.Transport{SASL: mechanism}
+	if cfg.TLSEnabled {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: kafkaBatchTimeout,
+		RequiredAcks: kafka.RequireAll,
+		Transport:    transport,
+	}
+	return &kafkaBackend{writer: writer, topic: cfg.Topic, fieldLimits: fieldLimits}, nil
+}
+
+// saslMechanism returns the named SASL mechanism authenticating as username, or nil if name is empty
+func saslMechanism(name, username, password string) (sasl.Mechanism, error) {
+	switch strings.ToUpper(name) {
+	case "":
+		return nil, nil
+	case "PLAIN":
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, username, password)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unsupported kafka SASL mechanism %q", name)
+	}
+}
+
+// Store produces the event to Kafka, returning any error so that the send can be retried
+func (b *kafkaBackend) Store(ctx context.Context, event Event) error {
+	jb, err := marshalMessage(ctx, b.fieldLimits, event)
+	if err != nil {
+		return err
+	}
+
+	if err := b.writer.WriteMessages(ctx, kafka.Message{Value: jb}); err != nil {
+		return errors.Wrap(err, "error producing kafka message")
+	}
+
+	log.Info(ctx, "stored analytics data in Kafka", log.Data{"topic": b.topic})
+	return nil
+}
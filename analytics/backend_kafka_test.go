@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/analytics/analyticstest"
+	"github.com/segmentio/kafka-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKafkaBackend(t *testing.T) {
+	Convey("Kafka backend initialises without error", t, func() {
+		backend, err := NewKafkaBackend(KafkaConfig{
+			Brokers:       []string{"localhost:9092"},
+			Topic:         "analytics",
+			TLSEnabled:    true,
+			SASLMechanism: "scram-sha-512",
+			SASLUsername:  "router",
+			SASLPassword:  "secret",
+		}, FieldLimits{})
+		So(err, ShouldBeNil)
+		So(backend, ShouldNotBeNil)
+	})
+
+	Convey("Kafka backend rejects incomplete or unsupported config", t, func() {
+		_, err := NewKafkaBackend(KafkaConfig{Topic: "analytics"}, FieldLimits{})
+		So(err, ShouldNotBeNil)
+
+		_, err = NewKafkaBackend(KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "analytics", SASLMechanism: "GSSAPI"}, FieldLimits{})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Kafka backend should produce the right data", t, func() {
+		mockWriter := &analyticstest.KafkaWriterMock{
+			WriteMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				return nil
+			},
+		}
+		kafkaBackend := &kafkaBackend{writer: mockWriter, topic: "analytics", fieldLimits: FieldLimits{Term: 4}}
+
+		fakeReq, err := http.NewRequest("GET", "/", http.NoBody)
+		So(err, ShouldBeNil)
+
+		kafkaBackend.Store(fakeReq.Context(), testEvent)
+		So(mockWriter.WriteMessagesCalls(), ShouldHaveLength, 1)
+		So(mockWriter.WriteMessagesCalls()[0].Msgs, ShouldHaveLength, 1)
+
+		var input map[string]interface{}
+		err = json.Unmarshal(mockWriter.WriteMessagesCalls()[0].Msgs[0].Value, &input)
+		So(err, ShouldBeNil)
+		So(input["url"], ShouldEqual, "/some/url")
+		So(input["term"], ShouldEqual, "some")
+		So(input["listType"], ShouldEqual, "list type")
+		So(input["gaID"], ShouldEqual, "gaID")
+		So(input["gID"], ShouldEqual, "gID")
+		So(input["pageIndex"], ShouldEqual, 10)
+		So(input["linkIndex"], ShouldEqual, 20)
+		So(input["pageSize"], ShouldEqual, 30)
+		So(input[truncatedField], ShouldResemble, []interface{}{"term"})
+	})
+
+	Convey("Kafka backend returns errors producing data so that they can be retried", t, func() {
+		mockWriter := &analyticstest.KafkaWriterMock{
+			WriteMessagesFunc: func(ctx context.Context, msgs ...kafka.Message) error {
+				return errors.New("leader not available")
+			},
+		}
+		kafkaBackend := &kafkaBackend{writer: mockWriter, topic: "analytics"}
+
+		err := kafkaBackend.Store(context.Background(), testEvent)
+		So(err, ShouldNotBeNil)
+	})
+}
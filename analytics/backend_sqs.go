@@ -2,29 +2,33 @@ package analytics
 
 import (
 	"context"
-	"encoding/json"
-	"net/http"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/pkg/errors"
 )
 
 var _ ServiceBackend = &sqsBackend{}
+var _ ServiceBackend = &listTypeSQSBackend{}
 
 //go:generate moq -out analyticstest/sqsclient.go -pkg analyticstest . SQSClient
 type SQSClient interface {
 	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
 }
 
 type sqsBackend struct {
-	sqsClient SQSClient
-	queueURL  string
+	sqsClient   SQSClient
+	queueURL    string
+	fieldLimits FieldLimits
 }
 
-// NewSQSBackend creates a new SQS backend for storing analytics data
-func NewSQSBackend(ctx context.Context, queueURL string) (ServiceBackend, error) {
+// NewSQSBackend creates a new SQS backend for storing analytics data, truncating string fields to fieldLimits. The queue
+// may be a FIFO queue, in which case each message is given a group and deduplication ID.
+func NewSQSBackend(ctx context.Context, queueURL string, fieldLimits FieldLimits) (ServiceBackend, error) {
 	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
 		return nil, err
@@ -32,28 +36,58 @@ func NewSQSBackend(ctx context.Context, queueURL string) (ServiceBackend, error)
 
 	sqsClient := sqs.NewFromConfig(cfg)
 	return &sqsBackend{
-		sqsClient,
-		queueURL,
+		sqsClient:   sqsClient,
+		queueURL:    queueURL,
+		fieldLimits: fieldLimits,
 	}, nil
 }
 
-func (b *sqsBackend) Store(req *http.Request, url, term, listType, gaID, gID string, pageIndex, linkIndex, pageSize float64) {
-	var data = map[string]interface{}{
-		"created":   time.Now().Format(time.RFC3339),
-		"url":       url,
-		"term":      term,
-		"listType":  listType,
-		"gaID":      gaID, // 2 year expiration cookie (_ga)
-		"gID":       gID,  // 24 hour expiration cookie (_gid)
-		"pageIndex": pageIndex,
-		"linkIndex": linkIndex,
-		"pageSize":  pageSize,
+// listTypeSQSBackend sends analytics data to the SQS queue configured for its list type, or to the default queue for
+// list types without one
+type listTypeSQSBackend struct {
+	defaultBackend *sqsBackend
+	backends       map[string]*sqsBackend
+}
+
+// NewListTypeSQSBackend creates a new SQS backend for storing analytics data, which selects the queue by list type from
+// queueURLs, falling back to defaultQueueURL, and truncates string fields to fieldLimits
+func NewListTypeSQSBackend(ctx context.Context, defaultQueueURL string, queueURLs map[string]string, fieldLimits FieldLimits) (ServiceBackend, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newListTypeSQSBackend(sqs.NewFromConfig(cfg), defaultQueueURL, queueURLs, fieldLimits), nil
+}
+
+func newListTypeSQSBackend(sqsClient SQSClient, defaultQueueURL string, queueURLs map[string]string, fieldLimits FieldLimits) *listTypeSQSBackend {
+	backends := make(map[string]*sqsBackend, len(queueURLs))
+	for listType, queueURL := range queueURLs {
+		backends[listType] = &sqsBackend{sqsClient: sqsClient, queueURL: queueURL, fieldLimits: fieldLimits}
+	}
+	return &listTypeSQSBackend{
+		defaultBackend: &sqsBackend{sqsClient: sqsClient, queueURL: defaultQueueURL, fieldLimits: fieldLimits},
+		backends:       backends,
+	}
+}
+
+// Store sends the event to the SQS queue for its list type
+func (b *listTypeSQSBackend) Store(ctx context.Context, event Event) error {
+	return b.backendFor(event.ListType).Store(ctx, event)
+}
+
+func (b *listTypeSQSBackend) backendFor(listType string) *sqsBackend {
+	if backend, ok := b.backends[listType]; ok {
+		return backend
 	}
+	return b.defaultBackend
+}
 
-	jb, err := json.Marshal(&data)
+// Store sends the event to SQS
+func (b *sqsBackend) Store(ctx context.Context, event Event) error {
+	jb, err := marshalMessage(ctx, b.fieldLimits, event)
 	if err != nil {
-		log.Error(req.Context(), "error marshaling json", err)
-		return
+		return err
 	}
 
 	strJSON := string(jb)
@@ -61,12 +95,39 @@ func (b *sqsBackend) Store(req *http.Request, url, term, listType, gaID, gID str
 		MessageBody: &strJSON,
 		QueueUrl:    &b.queueURL,
 	}
+	if isFIFOQueue(b.queueURL) {
+		smi.MessageGroupId, smi.MessageDeduplicationId = fifoMessageIDs(event)
+	}
 
-	smo, err := b.sqsClient.SendMessage(req.Context(), smi)
+	smo, err := b.sqsClient.SendMessage(ctx, smi)
 	if err != nil {
-		log.Error(req.Context(), "error sending sqs message", err)
-		return
+		return errors.Wrap(err, "error sending sqs message")
 	}
 
-	log.Info(req.Context(), "stored analytics data in SQS", log.Data{"message_id": *smo.MessageId})
+	log.Info(ctx, "stored analytics data in SQS", log.Data{"message_id": *smo.MessageId})
+	return nil
+}
+
+// isFIFOQueue reports whether queueURL is of a FIFO queue, as the names of FIFO queues end in .fifo
+func isFIFOQueue(queueURL string) bool {
+	return strings.HasSuffix(queueURL, ".fifo")
+}
+
+// fifoMessageIDs returns the message group ID and deduplication ID that FIFO queues require. Messages are grouped by
+// session, so that each session's events are received in order, while events without a session cookie share a group.
+// The deduplication ID is derived from the event's data, leaving out when it was created, so that SQS drops the same
+// event sent again within its deduplication interval, such as when a send is retried.
+func fifoMessageIDs(e Event) (groupID, dedupID *string) {
+	group := "analytics"
+	if session := sessionID(e.GAID, e.GID); session != "" {
+		// hashed, as group IDs are limited in length and characters while cookies are not
+		group = hashFields(session)
+	}
+	dedup := hashFields(
+		e.URL, e.Term, e.ListType, e.GAID, e.GID,
+		strconv.FormatFloat(e.PageIndex, 'g', -1, 64),
+		strconv.FormatFloat(e.LinkIndex, 'g', -1, 64),
+		strconv.FormatFloat(e.PageSize, 'g', -1, 64),
+	)
+	return &group, &dedup
 }
@@ -0,0 +1,188 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+var _ ServiceBackend = &BatchSQSBackend{}
+
+const (
+	// maxSQSBatchSize is the most messages SQS accepts in a single SendMessageBatch
+	maxSQSBatchSize = 10
+
+	// maxPendingSQSMessages bounds the messages buffered for each queue, so that memory does not grow while SQS is
+	// unavailable. Data that arrives while the buffer is full is dropped.
+	maxPendingSQSMessages = 1000
+
+	// sqsBatchTimeout is how long each SendMessageBatch is given
+	sqsBatchTimeout = 10 * time.Second
+)
+
+// BatchSQSBackend buffers analytics data and sends it to SQS with SendMessageBatch, once a full batch is buffered for a
+// queue or every flush interval, rather than sending a message for each request. Data is sent in the background, so
+// requests are never held up waiting on SQS. Close must be called on shutdown to send any data still buffered.
+type BatchSQSBackend struct {
+	sqsClient       SQSClient
+	defaultQueueURL string
+	queueURLs       map[string]string
+	fieldLimits     FieldLimits
+
+	mu      sync.Mutex
+	pending map[string][]types.SendMessageBatchRequestEntry
+	full    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewBatchSQSBackend creates a new batching SQS backend for storing analytics data, which selects the queue by list type
+// from queueURLs, falling back to defaultQueueURL, truncates string fields to fieldLimits and flushes buffered data every
+// flushInterval
+func NewBatchSQSBackend(
+	ctx context.Context, defaultQueueURL string, queueURLs map[string]string, fieldLimits FieldLimits, flushInterval time.Duration,
+) (*BatchSQSBackend, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBatchSQSBackend(sqs.NewFromConfig(cfg), defaultQueueURL, queueURLs, fieldLimits, flushInterval), nil
+}
+
+func newBatchSQSBackend(
+	sqsClient SQSClient, defaultQueueURL string, queueURLs map[string]string, fieldLimits FieldLimits, flushInterval time.Duration,
+) *BatchSQSBackend {
+	b := &BatchSQSBackend{
+		sqsClient:       sqsClient,
+		defaultQueueURL: defaultQueueURL,
+		queueURLs:       queueURLs,
+		fieldLimits:     fieldLimits,
+		pending:         make(map[string][]types.SendMessageBatchRequestEntry),
+		full:            make(chan struct{}, 1),
+		done:            make(chan struct{}),
+		stopped:         make(chan struct{}),
+	}
+	go b.run(flushInterval)
+	return b
+}
+
+// Store buffers the event to be sent with the next batch for its queue, returning an error if too many messages are
+// waiting to be sent for the queue. The batch is sent in the background, so errors sending it are only logged.
+func (b *BatchSQSBackend) Store(ctx context.Context, event Event) error {
+	jb, err := marshalMessage(ctx, b.fieldLimits, event)
+	if err != nil {
+		return err
+	}
+
+	queueURL := b.defaultQueueURL
+	if u, ok := b.queueURLs[event.ListType]; ok {
+		queueURL = u
+	}
+	body := string(jb)
+	entry := types.SendMessageBatchRequestEntry{MessageBody: &body}
+	if isFIFOQueue(queueURL) {
+		entry.MessageGroupId, entry.MessageDeduplicationId = fifoMessageIDs(event)
+	}
+
+	b.mu.Lock()
+	if len(b.pending[queueURL]) >= maxPendingSQSMessages {
+		b.mu.Unlock()
+		return errors.New("too many messages are waiting to be sent to SQS")
+	}
+	b.pending[queueURL] = append(b.pending[queueURL], entry)
+	isFull := len(b.pending[queueURL]) >= maxSQSBatchSize
+	b.mu.Unlock()
+
+	if isFull {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close stops flushing on an interval and sends any data still buffered
+func (b *BatchSQSBackend) Close() error {
+	close(b.done)
+	<-b.stopped
+	return nil
+}
+
+func (b *BatchSQSBackend) run(flushInterval time.Duration) {
+	defer close(b.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flush(true)
+		case <-b.full:
+			b.flush(false)
+		case <-b.done:
+			b.flush(true)
+			return
+		}
+	}
+}
+
+// flush sends the buffered messages in batches. Unless all is set, only full batches are sent and the rest are left for
+// the next flush.
+func (b *BatchSQSBackend) flush(all bool) {
+	b.mu.Lock()
+	batches := make(map[string][][]types.SendMessageBatchRequestEntry)
+	for queueURL, entries := range b.pending {
+		for len(entries) >= maxSQSBatchSize || (all && len(entries) > 0) {
+			n := min(len(entries), maxSQSBatchSize)
+			batches[queueURL] = append(batches[queueURL], entries[:n])
+			entries = entries[n:]
+		}
+		if len(entries) == 0 {
+			delete(b.pending, queueURL)
+		} else {
+			b.pending[queueURL] = entries
+		}
+	}
+	b.mu.Unlock()
+
+	for queueURL, queueBatches := range batches {
+		for _, batch := range queueBatches {
+			b.send(queueURL, batch)
+		}
+	}
+}
+
+// send sends a batch of messages to a queue, logging any that SQS did not accept
+func (b *BatchSQSBackend) send(queueURL string, entries []types.SendMessageBatchRequestEntry) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqsBatchTimeout)
+	defer cancel()
+
+	// IDs only need to be unique within a batch
+	for i := range entries {
+		id := strconv.Itoa(i)
+		entries[i].Id = &id
+	}
+
+	logData := log.Data{"queue_url": queueURL, "messages": len(entries)}
+	smbo, err := b.sqsClient.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{Entries: entries, QueueUrl: &queueURL})
+	if err != nil {
+		log.Error(ctx, "error sending sqs message batch", err, logData)
+		return
+	}
+	if len(smbo.Failed) > 0 {
+		logData["failed"] = len(smbo.Failed)
+		log.Warn(ctx, "sqs did not accept every message in the batch", logData)
+		return
+	}
+
+	log.Info(ctx, "stored analytics data in SQS", logData)
+}
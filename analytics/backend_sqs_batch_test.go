@@ -0,0 +1,145 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/analytics/analyticstest"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBatchSQSBackend(t *testing.T) {
+	Convey("Batch SQS backend initialises without error", t, func() {
+		backend, err := NewBatchSQSBackend(context.Background(), "https://default.url", nil, FieldLimits{}, time.Second)
+		So(err, ShouldBeNil)
+		So(backend, ShouldNotBeNil)
+		So(backend.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a batch SQS backend with a queue for the search list type", t, func() {
+		sent := make(chan int, 10)
+		mockSQSClient := &analyticstest.SQSClientMock{
+			SendMessageBatchFunc: func(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+				sent <- len(params.Entries)
+				return &sqs.SendMessageBatchOutput{}, nil
+			},
+		}
+		waitForBatch := func() int {
+			select {
+			case n := <-sent:
+				return n
+			case <-time.After(time.Second):
+				return 0
+			}
+		}
+		queueURLs := map[string]string{"search": "https://search.url"}
+
+		fakeReq, err := http.NewRequest("GET", "/", http.NoBody)
+		So(err, ShouldBeNil)
+		store := func(backend *BatchSQSBackend, n int, listType string) {
+			for i := 0; i < n; i++ {
+				e := testEvent
+				e.URL, e.ListType = "/some/url/"+strconv.Itoa(i), listType
+				backend.Store(fakeReq.Context(), e)
+			}
+		}
+
+		Convey("When fewer messages than a batch are stored", func() {
+			backend := newBatchSQSBackend(mockSQSClient, "https://default.url", queueURLs, FieldLimits{Term: 4}, time.Hour)
+			store(backend, 3, "search")
+
+			Convey("Then nothing is sent until the backend is closed", func() {
+				So(mockSQSClient.SendMessageBatchCalls(), ShouldBeEmpty)
+				So(backend.Close(), ShouldBeNil)
+
+				So(mockSQSClient.SendMessageBatchCalls(), ShouldHaveLength, 1)
+				params := mockSQSClient.SendMessageBatchCalls()[0].Params
+				So(*params.QueueUrl, ShouldEqual, "https://search.url")
+				So(params.Entries, ShouldHaveLength, 3)
+
+				ids := map[string]bool{}
+				for _, entry := range params.Entries {
+					ids[*entry.Id] = true
+				}
+				So(ids, ShouldHaveLength, 3)
+
+				var input map[string]interface{}
+				err = json.Unmarshal([]byte(*params.Entries[0].MessageBody), &input)
+				So(err, ShouldBeNil)
+				So(input["url"], ShouldEqual, "/some/url/0")
+				So(input["term"], ShouldEqual, "some")
+				So(input["listType"], ShouldEqual, "search")
+				So(input[truncatedField], ShouldResemble, []interface{}{"term"})
+			})
+		})
+
+		Convey("When a full batch is stored", func() {
+			backend := newBatchSQSBackend(mockSQSClient, "https://default.url", queueURLs, FieldLimits{}, time.Hour)
+			store(backend, maxSQSBatchSize+2, "timeseries")
+
+			Convey("Then the full batch is sent without waiting for the interval", func() {
+				So(waitForBatch(), ShouldEqual, maxSQSBatchSize)
+				params := mockSQSClient.SendMessageBatchCalls()[0].Params
+				So(*params.QueueUrl, ShouldEqual, "https://default.url")
+				So(params.Entries, ShouldHaveLength, maxSQSBatchSize)
+
+				Convey("And the rest is sent when the backend is closed", func() {
+					So(backend.Close(), ShouldBeNil)
+					So(mockSQSClient.SendMessageBatchCalls(), ShouldHaveLength, 2)
+					So(mockSQSClient.SendMessageBatchCalls()[1].Params.Entries, ShouldHaveLength, 2)
+				})
+			})
+		})
+
+		Convey("When the flush interval passes", func() {
+			backend := newBatchSQSBackend(mockSQSClient, "https://default.url", queueURLs, FieldLimits{}, 10*time.Millisecond)
+			defer backend.Close()
+			store(backend, 1, "search")
+			store(backend, 1, "timeseries")
+
+			Convey("Then a partial batch is sent to each queue", func() {
+				So(waitForBatch(), ShouldEqual, 1)
+				So(waitForBatch(), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When more messages are stored than can be buffered", func() {
+			// without running, so that nothing is sent
+			backend := &BatchSQSBackend{
+				sqsClient:       mockSQSClient,
+				defaultQueueURL: "https://default.url",
+				queueURLs:       queueURLs,
+				pending:         make(map[string][]types.SendMessageBatchRequestEntry),
+				full:            make(chan struct{}, 1),
+			}
+			store(backend, maxPendingSQSMessages+5, "search")
+
+			Convey("Then the excess are dropped", func() {
+				So(backend.pending["https://search.url"], ShouldHaveLength, maxPendingSQSMessages)
+			})
+		})
+
+		Convey("When a message is stored for a FIFO queue", func() {
+			backend := &BatchSQSBackend{
+				sqsClient:       mockSQSClient,
+				defaultQueueURL: "https://default.url.fifo",
+				pending:         make(map[string][]types.SendMessageBatchRequestEntry),
+				full:            make(chan struct{}, 1),
+			}
+			store(backend, 1, "search")
+
+			Convey("Then it has a group and deduplication ID", func() {
+				entries := backend.pending["https://default.url.fifo"]
+				So(entries, ShouldHaveLength, 1)
+				So(entries[0].MessageGroupId, ShouldNotBeNil)
+				So(entries[0].MessageDeduplicationId, ShouldNotBeNil)
+			})
+		})
+	})
+}
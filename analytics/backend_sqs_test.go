@@ -14,7 +14,7 @@ import (
 
 func TestSQSBackend(t *testing.T) {
 	Convey("SQS backend initialise without error", t, func() {
-		backend, err := NewSQSBackend(context.Background(), "https://fake.url")
+		backend, err := NewSQSBackend(context.Background(), "https://fake.url", FieldLimits{})
 		So(err, ShouldBeNil)
 		So(backend, ShouldNotBeNil)
 	})
@@ -29,14 +29,14 @@ func TestSQSBackend(t *testing.T) {
 		}
 
 		sqsBackend := &sqsBackend{
-			mockSQSClient,
-			"https://fake.url",
+			sqsClient: mockSQSClient,
+			queueURL:  "https://fake.url",
 		}
 
 		fakeReq, err := http.NewRequest("GET", "/", http.NoBody)
 		So(err, ShouldBeNil)
 
-		sqsBackend.Store(fakeReq, "/some/url", "some term", "list type", "gaID", "gID", 10, 20, 30)
+		sqsBackend.Store(fakeReq.Context(), testEvent)
 		requestParams := mockSQSClient.SendMessageCalls()[0].Params
 		So(requestParams, ShouldNotBeNil)
 
@@ -62,5 +62,176 @@ func TestSQSBackend(t *testing.T) {
 		So(input["pageSize"], ShouldEqual, 30)
 		So(input["term"], ShouldEqual, "some term")
 		So(input["url"], ShouldEqual, "/some/url")
+		So(requestParams.MessageGroupId, ShouldBeNil)
+		So(requestParams.MessageDeduplicationId, ShouldBeNil)
+	})
+
+	Convey("Given an SQS backend for a FIFO queue", t, func() {
+		msgID := "test-message-id"
+		mockSQSClient := &analyticstest.SQSClientMock{
+			SendMessageFunc: func(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+				return &sqs.SendMessageOutput{MessageId: &msgID}, nil
+			},
+		}
+		backend := &sqsBackend{sqsClient: mockSQSClient, queueURL: "https://fake.url/analytics.fifo"}
+		ctx := context.Background()
+
+		Convey("When the same event is sent twice, and other events are sent in the same and another session", func() {
+			So(backend.Store(ctx, Event{
+				URL: "/economy", Term: "gdp", ListType: "search", GAID: "ga", GID: "gid", PageIndex: 1, LinkIndex: 2, PageSize: 10,
+			}), ShouldBeNil)
+			So(backend.Store(ctx, Event{
+				URL: "/economy", Term: "gdp", ListType: "search", GAID: "ga", GID: "gid", PageIndex: 1, LinkIndex: 2, PageSize: 10,
+			}), ShouldBeNil)
+			So(backend.Store(ctx, Event{
+				URL: "/economy", Term: "gdp", ListType: "search", GAID: "ga", GID: "gid", PageIndex: 1, LinkIndex: 3, PageSize: 10,
+			}), ShouldBeNil)
+			So(backend.Store(ctx, Event{
+				URL: "/economy", Term: "gdp", ListType: "search", GAID: "ga", GID: "other", PageIndex: 1, LinkIndex: 2, PageSize: 10,
+			}), ShouldBeNil)
+			calls := mockSQSClient.SendMessageCalls()
+			So(calls, ShouldHaveLength, 4)
+
+			Convey("Then the same event has the same deduplication ID, and other events different ones", func() {
+				So(*calls[0].Params.MessageDeduplicationId, ShouldEqual, *calls[1].Params.MessageDeduplicationId)
+				So(*calls[0].Params.MessageDeduplicationId, ShouldNotEqual, *calls[2].Params.MessageDeduplicationId)
+				So(*calls[0].Params.MessageDeduplicationId, ShouldNotEqual, *calls[3].Params.MessageDeduplicationId)
+			})
+
+			Convey("And events are grouped by session", func() {
+				So(*calls[0].Params.MessageGroupId, ShouldEqual, *calls[2].Params.MessageGroupId)
+				So(*calls[0].Params.MessageGroupId, ShouldNotEqual, *calls[3].Params.MessageGroupId)
+			})
+		})
+
+		Convey("When an event without a session cookie is sent", func() {
+			So(backend.Store(ctx, gdpEvent), ShouldBeNil)
+
+			Convey("Then it is put in the shared group", func() {
+				So(*mockSQSClient.SendMessageCalls()[0].Params.MessageGroupId, ShouldEqual, "analytics")
+			})
+		})
+	})
+}
+
+func TestListTypeSQSBackend(t *testing.T) {
+	Convey("List type SQS backend initialises without error", t, func() {
+		backend, err := NewListTypeSQSBackend(context.Background(), "https://default.url", map[string]string{"search": "https://search.url"}, FieldLimits{})
+		So(err, ShouldBeNil)
+		So(backend, ShouldNotBeNil)
+	})
+
+	Convey("Given a list type SQS backend with a queue for the search list type", t, func() {
+		mockSQSClient := &analyticstest.SQSClientMock{
+			SendMessageFunc: func(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+				msgID := "test-message-id"
+				return &sqs.SendMessageOutput{
+					MessageId: &msgID,
+				}, nil
+			},
+		}
+		backend := newListTypeSQSBackend(mockSQSClient, "https://default.url", map[string]string{"search": "https://search.url"}, FieldLimits{})
+
+		Convey("When data for the search list type is stored", func() {
+			err := backend.Store(context.Background(), Event{
+				URL: "/some/url", Term: "some term", ListType: "search", GAID: "gaID", GID: "gID", PageIndex: 1, LinkIndex: 2, PageSize: 10,
+			})
+
+			Convey("Then it is sent to the search queue", func() {
+				So(err, ShouldBeNil)
+				So(mockSQSClient.SendMessageCalls(), ShouldHaveLength, 1)
+				So(*mockSQSClient.SendMessageCalls()[0].Params.QueueUrl, ShouldEqual, "https://search.url")
+			})
+		})
+
+		Convey("When data for an unmapped list type is stored", func() {
+			fakeReq, err := http.NewRequest("GET", "/", http.NoBody)
+			So(err, ShouldBeNil)
+			backend.Store(fakeReq.Context(), Event{
+				URL: "/some/url", Term: "some term", ListType: "timeseries", GAID: "gaID", GID: "gID", PageIndex: 1, LinkIndex: 2, PageSize: 10,
+			})
+
+			Convey("Then it is sent to the default queue", func() {
+				So(mockSQSClient.SendMessageCalls(), ShouldHaveLength, 1)
+				So(*mockSQSClient.SendMessageCalls()[0].Params.QueueUrl, ShouldEqual, "https://default.url")
+			})
+		})
+	})
+}
+
+func TestSQSBackendFieldLimits(t *testing.T) {
+	Convey("Given an SQS backend with a different maximum length for each string field", t, func() {
+		mockSQSClient := &analyticstest.SQSClientMock{
+			SendMessageFunc: func(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+				msgID := "test-message-id"
+				return &sqs.SendMessageOutput{
+					MessageId: &msgID,
+				}, nil
+			},
+		}
+		sqsBackend := &sqsBackend{
+			sqsClient:   mockSQSClient,
+			queueURL:    "https://fake.url",
+			fieldLimits: FieldLimits{Term: 5, URL: 10, ListType: 3},
+		}
+
+		sentData := func() map[string]interface{} {
+			var input map[string]interface{}
+			So(json.Unmarshal([]byte(*mockSQSClient.SendMessageCalls()[0].Params.MessageBody), &input), ShouldBeNil)
+			return input
+		}
+
+		Convey("When every field exceeds its limit", func() {
+			err := sqsBackend.Store(context.Background(), Event{
+				URL: "/economy/inflation", Term: "consumer prices", ListType: "search", GAID: "gaID", GID: "gID",
+				PageIndex: 1, LinkIndex: 2, PageSize: 10,
+			})
+			So(err, ShouldBeNil)
+			input := sentData()
+
+			Convey("Then each field is truncated at its own limit and flagged", func() {
+				So(input["term"], ShouldEqual, "consu")
+				So(input["url"], ShouldEqual, "/economy/i")
+				So(input["listType"], ShouldEqual, "sea")
+				So(input["truncated"], ShouldResemble, []interface{}{"listType", "term", "url"})
+			})
+		})
+
+		Convey("When only the term exceeds its limit", func() {
+			err := sqsBackend.Store(context.Background(), Event{
+				URL: "/economy", Term: "consumer prices", ListType: "dat", GAID: "gaID", GID: "gID", PageIndex: 1, LinkIndex: 2, PageSize: 10,
+			})
+			So(err, ShouldBeNil)
+			input := sentData()
+
+			Convey("Then only the term is truncated and flagged", func() {
+				So(input["term"], ShouldEqual, "consu")
+				So(input["url"], ShouldEqual, "/economy")
+				So(input["listType"], ShouldEqual, "dat")
+				So(input["truncated"], ShouldResemble, []interface{}{"term"})
+			})
+		})
+
+		Convey("When no field exceeds its limit", func() {
+			err := sqsBackend.Store(context.Background(), Event{
+				URL: "/economy", Term: "cpi", ListType: "dat", GAID: "gaID", GID: "gID", PageIndex: 1, LinkIndex: 2, PageSize: 10,
+			})
+			So(err, ShouldBeNil)
+
+			Convey("Then there is no truncation flag", func() {
+				So(sentData(), ShouldNotContainKey, "truncated")
+			})
+		})
+
+		Convey("When a field would be truncated in the middle of a multi-byte character", func() {
+			err := sqsBackend.Store(context.Background(), Event{
+				URL: "/economy", Term: "abcd\u00e9f", ListType: "dat", GAID: "gaID", GID: "gID", PageIndex: 1, LinkIndex: 2, PageSize: 10,
+			})
+			So(err, ShouldBeNil)
+
+			Convey("Then the whole character is removed", func() {
+				So(sentData()["term"], ShouldEqual, "abcd")
+			})
+		})
 	})
 }
@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/pkg/errors"
+)
+
+var _ ServiceBackend = &webhookBackend{}
+
+// WebhookConfig is the HTTP endpoint that analytics data is posted to
+type WebhookConfig struct {
+	URL string
+	// AuthHeader and AuthToken are the name and value of the header authenticating the router with the endpoint, which is
+	// not sent if AuthToken is empty
+	AuthHeader string
+	AuthToken  string
+	// MaxRetries and Timeout apply to each post, with failed posts retried by the client with a backoff
+	MaxRetries int
+	Timeout    time.Duration
+}
+
+type webhookBackend struct {
+	client      dphttp.Clienter
+	cfg         WebhookConfig
+	fieldLimits FieldLimits
+}
+
+// NewWebhookBackend creates a new backend that posts analytics data as JSON to an HTTP endpoint, truncating string
+// fields to fieldLimits
+func NewWebhookBackend(cfg WebhookConfig, fieldLimits FieldLimits) (ServiceBackend, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webhook URL is required")
+	}
+
+	client := dphttp.NewClient()
+	client.SetMaxRetries(cfg.MaxRetries)
+	client.SetTimeout(cfg.Timeout)
+	return &webhookBackend{client: client, cfg: cfg, fieldLimits: fieldLimits}, nil
+}
+
+// Store posts the event to the webhook, returning an error if it cannot be reached or does not respond with a success,
+// so that the post can be retried
+func (b *webhookBackend) Store(ctx context.Context, event Event) error {
+	jb, err := marshalMessage(ctx, b.fieldLimits, event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.cfg.URL, bytes.NewReader(jb))
+	if err != nil {
+		return errors.Wrap(err, "error creating webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.cfg.AuthToken != "" {
+		req.Header.Set(b.cfg.AuthHeader, b.cfg.AuthToken)
+	}
+
+	resp, err := b.client.Do(ctx, req)
+	if err != nil {
+		return errors.Wrap(err, "error posting to webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with %d", resp.StatusCode)
+	}
+
+	log.Info(ctx, "stored analytics data with webhook", log.Data{"status": resp.StatusCode})
+	return nil
+}
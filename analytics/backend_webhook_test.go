@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWebhookBackend(t *testing.T) {
+	Convey("Webhook backend initialises without error", t, func() {
+		backend, err := NewWebhookBackend(WebhookConfig{URL: "https://collector.example/events"}, FieldLimits{})
+		So(err, ShouldBeNil)
+		So(backend, ShouldNotBeNil)
+	})
+
+	Convey("Webhook backend requires a URL", t, func() {
+		_, err := NewWebhookBackend(WebhookConfig{}, FieldLimits{})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Given a webhook backend", t, func() {
+		status := http.StatusAccepted
+		var body []byte
+		client := &dphttp.ClienterMock{
+			DoFunc: func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				body, _ = io.ReadAll(req.Body)
+				return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+			},
+		}
+		webhookBackend := &webhookBackend{
+			client: client,
+			cfg: WebhookConfig{
+				URL:        "https://collector.example/events",
+				AuthHeader: "Authorization",
+				AuthToken:  "Bearer secret",
+			},
+			fieldLimits: FieldLimits{Term: 4},
+		}
+
+		Convey("When analytics data is stored", func() {
+			fakeReq, err := http.NewRequest("GET", "/", http.NoBody)
+			So(err, ShouldBeNil)
+			webhookBackend.Store(fakeReq.Context(), testEvent)
+
+			Convey("Then it is posted as JSON with the auth header", func() {
+				So(client.DoCalls(), ShouldHaveLength, 1)
+				req := client.DoCalls()[0].Req
+				So(req.Method, ShouldEqual, http.MethodPost)
+				So(req.URL.String(), ShouldEqual, "https://collector.example/events")
+				So(req.Header.Get("Content-Type"), ShouldEqual, "application/json")
+				So(req.Header.Get("Authorization"), ShouldEqual, "Bearer secret")
+
+				var input map[string]interface{}
+				err = json.Unmarshal(body, &input)
+				So(err, ShouldBeNil)
+				So(input["url"], ShouldEqual, "/some/url")
+				So(input["term"], ShouldEqual, "some")
+				So(input["listType"], ShouldEqual, "list type")
+				So(input["gaID"], ShouldEqual, "gaID")
+				So(input["gID"], ShouldEqual, "gID")
+				So(input["pageIndex"], ShouldEqual, 10)
+				So(input["linkIndex"], ShouldEqual, 20)
+				So(input["pageSize"], ShouldEqual, 30)
+				So(input[truncatedField], ShouldResemble, []interface{}{"term"})
+			})
+		})
+
+		Convey("When it has no auth token", func() {
+			webhookBackend.cfg.AuthToken = ""
+			err := webhookBackend.Store(context.Background(), testEvent)
+			So(err, ShouldBeNil)
+
+			Convey("Then no auth header is sent", func() {
+				So(client.DoCalls()[0].Req.Header, ShouldNotContainKey, "Authorization")
+			})
+		})
+
+		Convey("When the webhook responds with an error", func() {
+			status = http.StatusServiceUnavailable
+			err := webhookBackend.Store(context.Background(), testEvent)
+
+			Convey("Then the error is returned so that the post can be retried", func() {
+				So(err, ShouldBeError, "webhook responded with 503")
+			})
+		})
+
+		Convey("When the webhook cannot be reached", func() {
+			client.DoFunc = func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			}
+			err := webhookBackend.Store(context.Background(), testEvent)
+
+			Convey("Then the error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
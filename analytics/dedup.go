@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/cache"
+)
+
+// DedupCache records the idempotency keys of recently sent analytics events. It is shared by every worker sending
+// events, so that an event sent twice, such as by a client retrying the redirect, is only stored once within the TTL.
+type DedupCache struct {
+	sent *cache.Cache[struct{}]
+}
+
+// NewDedupCache creates a DedupCache remembering at most maxEntries events, each for ttl
+func NewDedupCache(maxEntries int, ttl time.Duration) *DedupCache {
+	return &DedupCache{sent: cache.New[struct{}](maxEntries, ttl)}
+}
+
+// claim returns true, and records key as sent, if no event with key has been claimed within the TTL
+func (d *DedupCache) claim(key string) bool {
+	return d.sent.SetIfAbsent(key, struct{}{})
+}
+
+// release forgets key, so that an event that could not be stored can be sent again
+func (d *DedupCache) release(key string) {
+	d.sent.Delete(key)
+}
+
+// clientIPKey is the context key for the IP of the client that sent an event
+type clientIPKey struct{}
+
+// withClientIP records in ctx the IP of the client that sent the event stored with it
+func withClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// idempotencyKey identifies an event by its data and the client that sent it, as recorded in ctx, so that the same event
+// from different clients is not collapsed
+func idempotencyKey(ctx context.Context, e Event) string {
+	clientIP, _ := ctx.Value(clientIPKey{}).(string)
+	return hashFields(
+		clientIP, e.URL, e.Term, e.ListType, e.GAID, e.GID,
+		strconv.FormatFloat(e.PageIndex, 'g', -1, 64),
+		strconv.FormatFloat(e.LinkIndex, 'g', -1, 64),
+		strconv.FormatFloat(e.PageSize, 'g', -1, 64),
+	)
+}
+
+// hashFields returns the hex SHA-256 hash of fields, separated so that moving text between fields changes the hash
+func hashFields(fields ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,103 @@
+package analytics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxUserAgentLength is the length in bytes that user agents are truncated to, as they are sent by the client and so
+// unbounded other than by the maximum header size
+const maxUserAgentLength = 512
+
+// Device classes of analytics events
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// browserFamilies are the substrings of user agents that identify each browser family, in the order they are checked,
+// as most browsers also claim to be those they are built on, such as Edge claiming to be Chrome and Safari
+var browserFamilies = []struct {
+	family  string
+	markers []string
+}{
+	{"Edge", []string{"Edg/", "EdgA/", "EdgiOS/", "Edge/"}},
+	{"Opera", []string{"OPR/", "Opera"}},
+	{"Samsung Internet", []string{"SamsungBrowser/"}},
+	{"Firefox", []string{"Firefox/", "FxiOS/"}},
+	{"Chrome", []string{"Chrome/", "CriOS/", "Chromium/"}},
+	{"Safari", []string{"Safari/"}},
+	{"Internet Explorer", []string{"MSIE ", "Trident/"}},
+}
+
+// enrich adds to e what the request says about where the user came from and the device they used: the referer, the user
+// agent with its device class and browser family, and the width of the viewport if the browser sends it as a client
+// hint, which browsers only do once asked to with an Accept-CH header
+func enrich(e *Event, r *http.Request) {
+	e.Referer = strings.TrimSpace(r.Referer())
+	e.UserAgent = r.UserAgent()
+	if len(e.UserAgent) > maxUserAgentLength {
+		e.UserAgent = truncate(e.UserAgent, maxUserAgentLength)
+	}
+	e.DeviceClass = deviceClass(e.UserAgent, r.Header.Get("Sec-CH-UA-Mobile"))
+	e.BrowserFamily = browserFamily(e.UserAgent)
+	e.ViewportWidth = viewportWidth(r.Header)
+}
+
+// deviceClass classifies the device from its user agent, or from the Sec-CH-UA-Mobile client hint if the user agent
+// does not identify a mobile device, as browsers are reducing the detail in user agents
+func deviceClass(userAgent, mobileHint string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case ua == "":
+		return DeviceUnknown
+	case containsAny(ua, "bot", "crawler", "spider", "slurp", "headless"):
+		return DeviceBot
+	case containsAny(ua, "ipad", "tablet", "kindle", "silk/") || (strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		return DeviceTablet
+	case containsAny(ua, "mobi", "iphone", "ipod", "android", "windows phone") || mobileHint == "?1":
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+// browserFamily returns the family of the browser with userAgent, such as Chrome or Firefox, Other if it is not a known
+// family, or empty if there is no user agent
+func browserFamily(userAgent string) string {
+	if userAgent == "" {
+		return ""
+	}
+	for _, b := range browserFamilies {
+		for _, marker := range b.markers {
+			if strings.Contains(userAgent, marker) {
+				return b.family
+			}
+		}
+	}
+	return "Other"
+}
+
+// viewportWidth returns the width of the viewport in CSS pixels from the Sec-CH-Viewport-Width client hint, or its older
+// Viewport-Width form, or zero if neither is sent with a valid width
+func viewportWidth(h http.Header) int {
+	for _, name := range []string{"Sec-CH-Viewport-Width", "Viewport-Width"} {
+		if width, err := strconv.Atoi(strings.TrimSpace(h.Get(name))); err == nil && width > 0 {
+			return width
+		}
+	}
+	return 0
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
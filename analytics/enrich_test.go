@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEnrich(t *testing.T) {
+	Convey("Given a redirect request from a browser", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/redir/abc", http.NoBody)
+		req.Header.Set("Referer", "https://www.ons.gov.uk/search?q=gdp")
+		req.Header.Set("User-Agent",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1")
+		req.Header.Set("Sec-CH-Viewport-Width", "390")
+
+		Convey("When the event is enriched from the request", func() {
+			var e Event
+			enrich(&e, req)
+
+			Convey("Then it has the referer, user agent, device class, browser family and viewport width", func() {
+				So(e.Referer, ShouldEqual, "https://www.ons.gov.uk/search?q=gdp")
+				So(e.UserAgent, ShouldEqual, req.UserAgent())
+				So(e.DeviceClass, ShouldEqual, DeviceMobile)
+				So(e.BrowserFamily, ShouldEqual, "Safari")
+				So(e.ViewportWidth, ShouldEqual, 390)
+			})
+		})
+
+		Convey("When the user agent is very long", func() {
+			req.Header.Set("User-Agent", "Mozilla/5.0 "+strings.Repeat("x", 1000))
+			var e Event
+			enrich(&e, req)
+
+			Convey("Then it is truncated", func() {
+				So(e.UserAgent, ShouldHaveLength, maxUserAgentLength)
+			})
+		})
+	})
+
+	Convey("Given a redirect request without any headers", t, func() {
+		var e Event
+		enrich(&e, httptest.NewRequest(http.MethodGet, "/redir/abc", http.NoBody))
+
+		Convey("Then the device is unknown, and the other fields empty", func() {
+			So(e, ShouldResemble, Event{DeviceClass: DeviceUnknown})
+		})
+	})
+}
+
+func TestDeviceClass(t *testing.T) {
+	Convey("Devices are classified by their user agent", t, func() {
+		for userAgent, class := range map[string]string{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36":       DeviceDesktop,
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36": DeviceMobile,
+			"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36":        DeviceTablet,
+			"Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/604.1":      DeviceTablet,
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)":                                              DeviceBot,
+			"": DeviceUnknown,
+		} {
+			So(deviceClass(userAgent, ""), ShouldEqual, class)
+		}
+	})
+
+	Convey("A device the user agent does not identify as mobile is mobile if the client hint says so", t, func() {
+		So(deviceClass("Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 Chrome/120.0.0.0 Mobile Safari/537.36", "?1"), ShouldEqual, DeviceMobile)
+		So(deviceClass("Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 Chrome/120.0.0.0 Safari/537.36", "?1"), ShouldEqual, DeviceMobile)
+		So(deviceClass("Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 Chrome/120.0.0.0 Safari/537.36", "?0"), ShouldEqual, DeviceDesktop)
+	})
+}
+
+func TestBrowserFamily(t *testing.T) {
+	Convey("Browsers are identified by family, rather than by those they claim to be built on", t, func() {
+		for userAgent, family := range map[string]string{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0":    "Edge",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 OPR/105.0.0.0":    "Opera",
+			"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36": "Samsung Internet",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0":                                                 "Firefox",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36":                  "Chrome",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15":               "Safari",
+			"Mozilla/5.0 (Windows NT 10.0; Trident/7.0; rv:11.0) like Gecko":                                                                   "Internet Explorer",
+			"curl/8.4.0": "Other",
+			"":           "",
+		} {
+			So(browserFamily(userAgent), ShouldEqual, family)
+		}
+	})
+}
+
+func TestViewportWidth(t *testing.T) {
+	Convey("The viewport width is read from either client hint, and ignored if it is not a positive whole number", t, func() {
+		So(viewportWidth(http.Header{"Viewport-Width": []string{"1280"}}), ShouldEqual, 1280)
+		So(viewportWidth(http.Header{"Sec-Ch-Viewport-Width": []string{"wide"}}), ShouldEqual, 0)
+		So(viewportWidth(http.Header{"Sec-Ch-Viewport-Width": []string{"-1"}}), ShouldEqual, 0)
+		So(viewportWidth(http.Header{}), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	jwt "github.com/form3tech-oss/jwt-go"
+)
+
+// EventSchemaVersion is the version of the schema of the analytics events stored by the backends. It is incremented
+// whenever a field is removed, renamed or changes meaning, so that consumers can tell which fields to expect.
+const EventSchemaVersion = 1
+
+// Event is an analytics event, in the form stored by the backends
+type Event struct {
+	SchemaVersion int `json:"schemaVersion"`
+	// Created is when the event was stored, in RFC 3339 format
+	Created   string  `json:"created"`
+	URL       string  `json:"url"`
+	Term      string  `json:"term"`
+	ListType  string  `json:"listType"`
+	GAID      string  `json:"gaID"` // 2 year expiration cookie (_ga)
+	GID       string  `json:"gID"`  // 24 hour expiration cookie (_gid)
+	PageIndex float64 `json:"pageIndex"`
+	LinkIndex float64 `json:"linkIndex"`
+	PageSize  float64 `json:"pageSize"`
+	// Referer is the page the user followed the link from, and UserAgent their browser, classified by DeviceClass and
+	// BrowserFamily. ViewportWidth is the width of the browser's viewport in CSS pixels, or zero if it did not say.
+	Referer       string `json:"referer"`
+	UserAgent     string `json:"userAgent"`
+	DeviceClass   string `json:"deviceClass"`
+	BrowserFamily string `json:"browserFamily"`
+	ViewportWidth int    `json:"viewportWidth"`
+	// Experiments maps the experiments the user is taking part in to the variant, or bucket, they were assigned to, so
+	// that the behaviour of each cohort can be compared
+	Experiments map[string]string `json:"experiments,omitempty"`
+	// Truncated lists the string fields that were truncated to their maximum length
+	Truncated []string `json:"truncated,omitempty"`
+}
+
+// eventFromClaims reads the analytics event from the claims of a redirect token, trimming the whitespace from its
+// string fields. Claims that are present but of the wrong type are left empty and reported as problems, rather than
+// silently read as empty.
+func eventFromClaims(claims jwt.MapClaims) (e Event, problems []string) {
+	readString := func(key string) string {
+		v, ok := claims[key]
+		if !ok {
+			return ""
+		}
+		s, ok := v.(string)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is not a string", key))
+		}
+		return strings.TrimSpace(s)
+	}
+	readNumber := func(key string) float64 {
+		v, ok := claims[key]
+		if !ok {
+			return 0
+		}
+		f, ok := v.(float64)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is not a number", key))
+		}
+		return f
+	}
+
+	e = Event{
+		URL:       readString("uri"),
+		Term:      readString("term"),
+		ListType:  readString("listType"),
+		PageIndex: readNumber("page"),
+		LinkIndex: readNumber("index"),
+		PageSize:  readNumber("pageSize"),
+	}
+	return e, problems
+}
+
+// validate returns the problems with the values of the event's fields, which are none if it is valid. The page index,
+// link index and page size must be whole numbers that are not negative.
+func (e Event) validate() []string {
+	var problems []string
+	numbers := []struct {
+		name  string
+		value float64
+	}{{pageIndexParam, e.PageIndex}, {linkIndexParam, e.LinkIndex}, {pageSizeParam, e.PageSize}}
+	for _, n := range numbers {
+		if math.IsNaN(n.value) || math.IsInf(n.value, 0) || n.value < 0 || n.value != math.Trunc(n.value) {
+			problems = append(problems, fmt.Sprintf("%s is not a whole number of zero or more: %v", n.name, n.value))
+		}
+	}
+	return problems
+}
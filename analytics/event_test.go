@@ -0,0 +1,129 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testEvent and gdpEvent are events for backends to store, with every field set or without any cookies
+var (
+	testEvent = Event{
+		URL: "/some/url", Term: "some term", ListType: "list type", GAID: "gaID", GID: "gID", PageIndex: 10, LinkIndex: 20, PageSize: 30,
+	}
+	gdpEvent = Event{URL: "/economy", Term: "gdp", ListType: "search", PageIndex: 1, LinkIndex: 2, PageSize: 10}
+)
+
+func TestEventFromClaims(t *testing.T) {
+	Convey("Given the claims of a redirect token", t, func() {
+		Convey("When they are well formed", func() {
+			e, problems := eventFromClaims(jwt.MapClaims{
+				"uri": " /economy ", "term": "gdp\n", "listType": "search", "page": 2.0, "index": 3.0, "pageSize": 10.0,
+			})
+
+			Convey("Then the event is read with its string fields trimmed", func() {
+				So(problems, ShouldBeEmpty)
+				So(e, ShouldResemble, Event{URL: "/economy", Term: "gdp", ListType: "search", PageIndex: 2, LinkIndex: 3, PageSize: 10})
+				So(e.validate(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When optional claims are missing", func() {
+			e, problems := eventFromClaims(jwt.MapClaims{"uri": "/economy"})
+
+			Convey("Then they are left empty without any problems", func() {
+				So(problems, ShouldBeEmpty)
+				So(e, ShouldResemble, Event{URL: "/economy"})
+			})
+		})
+
+		Convey("When claims are of the wrong type", func() {
+			e, problems := eventFromClaims(jwt.MapClaims{"uri": "/economy", "term": 5.0, "page": "2"})
+
+			Convey("Then they are reported as problems", func() {
+				So(problems, ShouldResemble, []string{"term is not a string", "page is not a number"})
+				So(e.Term, ShouldBeEmpty)
+				So(e.PageIndex, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestEventValidate(t *testing.T) {
+	Convey("An event with negative, fractional or non-finite numbers is invalid", t, func() {
+		e := Event{URL: "/economy", PageIndex: -1, LinkIndex: 1.5, PageSize: math.Inf(1)}
+		So(e.validate(), ShouldResemble, []string{
+			"pageIndex is not a whole number of zero or more: -1",
+			"linkIndex is not a whole number of zero or more: 1.5",
+			"pageSize is not a whole number of zero or more: +Inf",
+		})
+	})
+}
+
+func TestMarshalMessage(t *testing.T) {
+	Convey("The stored event has the current schema version and every field", t, func() {
+		jb, err := marshalMessage(context.Background(), FieldLimits{}, Event{
+			URL: "/economy", Term: "gdp", ListType: "search", GAID: "ga", GID: "g", PageIndex: 1, LinkIndex: 2, PageSize: 10,
+		})
+		So(err, ShouldBeNil)
+
+		var e Event
+		So(json.Unmarshal(jb, &e), ShouldBeNil)
+		So(e.SchemaVersion, ShouldEqual, EventSchemaVersion)
+		So(e.Created, ShouldNotBeEmpty)
+		e.Created = ""
+		So(e, ShouldResemble, Event{
+			SchemaVersion: EventSchemaVersion, URL: "/economy", Term: "gdp", ListType: "search", GAID: "ga", GID: "g",
+			PageIndex: 1, LinkIndex: 2, PageSize: 10,
+		})
+
+		var fields map[string]interface{}
+		So(json.Unmarshal(jb, &fields), ShouldBeNil)
+		So(fields, ShouldNotContainKey, truncatedField)
+	})
+}
+
+func TestServiceRejectsMalformedEvents(t *testing.T) {
+	Convey("Given an analytics service", t, func() {
+		backend := &recordingBackend{pageIndex: -1}
+		s := NewServiceImpl(backend, "secret")
+		capture := func(claims jwt.MapClaims) (url string, err error) {
+			tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(hmacSampleSecret)
+			So(err, ShouldBeNil)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/redir/{data:.*}", func(w http.ResponseWriter, req *http.Request) {
+				url, err = s.CaptureAnalyticsData(req)
+			})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/redir/"+tokenString, http.NoBody))
+			return url, err
+		}
+
+		Convey("When a malformed event is captured", func() {
+			url, err := capture(jwt.MapClaims{"uri": "/economy", "page": "two", "index": 1.5})
+
+			Convey("Then the user is still redirected, but the event is not stored", func() {
+				So(err, ShouldBeNil)
+				So(url, ShouldEqual, "/economy")
+				So(backend.pageIndex, ShouldEqual, -1)
+			})
+		})
+
+		Convey("When a well formed event is captured", func() {
+			url, err := capture(jwt.MapClaims{"uri": "/economy", "page": 2, "index": 1})
+
+			Convey("Then it is stored", func() {
+				So(err, ShouldBeNil)
+				So(url, ShouldEqual, "/economy")
+				So(backend.pageIndex, ShouldEqual, 2)
+			})
+		})
+	})
+}
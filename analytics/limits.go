@@ -0,0 +1,41 @@
+package analytics
+
+import (
+	"context"
+	"math"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Limits are the inclusive upper bounds that the analytics page index, link index and page size are clamped to. The
+// lower bound of each is zero.
+type Limits struct {
+	MaxPageIndex float64
+	MaxLinkIndex float64
+	MaxPageSize  float64
+}
+
+// clamp returns value limited to the range [0, max]. Values that are NaN or infinite are rejected and replaced with
+// zero, as they cannot be clamped meaningfully.
+func clamp(ctx context.Context, name string, value, max float64) float64 {
+	var clamped float64
+	switch {
+	case math.IsNaN(value) || math.IsInf(value, 0):
+		clamped = 0
+	case value < 0:
+		clamped = 0
+	case value > max:
+		clamped = max
+	default:
+		return value
+	}
+
+	log.Warn(ctx, "clamped out of range analytics value", log.Data{"param": name, "value": value, "clamped": clamped})
+	return clamped
+}
+
+func (l Limits) clampAll(ctx context.Context, pageIndex, linkIndex, pageSize float64) (clampedPageIndex, clampedLinkIndex, clampedPageSize float64) {
+	return clamp(ctx, pageIndexParam, pageIndex, l.MaxPageIndex),
+		clamp(ctx, linkIndexParam, linkIndex, l.MaxLinkIndex),
+		clamp(ctx, pageSizeParam, pageSize, l.MaxPageSize)
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recordingBackend records the numeric values it is asked to store
+type recordingBackend struct {
+	pageIndex, linkIndex, pageSize float64
+}
+
+func (b *recordingBackend) Store(ctx context.Context, event Event) error {
+	b.pageIndex, b.linkIndex, b.pageSize = event.PageIndex, event.LinkIndex, event.PageSize
+	return nil
+}
+
+func TestLimits(t *testing.T) {
+	Convey("Given analytics limits", t, func() {
+		limits := Limits{MaxPageIndex: 100, MaxLinkIndex: 50, MaxPageSize: 10}
+		ctx := context.Background()
+
+		Convey("When the values are valid", func() {
+			pageIndex, linkIndex, pageSize := limits.clampAll(ctx, 3, 7, 10)
+
+			Convey("Then they are unchanged", func() {
+				So(pageIndex, ShouldEqual, 3)
+				So(linkIndex, ShouldEqual, 7)
+				So(pageSize, ShouldEqual, 10)
+			})
+		})
+
+		Convey("When the values are negative", func() {
+			pageIndex, linkIndex, pageSize := limits.clampAll(ctx, -1, -0.5, -100)
+
+			Convey("Then they are clamped to zero", func() {
+				So(pageIndex, ShouldEqual, 0)
+				So(linkIndex, ShouldEqual, 0)
+				So(pageSize, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the values are oversized", func() {
+			pageIndex, linkIndex, pageSize := limits.clampAll(ctx, 1e12, 51, math.MaxFloat64)
+
+			Convey("Then they are clamped to the maximums", func() {
+				So(pageIndex, ShouldEqual, 100)
+				So(linkIndex, ShouldEqual, 50)
+				So(pageSize, ShouldEqual, 10)
+			})
+		})
+
+		Convey("When the values are NaN or infinite", func() {
+			pageIndex, linkIndex, pageSize := limits.clampAll(ctx, math.NaN(), math.Inf(1), math.Inf(-1))
+
+			Convey("Then they are rejected and replaced with zero", func() {
+				So(pageIndex, ShouldEqual, 0)
+				So(linkIndex, ShouldEqual, 0)
+				So(pageSize, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestServiceWithLimits(t *testing.T) {
+	Convey("Given an analytics service with limits", t, func() {
+		backend := &recordingBackend{}
+		s := NewServiceImpl(backend, "secret").WithLimits(Limits{MaxPageIndex: 100, MaxLinkIndex: 50, MaxPageSize: 10})
+
+		Convey("When redirect data with out of range values is captured", func() {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"index":    -3,
+				"pageSize": 1000000,
+				"page":     2,
+				"uri":      "/economy",
+			})
+			tokenString, err := token.SignedString(hmacSampleSecret)
+			So(err, ShouldBeNil)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/redir/{data:.*}", func(w http.ResponseWriter, req *http.Request) {
+				_, err = s.CaptureAnalyticsData(req)
+			})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/redir/"+tokenString, http.NoBody))
+
+			Convey("Then the clamped values are stored", func() {
+				So(err, ShouldBeNil)
+				So(backend.pageIndex, ShouldEqual, 2)
+				So(backend.linkIndex, ShouldEqual, 0)
+				So(backend.pageSize, ShouldEqual, 10)
+			})
+		})
+	})
+}
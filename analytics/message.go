@@ -0,0 +1,30 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/pkg/errors"
+)
+
+// marshalMessage returns the JSON that a backend stores for the event, with the current schema version and string fields
+// truncated to fieldLimits. The event is created now, unless it records an earlier time, such as when it was queued.
+func marshalMessage(ctx context.Context, fieldLimits FieldLimits, event Event) ([]byte, error) {
+	event.SchemaVersion = EventSchemaVersion
+	if event.Created == "" {
+		event.Created = time.Now().Format(time.RFC3339)
+	}
+
+	if truncated := fieldLimits.apply(&event); len(truncated) > 0 {
+		event.Truncated = truncated
+		log.Warn(ctx, "truncated analytics fields exceeding their maximum length", log.Data{"fields": truncated})
+	}
+
+	jb, err := json.Marshal(&event)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling json")
+	}
+	return jb, nil
+}
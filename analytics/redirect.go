@@ -0,0 +1,38 @@
+package analytics
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// isAllowedRedirect reports whether target is safe to redirect to: a path on this site, or an http or https URL on one
+// of allowedDomains or their subdomains. Targets that browsers could read as a different host, such as those with a
+// backslash or control character, are never allowed.
+func isAllowedRedirect(target string, allowedDomains []string) bool {
+	if strings.ContainsFunc(target, func(r rune) bool { return r == '\\' || unicode.IsControl(r) }) {
+		return false
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+
+	if u.Scheme == "" && u.Host == "" {
+		// a path, but not one starting // that browsers read as a host
+		return strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//")
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.User != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range allowedDomains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
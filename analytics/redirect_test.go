@@ -0,0 +1,76 @@
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIsAllowedRedirect(t *testing.T) {
+	allowed := []string{"ons.gov.uk"}
+
+	Convey("Paths on this site and URLs on allowed domains or their subdomains are allowed", t, func() {
+		for _, target := range []string{
+			"/economy",
+			"/economy?page=2#contents",
+			"https://ons.gov.uk/economy",
+			"https://www.ons.gov.uk/economy",
+			"http://WWW.ONS.GOV.UK:443/economy",
+		} {
+			So(isAllowedRedirect(target, allowed), ShouldBeTrue)
+		}
+	})
+
+	Convey("Any other URL is not allowed", t, func() {
+		for _, target := range []string{
+			"economy",
+			"//evil.com/economy",
+			"/\\evil.com/economy",
+			"/\t/evil.com",
+			"https://evil.com/economy",
+			"https://evilons.gov.uk/economy",
+			"https://ons.gov.uk.evil.com/economy",
+			"https://ons.gov.uk@evil.com/economy",
+			"https://user@ons.gov.uk/economy",
+			"javascript:alert(1)",
+			"ftp://ons.gov.uk/economy",
+		} {
+			So(isAllowedRedirect(target, allowed), ShouldBeFalse)
+		}
+	})
+
+	Convey("Only paths are allowed when there are no allowed domains", t, func() {
+		So(isAllowedRedirect("/economy", nil), ShouldBeTrue)
+		So(isAllowedRedirect("https://www.ons.gov.uk/economy", nil), ShouldBeFalse)
+	})
+}
+
+func TestServiceRejectsDisallowedRedirects(t *testing.T) {
+	Convey("Given an analytics service allowing redirects to ons.gov.uk", t, func() {
+		backend := &recordingBackend{pageIndex: -1}
+		s := NewServiceImpl(backend, "secret").WithAllowedRedirectDomains([]string{"ons.gov.uk"})
+
+		Convey("When a validly signed token redirects to another domain", func() {
+			tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"uri": "https://evil.com", "page": 2}).
+				SignedString(hmacSampleSecret)
+			So(err, ShouldBeNil)
+
+			var url string
+			router := mux.NewRouter()
+			router.HandleFunc("/redir/{data:.*}", func(w http.ResponseWriter, req *http.Request) {
+				url, err = s.CaptureAnalyticsData(req)
+			})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/redir/"+tokenString, http.NoBody))
+
+			Convey("Then an error is returned and the data is not stored", func() {
+				So(err, ShouldBeError, "URL is not a path or on an allowed domain")
+				So(url, ShouldBeEmpty)
+				So(backend.pageIndex, ShouldEqual, -1)
+			})
+		})
+	})
+}
@@ -0,0 +1,31 @@
+package analytics
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+)
+
+// randomSample returns a number in [0, 1) that is compared against the sample rate for events without a session cookie
+var randomSample = rand.Float64
+
+// sessionSample returns a number in [0, 1) that is compared against the sample rate. It is derived from the session's
+// cookie, so that every event in a session is either stored or not, keeping sessions whole for analysis. Events without
+// a session cookie are sampled at random.
+func sessionSample(gaID, gID string) float64 {
+	session := sessionID(gaID, gID)
+	if session == "" {
+		return randomSample()
+	}
+	h := fnv.New64a()
+	h.Write([]byte(session))
+	return float64(h.Sum64()) / (math.MaxUint64 + 1.0)
+}
+
+// sessionID identifies the session of an event by its _gid cookie or else its _ga cookie, returning "" if it has neither
+func sessionID(gaID, gID string) string {
+	if gID != "" {
+		return gID
+	}
+	return gaID
+}
@@ -0,0 +1,80 @@
+package analytics
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSessionSample(t *testing.T) {
+	Convey("Every event in a session gets the same sample, from the _gid cookie or else the _ga cookie", t, func() {
+		So(sessionSample("ga", "gid"), ShouldEqual, sessionSample("", "gid"))
+		So(sessionSample("ga", ""), ShouldEqual, sessionSample("ga", ""))
+		So(sessionSample("ga", ""), ShouldNotEqual, sessionSample("", "gid"))
+	})
+
+	Convey("The samples of sessions are spread evenly from 0 to 1", t, func() {
+		below := 0
+		for i := 0; i < 10000; i++ {
+			s := sessionSample("", fmt.Sprintf("GA1.2.%d.1697000000", i))
+			So(s, ShouldBeBetweenOrEqual, 0, 1)
+			if s < 0.1 {
+				below++
+			}
+		}
+		So(below, ShouldBeBetween, 800, 1200)
+	})
+
+	Convey("Events without a session cookie are sampled at random", t, func() {
+		randomSample = func() float64 { return 0.25 }
+		defer func() { randomSample = rand.Float64 }()
+		So(sessionSample("", ""), ShouldEqual, 0.25)
+	})
+}
+
+func TestServiceSampling(t *testing.T) {
+	Convey("Given an analytics service storing half of the events", t, func() {
+		backend := &fakeBackend{}
+		s := NewServiceImpl(backend, "secret").WithSampleRate(0.5)
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"uri": "/economy"}).SignedString(hmacSampleSecret)
+		So(err, ShouldBeNil)
+
+		capture := func(gID string) string {
+			var url string
+			router := mux.NewRouter()
+			router.HandleFunc("/redir/{data:.*}", func(w http.ResponseWriter, req *http.Request) {
+				url, err = s.CaptureAnalyticsData(req)
+				So(err, ShouldBeNil)
+			})
+			req := httptest.NewRequest("GET", "/redir/"+tokenString, http.NoBody)
+			req.AddCookie(&http.Cookie{Name: "_gid", Value: gID})
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			return url
+		}
+
+		Convey("When events are captured for sessions in and out of the sample", func() {
+			var in, out string
+			for i := 0; in == "" || out == ""; i++ {
+				gID := fmt.Sprint(i)
+				if sessionSample("", gID) < 0.5 {
+					in = gID
+				} else {
+					out = gID
+				}
+			}
+			So(capture(in), ShouldEqual, "/economy")
+			So(capture(out), ShouldEqual, "/economy")
+			So(capture(in), ShouldEqual, "/economy")
+
+			Convey("Then every user is redirected, but only the events of the sampled session are stored", func() {
+				So(backend.urls, ShouldResemble, []string{"/economy", "/economy"})
+			})
+		})
+	})
+}
@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// redacted replaces personal data that is stripped from analytics events
+const redacted = "[redacted]"
+
+// defaultScrubPatterns match the personal data that is always scrubbed: email addresses, and IPv4 and IPv6 addresses
+var defaultScrubPatterns = []string{
+	`(?i)[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}`,
+	`\b(?:\d{1,3}\.){3}\d{1,3}\b`,
+	`(?i)\b[0-9a-f]{1,4}(?::[0-9a-f]{0,4}){3,7}\b`,
+}
+
+// Scrubber removes personal data, such as email addresses that users search for, from the term, URL and referer of
+// analytics events before they are logged or stored. Matches are replaced with [redacted] or, if there is a hash key,
+// with a keyed hash of the match, so that repeated searches for the same value can still be counted without storing it.
+type Scrubber struct {
+	patterns []*regexp.Regexp
+	hashKey  []byte
+}
+
+// NewScrubber creates a Scrubber removing email and IP addresses, along with anything matching the regular expressions
+// in patterns. Matches are hashed with hashKey, unless it is empty.
+func NewScrubber(patterns []string, hashKey string) (*Scrubber, error) {
+	s := &Scrubber{hashKey: []byte(hashKey)}
+	for _, p := range append(defaultScrubPatterns[:len(defaultScrubPatterns):len(defaultScrubPatterns)], patterns...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid analytics scrub pattern %q", p)
+		}
+		s.patterns = append(s.patterns, re)
+	}
+	return s, nil
+}
+
+// apply scrubs the term, URL and referer of e, returning the names of the fields that had personal data removed
+func (s *Scrubber) apply(e *Event) []string {
+	var scrubbed []string
+	if term := s.scrub(e.Term); term != e.Term {
+		e.Term = term
+		scrubbed = append(scrubbed, termParam)
+	}
+	if u := s.scrubURL(e.URL); u != e.URL {
+		e.URL = u
+		scrubbed = append(scrubbed, urlParam)
+	}
+	// the referer is usually the search page, with the term in its query
+	if referer := s.scrubURL(e.Referer); referer != e.Referer {
+		e.Referer = referer
+		scrubbed = append(scrubbed, "referer")
+	}
+	return scrubbed
+}
+
+func (s *Scrubber) scrub(value string) string {
+	for _, re := range s.patterns {
+		value = re.ReplaceAllStringFunc(value, s.replace)
+	}
+	return value
+}
+
+// scrubURL scrubs rawURL as is, then each of its decoded query values, as personal data in a query is usually encoded
+// (for instance, the @ of an email address as %40)
+func (s *Scrubber) scrubURL(rawURL string) string {
+	rawURL = s.scrub(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+
+	query := u.Query()
+	changed := false
+	for _, values := range query {
+		for i, v := range values {
+			if scrubbed := s.scrub(v); scrubbed != v {
+				values[i] = scrubbed
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return rawURL
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func (s *Scrubber) replace(match string) string {
+	if len(s.hashKey) == 0 {
+		return redacted
+	}
+	mac := hmac.New(sha256.New, s.hashKey)
+	mac.Write([]byte(match))
+	return "[redacted:" + hex.EncodeToString(mac.Sum(nil))[:16] + "]"
+}
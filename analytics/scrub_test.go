@@ -0,0 +1,92 @@
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrubber(t *testing.T) {
+	Convey("Given a scrubber with an extra pattern for National Insurance numbers", t, func() {
+		s, err := NewScrubber([]string{`\b[A-Z]{2}\d{6}[A-Z]\b`}, "")
+		So(err, ShouldBeNil)
+
+		Convey("Email, IP addresses and values matching the pattern are replaced in the term", func() {
+			e := Event{Term: "jo.bloggs@example.com 192.168.0.1 2001:db8::1 QQ123456C", URL: "/economy"}
+			So(s.apply(&e), ShouldResemble, []string{termParam})
+			So(e.Term, ShouldEqual, "[redacted] [redacted] [redacted] [redacted]")
+			So(e.URL, ShouldEqual, "/economy")
+		})
+
+		Convey("Terms without personal data, such as times and decimal figures, are left as they are", func() {
+			e := Event{Term: "gdp 12:30:45 2.5", URL: "/economy"}
+			So(s.apply(&e), ShouldBeEmpty)
+			So(e.Term, ShouldEqual, "gdp 12:30:45 2.5")
+		})
+
+		Convey("Personal data encoded in the query of the URL is replaced", func() {
+			e := Event{URL: "/search?q=jo.bloggs%40example.com&page=2"}
+			So(s.apply(&e), ShouldResemble, []string{urlParam})
+			So(e.URL, ShouldEqual, "/search?page=2&q=%5Bredacted%5D")
+		})
+
+		Convey("Personal data in the referer, such as the search the link was followed from, is replaced", func() {
+			e := Event{URL: "/economy", Referer: "https://www.ons.gov.uk/search?q=jo.bloggs%40example.com"}
+			So(s.apply(&e), ShouldResemble, []string{"referer"})
+			So(e.Referer, ShouldEqual, "https://www.ons.gov.uk/search?q=%5Bredacted%5D")
+		})
+	})
+
+	Convey("Given a scrubber with a hash key", t, func() {
+		s, err := NewScrubber(nil, "key")
+		So(err, ShouldBeNil)
+
+		Convey("The same values are replaced with the same hash, and different values with different hashes", func() {
+			a, b, c := Event{Term: "jo@example.com"}, Event{Term: "jo@example.com"}, Event{Term: "al@example.com"}
+			s.apply(&a)
+			s.apply(&b)
+			s.apply(&c)
+			So(a.Term, ShouldStartWith, "[redacted:")
+			So(a.Term, ShouldNotContainSubstring, "example")
+			So(a.Term, ShouldEqual, b.Term)
+			So(a.Term, ShouldNotEqual, c.Term)
+		})
+	})
+
+	Convey("An invalid pattern is an error", t, func() {
+		_, err := NewScrubber([]string{"("}, "")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestServiceScrubbing(t *testing.T) {
+	Convey("Given an analytics service scrubbing personal data", t, func() {
+		backend := &fakeBackend{}
+		scrubber, err := NewScrubber(nil, "")
+		So(err, ShouldBeNil)
+		s := NewServiceImpl(backend, "secret").WithScrubber(scrubber)
+
+		Convey("When the URL has an email address in its query", func() {
+			tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"uri": "/search?q=jo@example.com"}).
+				SignedString(hmacSampleSecret)
+			So(err, ShouldBeNil)
+
+			var url string
+			router := mux.NewRouter()
+			router.HandleFunc("/redir/{data:.*}", func(w http.ResponseWriter, req *http.Request) {
+				url, err = s.CaptureAnalyticsData(req)
+			})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/redir/"+tokenString, http.NoBody))
+
+			Convey("Then the user is redirected to the URL as given, but it is stored scrubbed", func() {
+				So(err, ShouldBeNil)
+				So(url, ShouldEqual, "/search?q=jo@example.com")
+				So(backend.urls, ShouldResemble, []string{"/search?q=[redacted]"})
+			})
+		})
+	})
+}
@@ -1,9 +1,12 @@
 package analytics
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"slices"
 
+	"github.com/ONSdigital/dp-frontend-router/helpers"
 	"github.com/ONSdigital/log.go/v2/log"
 	jwt "github.com/form3tech-oss/jwt-go"
 	"github.com/gorilla/mux"
@@ -24,20 +27,67 @@ type Service interface {
 	CaptureAnalyticsData(r *http.Request) (string, error)
 }
 
-// ServiceBackend is used to store data output by the analytics service
+// ServiceBackend is used to store data output by the analytics service. Store returns any error storing the event, so
+// that it can be retried or counted, and stops if ctx is cancelled.
 type ServiceBackend interface {
-	Store(req *http.Request, url, term, listType, gaID string, gID string, pageIndex, linkIndex, pageSize float64)
+	Store(ctx context.Context, event Event) error
 }
 
 // ServiceImpl - Implementation of the Analytics Service interface.
 type ServiceImpl struct {
 	backend        ServiceBackend
 	redirectSecret string
+	limits         *Limits
+	allowedDomains []string
+	sampleRate     float64
+	scrubber       *Scrubber
+	experiments    []Experiment
+}
+
+// Experiment is an experiment that users are assigned to a bucket of by a cookie, recorded in their events
+type Experiment struct {
+	Name   string
+	Cookie string
+	// Buckets are the values of the cookie that are recorded, as the cookie is sent by the client
+	Buckets []string
 }
 
 // NewServiceImpl - Creates a new Analytics ServiceImpl.
 func NewServiceImpl(backend ServiceBackend, redirectSecret string) *ServiceImpl {
-	return &ServiceImpl{backend, redirectSecret}
+	return &ServiceImpl{backend: backend, redirectSecret: redirectSecret, sampleRate: 1}
+}
+
+// WithLimits - clamps the page index, link index and page size to the given limits before they are stored.
+func (s *ServiceImpl) WithLimits(limits Limits) *ServiceImpl {
+	s.limits = &limits
+	return s
+}
+
+// WithAllowedRedirectDomains - allows redirecting to URLs on the given domains and their subdomains, as well as to paths on
+// this site, which are always allowed.
+func (s *ServiceImpl) WithAllowedRedirectDomains(domains []string) *ServiceImpl {
+	s.allowedDomains = domains
+	return s
+}
+
+// WithSampleRate - stores only the given fraction of events, from 0 to 1, to control the volume of data stored. Events
+// are sampled by session, using the _gid cookie or else the _ga cookie, so a session's events are all stored or none are.
+func (s *ServiceImpl) WithSampleRate(rate float64) *ServiceImpl {
+	s.sampleRate = rate
+	return s
+}
+
+// WithScrubber - removes personal data from the events with the given scrubber before they are logged or stored.
+func (s *ServiceImpl) WithScrubber(scrubber *Scrubber) *ServiceImpl {
+	s.scrubber = scrubber
+	return s
+}
+
+// WithExperiments - records the bucket that the user was assigned to in each of the given experiments in their events,
+// from the experiment's cookie.
+func (s *ServiceImpl) WithExperiments(experiments []Experiment) *ServiceImpl {
+	s.experiments = experiments
+	return s
 }
 
 // CaptureAnalyticsData - captures the analytics values
@@ -63,51 +113,71 @@ func (s *ServiceImpl) CaptureAnalyticsData(r *http.Request) (string, error) {
 		return "", errors.New("error validating token")
 	}
 
-	url := getStringClaim(claims, "uri")
-	term := getStringClaim(claims, "term")
-	listType := getStringClaim(claims, "listType")
-	pageIndex := getFloat64Claim(claims, "page")
-	linkIndex := getFloat64Claim(claims, "index")
-	pageSize := getFloat64Claim(claims, "pageSize")
+	event, problems := eventFromClaims(claims)
+	event.GAID = getCookieValue(r, "_ga")
+	event.GID = getCookieValue(r, "_gid")
+	enrich(&event, r)
+	event.Experiments = experimentBuckets(r, s.experiments)
 
-	gaID := getCookieValue(r, "_ga")
-	gID := getCookieValue(r, "_gid")
-
-	if url == "" {
+	if event.URL == "" {
 		return "", errors.New("URL is a mandatory parameter")
 	}
 
-	logData := log.Data{
-		urlParam:        url,
-		termParam:       term,
-		searchTypeParam: listType,
-		pageIndexParam:  pageIndex,
-		linkIndexParam:  linkIndex,
-		pageSizeParam:   pageSize,
-		gaIDParam:       gaID,
-		gIDParam:        gID,
+	// never redirect off the site, other than to an allowed domain, even with a validly signed token
+	if !isAllowedRedirect(event.URL, s.allowedDomains) {
+		return "", errors.New("URL is not a path or on an allowed domain")
 	}
-	log.Info(r.Context(), "search analytics data", logData)
 
-	if s.backend != nil {
-		s.backend.Store(r, url, term, listType, gaID, gID, pageIndex, linkIndex, pageSize)
+	if s.limits != nil {
+		event.PageIndex, event.LinkIndex, event.PageSize = s.limits.clampAll(r.Context(), event.PageIndex, event.LinkIndex, event.PageSize)
 	}
 
-	return url, nil
-}
+	// the user is redirected to the URL as given, while any personal data in it is not logged or stored
+	redirectURL := event.URL
+	var scrubbed []string
+	if s.scrubber != nil {
+		scrubbed = s.scrubber.apply(&event)
+	}
 
-func getStringClaim(claims jwt.MapClaims, key string) string {
-	if val, ok := claims[key].(string); ok {
-		return val
+	logData := log.Data{
+		urlParam:        event.URL,
+		termParam:       event.Term,
+		searchTypeParam: event.ListType,
+		pageIndexParam:  event.PageIndex,
+		linkIndexParam:  event.LinkIndex,
+		pageSizeParam:   event.PageSize,
+		gaIDParam:       event.GAID,
+		gIDParam:        event.GID,
+	}
+	if len(event.Experiments) > 0 {
+		logData["experiments"] = event.Experiments
+	}
+	if len(scrubbed) > 0 {
+		logData["scrubbed"] = scrubbed
 	}
-	return ""
-}
 
-func getFloat64Claim(claims jwt.MapClaims, key string) float64 {
-	if val, ok := claims[key].(float64); ok {
-		return val
+	// a malformed event is not stored, so that consumers only get events matching the schema, but the user is still
+	// redirected to the URL
+	if problems = append(problems, event.validate()...); len(problems) > 0 {
+		logData["problems"] = problems
+		log.Warn(r.Context(), "rejecting malformed search analytics data", logData)
+		return redirectURL, nil
 	}
-	return 0
+	if s.sampleRate < 1 && sessionSample(event.GAID, event.GID) >= s.sampleRate {
+		logData["sampled"] = false
+		log.Info(r.Context(), "search analytics data", logData)
+		return redirectURL, nil
+	}
+	log.Info(r.Context(), "search analytics data", logData)
+
+	if s.backend != nil {
+		ctx := withClientIP(r.Context(), helpers.ClientIP(r))
+		if err := s.backend.Store(ctx, event); err != nil {
+			log.Error(r.Context(), "error storing search analytics data", err, logData)
+		}
+	}
+
+	return redirectURL, nil
 }
 
 func getCookieValue(r *http.Request, cookieName string) string {
@@ -116,3 +186,20 @@ func getCookieValue(r *http.Request, cookieName string) string {
 	}
 	return ""
 }
+
+// experimentBuckets returns the buckets of the experiments the user is in, from their cookies, ignoring any cookie that
+// does not hold one of its experiment's buckets
+func experimentBuckets(r *http.Request, experiments []Experiment) map[string]string {
+	var buckets map[string]string
+	for _, e := range experiments {
+		bucket := getCookieValue(r, e.Cookie)
+		if bucket == "" || !slices.Contains(e.Buckets, bucket) {
+			continue
+		}
+		if buckets == nil {
+			buckets = make(map[string]string)
+		}
+		buckets[e.Name] = bucket
+	}
+	return buckets
+}
@@ -359,3 +359,28 @@ func Test_mapClaims_string_aud_no_claim_not_required(t *testing.T) {
 		t.Fatalf("Failed to verify claims, wanted: %v got %v", want, got)
 	}
 }
+
+func TestExperimentBuckets(t *testing.T) {
+	experiments := []Experiment{
+		{Name: "search", Cookie: "exp_search", Buckets: []string{"control", "new"}},
+		{Name: "homepage", Cookie: "exp_homepage", Buckets: []string{"control", "hero"}},
+	}
+
+	Convey("Given a request from a user in an experiment's bucket", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/redir/abc", http.NoBody)
+		req.AddCookie(&http.Cookie{Name: "exp_search", Value: "new"})
+
+		Convey("Then the bucket is recorded against the experiment", func() {
+			So(experimentBuckets(req, experiments), ShouldResemble, map[string]string{"search": "new"})
+		})
+	})
+
+	Convey("Given a request with an experiment cookie that does not hold one of its buckets", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/redir/abc", http.NoBody)
+		req.AddCookie(&http.Cookie{Name: "exp_homepage", Value: "<script>"})
+
+		Convey("Then nothing is recorded", func() {
+			So(experimentBuckets(req, experiments), ShouldBeNil)
+		})
+	})
+}
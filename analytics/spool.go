@@ -0,0 +1,199 @@
+package analytics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/pkg/errors"
+)
+
+// Spool keeps analytics events that a backend failed to store in a file, as JSON lines, and sends them to the backend
+// again in the background, so that an outage of the backend does not lose the data. The events are sent in the order
+// they were spooled, stopping at the first failure until the next retry. The file outlives the router, so events
+// spooled before a restart are sent once it starts again.
+type Spool struct {
+	backend   ServiceBackend
+	path      string
+	maxEvents int
+	timeout   time.Duration
+
+	// mu guards the file and count, the number of events in it
+	mu    sync.Mutex
+	count int
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// NewSpool creates a Spool keeping up to maxEvents events in the file at path, and sending them to backend every
+// retryInterval, giving each send timeout
+func NewSpool(backend ServiceBackend, path string, maxEvents int, retryInterval, timeout time.Duration) (*Spool, error) {
+	s := &Spool{
+		backend:   backend,
+		path:      path,
+		maxEvents: maxEvents,
+		timeout:   timeout,
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+
+	// count the events left from before a restart, so that they are within maxEvents
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "error reading analytics spool")
+	}
+	s.count = bytes.Count(b, []byte{'\n'})
+
+	go s.run(retryInterval)
+	return s, nil
+}
+
+// add spools the event, returning false if it could not be, as the spool is full or the file could not be written
+func (s *Spool) add(ctx context.Context, e Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count >= s.maxEvents {
+		log.Warn(ctx, "analytics spool is full", log.Data{"path": s.path, "events": s.count})
+		return false
+	}
+	if err := s.write([]Event{e}); err != nil {
+		log.Error(ctx, "error writing to analytics spool", err, log.Data{"path": s.path})
+		return false
+	}
+	return true
+}
+
+// Close stops sending the spooled events, leaving any not yet sent in the file. It may be called more than once.
+func (s *Spool) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	<-s.stopped
+	return nil
+}
+
+func (s *Spool) run(retryInterval time.Duration) {
+	defer close(s.stopped)
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.send()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// send sends the spooled events to the backend, putting back those not sent if it fails
+func (s *Spool) send() {
+	ctx := context.Background()
+	events, err := s.take()
+	if err != nil {
+		log.Error(ctx, "error reading analytics spool", err, log.Data{"path": s.path})
+		return
+	}
+
+	for i, e := range events {
+		if err := s.store(ctx, e); err != nil {
+			log.Warn(ctx, "failed to send spooled analytics data, will retry", log.Data{"remaining": len(events) - i})
+			s.putBack(ctx, events[i:])
+			return
+		}
+	}
+	if len(events) > 0 {
+		log.Info(ctx, "sent spooled analytics data", log.Data{"events": len(events)})
+	}
+}
+
+// store sends an event to the backend, within the timeout
+func (s *Spool) store(ctx context.Context, e Event) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.backend.Store(ctx, e)
+}
+
+// take removes and returns every spooled event, so that events spooled while they are sent are not lost when those not
+// sent are put back
+func (s *Spool) take() ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			log.Warn(context.Background(), "skipping malformed event in analytics spool", log.Data{"path": s.path})
+			continue
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := os.Remove(s.path); err != nil {
+		return nil, err
+	}
+	s.count = 0
+	return events, nil
+}
+
+// putBack spools events that were taken but not sent, dropping the newest of them if the spool has since filled up
+func (s *Spool) putBack(ctx context.Context, events []Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if space := s.maxEvents - s.count; len(events) > space {
+		log.Warn(ctx, "dropping spooled analytics data as the spool is full", log.Data{"dropped": len(events) - space})
+		events = events[:max(space, 0)]
+	}
+	if err := s.write(events); err != nil {
+		log.Error(ctx, "error writing to analytics spool, dropping spooled analytics data", err,
+			log.Data{"path": s.path, "dropped": len(events)})
+	}
+}
+
+// write appends events to the file, which mu must be held for
+func (s *Spool) write(events []Event) error {
+	var buf bytes.Buffer
+	for i := range events {
+		jb, err := json.Marshal(&events[i])
+		if err != nil {
+			return err
+		}
+		buf.Write(append(jb, '\n'))
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	s.count += len(events)
+	return nil
+}
@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// outageBackend fails every store while down, and records the URL and creation time of the data stored otherwise
+type outageBackend struct {
+	mu      sync.Mutex
+	down    bool
+	urls    []string
+	created []string
+}
+
+func (b *outageBackend) Store(ctx context.Context, event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.down {
+		return errors.New("sqs unavailable")
+	}
+	b.urls = append(b.urls, event.URL)
+	b.created = append(b.created, event.Created)
+	return nil
+}
+
+func TestSpool(t *testing.T) {
+	Convey("Given a spool for a backend that is down", t, func() {
+		backend := &outageBackend{down: true}
+		path := filepath.Join(t.TempDir(), "sqs.jsonl")
+		// sent by calling send, rather than on the interval
+		spool, err := NewSpool(backend, path, 3, time.Hour, time.Second)
+		So(err, ShouldBeNil)
+		defer func() { spool.Close() }()
+
+		ctx := context.Background()
+		created := "2024-01-02T03:04:05Z"
+		So(spool.add(ctx, Event{URL: "/first", Created: created}), ShouldBeTrue)
+		So(spool.add(ctx, Event{URL: "/second", Created: created}), ShouldBeTrue)
+
+		Convey("When the events are sent while the backend is still down", func() {
+			spool.send()
+
+			Convey("Then they are kept in the spool", func() {
+				So(backend.urls, ShouldBeEmpty)
+				So(spool.count, ShouldEqual, 2)
+				_, err := os.Stat(path)
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When the events are sent once the backend has recovered", func() {
+			backend.down = false
+			spool.send()
+
+			Convey("Then they are stored in order, with the time they were created", func() {
+				So(backend.urls, ShouldResemble, []string{"/first", "/second"})
+				So(backend.created, ShouldResemble, []string{created, created})
+			})
+
+			Convey("And the spool is emptied", func() {
+				So(spool.count, ShouldEqual, 0)
+				_, err := os.Stat(path)
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the spool is full", func() {
+			So(spool.add(ctx, Event{URL: "/third"}), ShouldBeTrue)
+
+			Convey("Then no more events are spooled", func() {
+				So(spool.add(ctx, Event{URL: "/fourth"}), ShouldBeFalse)
+				So(spool.count, ShouldEqual, 3)
+			})
+		})
+
+		Convey("When the router restarts", func() {
+			So(spool.Close(), ShouldBeNil)
+			restarted, err := NewSpool(backend, path, 3, time.Hour, time.Second)
+			So(err, ShouldBeNil)
+			spool = restarted
+
+			Convey("Then the events spooled before are kept", func() {
+				So(restarted.count, ShouldEqual, 2)
+				backend.down = false
+				restarted.send()
+				So(backend.urls, ShouldResemble, []string{"/first", "/second"})
+			})
+		})
+	})
+}
+
+func TestAsyncBackendWithSpool(t *testing.T) {
+	Convey("Given an async backend with a spool, wrapping a backend that is down", t, func() {
+		backend := &outageBackend{down: true}
+		spool, err := NewSpool(backend, filepath.Join(t.TempDir(), "sqs.jsonl"), 10, time.Hour, time.Second)
+		So(err, ShouldBeNil)
+		async := NewAsyncBackend(backend, 1, 1, 1, time.Second).WithMetrics(NewAsyncMetrics(nil), "sqs").WithSpool(spool)
+		async.retryBackoff = time.Millisecond
+
+		Convey("When data fails to store after retrying", func() {
+			async.Store(context.Background(), gdpEvent)
+			async.Wait()
+
+			Convey("Then it is spooled rather than dropped", func() {
+				So(async.metrics.dropped.Value("sqs", dropFailed), ShouldEqual, 0)
+				So(spool.count, ShouldEqual, 1)
+
+				Convey("And it is stored once the backend recovers", func() {
+					backend.down = false
+					spool.send()
+					So(backend.urls, ShouldResemble, []string{"/economy"})
+					So(async.Close(), ShouldBeNil)
+				})
+			})
+		})
+
+		Convey("When the async backend is closed", func() {
+			So(async.Close(), ShouldBeNil)
+
+			Convey("Then the spool has stopped sending", func() {
+				select {
+				case <-spool.stopped:
+				default:
+					So("spool still running", ShouldBeEmpty)
+				}
+				So(spool.Close(), ShouldBeNil)
+			})
+		})
+	})
+}
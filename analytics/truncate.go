@@ -0,0 +1,47 @@
+package analytics
+
+import "unicode/utf8"
+
+// FieldLimits are the maximum lengths, in bytes, of the analytics string fields. A limit of zero leaves the field
+// unlimited. The URL limit also applies to the referer.
+type FieldLimits struct {
+	Term     int
+	URL      int
+	ListType int
+}
+
+// truncatedField is the event field listing the names of any fields that were truncated
+const truncatedField = "truncated"
+
+// apply truncates each string field of e that exceeds its limit, returning the names of the fields truncated in name
+// order
+func (l FieldLimits) apply(e *Event) []string {
+	fields := []struct {
+		name  string
+		value *string
+		limit int
+	}{
+		{"listType", &e.ListType, l.ListType},
+		{"referer", &e.Referer, l.URL},
+		{termParam, &e.Term, l.Term},
+		{urlParam, &e.URL, l.URL},
+	}
+
+	var truncated []string
+	for _, f := range fields {
+		if f.limit <= 0 || len(*f.value) <= f.limit {
+			continue
+		}
+		*f.value = truncate(*f.value, f.limit)
+		truncated = append(truncated, f.name)
+	}
+	return truncated
+}
+
+// truncate shortens s to at most n bytes without splitting a multi-byte character
+func truncate(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
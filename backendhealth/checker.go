@@ -0,0 +1,76 @@
+package backendhealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+)
+
+// Backend is a backend that the router proxies to, checked by the router's healthcheck
+type Backend struct {
+	Name string
+	URL  *url.URL
+	// HeadOnly checks a backend with no /health endpoint, such as Babbage, by a HEAD request for its root instead
+	HeadOnly bool
+}
+
+// Checker returns a healthcheck checker reporting whether backend is reachable, by a GET of its /health endpoint or, if
+// it has none, a HEAD of its root. The backend is critical if it cannot be reached or responds with an error, other
+// than a 429, which is only a warning as the backend is up but busy.
+func Checker(client dphttp.Clienter, backend Backend) healthcheck.Checker {
+	return func(ctx context.Context, state *healthcheck.CheckState) error {
+		status, code, message, err := check(ctx, client, backend)
+		if err != nil {
+			return err
+		}
+		return state.Update(status, message, code)
+	}
+}
+
+// Reachable returns a readiness check that fails unless backend is reachable, as reported by Checker
+func Reachable(client dphttp.Clienter, backend Backend) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		status, _, message, err := check(ctx, client, backend)
+		if err != nil {
+			return err
+		}
+		if status == healthcheck.StatusCritical {
+			return errors.New(message)
+		}
+		return nil
+	}
+}
+
+// check requests backend, returning its healthcheck status, the status code it responded with and a message describing
+// the status
+func check(ctx context.Context, client dphttp.Clienter, backend Backend) (status string, code int, message string, err error) {
+	method, target := http.MethodGet, backend.URL.JoinPath("/health").String()
+	if backend.HeadOnly {
+		method, target = http.MethodHead, backend.URL.String()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, http.NoBody)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	resp, err := client.Do(ctx, req)
+	if err != nil {
+		return healthcheck.StatusCritical, 0, fmt.Sprintf("%s is unreachable: %s", backend.Name, err), nil
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return healthcheck.StatusWarning, resp.StatusCode, backend.Name + " is degraded", nil
+	case resp.StatusCode >= http.StatusBadRequest:
+		return healthcheck.StatusCritical, resp.StatusCode, fmt.Sprintf("%s responded with %d", backend.Name, resp.StatusCode), nil
+	default:
+		return healthcheck.StatusOK, resp.StatusCode, backend.Name + " is reachable", nil
+	}
+}
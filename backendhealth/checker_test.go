@@ -0,0 +1,115 @@
+package backendhealth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestChecker(t *testing.T) {
+	Convey("Given a backend", t, func() {
+		backendURL, _ := url.Parse("http://search:25000")
+		var status int
+		var err error
+		client := &dphttp.ClienterMock{
+			DoFunc: func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				if err != nil {
+					return nil, err
+				}
+				return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+			},
+		}
+		check := func(backend Backend) *healthcheck.CheckState {
+			state := healthcheck.NewCheckState(backend.Name)
+			So(Checker(client, backend)(context.Background(), state), ShouldBeNil)
+			return state
+		}
+
+		Convey("When it has a health endpoint that responds OK", func() {
+			status = http.StatusOK
+			state := check(Backend{Name: "search", URL: backendURL})
+
+			Convey("Then its health endpoint is requested", func() {
+				So(client.DoCalls(), ShouldHaveLength, 1)
+				So(client.DoCalls()[0].Req.Method, ShouldEqual, http.MethodGet)
+				So(client.DoCalls()[0].Req.URL.String(), ShouldEqual, "http://search:25000/health")
+			})
+
+			Convey("Then it is reported as OK", func() {
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+				So(state.StatusCode(), ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("When it has no health endpoint", func() {
+			status = http.StatusOK
+			check(Backend{Name: "babbage", URL: backendURL, HeadOnly: true})
+
+			Convey("Then its root is requested with a HEAD", func() {
+				So(client.DoCalls(), ShouldHaveLength, 1)
+				So(client.DoCalls()[0].Req.Method, ShouldEqual, http.MethodHead)
+				So(client.DoCalls()[0].Req.URL.String(), ShouldEqual, "http://search:25000")
+			})
+		})
+
+		Convey("When it is busy", func() {
+			status = http.StatusTooManyRequests
+			state := check(Backend{Name: "search", URL: backendURL})
+
+			Convey("Then it is reported as a warning", func() {
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+			})
+		})
+
+		Convey("When it responds with an error", func() {
+			status = http.StatusInternalServerError
+			state := check(Backend{Name: "search", URL: backendURL})
+
+			Convey("Then it is reported as critical", func() {
+				So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+				So(state.Message(), ShouldEqual, "search responded with 500")
+			})
+		})
+
+		Convey("When it cannot be reached", func() {
+			err = errors.New("connection refused")
+			state := check(Backend{Name: "search", URL: backendURL})
+
+			Convey("Then it is reported as critical", func() {
+				So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+				So(state.Message(), ShouldContainSubstring, "connection refused")
+			})
+		})
+	})
+}
+
+func TestReachable(t *testing.T) {
+	Convey("Given a readiness check of a backend", t, func() {
+		backendURL, _ := url.Parse("http://search:25000")
+		var status int
+		client := &dphttp.ClienterMock{
+			DoFunc: func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+			},
+		}
+		check := Reachable(client, Backend{Name: "search", URL: backendURL})
+
+		Convey("Then it passes while the backend is up, even when busy", func() {
+			status = http.StatusOK
+			So(check(context.Background()), ShouldBeNil)
+			status = http.StatusTooManyRequests
+			So(check(context.Background()), ShouldBeNil)
+		})
+
+		Convey("Then it fails while the backend responds with an error", func() {
+			status = http.StatusBadGateway
+			So(check(context.Background()), ShouldBeError, "search responded with 502")
+		})
+	})
+}
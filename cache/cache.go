@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+var _ StatsReporter = &Cache[string]{}
+
+type entry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// Cache is a concurrency safe, size bounded cache that evicts the least recently used entry when full, and whose
+// entries expire after a fixed time to live
+type Cache[V any] struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	ll         *list.List
+	items      map[string]*list.Element
+	hits       uint64
+	misses     uint64
+	evictions  uint64
+	now        func() time.Time
+}
+
+// New creates a cache holding at most maxEntries entries, each for no longer than ttl
+func New[V any](maxEntries int, ttl time.Duration) *Cache[V] {
+	return &Cache[V]{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get returns the value cached against key, if present and not expired
+func (c *Cache[V]) Get(key string) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return value, false
+	}
+
+	e := el.Value.(*entry[V])
+	if c.now().After(e.expires) {
+		c.removeElement(el)
+		c.evictions++
+		c.misses++
+		return value, false
+	}
+
+	c.ll.MoveToFront(el)
+	c.hits++
+	return e.value, true
+}
+
+// Set caches value against key, evicting the least recently used entry if the cache is full
+func (c *Cache[V]) Set(key string, value V) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL caches value against key for ttl rather than the cache's own time to live, evicting the least recently
+// used entry if the cache is full
+func (c *Cache[V]) SetWithTTL(key string, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		e := el.Value.(*entry[V])
+		e.value = value
+		e.expires = expires
+		return
+	}
+
+	c.add(key, value, expires)
+}
+
+// SetIfAbsent caches value against key only if no unexpired value is cached against it already, returning whether
+// value was cached. Checking and setting happen atomically, so exactly one of any concurrent callers succeeds.
+func (c *Cache[V]) SetIfAbsent(key string, value V) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if el, ok := c.items[key]; ok {
+		if !now.After(el.Value.(*entry[V]).expires) {
+			return false
+		}
+		c.removeElement(el)
+		c.evictions++
+	}
+
+	c.add(key, value, now.Add(c.ttl))
+	return true
+}
+
+// Delete removes any value cached against key
+func (c *Cache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// Len returns the number of entries currently held, including any that have expired but not yet been removed
+func (c *Cache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// Stats returns the hit, miss and eviction counts of the cache along with its current size. Expired entries are
+// counted as evictions when they are removed.
+func (c *Cache[V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.ll.Len(),
+	}
+}
+
+// add caches a new entry, evicting the least recently used entry if the cache is full. The lock must be held.
+func (c *Cache[V]) add(key string, value V, expires time.Time) {
+	c.items[key] = c.ll.PushFront(&entry[V]{key: key, value: value, expires: expires})
+	if c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+		c.evictions++
+	}
+}
+
+func (c *Cache[V]) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry[V]).key)
+}
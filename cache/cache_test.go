@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCache(t *testing.T) {
+	Convey("Given a cache holding two entries", t, func() {
+		now := time.Now()
+		c := New[string](2, time.Minute)
+		c.now = func() time.Time { return now }
+
+		Convey("When values are set and read back", func() {
+			c.Set("a", "1")
+			c.Set("b", "2")
+			a, aOK := c.Get("a")
+			_, missingOK := c.Get("missing")
+
+			Convey("Then cached values are returned as hits and unknown keys as misses", func() {
+				So(aOK, ShouldBeTrue)
+				So(a, ShouldEqual, "1")
+				So(missingOK, ShouldBeFalse)
+				So(c.Stats(), ShouldResemble, Stats{Hits: 1, Misses: 1, Evictions: 0, Size: 2})
+			})
+		})
+
+		Convey("When a third value is set", func() {
+			c.Set("a", "1")
+			c.Set("b", "2")
+			c.Get("a")
+			c.Set("c", "3")
+
+			Convey("Then the least recently used entry is evicted", func() {
+				_, bOK := c.Get("b")
+				_, aOK := c.Get("a")
+				_, cOK := c.Get("c")
+				So(bOK, ShouldBeFalse)
+				So(aOK, ShouldBeTrue)
+				So(cOK, ShouldBeTrue)
+				So(c.Stats(), ShouldResemble, Stats{Hits: 3, Misses: 1, Evictions: 1, Size: 2})
+			})
+		})
+
+		Convey("When an entry outlives its time to live", func() {
+			c.Set("a", "1")
+			now = now.Add(2 * time.Minute)
+			_, ok := c.Get("a")
+
+			Convey("Then it is a miss and is counted as an eviction", func() {
+				So(ok, ShouldBeFalse)
+				So(c.Stats(), ShouldResemble, Stats{Hits: 0, Misses: 1, Evictions: 1, Size: 0})
+			})
+		})
+
+		Convey("When an entry is set with its own time to live", func() {
+			c.SetWithTTL("a", "1", 5*time.Minute)
+			c.SetWithTTL("b", "2", 10*time.Second)
+			now = now.Add(2 * time.Minute)
+			_, aOK := c.Get("a")
+			_, bOK := c.Get("b")
+
+			Convey("Then it expires after that time to live instead of the cache's", func() {
+				So(aOK, ShouldBeTrue)
+				So(bOK, ShouldBeFalse)
+			})
+		})
+
+		Convey("When an entry is deleted", func() {
+			c.Set("a", "1")
+			c.Delete("a")
+			_, ok := c.Get("a")
+
+			Convey("Then it is no longer returned", func() {
+				So(ok, ShouldBeFalse)
+				So(c.Len(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a value is set only if absent", func() {
+			first := c.SetIfAbsent("a", "1")
+			second := c.SetIfAbsent("a", "2")
+
+			Convey("Then only the first value is cached", func() {
+				So(first, ShouldBeTrue)
+				So(second, ShouldBeFalse)
+				v, _ := c.Get("a")
+				So(v, ShouldEqual, "1")
+			})
+
+			Convey("Then it can be set again once the first value has expired", func() {
+				now = now.Add(2 * time.Minute)
+				So(c.SetIfAbsent("a", "3"), ShouldBeTrue)
+				v, _ := c.Get("a")
+				So(v, ShouldEqual, "3")
+			})
+		})
+	})
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CookiePolicy decides how the cookies on a request affect the caching of content for it. Personalised cookies, such as
+// locale or experiment bucket, should vary the key so that each variant is cached separately, while cookies that make
+// content private, such as preview collections, should bypass the cache altogether.
+type CookiePolicy struct {
+	VaryCookies   []string
+	BypassCookies []string
+}
+
+// Key returns the cache key for req, built from base and the values of any vary cookies present. It returns false if a
+// bypass cookie is present, in which case the cache must not be read from or written to.
+func (p CookiePolicy) Key(req *http.Request, base string) (string, bool) {
+	for _, name := range p.BypassCookies {
+		if c, err := req.Cookie(name); err == nil && c.Value != "" {
+			return "", false
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base)
+	for _, name := range p.VaryCookies {
+		if c, err := req.Cookie(name); err == nil && c.Value != "" {
+			sb.WriteString("|" + name + "=" + c.Value)
+		}
+	}
+	return sb.String(), true
+}
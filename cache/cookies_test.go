@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCookiePolicy(t *testing.T) {
+	Convey("Given a cookie policy varying on the lang and bucket cookies, and bypassing on the collection cookie", t, func() {
+		p := CookiePolicy{VaryCookies: []string{"lang", "bucket"}, BypassCookies: []string{"collection"}}
+
+		request := func(cookies ...*http.Cookie) *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			return req
+		}
+
+		Convey("When a request has no cookies", func() {
+			key, ok := p.Key(request(), "/economy")
+
+			Convey("Then the base key is used", func() {
+				So(ok, ShouldBeTrue)
+				So(key, ShouldEqual, "/economy")
+			})
+		})
+
+		Convey("When requests have different values of a vary cookie", func() {
+			en, _ := p.Key(request(&http.Cookie{Name: "lang", Value: "en"}), "/economy")
+			cy, _ := p.Key(request(&http.Cookie{Name: "lang", Value: "cy"}), "/economy")
+
+			Convey("Then their keys are separate", func() {
+				So(en, ShouldEqual, "/economy|lang=en")
+				So(cy, ShouldEqual, "/economy|lang=cy")
+			})
+		})
+
+		Convey("When a request has vary cookies in any order", func() {
+			key, _ := p.Key(request(&http.Cookie{Name: "bucket", Value: "b"}, &http.Cookie{Name: "lang", Value: "cy"}), "/economy")
+
+			Convey("Then the key follows the configured order", func() {
+				So(key, ShouldEqual, "/economy|lang=cy|bucket=b")
+			})
+		})
+
+		Convey("When a request has a cookie not in the policy", func() {
+			key, ok := p.Key(request(&http.Cookie{Name: "_ga", Value: "GA1.1"}), "/economy")
+
+			Convey("Then it does not affect the key", func() {
+				So(ok, ShouldBeTrue)
+				So(key, ShouldEqual, "/economy")
+			})
+		})
+
+		Convey("When a request has a bypass cookie", func() {
+			_, ok := p.Key(request(&http.Cookie{Name: "lang", Value: "en"}, &http.Cookie{Name: "collection", Value: "abc"}), "/economy")
+
+			Convey("Then the cache is bypassed", func() {
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EncodingKey returns the cache key for req, built from base and the content codings that req accepts, so that a
+// response compressed for one client is never served to a client that cannot decode it. Requests that accept the same
+// codings share a key however their Accept-Encoding is written.
+func EncodingKey(req *http.Request, base string) string {
+	var codings []string
+	for _, value := range req.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(part, ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding == "" || coding == "identity" || !accepted(params) {
+				continue
+			}
+			if !slices.Contains(codings, coding) {
+				codings = append(codings, coding)
+			}
+		}
+	}
+	if len(codings) == 0 {
+		return base
+	}
+	slices.Sort(codings)
+	return base + "|encoding=" + strings.Join(codings, ",")
+}
+
+// accepted reports whether the parameters of a coding in Accept-Encoding accept it, that is, do not give it a q of 0
+func accepted(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(name, "q") {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			return err == nil && q > 0
+		}
+	}
+	return true
+}
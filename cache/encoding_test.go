@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEncodingKey(t *testing.T) {
+	request := func(acceptEncoding string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		return req
+	}
+
+	Convey("A request that accepts no encoding uses the base key", t, func() {
+		So(EncodingKey(request(""), "/economy"), ShouldEqual, "/economy")
+		So(EncodingKey(request("identity"), "/economy"), ShouldEqual, "/economy")
+	})
+
+	Convey("A request that accepts gzip is keyed separately", t, func() {
+		So(EncodingKey(request("gzip"), "/economy"), ShouldEqual, "/economy|encoding=gzip")
+	})
+
+	Convey("Requests that accept the same codings share a key however they are written", t, func() {
+		So(EncodingKey(request("gzip, deflate, br"), "/economy"), ShouldEqual, "/economy|encoding=br,deflate,gzip")
+		So(EncodingKey(request("BR;q=1.0,gzip;q=0.8, deflate"), "/economy"), ShouldEqual, "/economy|encoding=br,deflate,gzip")
+	})
+
+	Convey("A coding with a q of 0 is not accepted", t, func() {
+		So(EncodingKey(request("gzip;q=0, br"), "/economy"), ShouldEqual, "/economy|encoding=br")
+		So(EncodingKey(request("gzip; q=0.000"), "/economy"), ShouldEqual, "/economy")
+	})
+}
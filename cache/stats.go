@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Stats holds the statistics reported by a cache
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+}
+
+// StatsReporter is implemented by caches that report statistics
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// Registry holds the caches whose statistics are reported, by name
+type Registry struct {
+	mu     sync.RWMutex
+	caches map[string]StatsReporter
+}
+
+// DefaultRegistry is the registry that caches register themselves with
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{caches: make(map[string]StatsReporter)}
+}
+
+// Register adds a cache to the registry, replacing any cache already registered with the same name
+func (r *Registry) Register(name string, c StatsReporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.caches[name] = c
+}
+
+// Stats returns the current statistics of every registered cache, by name
+func (r *Registry) Stats() map[string]Stats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make(map[string]Stats, len(r.caches))
+	for name, c := range r.caches {
+		stats[name] = c.Stats()
+	}
+	return stats
+}
+
+// Register adds a cache to the DefaultRegistry
+func Register(name string, c StatsReporter) {
+	DefaultRegistry.Register(name, c)
+}
+
+// StatsHandler returns a handler serving the statistics of every cache in the registry as JSON
+func StatsHandler(r *Registry) func(w http.ResponseWriter, req *http.Request) {
+	return func(w http.ResponseWriter, req *http.Request) {
+		b, err := json.Marshal(map[string]interface{}{"caches": r.Stats()})
+		if err != nil {
+			log.Error(req.Context(), "error marshalling cache stats", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(b); err != nil {
+			log.Error(req.Context(), "error writing response", err)
+		}
+	}
+}
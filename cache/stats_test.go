@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegistry(t *testing.T) {
+	Convey("Given a registry with two caches registered", t, func() {
+		registry := NewRegistry()
+		pageTypes := New[string](10, time.Minute)
+		responses := New[[]byte](10, time.Minute)
+		registry.Register("page_types", pageTypes)
+		registry.Register("responses", responses)
+
+		Convey("When each cache is used", func() {
+			pageTypes.Set("/economy", "taxonomy_landing_page")
+			pageTypes.Get("/economy")
+			pageTypes.Get("/economy")
+			responses.Get("/economy")
+
+			Convey("Then the registry reports the stats of each cache", func() {
+				stats := registry.Stats()
+				So(stats["page_types"], ShouldResemble, Stats{Hits: 2, Misses: 0, Evictions: 0, Size: 1})
+				So(stats["responses"], ShouldResemble, Stats{Hits: 0, Misses: 1, Evictions: 0, Size: 0})
+			})
+
+			Convey("Then the stats handler serves them as JSON", func() {
+				w := httptest.NewRecorder()
+				StatsHandler(registry)(w, httptest.NewRequest(http.MethodGet, "/status", http.NoBody))
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+
+				var body struct {
+					Caches map[string]Stats `json:"caches"`
+				}
+				So(json.Unmarshal(w.Body.Bytes(), &body), ShouldBeNil)
+				So(body.Caches["page_types"].Hits, ShouldEqual, 2)
+				So(body.Caches["responses"].Misses, ShouldEqual, 1)
+			})
+		})
+	})
+}
@@ -3,55 +3,235 @@ package config
 import (
 	"strings"
 	"time"
-
-	"github.com/kelseyhightower/envconfig"
 )
 
 // Config represents service configuration for dp-frontend-router
 type Config struct {
-	AWS                          AWS
-	APIRouterURL                 string        `envconfig:"API_ROUTER_URL"`
-	AreaProfilesControllerURL    string        `envconfig:"AREA_PROFILE_CONTROLLER_URL"`
-	AreaProfilesRoutesEnabled    bool          `envconfig:"AREA_PROFILE_ROUTES_ENABLED"`
-	BabbageURL                   string        `envconfig:"BABBAGE_URL"`
-	BindAddr                     string        `envconfig:"BIND_ADDR"`
-	CensusAtlasRoutesEnabled     bool          `envconfig:"CENSUS_ATLAS_ROUTES_ENABLED"`
-	CensusAtlasURL               string        `envconfig:"CENSUS_ATLAS_URL"`
-	ContentTypeByteLimit         int           `envconfig:"CONTENT_TYPE_BYTE_LIMIT"`
-	CookiesControllerURL         string        `envconfig:"COOKIES_CONTROLLER_URL"`
-	DatasetControllerURL         string        `envconfig:"DATASET_CONTROLLER_URL"`
-	DatasetFinderEnabled         bool          `envconfig:"DATASET_FINDER_ENABLED"`
-	DownloaderURL                string        `envconfig:"DOWNLOADER_URL"`
-	FeedbackControllerURL        string        `envconfig:"FEEDBACK_CONTROLLER_URL"`
-	FeedbackEnabled              bool          `envconfig:"FEEDBACK_ENABLED"`
-	FilterDatasetControllerURL   string        `envconfig:"FILTER_DATASET_CONTROLLER_URL"`
-	FilterFlexDatasetServiceURL  string        `envconfig:"FILTER_FLEX_DATASET_SERVICE_URL"`
-	HealthcheckCriticalTimeout   time.Duration `envconfig:"HEALTHCHECK_CRITICAL_TIMEOUT"`
-	HealthcheckInterval          time.Duration `envconfig:"HEALTHCHECK_INTERVAL"`
-	HomepageControllerURL        string        `envconfig:"HOMEPAGE_CONTROLLER_URL"`
-	HTTPMaxConnections           int           `envconfig:"HTTP_MAX_CONNECTIONS"`
-	LegacySearchRedirectsEnabled bool          `envconfig:"LEGACY_SEARCH_REDIRECTS_ENABLED"`
-	LegacyCacheProxyEnabled      bool          `envconfig:"LEGACY_CACHE_PROXY_ENABLED"`
-	LegacyCacheProxyURL          string        `envconfig:"LEGACY_CACHE_PROXY_URL"`
-	NewDatasetRoutingEnabled     bool          `envconfig:"NEW_DATASET_ROUTING_ENABLED"`
-	OTExporterOTLPEndpoint       string        `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT"`
-	OTServiceName                string        `envconfig:"OTEL_SERVICE_NAME"`
-	OTBatchTimeout               time.Duration `envconfig:"OTEL_BATCH_TIMEOUT"`
-	OtelEnabled                  bool          `envconfig:"OTEL_ENABLED"`
-	PatternLibraryAssetsPath     string        `envconfig:"PATTERN_LIBRARY_ASSETS_PATH"`
-	ProxyTimeout                 time.Duration `envconfig:"PROXY_TIMEOUT"`
-	RedirectSecret               string        `envconfig:"REDIRECT_SECRET" json:"-"`
-	ReleaseCalendarControllerURL string        `envconfig:"RELEASE_CALENDAR_CONTROLLER_URL"`
-	ReleaseCalendarEnabled       bool          `envconfig:"RELEASE_CALENDAR_ENABLED"`
-	ReleaseCalendarRoutePrefix   string        `envconfig:"RELEASE_CALENDAR_ROUTE_PREFIX"`
-	UseNewReleaseCalendar        bool          `envconfig:"USE_NEW_RELEASE_CALENDAR"`
-	SearchControllerURL          string        `envconfig:"SEARCH_CONTROLLER_URL"`
-	DataAggregationPagesEnabled  bool          `envconfig:"DATA_AGGREGATION_PAGES_ENABLED"`
-	SearchRoutesEnabled          bool          `envconfig:"SEARCH_ROUTES_ENABLED"`
-	SiteDomain                   string        `envconfig:"SITE_DOMAIN"`
-	SQSAnalyticsURL              string        `envconfig:"SQS_ANALYTICS_URL"`
-	ZebedeeRequestMaximumRetries int           `envconfig:"ZEBEDEE_REQUEST_MAXIMUM_RETRIES"`
-	ZebedeeRequestMaximumTimeout time.Duration `envconfig:"ZEBEDEE_REQUEST_TIMEOUT_SECONDS"`
+	AWS                           AWS
+	AccessLogSuccessSampleRate    float64           `envconfig:"ACCESS_LOG_SUCCESS_SAMPLE_RATE"`
+	AdminBindAddr                 string            `envconfig:"ADMIN_BIND_ADDR"`
+	AdminAllowedRanges            []string          `envconfig:"ADMIN_ALLOWED_RANGES"`
+	AdminSecret                   string            `envconfig:"ADMIN_SECRET" json:"-"`
+	AdminSecretHeader             string            `envconfig:"ADMIN_SECRET_HEADER"`
+	AnalyticsAsyncEnabled         bool              `envconfig:"ANALYTICS_ASYNC_ENABLED"`
+	AnalyticsAsyncMaxInFlight     int               `envconfig:"ANALYTICS_ASYNC_MAX_IN_FLIGHT"`
+	AnalyticsAsyncMaxRetries      int               `envconfig:"ANALYTICS_ASYNC_MAX_RETRIES"`
+	AnalyticsAsyncQueueSize       int               `envconfig:"ANALYTICS_ASYNC_QUEUE_SIZE"`
+	AnalyticsAsyncTimeout         time.Duration     `envconfig:"ANALYTICS_ASYNC_TIMEOUT"`
+	AnalyticsClampEnabled         bool              `envconfig:"ANALYTICS_CLAMP_ENABLED"`
+	AnalyticsFanOutEnabled        bool              `envconfig:"ANALYTICS_FAN_OUT_ENABLED"`
+	AnalyticsFile                 string            `envconfig:"ANALYTICS_FILE"`
+	AnalyticsMaxLinkIndex         int               `envconfig:"ANALYTICS_MAX_LINK_INDEX"`
+	AnalyticsMaxListTypeLength    int               `envconfig:"ANALYTICS_MAX_LIST_TYPE_LENGTH"`
+	AnalyticsMaxPageIndex         int               `envconfig:"ANALYTICS_MAX_PAGE_INDEX"`
+	AnalyticsMaxPageSize          int               `envconfig:"ANALYTICS_MAX_PAGE_SIZE"`
+	AnalyticsMaxTermLength        int               `envconfig:"ANALYTICS_MAX_TERM_LENGTH"`
+	AnalyticsMaxURLLength         int               `envconfig:"ANALYTICS_MAX_URL_LENGTH"`
+	AnalyticsRateLimit            float64           `envconfig:"ANALYTICS_RATE_LIMIT"`
+	AnalyticsRateLimitBurst       int               `envconfig:"ANALYTICS_RATE_LIMIT_BURST"`
+	AnalyticsRateLimitReject      bool              `envconfig:"ANALYTICS_RATE_LIMIT_REJECT"`
+	AnalyticsSampleRate           float64           `envconfig:"ANALYTICS_SAMPLE_RATE"`
+	AnalyticsSamplingEnabled      bool              `envconfig:"ANALYTICS_SAMPLING_ENABLED"`
+	AnalyticsScrubEnabled         bool              `envconfig:"ANALYTICS_SCRUB_ENABLED"`
+	AnalyticsScrubHashKey         string            `envconfig:"ANALYTICS_SCRUB_HASH_KEY" json:"-"`
+	AnalyticsScrubPatterns        []string          `envconfig:"ANALYTICS_SCRUB_PATTERNS"`
+	AnalyticsSpoolDir             string            `envconfig:"ANALYTICS_SPOOL_DIR"`
+	AnalyticsSpoolMaxEvents       int               `envconfig:"ANALYTICS_SPOOL_MAX_EVENTS"`
+	AnalyticsSpoolRetryInterval   time.Duration     `envconfig:"ANALYTICS_SPOOL_RETRY_INTERVAL"`
+	APIRouterURL                  string            `envconfig:"API_ROUTER_URL"`
+	AreaProfilesControllerURL     string            `envconfig:"AREA_PROFILE_CONTROLLER_URL"`
+	AreaProfilesRoutesEnabled     bool              `envconfig:"AREA_PROFILE_ROUTES_ENABLED"`
+	BabbageRewriteHost            bool              `envconfig:"BABBAGE_REWRITE_HOST"`
+	BabbageURL                    string            `envconfig:"BABBAGE_URL"`
+	BabbageXForwardedEnabled      bool              `envconfig:"BABBAGE_X_FORWARDED_ENABLED"`
+	BackendHealthchecksEnabled    bool              `envconfig:"BACKEND_HEALTHCHECKS_ENABLED"`
+	BackendInstances              string            `envconfig:"BACKEND_INSTANCES"`
+	BackendBalancing              string            `envconfig:"BACKEND_BALANCING"`
+	BackendInstanceCheckInterval  time.Duration     `envconfig:"BACKEND_INSTANCE_CHECK_INTERVAL"`
+	BackendSets                   string            `envconfig:"BACKEND_SETS"`
+	BackendSetHeader              string            `envconfig:"BACKEND_SET_HEADER"`
+	BackendSetSecret              string            `envconfig:"BACKEND_SET_SECRET" json:"-"`
+	BackendSetSecretHeader        string            `envconfig:"BACKEND_SET_SECRET_HEADER"`
+	BindAddr                      string            `envconfig:"BIND_ADDR"`
+	BotBackendPaths               []string          `envconfig:"BOT_BACKEND_PATHS"`
+	BotBackendURL                 string            `envconfig:"BOT_BACKEND_URL"`
+	BotDetectionEnabled           bool              `envconfig:"BOT_DETECTION_ENABLED"`
+	BotIPRanges                   []string          `envconfig:"BOT_IP_RANGES"`
+	BotRateLimit                  float64           `envconfig:"BOT_RATE_LIMIT"`
+	BotRateLimitBurst             int               `envconfig:"BOT_RATE_LIMIT_BURST"`
+	BotUserAgents                 []string          `envconfig:"BOT_USER_AGENTS"`
+	CacheBypassCookies            []string          `envconfig:"CACHE_BYPASS_COOKIES"`
+	CacheControlPolicies          string            `envconfig:"CACHE_CONTROL_POLICIES"`
+	CacheVaryCookies              []string          `envconfig:"CACHE_VARY_COOKIES"`
+	CacheStatsEnabled             bool              `envconfig:"CACHE_STATS_ENABLED"`
+	CanaryRoutes                  string            `envconfig:"CANARY_ROUTES"`
+	CensusAtlasRoutesEnabled      bool              `envconfig:"CENSUS_ATLAS_ROUTES_ENABLED"`
+	CensusAtlasEmptyURIRedirect   bool              `envconfig:"CENSUS_ATLAS_EMPTY_URI_REDIRECT"`
+	CensusAtlasURL                string            `envconfig:"CENSUS_ATLAS_URL"`
+	CircuitBreakerCooldown        time.Duration     `envconfig:"CIRCUIT_BREAKER_COOLDOWN"`
+	CircuitBreakerThreshold       int               `envconfig:"CIRCUIT_BREAKER_THRESHOLD"`
+	CompressionContentTypes       []string          `envconfig:"COMPRESSION_CONTENT_TYPES"`
+	CompressionEnabled            bool              `envconfig:"COMPRESSION_ENABLED"`
+	CompressionMinSize            int               `envconfig:"COMPRESSION_MIN_SIZE"`
+	ContentSecurityPolicy         string            `envconfig:"CONTENT_SECURITY_POLICY"`
+	ContentTypeByteLimit          int               `envconfig:"CONTENT_TYPE_BYTE_LIMIT"`
+	ContentTypeOptions            string            `envconfig:"CONTENT_TYPE_OPTIONS"`
+	CookieHardeningEnabled        bool              `envconfig:"COOKIE_HARDENING_ENABLED"`
+	CookieHTTPOnly                bool              `envconfig:"COOKIE_HTTP_ONLY"`
+	CookieHTTPOnlyExclusions      []string          `envconfig:"COOKIE_HTTP_ONLY_EXCLUSIONS"`
+	CookieSameSite                string            `envconfig:"COOKIE_SAME_SITE"`
+	CookiesControllerURL          string            `envconfig:"COOKIES_CONTROLLER_URL"`
+	CookieSecure                  bool              `envconfig:"COOKIE_SECURE"`
+	CORSRules                     string            `envconfig:"CORS_RULES"`
+	CSPOverrides                  map[string]string `envconfig:"CONTENT_SECURITY_POLICY_OVERRIDES"`
+	CSPReportOnly                 bool              `envconfig:"CONTENT_SECURITY_POLICY_REPORT_ONLY"`
+	DatasetControllerURL          string            `envconfig:"DATASET_CONTROLLER_URL"`
+	DatasetFinderEnabled          bool              `envconfig:"DATASET_FINDER_ENABLED"`
+	DownloaderURL                 string            `envconfig:"DOWNLOADER_URL"`
+	Experiments                   string            `envconfig:"EXPERIMENTS"`
+	ExperimentIDCookie            string            `envconfig:"EXPERIMENT_ID_COOKIE"`
+	FeedbackControllerURL         string            `envconfig:"FEEDBACK_CONTROLLER_URL"`
+	FeedbackEnabled               bool              `envconfig:"FEEDBACK_ENABLED"`
+	FilterDatasetControllerURL    string            `envconfig:"FILTER_DATASET_CONTROLLER_URL"`
+	FilterFlexDatasetServiceURL   string            `envconfig:"FILTER_FLEX_DATASET_SERVICE_URL"`
+	FirehoseAnalyticsStream       string            `envconfig:"FIREHOSE_ANALYTICS_STREAM"`
+	GeoCountryHeader              string            `envconfig:"GEO_COUNTRY_HEADER"`
+	GeoIPDBPath                   string            `envconfig:"GEOIP_DB_PATH"`
+	GeoRoutes                     string            `envconfig:"GEO_ROUTES"`
+	GracefulShutdownTimeout       time.Duration     `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
+	HealthcheckCriticalTimeout    time.Duration     `envconfig:"HEALTHCHECK_CRITICAL_TIMEOUT"`
+	HealthcheckInterval           time.Duration     `envconfig:"HEALTHCHECK_INTERVAL"`
+	HomepageControllerURL         string            `envconfig:"HOMEPAGE_CONTROLLER_URL"`
+	HSTSIncludeSubdomains         bool              `envconfig:"HSTS_INCLUDE_SUBDOMAINS"`
+	HSTSMaxAge                    time.Duration     `envconfig:"HSTS_MAX_AGE"`
+	HSTSPreload                   bool              `envconfig:"HSTS_PRELOAD"`
+	HTTPMaxConnections            int               `envconfig:"HTTP_MAX_CONNECTIONS"`
+	IPAllowLists                  map[string]string `envconfig:"IP_ALLOW_LISTS"`
+	IPDenyList                    []string          `envconfig:"IP_DENY_LIST"`
+	IPTrustedProxies              []string          `envconfig:"IP_TRUSTED_PROXIES"`
+	KafkaAnalyticsBrokers         []string          `envconfig:"KAFKA_ANALYTICS_BROKERS"`
+	KafkaAnalyticsSASLMechanism   string            `envconfig:"KAFKA_ANALYTICS_SASL_MECHANISM"`
+	KafkaAnalyticsSASLPassword    string            `envconfig:"KAFKA_ANALYTICS_SASL_PASSWORD" json:"-"`
+	KafkaAnalyticsSASLUsername    string            `envconfig:"KAFKA_ANALYTICS_SASL_USERNAME"`
+	KafkaAnalyticsTLSEnabled      bool              `envconfig:"KAFKA_ANALYTICS_TLS_ENABLED"`
+	KafkaAnalyticsTopic           string            `envconfig:"KAFKA_ANALYTICS_TOPIC"`
+	LegacySearchRedirectsEnabled  bool              `envconfig:"LEGACY_SEARCH_REDIRECTS_ENABLED"`
+	LegacySearchRedirectStatus    int               `envconfig:"LEGACY_SEARCH_REDIRECT_STATUS"`
+	LegacySearchQueryParams       map[string]string `envconfig:"LEGACY_SEARCH_QUERY_PARAMS"`
+	LegacyCacheProxyEnabled       bool              `envconfig:"LEGACY_CACHE_PROXY_ENABLED"`
+	LegacyCacheProxyURL           string            `envconfig:"LEGACY_CACHE_PROXY_URL"`
+	LegacyURLPatterns             []string          `envconfig:"LEGACY_URL_PATTERNS"`
+	MaintenanceEnabled            bool              `envconfig:"MAINTENANCE_ENABLED"`
+	MaintenanceBody               string            `envconfig:"MAINTENANCE_BODY"`
+	MaintenanceRetryAfter         time.Duration     `envconfig:"MAINTENANCE_RETRY_AFTER"`
+	MetricsEnabled                bool              `envconfig:"METRICS_ENABLED"`
+	FeatureFlagMetricsEnabled     bool              `envconfig:"FEATURE_FLAG_METRICS_ENABLED"`
+	FeatureFlagsURL               string            `envconfig:"FEATURE_FLAGS_URL"`
+	FeatureFlagsPollInterval      time.Duration     `envconfig:"FEATURE_FLAGS_POLL_INTERVAL"`
+	FeatureFlagsTimeout           time.Duration     `envconfig:"FEATURE_FLAGS_TIMEOUT"`
+	NewDatasetRoutingEnabled      bool              `envconfig:"NEW_DATASET_ROUTING_ENABLED"`
+	OTExporterOTLPEndpoint        string            `envconfig:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	OTExporterHeaders             map[string]string `envconfig:"OTEL_EXPORTER_HEADERS" json:"-"`
+	OTExporterInsecure            bool              `envconfig:"OTEL_EXPORTER_INSECURE"`
+	OTServiceName                 string            `envconfig:"OTEL_SERVICE_NAME"`
+	OTBatchTimeout                time.Duration     `envconfig:"OTEL_BATCH_TIMEOUT"`
+	OTBatchMaxQueueSize           int               `envconfig:"OTEL_BATCH_MAX_QUEUE_SIZE"`
+	OTBatchMaxExportSize          int               `envconfig:"OTEL_BATCH_MAX_EXPORT_SIZE"`
+	OTSampleRatio                 float64           `envconfig:"OTEL_SAMPLE_RATIO"`
+	OtelEnabled                   bool              `envconfig:"OTEL_ENABLED"`
+	PageTypeCacheMaxEntries       int               `envconfig:"PAGE_TYPE_CACHE_MAX_ENTRIES"`
+	PageTypeCacheTTL              time.Duration     `envconfig:"PAGE_TYPE_CACHE_TTL"`
+	PageTypeRedisTTL              time.Duration     `envconfig:"PAGE_TYPE_REDIS_TTL"`
+	PageTypeRedisURL              string            `envconfig:"PAGE_TYPE_REDIS_URL" json:"-"`
+	PathTraversalBlockEnabled     bool              `envconfig:"PATH_TRAVERSAL_BLOCK_ENABLED"`
+	PatternLibraryAssetsPath      string            `envconfig:"PATTERN_LIBRARY_ASSETS_PATH"`
+	PermissionsPolicy             string            `envconfig:"PERMISSIONS_POLICY"`
+	PprofEnabled                  bool              `envconfig:"ENABLE_PPROF"`
+	PreconnectOrigin              string            `envconfig:"PRECONNECT_ORIGIN"`
+	PreconnectPaths               []string          `envconfig:"PRECONNECT_PATHS"`
+	ProbeLogMode                  string            `envconfig:"PROBE_LOG_MODE"`
+	ProbeLogPaths                 []string          `envconfig:"PROBE_LOG_PATHS"`
+	ProxyRetryBackoff             time.Duration     `envconfig:"PROXY_RETRY_BACKOFF"`
+	ProxyRetryBudgetRatio         float64           `envconfig:"PROXY_RETRY_BUDGET_RATIO"`
+	ProxyRetryBudgetRoutes        map[string]string `envconfig:"PROXY_RETRY_BUDGET_ROUTES"`
+	ProxyRetryMaxAttempts         int               `envconfig:"PROXY_RETRY_MAX_ATTEMPTS"`
+	ProxyTimeout                  time.Duration     `envconfig:"PROXY_TIMEOUT"`
+	RateLimits                    map[string]string `envconfig:"RATE_LIMITS"`
+	ReadinessBackendsEnabled      bool              `envconfig:"READINESS_BACKENDS_ENABLED"`
+	ReadinessCacheWarmthEnabled   bool              `envconfig:"READINESS_CACHE_WARMTH_ENABLED"`
+	ReadinessCacheMinEntries      int               `envconfig:"READINESS_CACHE_MIN_ENTRIES"`
+	ReadinessWarmupGracePeriod    time.Duration     `envconfig:"READINESS_WARMUP_GRACE_PERIOD"`
+	ReadinessCheckSelection       bool              `envconfig:"READINESS_CHECK_SELECTION_ENABLED"`
+	RedirectAllowedDomains        []string          `envconfig:"REDIRECT_ALLOWED_DOMAINS"`
+	RedirectMapFile               string            `envconfig:"REDIRECT_MAP_FILE"`
+	RedirectMapReloadInterval     time.Duration     `envconfig:"REDIRECT_MAP_RELOAD_INTERVAL"`
+	RedirectMapURL                string            `envconfig:"REDIRECT_MAP_URL"`
+	RedirectMaxHops               int               `envconfig:"REDIRECT_MAX_HOPS"`
+	RedirectMetricsEnabled        bool              `envconfig:"REDIRECT_METRICS_ENABLED"`
+	RedirectRules                 string            `envconfig:"REDIRECT_RULES"`
+	RedirectSecret                string            `envconfig:"REDIRECT_SECRET" json:"-"`
+	ReferrerPolicy                string            `envconfig:"REFERRER_POLICY"`
+	ReleaseCalendarControllerURL  string            `envconfig:"RELEASE_CALENDAR_CONTROLLER_URL"`
+	ReleaseCalendarEnabled        bool              `envconfig:"RELEASE_CALENDAR_ENABLED"`
+	ReleaseCalendarRoutePrefix    string            `envconfig:"RELEASE_CALENDAR_ROUTE_PREFIX"`
+	RequestBodyLimits             map[string]string `envconfig:"REQUEST_BODY_LIMITS"`
+	RequestBodyMaxBytes           int64             `envconfig:"REQUEST_BODY_MAX_BYTES"`
+	ResponseCacheDefaultTTL       time.Duration     `envconfig:"RESPONSE_CACHE_DEFAULT_TTL"`
+	ResponseCacheEnabled          bool              `envconfig:"RESPONSE_CACHE_ENABLED"`
+	ResponseCacheMaxEntries       int               `envconfig:"RESPONSE_CACHE_MAX_ENTRIES"`
+	ResponseCacheMaxTTL           time.Duration     `envconfig:"RESPONSE_CACHE_MAX_TTL"`
+	RetiredPaths                  []string          `envconfig:"RETIRED_PATHS"`
+	RetiredPathsBody              string            `envconfig:"RETIRED_PATHS_BODY"`
+	RouteConfigFile               string            `envconfig:"ROUTE_CONFIG_FILE"`
+	RouteTimeoutBody              string            `envconfig:"ROUTE_TIMEOUT_BODY"`
+	RouteTimeouts                 map[string]string `envconfig:"ROUTE_TIMEOUTS"`
+	RoutingTableLogEnabled        bool              `envconfig:"ROUTING_TABLE_LOG_ENABLED"`
+	RoutingTableFile              string            `envconfig:"ROUTING_TABLE_FILE"`
+	SecurityHeaderProfilesEnabled bool              `envconfig:"SECURITY_HEADER_PROFILES_ENABLED"`
+	ShadowRoutes                  string            `envconfig:"SHADOW_ROUTES"`
+	ShadowTimeout                 time.Duration     `envconfig:"SHADOW_TIMEOUT"`
+	SLOMetricsEnabled             bool              `envconfig:"SLO_METRICS_ENABLED"`
+	SLODefaultLatencyThreshold    time.Duration     `envconfig:"SLO_DEFAULT_LATENCY_THRESHOLD"`
+	SLOLatencyThresholds          map[string]string `envconfig:"SLO_LATENCY_THRESHOLDS"`
+	SQSAnalyticsBatchEnabled      bool              `envconfig:"SQS_ANALYTICS_BATCH_ENABLED"`
+	SQSAnalyticsBatchInterval     time.Duration     `envconfig:"SQS_ANALYTICS_BATCH_INTERVAL"`
+	StaleIfErrorEnabled           bool              `envconfig:"STALE_IF_ERROR_ENABLED"`
+	StaleIfErrorMaxEntries        int               `envconfig:"STALE_IF_ERROR_MAX_ENTRIES"`
+	StaleIfErrorWindow            time.Duration     `envconfig:"STALE_IF_ERROR_WINDOW"`
+	StreamingMaxConnections       int               `envconfig:"STREAMING_MAX_CONNECTIONS"`
+	StreamingPaths                []string          `envconfig:"STREAMING_PATHS"`
+	TrailingSlashPolicies         map[string]string `envconfig:"TRAILING_SLASH_POLICIES"`
+	UpstreamCacheHeaderEnabled    bool              `envconfig:"UPSTREAM_CACHE_HEADER_ENABLED"`
+	URIValidationEnabled          bool              `envconfig:"URI_VALIDATION_ENABLED"`
+	UseNewReleaseCalendar         bool              `envconfig:"USE_NEW_RELEASE_CALENDAR"`
+	SearchControllerURL           string            `envconfig:"SEARCH_CONTROLLER_URL"`
+	DataAggregationPagesEnabled   bool              `envconfig:"DATA_AGGREGATION_PAGES_ENABLED"`
+	SearchRoutesEnabled           bool              `envconfig:"SEARCH_ROUTES_ENABLED"`
+	SiteDomain                    string            `envconfig:"SITE_DOMAIN"`
+	SQSAnalyticsURL               string            `envconfig:"SQS_ANALYTICS_URL"`
+	SQSAnalyticsQueuesByListType  map[string]string `envconfig:"SQS_ANALYTICS_QUEUES_BY_LIST_TYPE"`
+	WebhookAnalyticsAuthHeader    string            `envconfig:"WEBHOOK_ANALYTICS_AUTH_HEADER"`
+	WebhookAnalyticsAuthToken     string            `envconfig:"WEBHOOK_ANALYTICS_AUTH_TOKEN" json:"-"`
+	WebhookAnalyticsMaxRetries    int               `envconfig:"WEBHOOK_ANALYTICS_MAX_RETRIES"`
+	WebhookAnalyticsTimeout       time.Duration     `envconfig:"WEBHOOK_ANALYTICS_TIMEOUT"`
+	WebhookAnalyticsURL           string            `envconfig:"WEBHOOK_ANALYTICS_URL"`
+	ZebedeeRequestMaximumRetries  int               `envconfig:"ZEBEDEE_REQUEST_MAXIMUM_RETRIES"`
+	ZebedeeRequestMaximumTimeout  time.Duration     `envconfig:"ZEBEDEE_REQUEST_TIMEOUT_SECONDS"`
+	ZebedeeSecondaryURL           string            `envconfig:"ZEBEDEE_SECONDARY_URL"`
+	ZebedeeSecondaryTimeout       time.Duration     `envconfig:"ZEBEDEE_SECONDARY_TIMEOUT"`
+	CDNAssetBaseURL               string            `envconfig:"CDN_ASSET_BASE_URL"`
+	CDNAssetPrefixes              []string          `envconfig:"CDN_ASSET_PREFIXES"`
+	CDNAssetRedirectPermanent     bool              `envconfig:"CDN_ASSET_REDIRECT_PERMANENT"`
+	ForwardedProtoCheckEnabled    bool              `envconfig:"FORWARDED_PROTO_CHECK_ENABLED"`
+	ForwardedProtoReject          bool              `envconfig:"FORWARDED_PROTO_REJECT"`
+	APIDescriptionPath            string            `envconfig:"API_DESCRIPTION_PATH"`
+	AnalyticsDedupEnabled         bool              `envconfig:"ANALYTICS_DEDUP_ENABLED"`
+	AnalyticsDedupTTL             time.Duration     `envconfig:"ANALYTICS_DEDUP_TTL"`
+	AnalyticsDedupMaxEntries      int               `envconfig:"ANALYTICS_DEDUP_MAX_ENTRIES"`
+	OutboundUserAgent             string            `envconfig:"OUTBOUND_USER_AGENT"`
+	ConfigProfile                 string            `envconfig:"CONFIG_PROFILE"`
+	ConfigReloadEnabled           bool              `envconfig:"CONFIG_RELOAD_ENABLED"`
+	ConfigReloadFile              string            `envconfig:"CONFIG_RELOAD_FILE"`
 }
 
 type AWS struct {
@@ -62,69 +242,217 @@ type AWS struct {
 
 var cfg *Config
 
-// Get returns the default config with any modifications made through environment variables
+// defaultCompressionContentTypes are the text based content types served through the router, which compress well
+var defaultCompressionContentTypes = []string{
+	"text/html", "text/css", "text/plain", "text/csv", "text/javascript",
+	"application/javascript", "application/json", "application/xml", "image/svg+xml",
+}
+
+// Get returns the default config, including those of the config profile if set, with any modifications made through
+// environment variables, and an error if any of its values are invalid
 func Get() (*Config, error) {
 	if cfg != nil {
 		return cfg, nil
 	}
 
-	cfg = &Config{
-		APIRouterURL:                 "http://localhost:23200/v1",
-		AreaProfilesControllerURL:    "http://localhost:26600",
-		AreaProfilesRoutesEnabled:    false,
-		BabbageURL:                   "http://localhost:8080",
-		BindAddr:                     ":20000",
-		CensusAtlasRoutesEnabled:     false,
-		CensusAtlasURL:               "http://localhost:28100",
-		ContentTypeByteLimit:         5000000,
-		CookiesControllerURL:         "http://localhost:24100",
-		DatasetControllerURL:         "http://localhost:20200",
-		DatasetFinderEnabled:         false,
-		DownloaderURL:                "http://localhost:23400",
-		FeedbackControllerURL:        "http://localhost:25200",
-		FeedbackEnabled:              false,
-		FilterDatasetControllerURL:   "http://localhost:20001",
-		FilterFlexDatasetServiceURL:  "http://localhost:20100",
-		HealthcheckCriticalTimeout:   90 * time.Second,
-		HealthcheckInterval:          30 * time.Second,
-		HomepageControllerURL:        "http://localhost:24400",
-		HTTPMaxConnections:           0,
-		LegacySearchRedirectsEnabled: false,
-		LegacyCacheProxyEnabled:      false,
-		LegacyCacheProxyURL:          "http://localhost:29200",
-		NewDatasetRoutingEnabled:     false,
-		OTExporterOTLPEndpoint:       "localhost:4317",
-		OTServiceName:                "dp-frontend-router",
-		OTBatchTimeout:               5 * time.Second,
-		OtelEnabled:                  false,
-		PatternLibraryAssetsPath:     "https://cdn.ons.gov.uk/sixteens/f816ac8",
-		ProxyTimeout:                 5 * time.Second,
-		RedirectSecret:               "secret",
-		ReleaseCalendarControllerURL: "http://localhost:27700",
-		ReleaseCalendarEnabled:       false,
-		UseNewReleaseCalendar:        false,
-		SearchControllerURL:          "http://localhost:25000",
-		SearchRoutesEnabled:          true,
-		DataAggregationPagesEnabled:  false,
-		SiteDomain:                   "ons.gov.uk",
-		SQSAnalyticsURL:              "",
-		ZebedeeRequestMaximumRetries: 0,
-		ZebedeeRequestMaximumTimeout: 5 * time.Second,
-	}
-
-	cfg.AWS = AWS{
-		AccessKeyID:     "",
-		Region:          "eu-west-2",
-		SecretAccessKey: "",
-	}
+	var err error
+	cfg, err = read()
+	return cfg, err
+}
 
-	if err := envconfig.Process("", cfg); err != nil {
-		return cfg, err
+// newDefault returns the default config, before any modifications made through environment variables
+func newDefault() *Config {
+	return &Config{
+		AccessLogSuccessSampleRate:    1,
+		AdminSecret:                   "",
+		AdminSecretHeader:             "X-Admin-Secret",
+		AnalyticsAsyncEnabled:         true,
+		AnalyticsAsyncMaxInFlight:     100,
+		AnalyticsAsyncMaxRetries:      3,
+		AnalyticsAsyncQueueSize:       1000,
+		AnalyticsAsyncTimeout:         10 * time.Second,
+		AnalyticsClampEnabled:         false,
+		AnalyticsFanOutEnabled:        false,
+		AnalyticsMaxLinkIndex:         1000,
+		AnalyticsMaxListTypeLength:    0,
+		AnalyticsMaxPageIndex:         10000,
+		AnalyticsMaxPageSize:          100,
+		AnalyticsMaxTermLength:        0,
+		AnalyticsMaxURLLength:         0,
+		AnalyticsRateLimit:            0,
+		AnalyticsRateLimitBurst:       5,
+		AnalyticsRateLimitReject:      false,
+		AnalyticsSampleRate:           1,
+		AnalyticsSamplingEnabled:      false,
+		AnalyticsScrubEnabled:         true,
+		AnalyticsSpoolMaxEvents:       100000,
+		AnalyticsSpoolRetryInterval:   time.Minute,
+		APIRouterURL:                  "http://localhost:23200/v1",
+		AreaProfilesControllerURL:     "http://localhost:26600",
+		AreaProfilesRoutesEnabled:     false,
+		BabbageRewriteHost:            false,
+		BabbageURL:                    "http://localhost:8080",
+		BabbageXForwardedEnabled:      false,
+		BackendHealthchecksEnabled:    false,
+		BackendInstances:              "",
+		BackendBalancing:              "round-robin",
+		BackendInstanceCheckInterval:  10 * time.Second,
+		BackendSets:                   "",
+		BackendSetHeader:              "X-Router-Backend",
+		BackendSetSecret:              "",
+		BackendSetSecretHeader:        "X-Router-Backend-Secret",
+		BindAddr:                      ":20000",
+		BotBackendURL:                 "",
+		BotDetectionEnabled:           false,
+		BotRateLimit:                  0,
+		BotRateLimitBurst:             10,
+		CacheBypassCookies:            []string{"access_token", "collection"},
+		CacheControlPolicies:          "",
+		CacheStatsEnabled:             false,
+		CensusAtlasRoutesEnabled:      false,
+		CensusAtlasEmptyURIRedirect:   false,
+		CensusAtlasURL:                "http://localhost:28100",
+		CircuitBreakerCooldown:        30 * time.Second,
+		CompressionContentTypes:       defaultCompressionContentTypes,
+		CompressionEnabled:            false,
+		CompressionMinSize:            1024,
+		ContentSecurityPolicy:         "",
+		ContentTypeByteLimit:          5000000,
+		ContentTypeOptions:            "nosniff",
+		CookieHardeningEnabled:        false,
+		CookieHTTPOnly:                true,
+		CookieHTTPOnlyExclusions:      []string{"cookies_policy", "cookies_preferences_set"},
+		CookieSameSite:                "Lax",
+		CookiesControllerURL:          "http://localhost:24100",
+		CookieSecure:                  true,
+		CORSRules:                     "",
+		CSPReportOnly:                 false,
+		DatasetControllerURL:          "http://localhost:20200",
+		DatasetFinderEnabled:          false,
+		DownloaderURL:                 "http://localhost:23400",
+		FeedbackControllerURL:         "http://localhost:25200",
+		FeedbackEnabled:               false,
+		FilterDatasetControllerURL:    "http://localhost:20001",
+		FilterFlexDatasetServiceURL:   "http://localhost:20100",
+		GeoCountryHeader:              "",
+		GeoIPDBPath:                   "",
+		GeoRoutes:                     "",
+		GracefulShutdownTimeout:       30 * time.Second,
+		HealthcheckCriticalTimeout:    90 * time.Second,
+		HealthcheckInterval:           30 * time.Second,
+		HomepageControllerURL:         "http://localhost:24400",
+		HSTSIncludeSubdomains:         false,
+		HSTSMaxAge:                    0,
+		HSTSPreload:                   false,
+		HTTPMaxConnections:            0,
+		KafkaAnalyticsTLSEnabled:      false,
+		KafkaAnalyticsTopic:           "search-analytics",
+		LegacySearchRedirectsEnabled:  false,
+		LegacySearchRedirectStatus:    301,
+		LegacySearchQueryParams:       nil,
+		LegacyCacheProxyEnabled:       false,
+		LegacyCacheProxyURL:           "http://localhost:29200",
+		LegacyURLPatterns:             []string{"^/ons/"},
+		MaintenanceEnabled:            false,
+		MaintenanceBody:               "",
+		MaintenanceRetryAfter:         5 * time.Minute,
+		MetricsEnabled:                false,
+		FeatureFlagMetricsEnabled:     false,
+		FeatureFlagsURL:               "",
+		FeatureFlagsPollInterval:      30 * time.Second,
+		FeatureFlagsTimeout:           5 * time.Second,
+		NewDatasetRoutingEnabled:      false,
+		OTExporterOTLPEndpoint:        "localhost:4317",
+		OTExporterInsecure:            true,
+		OTServiceName:                 "dp-frontend-router",
+		OTBatchTimeout:                5 * time.Second,
+		OTBatchMaxQueueSize:           2048,
+		OTBatchMaxExportSize:          512,
+		OTSampleRatio:                 1,
+		OtelEnabled:                   false,
+		PageTypeCacheMaxEntries:       10000,
+		PageTypeCacheTTL:              30 * time.Second,
+		PageTypeRedisTTL:              10 * time.Minute,
+		PageTypeRedisURL:              "",
+		PathTraversalBlockEnabled:     false,
+		PatternLibraryAssetsPath:      "https://cdn.ons.gov.uk/sixteens/f816ac8",
+		PermissionsPolicy:             "",
+		PprofEnabled:                  false,
+		ProbeLogMode:                  "full",
+		ProbeLogPaths:                 []string{"/health"},
+		ProxyRetryBackoff:             100 * time.Millisecond,
+		ProxyRetryBudgetRatio:         0.2,
+		ProxyTimeout:                  5 * time.Second,
+		ReadinessBackendsEnabled:      false,
+		ReadinessCacheWarmthEnabled:   false,
+		ReadinessCacheMinEntries:      100,
+		ReadinessWarmupGracePeriod:    2 * time.Minute,
+		ReadinessCheckSelection:       false,
+		RedirectAllowedDomains:        []string{"ons.gov.uk"},
+		RedirectMapFile:               "",
+		RedirectMapReloadInterval:     time.Minute,
+		RedirectMapURL:                "",
+		RedirectMaxHops:               0,
+		RedirectMetricsEnabled:        false,
+		RedirectRules:                 "",
+		RedirectSecret:                "secret",
+		ReferrerPolicy:                "strict-origin-when-cross-origin",
+		ReleaseCalendarControllerURL:  "http://localhost:27700",
+		ReleaseCalendarEnabled:        false,
+		RequestBodyLimits:             map[string]string{"/feedback": "10485760", "/filters": "10485760"},
+		RequestBodyMaxBytes:           1048576,
+		ResponseCacheEnabled:          false,
+		ResponseCacheMaxEntries:       1000,
+		ResponseCacheMaxTTL:           5 * time.Minute,
+		RetiredPaths:                  []string{},
+		RetiredPathsBody:              "",
+		RoutingTableLogEnabled:        false,
+		SecurityHeaderProfilesEnabled: false,
+		ShadowRoutes:                  "",
+		ShadowTimeout:                 10 * time.Second,
+		SLOMetricsEnabled:             false,
+		SLODefaultLatencyThreshold:    time.Second,
+		SQSAnalyticsBatchEnabled:      false,
+		SQSAnalyticsBatchInterval:     time.Second,
+		StaleIfErrorEnabled:           false,
+		StaleIfErrorMaxEntries:        1000,
+		StaleIfErrorWindow:            5 * time.Minute,
+		StreamingMaxConnections:       0,
+		StreamingPaths:                []string{},
+		UpstreamCacheHeaderEnabled:    false,
+		URIValidationEnabled:          false,
+		UseNewReleaseCalendar:         false,
+		SearchControllerURL:           "http://localhost:25000",
+		SearchRoutesEnabled:           true,
+		DataAggregationPagesEnabled:   false,
+		SiteDomain:                    "ons.gov.uk",
+		SQSAnalyticsURL:               "",
+		WebhookAnalyticsAuthHeader:    "Authorization",
+		WebhookAnalyticsMaxRetries:    3,
+		WebhookAnalyticsTimeout:       5 * time.Second,
+		ZebedeeRequestMaximumRetries:  0,
+		ZebedeeRequestMaximumTimeout:  5 * time.Second,
+		ZebedeeSecondaryURL:           "",
+		ZebedeeSecondaryTimeout:       2 * time.Second,
+		CDNAssetBaseURL:               "",
+		ExperimentIDCookie:            "_ga",
+		CDNAssetRedirectPermanent:     false,
+		ForwardedProtoCheckEnabled:    false,
+		ForwardedProtoReject:          false,
+		APIDescriptionPath:            "",
+		AnalyticsDedupEnabled:         false,
+		AnalyticsDedupTTL:             10 * time.Second,
+		AnalyticsDedupMaxEntries:      10000,
+		OutboundUserAgent:             "",
+		ConfigProfile:                 "",
+		ConfigReloadEnabled:           false,
+		ConfigReloadFile:              "",
+		AWS: AWS{
+			AccessKeyID:     "",
+			Region:          "eu-west-2",
+			SecretAccessKey: "",
+		},
 	}
-
-	cfg.ReleaseCalendarRoutePrefix = validatePrivatePrefix(cfg.ReleaseCalendarRoutePrefix)
-
-	return cfg, nil
 }
 
 // validatePrivatePrefix ensures that a non-empty private path prefix starts with a '/'
@@ -17,6 +17,7 @@ func TestSpec(t *testing.T) {
 			Convey("The values should be set to the expected defaults", func() {
 				So(cfg.BindAddr, ShouldEqual, ":20000")
 				So(cfg.BabbageURL, ShouldEqual, "http://localhost:8080")
+				So(cfg.BabbageRewriteHost, ShouldBeFalse)
 				So(cfg.CookiesControllerURL, ShouldEqual, "http://localhost:24100")
 				So(cfg.DatasetControllerURL, ShouldEqual, "http://localhost:20200")
 				So(cfg.FilterDatasetControllerURL, ShouldEqual, "http://localhost:20001")
@@ -47,6 +48,187 @@ func TestSpec(t *testing.T) {
 				So(cfg.ProxyTimeout, ShouldEqual, 5*time.Second)
 				So(cfg.LegacyCacheProxyEnabled, ShouldBeFalse)
 				So(cfg.LegacyCacheProxyURL, ShouldEqual, "http://localhost:29200")
+				So(cfg.BabbageXForwardedEnabled, ShouldBeFalse)
+				So(cfg.RetiredPaths, ShouldBeEmpty)
+				So(cfg.RetiredPathsBody, ShouldBeEmpty)
+				So(cfg.StreamingMaxConnections, ShouldEqual, 0)
+				So(cfg.StreamingPaths, ShouldBeEmpty)
+				So(cfg.URIValidationEnabled, ShouldBeFalse)
+				So(cfg.CacheStatsEnabled, ShouldBeFalse)
+				So(cfg.SecurityHeaderProfilesEnabled, ShouldBeFalse)
+				So(cfg.ContentSecurityPolicy, ShouldBeEmpty)
+				So(cfg.AnalyticsAsyncEnabled, ShouldBeTrue)
+				So(cfg.AnalyticsAsyncMaxInFlight, ShouldEqual, 100)
+				So(cfg.AnalyticsAsyncQueueSize, ShouldEqual, 1000)
+				So(cfg.AnalyticsAsyncMaxRetries, ShouldEqual, 3)
+				So(cfg.AnalyticsAsyncTimeout, ShouldEqual, 10*time.Second)
+				So(cfg.TrailingSlashPolicies, ShouldBeEmpty)
+				So(cfg.PathTraversalBlockEnabled, ShouldBeFalse)
+				So(cfg.StaleIfErrorEnabled, ShouldBeFalse)
+				So(cfg.StaleIfErrorMaxEntries, ShouldEqual, 1000)
+				So(cfg.StaleIfErrorWindow, ShouldEqual, 5*time.Minute)
+				So(cfg.RoutingTableLogEnabled, ShouldBeFalse)
+				So(cfg.RoutingTableFile, ShouldBeEmpty)
+				So(cfg.CacheBypassCookies, ShouldResemble, []string{"access_token", "collection"})
+				So(cfg.CacheVaryCookies, ShouldBeEmpty)
+				So(cfg.ProbeLogMode, ShouldEqual, "full")
+				So(cfg.ProbeLogPaths, ShouldResemble, []string{"/health"})
+				So(cfg.SQSAnalyticsQueuesByListType, ShouldBeEmpty)
+				So(cfg.AnalyticsClampEnabled, ShouldBeFalse)
+				So(cfg.AnalyticsMaxLinkIndex, ShouldEqual, 1000)
+				So(cfg.AnalyticsMaxPageIndex, ShouldEqual, 10000)
+				So(cfg.AnalyticsMaxPageSize, ShouldEqual, 100)
+				So(cfg.ReadinessCacheWarmthEnabled, ShouldBeFalse)
+				So(cfg.ReadinessCacheMinEntries, ShouldEqual, 100)
+				So(cfg.ReadinessWarmupGracePeriod, ShouldEqual, 2*time.Minute)
+				So(cfg.Experiments, ShouldBeEmpty)
+				So(cfg.ExperimentIDCookie, ShouldEqual, "_ga")
+				So(cfg.PreconnectOrigin, ShouldBeEmpty)
+				So(cfg.PreconnectPaths, ShouldBeEmpty)
+				So(cfg.RedirectMaxHops, ShouldEqual, 0)
+				So(cfg.UpstreamCacheHeaderEnabled, ShouldBeFalse)
+				So(cfg.AnalyticsMaxListTypeLength, ShouldEqual, 0)
+				So(cfg.AnalyticsMaxTermLength, ShouldEqual, 0)
+				So(cfg.AnalyticsMaxURLLength, ShouldEqual, 0)
+				So(cfg.MetricsEnabled, ShouldBeFalse)
+				So(cfg.SLOMetricsEnabled, ShouldBeFalse)
+				So(cfg.SLODefaultLatencyThreshold, ShouldEqual, time.Second)
+				So(cfg.SLOLatencyThresholds, ShouldBeEmpty)
+				So(cfg.AnalyticsRateLimit, ShouldEqual, 0)
+				So(cfg.AnalyticsRateLimitBurst, ShouldEqual, 5)
+				So(cfg.AnalyticsRateLimitReject, ShouldBeFalse)
+				So(cfg.CensusAtlasEmptyURIRedirect, ShouldBeFalse)
+				So(cfg.FeatureFlagMetricsEnabled, ShouldBeFalse)
+				So(cfg.ZebedeeSecondaryURL, ShouldBeEmpty)
+				So(cfg.ZebedeeSecondaryTimeout, ShouldEqual, 2*time.Second)
+				So(cfg.CDNAssetBaseURL, ShouldBeEmpty)
+				So(cfg.CDNAssetPrefixes, ShouldBeEmpty)
+				So(cfg.CDNAssetRedirectPermanent, ShouldBeFalse)
+				So(cfg.ForwardedProtoCheckEnabled, ShouldBeFalse)
+				So(cfg.ForwardedProtoReject, ShouldBeFalse)
+				So(cfg.APIDescriptionPath, ShouldBeEmpty)
+				So(cfg.AnalyticsDedupEnabled, ShouldBeFalse)
+				So(cfg.AnalyticsDedupTTL, ShouldEqual, 10*time.Second)
+				So(cfg.AnalyticsDedupMaxEntries, ShouldEqual, 10000)
+				So(cfg.ReadinessCheckSelection, ShouldBeFalse)
+				So(cfg.OutboundUserAgent, ShouldBeEmpty)
+				So(cfg.ConfigReloadEnabled, ShouldBeFalse)
+				So(cfg.ConfigReloadFile, ShouldBeEmpty)
+				So(cfg.RouteConfigFile, ShouldBeEmpty)
+				So(cfg.AdminBindAddr, ShouldBeEmpty)
+				So(cfg.CanaryRoutes, ShouldBeEmpty)
+				So(cfg.RouteTimeouts, ShouldBeEmpty)
+				So(cfg.RouteTimeoutBody, ShouldBeEmpty)
+				So(cfg.CircuitBreakerThreshold, ShouldEqual, 0)
+				So(cfg.CircuitBreakerCooldown, ShouldEqual, 30*time.Second)
+				So(cfg.ProxyRetryMaxAttempts, ShouldEqual, 0)
+				So(cfg.ProxyRetryBackoff, ShouldEqual, 100*time.Millisecond)
+				So(cfg.ProxyRetryBudgetRatio, ShouldEqual, 0.2)
+				So(cfg.ProxyRetryBudgetRoutes, ShouldBeEmpty)
+				So(cfg.RateLimits, ShouldBeEmpty)
+				So(cfg.ResponseCacheEnabled, ShouldBeFalse)
+				So(cfg.ResponseCacheMaxEntries, ShouldEqual, 1000)
+				So(cfg.ResponseCacheDefaultTTL, ShouldEqual, 0)
+				So(cfg.ResponseCacheMaxTTL, ShouldEqual, 5*time.Minute)
+				So(cfg.PageTypeRedisURL, ShouldBeEmpty)
+				So(cfg.PageTypeRedisTTL, ShouldEqual, 10*time.Minute)
+				So(cfg.PageTypeCacheMaxEntries, ShouldEqual, 10000)
+				So(cfg.PageTypeCacheTTL, ShouldEqual, 30*time.Second)
+				So(cfg.OTExporterHeaders, ShouldBeEmpty)
+				So(cfg.OTExporterInsecure, ShouldBeTrue)
+				So(cfg.OTBatchMaxQueueSize, ShouldEqual, 2048)
+				So(cfg.OTBatchMaxExportSize, ShouldEqual, 512)
+				So(cfg.OTSampleRatio, ShouldEqual, 1)
+				So(cfg.AccessLogSuccessSampleRate, ShouldEqual, 1)
+				So(cfg.PprofEnabled, ShouldBeFalse)
+				So(cfg.BackendHealthchecksEnabled, ShouldBeFalse)
+				So(cfg.ReadinessBackendsEnabled, ShouldBeFalse)
+				So(cfg.GracefulShutdownTimeout, ShouldEqual, 30*time.Second)
+				So(cfg.KafkaAnalyticsBrokers, ShouldBeEmpty)
+				So(cfg.KafkaAnalyticsSASLMechanism, ShouldBeEmpty)
+				So(cfg.KafkaAnalyticsSASLPassword, ShouldBeEmpty)
+				So(cfg.KafkaAnalyticsSASLUsername, ShouldBeEmpty)
+				So(cfg.KafkaAnalyticsTLSEnabled, ShouldBeFalse)
+				So(cfg.KafkaAnalyticsTopic, ShouldEqual, "search-analytics")
+				So(cfg.FirehoseAnalyticsStream, ShouldBeEmpty)
+				So(cfg.AnalyticsFile, ShouldBeEmpty)
+				So(cfg.WebhookAnalyticsURL, ShouldBeEmpty)
+				So(cfg.WebhookAnalyticsAuthHeader, ShouldEqual, "Authorization")
+				So(cfg.WebhookAnalyticsAuthToken, ShouldBeEmpty)
+				So(cfg.WebhookAnalyticsMaxRetries, ShouldEqual, 3)
+				So(cfg.WebhookAnalyticsTimeout, ShouldEqual, 5*time.Second)
+				So(cfg.SQSAnalyticsBatchEnabled, ShouldBeFalse)
+				So(cfg.SQSAnalyticsBatchInterval, ShouldEqual, time.Second)
+				So(cfg.AnalyticsFanOutEnabled, ShouldBeFalse)
+				So(cfg.RedirectAllowedDomains, ShouldResemble, []string{"ons.gov.uk"})
+				So(cfg.AnalyticsSamplingEnabled, ShouldBeFalse)
+				So(cfg.AnalyticsSampleRate, ShouldEqual, 1)
+				So(cfg.AnalyticsScrubEnabled, ShouldBeTrue)
+				So(cfg.AnalyticsScrubPatterns, ShouldBeEmpty)
+				So(cfg.AnalyticsScrubHashKey, ShouldBeEmpty)
+				So(cfg.AnalyticsSpoolDir, ShouldBeEmpty)
+				So(cfg.AnalyticsSpoolMaxEvents, ShouldEqual, 100000)
+				So(cfg.AnalyticsSpoolRetryInterval, ShouldEqual, time.Minute)
+				So(cfg.FeatureFlagsURL, ShouldEqual, "")
+				So(cfg.FeatureFlagsPollInterval, ShouldEqual, 30*time.Second)
+				So(cfg.FeatureFlagsTimeout, ShouldEqual, 5*time.Second)
+				So(cfg.ConfigProfile, ShouldEqual, "")
+				So(cfg.MaintenanceEnabled, ShouldBeFalse)
+				So(cfg.MaintenanceBody, ShouldEqual, "")
+				So(cfg.MaintenanceRetryAfter, ShouldEqual, 5*time.Minute)
+				So(cfg.IPAllowLists, ShouldBeEmpty)
+				So(cfg.IPDenyList, ShouldBeEmpty)
+				So(cfg.IPTrustedProxies, ShouldBeEmpty)
+				So(cfg.AdminAllowedRanges, ShouldBeEmpty)
+				So(cfg.AdminSecret, ShouldEqual, "")
+				So(cfg.AdminSecretHeader, ShouldEqual, "X-Admin-Secret")
+				So(cfg.BotDetectionEnabled, ShouldBeFalse)
+				So(cfg.BotUserAgents, ShouldBeEmpty)
+				So(cfg.BotIPRanges, ShouldBeEmpty)
+				So(cfg.BotBackendURL, ShouldBeEmpty)
+				So(cfg.BotBackendPaths, ShouldBeEmpty)
+				So(cfg.BotRateLimit, ShouldEqual, 0)
+				So(cfg.BotRateLimitBurst, ShouldEqual, 10)
+				So(cfg.GeoIPDBPath, ShouldBeEmpty)
+				So(cfg.GeoRoutes, ShouldBeEmpty)
+				So(cfg.GeoCountryHeader, ShouldBeEmpty)
+				So(cfg.CSPOverrides, ShouldBeEmpty)
+				So(cfg.CSPReportOnly, ShouldBeFalse)
+				So(cfg.HSTSMaxAge, ShouldEqual, time.Duration(0))
+				So(cfg.HSTSIncludeSubdomains, ShouldBeFalse)
+				So(cfg.HSTSPreload, ShouldBeFalse)
+				So(cfg.ContentTypeOptions, ShouldEqual, "nosniff")
+				So(cfg.ReferrerPolicy, ShouldEqual, "strict-origin-when-cross-origin")
+				So(cfg.PermissionsPolicy, ShouldBeEmpty)
+				So(cfg.CookieHardeningEnabled, ShouldBeFalse)
+				So(cfg.CookieSecure, ShouldBeTrue)
+				So(cfg.CookieHTTPOnly, ShouldBeTrue)
+				So(cfg.CookieHTTPOnlyExclusions, ShouldResemble, []string{"cookies_policy", "cookies_preferences_set"})
+				So(cfg.CookieSameSite, ShouldEqual, "Lax")
+				So(cfg.CORSRules, ShouldBeEmpty)
+				So(cfg.RequestBodyMaxBytes, ShouldEqual, int64(1048576))
+				So(cfg.RequestBodyLimits, ShouldResemble, map[string]string{"/feedback": "10485760", "/filters": "10485760"})
+				So(cfg.CompressionEnabled, ShouldBeFalse)
+				So(cfg.CompressionMinSize, ShouldEqual, 1024)
+				So(cfg.CompressionContentTypes, ShouldContain, "text/html")
+				So(cfg.CacheControlPolicies, ShouldBeEmpty)
+				So(cfg.RedirectMapFile, ShouldBeEmpty)
+				So(cfg.RedirectMapReloadInterval, ShouldEqual, time.Minute)
+				So(cfg.RedirectMapURL, ShouldBeEmpty)
+				So(cfg.RedirectRules, ShouldBeEmpty)
+				So(cfg.LegacySearchRedirectStatus, ShouldEqual, 301)
+				So(cfg.LegacySearchQueryParams, ShouldBeEmpty)
+				So(cfg.RedirectMetricsEnabled, ShouldBeFalse)
+				So(cfg.LegacyURLPatterns, ShouldResemble, []string{"^/ons/"})
+				So(cfg.ShadowRoutes, ShouldBeEmpty)
+				So(cfg.ShadowTimeout, ShouldEqual, 10*time.Second)
+				So(cfg.BackendSets, ShouldBeEmpty)
+				So(cfg.BackendSetHeader, ShouldEqual, "X-Router-Backend")
+				So(cfg.BackendSetSecret, ShouldBeEmpty)
+				So(cfg.BackendSetSecretHeader, ShouldEqual, "X-Router-Backend-Secret")
+				So(cfg.BackendInstances, ShouldBeEmpty)
+				So(cfg.BackendBalancing, ShouldEqual, "round-robin")
+				So(cfg.BackendInstanceCheckInterval, ShouldEqual, 10*time.Second)
 			})
 		})
 	})
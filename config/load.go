@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	overridesMu sync.Mutex
+	// overridden holds the original value, or nil if unset, of each environment variable set from an overrides file, so
+	// that a variable removed from the file returns to its original value on the next load
+	overridden = make(map[string]*string)
+)
+
+// Load reads the config afresh, rather than returning the config read at startup, so that changes can be reloaded.
+// Environment variable overrides in file, if set, are applied on top of the environment, one KEY=VALUE per line.
+// Blank lines and lines starting with '#' are ignored.
+func Load(file string) (*Config, error) {
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+
+	if file != "" {
+		overrides, err := readOverrides(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyOverrides(overrides); err != nil {
+			return nil, err
+		}
+	}
+
+	loaded, err := read()
+	if err != nil {
+		return nil, err
+	}
+	return loaded, nil
+}
+
+func readOverrides(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	overrides := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid override on line %d of %s, expected KEY=VALUE", n, file)
+		}
+		overrides[key] = strings.TrimSpace(value)
+	}
+	return overrides, scanner.Err()
+}
+
+// applyOverrides sets the overrides as environment variables, restoring any variable overridden by a previous load
+// that is no longer overridden
+func applyOverrides(overrides map[string]string) error {
+	for key, original := range overridden {
+		if _, ok := overrides[key]; ok {
+			continue
+		}
+		var err error
+		if original == nil {
+			err = os.Unsetenv(key)
+		} else {
+			err = os.Setenv(key, *original)
+		}
+		if err != nil {
+			return err
+		}
+		delete(overridden, key)
+	}
+
+	for key, value := range overrides {
+		if _, ok := overridden[key]; !ok {
+			var original *string
+			if v, ok := os.LookupEnv(key); ok {
+				original = &v
+			}
+			overridden[key] = original
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
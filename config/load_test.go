@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("ANALYTICS_RATE_LIMIT", "5")
+	t.Cleanup(func() {
+		// restore the environment that any overrides replaced, so they don't leak into other tests
+		overridesMu.Lock()
+		defer overridesMu.Unlock()
+		if err := applyOverrides(nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	Convey("Given an environment setting the analytics rate limit, and an overrides file", t, func() {
+		file := filepath.Join(t.TempDir(), "overrides.env")
+		writeOverrides := func(content string) {
+			So(os.WriteFile(file, []byte(content), 0o600), ShouldBeNil)
+		}
+
+		Convey("When the config is loaded with overrides", func() {
+			writeOverrides("# reloadable settings\n\nANALYTICS_RATE_LIMIT = 2.5\nANALYTICS_RATE_LIMIT_BURST=20\n")
+			loaded, err := Load(file)
+
+			Convey("Then the overrides are applied on top of the environment and defaults", func() {
+				So(err, ShouldBeNil)
+				So(loaded.AnalyticsRateLimit, ShouldEqual, 2.5)
+				So(loaded.AnalyticsRateLimitBurst, ShouldEqual, 20)
+				So(loaded.BindAddr, ShouldEqual, ":20000")
+			})
+
+			Convey("And the config is loaded again with the overrides removed", func() {
+				writeOverrides("")
+				reloaded, err := Load(file)
+
+				Convey("Then the original environment and defaults apply again", func() {
+					So(err, ShouldBeNil)
+					So(reloaded.AnalyticsRateLimit, ShouldEqual, 5)
+					So(reloaded.AnalyticsRateLimitBurst, ShouldEqual, 5)
+					_, set := os.LookupEnv("ANALYTICS_RATE_LIMIT_BURST")
+					So(set, ShouldBeFalse)
+				})
+			})
+		})
+
+		Convey("When the overrides file has an invalid line", func() {
+			writeOverrides("ANALYTICS_RATE_LIMIT\n")
+			_, err := Load(file)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When the overrides make the config invalid", func() {
+			writeOverrides("PROXY_TIMEOUT=0s\n")
+			_, err := Load(file)
+
+			Convey("Then the validation error is returned", func() {
+				So(err, ShouldBeError, "PROXY_TIMEOUT must be positive: 0s")
+			})
+		})
+
+		Convey("When the overrides file does not exist", func() {
+			_, err := Load(filepath.Join(t.TempDir(), "missing.env"))
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
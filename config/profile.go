@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// profiles are the defaults of each environment the router is deployed to, by CONFIG_PROFILE, which are layered over the
+// base defaults
+var profiles = map[string]func(c *Config){
+	"sandbox": func(c *Config) {
+		withDeployedBackends(c)
+		c.PprofEnabled = true
+	},
+	"staging": withDeployedBackends,
+	"prod": func(c *Config) {
+		withDeployedBackends(c)
+		c.AccessLogSuccessSampleRate = 0.1
+		c.OTSampleRatio = 0.1
+	},
+}
+
+// withDeployedBackends sets the backend URLs to the services registered in Consul in the environment, which listen on
+// the same ports as when running locally
+func withDeployedBackends(c *Config) {
+	c.APIRouterURL = "http://dp-api-router.service.consul:23200/v1"
+	c.AreaProfilesControllerURL = "http://dp-frontend-area-profiles.service.consul:26600"
+	c.BabbageURL = "http://babbage.service.consul:8080"
+	c.CensusAtlasURL = "http://dp-census-atlas.service.consul:28100"
+	c.CookiesControllerURL = "http://dp-frontend-cookie-controller.service.consul:24100"
+	c.DatasetControllerURL = "http://dp-frontend-dataset-controller.service.consul:20200"
+	c.DownloaderURL = "http://dp-download-service.service.consul:23400"
+	c.FeedbackControllerURL = "http://dp-frontend-feedback-controller.service.consul:25200"
+	c.FilterDatasetControllerURL = "http://dp-frontend-filter-dataset-controller.service.consul:20001"
+	c.FilterFlexDatasetServiceURL = "http://dp-frontend-filter-flex-dataset.service.consul:20100"
+	c.HomepageControllerURL = "http://dp-frontend-homepage-controller.service.consul:24400"
+	c.LegacyCacheProxyURL = "http://dp-legacy-cache-proxy.service.consul:29200"
+	c.ReleaseCalendarControllerURL = "http://dp-frontend-release-calendar.service.consul:27700"
+	c.SearchControllerURL = "http://dp-frontend-search-controller.service.consul:25000"
+}
+
+// read returns the base defaults, with the defaults of the profile named by CONFIG_PROFILE layered over them and any
+// modifications made through environment variables on top, and an error if any of its values are invalid
+func read() (*Config, error) {
+	c := newDefault()
+	if name := os.Getenv("CONFIG_PROFILE"); name != "" {
+		profile, ok := profiles[name]
+		if !ok {
+			return c, fmt.Errorf("unknown config profile %q", name)
+		}
+		profile(c)
+	}
+
+	if err := envconfig.Process("", c); err != nil {
+		return c, err
+	}
+
+	c.ReleaseCalendarRoutePrefix = validatePrivatePrefix(c.ReleaseCalendarRoutePrefix)
+
+	return c, c.Validate()
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProfiles(t *testing.T) {
+	Convey("Every profile is valid", t, func() {
+		for _, profile := range profiles {
+			c := newDefault()
+			profile(c)
+			So(c.Validate(), ShouldBeNil)
+			So(c.BabbageURL, ShouldNotEqual, newDefault().BabbageURL)
+		}
+	})
+}
+
+func TestRead(t *testing.T) {
+	Convey("Given the prod config profile", t, func() {
+		t.Setenv("CONFIG_PROFILE", "prod")
+
+		Convey("When the config is read", func() {
+			c, err := read()
+
+			Convey("Then the profile's defaults are layered over the base defaults", func() {
+				So(err, ShouldBeNil)
+				So(c.ConfigProfile, ShouldEqual, "prod")
+				So(c.BabbageURL, ShouldEqual, "http://babbage.service.consul:8080")
+				So(c.AccessLogSuccessSampleRate, ShouldEqual, 0.1)
+				So(c.BindAddr, ShouldEqual, ":20000")
+			})
+		})
+
+		Convey("When an environment variable sets a value the profile also sets", func() {
+			t.Setenv("BABBAGE_URL", "http://babbage-canary:8080")
+			c, err := read()
+
+			Convey("Then the environment variable takes precedence", func() {
+				So(err, ShouldBeNil)
+				So(c.BabbageURL, ShouldEqual, "http://babbage-canary:8080")
+				So(c.SearchControllerURL, ShouldEqual, "http://dp-frontend-search-controller.service.consul:25000")
+			})
+		})
+	})
+
+	Convey("Given an unknown config profile", t, func() {
+		t.Setenv("CONFIG_PROFILE", "dev")
+
+		Convey("When the config is read", func() {
+			_, err := read()
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldBeError, `unknown config profile "dev"`)
+			})
+		})
+	})
+}
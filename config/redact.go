@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// redacted replaces the value of a secret that is set, so that it can be seen to be set without revealing it
+const redacted = "[redacted]"
+
+// Redacted returns every config value by its environment variable, with the secrets, which are the fields not marshalled
+// to JSON, and any passwords in URLs masked
+func (c *Config) Redacted() map[string]interface{} {
+	values := make(map[string]interface{})
+	addRedacted(values, reflect.ValueOf(c).Elem())
+	return values
+}
+
+// addRedacted adds the redacted values of the fields of the struct v to values, including those of nested structs
+func addRedacted(values map[string]interface{}, v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type.PkgPath() == v.Type().PkgPath() {
+			addRedacted(values, value)
+			continue
+		}
+
+		name := field.Tag.Get("envconfig")
+		if name == "" {
+			continue
+		}
+		switch {
+		case field.Tag.Get("json") == "-":
+			values[name] = redactSecret(value)
+		case value.Kind() == reflect.String:
+			values[name] = redactURL(value.String())
+		default:
+			values[name] = value.Interface()
+		}
+	}
+}
+
+// redactSecret masks a secret that is set, or each value of a map of secrets, leaving those that are empty as they are
+func redactSecret(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Map {
+		masked := make(map[string]string, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			masked[iter.Key().String()] = redacted
+		}
+		return masked
+	}
+	if v.IsZero() {
+		return v.Interface()
+	}
+	return redacted
+}
+
+// redactURL masks the password of s if it is a URL that has one
+func redactURL(s string) string {
+	if !strings.Contains(s, "@") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return u.Redacted()
+}
+
+// Handler serves the running config, as returned by current, with its secrets redacted, so that the feature flags and
+// backend URLs an instance is running with can be checked
+func Handler(current func() *Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		b, err := json.Marshal(current().Redacted())
+		if err != nil {
+			log.Error(req.Context(), "error marshalling config", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(b); err != nil {
+			log.Error(req.Context(), "error writing response", err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Validate returns an error listing every invalid config value, or nil if they are all valid, so that a misconfigured
+// router fails on startup rather than when the value is first used
+func (c *Config) Validate() error {
+	var errs []error
+	errs = append(errs, c.validateRequired()...)
+	errs = append(errs, c.validateURLs()...)
+	errs = append(errs, c.validateDurations()...)
+	errs = append(errs, c.validateFractions()...)
+	errs = append(errs, c.validateCookies()...)
+	errs = append(errs, c.validateRedirectStatuses()...)
+	errs = append(errs, c.validateBalancing()...)
+	return errors.Join(errs...)
+}
+
+// validateRequired checks the values the router cannot run without are set
+func (c *Config) validateRequired() []error {
+	var errs []error
+	if c.BindAddr == "" {
+		errs = append(errs, errors.New("BIND_ADDR is required"))
+	}
+	if c.RedirectSecret == "" {
+		errs = append(errs, errors.New("REDIRECT_SECRET is required"))
+	}
+	if c.AdminSecret != "" && c.AdminSecretHeader == "" {
+		errs = append(errs, errors.New("ADMIN_SECRET_HEADER is required when ADMIN_SECRET is set"))
+	}
+	if (c.GeoRoutes != "" || c.GeoCountryHeader != "") && c.GeoIPDBPath == "" {
+		errs = append(errs, errors.New("GEOIP_DB_PATH is required when GEO_ROUTES or GEO_COUNTRY_HEADER is set"))
+	}
+	if c.RedirectMapFile != "" && c.RedirectMapURL != "" {
+		errs = append(errs, errors.New("REDIRECT_MAP_FILE and REDIRECT_MAP_URL cannot both be set"))
+	}
+	if c.BackendSets != "" && c.BackendSetSecret == "" {
+		errs = append(errs, errors.New("BACKEND_SET_SECRET is required when BACKEND_SETS is set"))
+	}
+	return errs
+}
+
+// validateURLs checks the backend URLs are absolute http or https URLs, including the optional ones if they are set
+func (c *Config) validateURLs() []error {
+	var errs []error
+	required := []struct{ name, value string }{
+		{"API_ROUTER_URL", c.APIRouterURL},
+		{"AREA_PROFILE_CONTROLLER_URL", c.AreaProfilesControllerURL},
+		{"BABBAGE_URL", c.BabbageURL},
+		{"COOKIES_CONTROLLER_URL", c.CookiesControllerURL},
+		{"DATASET_CONTROLLER_URL", c.DatasetControllerURL},
+		{"DOWNLOADER_URL", c.DownloaderURL},
+		{"FEEDBACK_CONTROLLER_URL", c.FeedbackControllerURL},
+		{"FILTER_DATASET_CONTROLLER_URL", c.FilterDatasetControllerURL},
+		{"FILTER_FLEX_DATASET_SERVICE_URL", c.FilterFlexDatasetServiceURL},
+		{"HOMEPAGE_CONTROLLER_URL", c.HomepageControllerURL},
+		{"LEGACY_CACHE_PROXY_URL", c.LegacyCacheProxyURL},
+		{"RELEASE_CALENDAR_CONTROLLER_URL", c.ReleaseCalendarControllerURL},
+		{"SEARCH_CONTROLLER_URL", c.SearchControllerURL},
+	}
+	for _, u := range required {
+		if u.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", u.name))
+		} else if err := validateURL(u.name, u.value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	optional := []struct{ name, value string }{
+		{"BOT_BACKEND_URL", c.BotBackendURL},
+		{"CDN_ASSET_BASE_URL", c.CDNAssetBaseURL},
+		{"CENSUS_ATLAS_URL", c.CensusAtlasURL},
+		{"FEATURE_FLAGS_URL", c.FeatureFlagsURL},
+		{"SQS_ANALYTICS_URL", c.SQSAnalyticsURL},
+		{"WEBHOOK_ANALYTICS_URL", c.WebhookAnalyticsURL},
+		{"ZEBEDEE_SECONDARY_URL", c.ZebedeeSecondaryURL},
+	}
+	for _, u := range optional {
+		if u.value == "" {
+			continue
+		}
+		if err := validateURL(u.name, u.value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// validateDurations checks the timeouts and intervals are positive
+func (c *Config) validateDurations() []error {
+	var errs []error
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"FEATURE_FLAGS_POLL_INTERVAL", c.FeatureFlagsPollInterval},
+		{"FEATURE_FLAGS_TIMEOUT", c.FeatureFlagsTimeout},
+		{"GRACEFUL_SHUTDOWN_TIMEOUT", c.GracefulShutdownTimeout},
+		{"HEALTHCHECK_CRITICAL_TIMEOUT", c.HealthcheckCriticalTimeout},
+		{"HEALTHCHECK_INTERVAL", c.HealthcheckInterval},
+		{"PROXY_TIMEOUT", c.ProxyTimeout},
+		{"SHADOW_TIMEOUT", c.ShadowTimeout},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive: %s", d.name, d.value))
+		}
+	}
+	return errs
+}
+
+// validateFractions checks the sample rates are between 0 and 1, and so not NaN
+func (c *Config) validateFractions() []error {
+	var errs []error
+	fractions := []struct {
+		name  string
+		value float64
+	}{
+		{"ACCESS_LOG_SUCCESS_SAMPLE_RATE", c.AccessLogSuccessSampleRate},
+		{"ANALYTICS_SAMPLE_RATE", c.AnalyticsSampleRate},
+		{"OTEL_SAMPLE_RATIO", c.OTSampleRatio},
+	}
+	for _, f := range fractions {
+		if !(f.value >= 0 && f.value <= 1) {
+			errs = append(errs, fmt.Errorf("%s must be between 0 and 1: %v", f.name, f.value))
+		}
+	}
+	return errs
+}
+
+// validateURL returns an error if value is not an absolute http or https URL
+func validateURL(name, value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("%s is not a valid URL: %w", name, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s is not an absolute http or https URL: %s", name, value)
+	}
+	return nil
+}
+
+// validateCookies checks the SameSite enforced on cookies is one that browsers accept
+func (c *Config) validateCookies() []error {
+	var errs []error
+	switch c.CookieSameSite {
+	case "", "Strict", "Lax":
+	case "None":
+		if !c.CookieSecure {
+			errs = append(errs, errors.New("COOKIE_SAME_SITE None requires COOKIE_SECURE, as browsers reject it on cookies that are not secure"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("COOKIE_SAME_SITE must be Strict, Lax or None: %s", c.CookieSameSite))
+	}
+	return errs
+}
+
+// validateRedirectStatuses checks the statuses redirects are configured with are redirect statuses
+func (c *Config) validateRedirectStatuses() []error {
+	switch c.LegacySearchRedirectStatus {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return nil
+	}
+	return []error{fmt.Errorf("LEGACY_SEARCH_REDIRECT_STATUS must be 301, 302, 303, 307 or 308: %d", c.LegacySearchRedirectStatus)}
+}
+
+// validateBalancing checks requests are balanced across the instances of backends by a known strategy
+func (c *Config) validateBalancing() []error {
+	switch c.BackendBalancing {
+	case "round-robin", "least-connections":
+		return nil
+	}
+	return []error{fmt.Errorf("BACKEND_BALANCING must be round-robin or least-connections: %s", c.BackendBalancing)}
+}
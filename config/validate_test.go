@@ -0,0 +1,65 @@
+package config
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidate(t *testing.T) {
+	Convey("The default config is valid", t, func() {
+		So(newDefault().Validate(), ShouldBeNil)
+	})
+
+	Convey("Optional URLs may be left empty", t, func() {
+		cfg := newDefault()
+		cfg.CensusAtlasURL = ""
+		cfg.SQSAnalyticsURL = ""
+		So(cfg.Validate(), ShouldBeNil)
+	})
+
+	Convey("Given a config with several invalid values", t, func() {
+		cfg := newDefault()
+		cfg.BindAddr = ""
+		cfg.BabbageURL = ""
+		cfg.AdminSecret = "s3cret"
+		cfg.AdminSecretHeader = ""
+		cfg.GeoCountryHeader = "X-Country-Code"
+		cfg.RedirectMapFile = "redirects.csv"
+		cfg.RedirectMapURL = "s3://redirects/redirects.csv"
+		cfg.BackendSets = `{"green":{"search":"http://green-search:25000"}}`
+		cfg.LegacySearchRedirectStatus = 200
+		cfg.BackendBalancing = "random"
+		cfg.CookieSameSite = "lax"
+		cfg.SearchControllerURL = "localhost:25000"
+		cfg.DownloaderURL = "http://local host:23400"
+		cfg.WebhookAnalyticsURL = "ftp://localhost/analytics"
+		cfg.ProxyTimeout = 0
+		cfg.AnalyticsSampleRate = 1.5
+		cfg.OTSampleRatio = math.NaN()
+
+		Convey("When it is validated", func() {
+			err := cfg.Validate()
+
+			Convey("Then every problem is reported", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "BIND_ADDR is required")
+				So(err.Error(), ShouldContainSubstring, "BABBAGE_URL is required")
+				So(err.Error(), ShouldContainSubstring, "ADMIN_SECRET_HEADER is required when ADMIN_SECRET is set")
+				So(err.Error(), ShouldContainSubstring, "GEOIP_DB_PATH is required when GEO_ROUTES or GEO_COUNTRY_HEADER is set")
+				So(err.Error(), ShouldContainSubstring, "REDIRECT_MAP_FILE and REDIRECT_MAP_URL cannot both be set")
+				So(err.Error(), ShouldContainSubstring, "BACKEND_SET_SECRET is required when BACKEND_SETS is set")
+				So(err.Error(), ShouldContainSubstring, "COOKIE_SAME_SITE must be Strict, Lax or None: lax")
+				So(err.Error(), ShouldContainSubstring, "SEARCH_CONTROLLER_URL is not an absolute http or https URL: localhost:25000")
+				So(err.Error(), ShouldContainSubstring, "DOWNLOADER_URL is not a valid URL")
+				So(err.Error(), ShouldContainSubstring, "WEBHOOK_ANALYTICS_URL is not an absolute http or https URL")
+				So(err.Error(), ShouldContainSubstring, "PROXY_TIMEOUT must be positive: 0s")
+				So(err.Error(), ShouldContainSubstring, "ANALYTICS_SAMPLE_RATE must be between 0 and 1: 1.5")
+				So(err.Error(), ShouldContainSubstring, "OTEL_SAMPLE_RATIO must be between 0 and 1: NaN")
+				So(err.Error(), ShouldContainSubstring, "LEGACY_SEARCH_REDIRECT_STATUS must be 301, 302, 303, 307 or 308: 200")
+				So(err.Error(), ShouldContainSubstring, "BACKEND_BALANCING must be round-robin or least-connections: random")
+			})
+		})
+	})
+}
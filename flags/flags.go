@@ -0,0 +1,77 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/ONSdigital/dp-frontend-router/config"
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+	"github.com/pkg/errors"
+)
+
+// Provider provides the values of feature flags, by the environment variable that sets them, e.g.
+// SEARCH_ROUTES_ENABLED. Flags it does not provide keep their values from the environment.
+type Provider interface {
+	Flags(ctx context.Context) (map[string]bool, error)
+}
+
+// HTTPProvider provides feature flags from a flag service, as a JSON object of flag names and values, e.g.
+// {"SEARCH_ROUTES_ENABLED": true}
+type HTTPProvider struct {
+	client dphttp.Clienter
+	url    string
+}
+
+// NewHTTPProvider creates a Provider getting the feature flags from url with client
+func NewHTTPProvider(client dphttp.Clienter, url string) *HTTPProvider {
+	return &HTTPProvider{client: client, url: url}
+}
+
+// Flags gets the feature flags from the flag service
+func (p *HTTPProvider) Flags(ctx context.Context) (map[string]bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, http.NoBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating feature flags request")
+	}
+
+	resp, err := p.client.Do(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting feature flags")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feature flag service responded with %d", resp.StatusCode)
+	}
+
+	var values map[string]bool
+	if err := json.NewDecoder(resp.Body).Decode(&values); err != nil {
+		return nil, errors.Wrap(err, "error decoding feature flags")
+	}
+	return values, nil
+}
+
+// Apply sets the flags of cfg to values, returning the names of any values that are not the environment variable of a
+// flag, which are ignored
+func Apply(cfg *config.Config, values map[string]bool) (unknown []string) {
+	fields := make(map[string]reflect.Value)
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if name := v.Type().Field(i).Tag.Get("envconfig"); name != "" && v.Field(i).Kind() == reflect.Bool {
+			fields[name] = v.Field(i)
+		}
+	}
+
+	for name, value := range values {
+		field, ok := fields[name]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		field.SetBool(value)
+	}
+	return unknown
+}
@@ -0,0 +1,71 @@
+package flags
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/config"
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHTTPProvider(t *testing.T) {
+	Convey("Given a flag service", t, func() {
+		status, body := http.StatusOK, `{"SEARCH_ROUTES_ENABLED":false,"DATA_AGGREGATION_PAGES_ENABLED":true}`
+		client := &dphttp.ClienterMock{
+			DoFunc: func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+			},
+		}
+		provider := NewHTTPProvider(client, "http://flags:8080/flags")
+
+		Convey("When it returns the flags", func() {
+			values, err := provider.Flags(context.Background())
+
+			Convey("Then they are provided", func() {
+				So(err, ShouldBeNil)
+				So(client.DoCalls(), ShouldHaveLength, 1)
+				So(client.DoCalls()[0].Req.URL.String(), ShouldEqual, "http://flags:8080/flags")
+				So(values, ShouldResemble, map[string]bool{"SEARCH_ROUTES_ENABLED": false, "DATA_AGGREGATION_PAGES_ENABLED": true})
+			})
+		})
+
+		Convey("When it responds with an error", func() {
+			status = http.StatusInternalServerError
+			_, err := provider.Flags(context.Background())
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldBeError, "feature flag service responded with 500")
+			})
+		})
+
+		Convey("When it returns something other than flags", func() {
+			body = `{"SEARCH_ROUTES_ENABLED":"yes"}`
+			_, err := provider.Flags(context.Background())
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestApply(t *testing.T) {
+	Convey("Given a config", t, func() {
+		cfg := &config.Config{SearchRoutesEnabled: true, BindAddr: ":20000"}
+
+		Convey("When flags are applied to it", func() {
+			unknown := Apply(cfg, map[string]bool{"SEARCH_ROUTES_ENABLED": false, "DATA_AGGREGATION_PAGES_ENABLED": true, "BIND_ADDR": true})
+
+			Convey("Then the flags are set, and values that are not flags are ignored", func() {
+				So(cfg.SearchRoutesEnabled, ShouldBeFalse)
+				So(cfg.DataAggregationPagesEnabled, ShouldBeTrue)
+				So(cfg.BindAddr, ShouldEqual, ":20000")
+				So(unknown, ShouldResemble, []string{"BIND_ADDR"})
+			})
+		})
+	})
+}
@@ -0,0 +1,94 @@
+package flags
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Poller polls a Provider for the feature flags, calling onChange whenever their values change. If the provider cannot
+// be reached, the last values it provided are kept, or none if it has not been reached yet, so that the flags fall back
+// to their values from the environment.
+type Poller struct {
+	provider Provider
+	interval time.Duration
+	timeout  time.Duration
+	onChange func(ctx context.Context)
+
+	mu     sync.Mutex
+	values map[string]bool
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewPoller creates a Poller of provider every interval, giving each poll timeout
+func NewPoller(provider Provider, interval, timeout time.Duration, onChange func(ctx context.Context)) *Poller {
+	return &Poller{
+		provider: provider,
+		interval: interval,
+		timeout:  timeout,
+		onChange: onChange,
+		values:   map[string]bool{},
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Start polls the provider straight away and then every interval, until Close is called
+func (p *Poller) Start(ctx context.Context) {
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.poll(ctx)
+			select {
+			case <-ticker.C:
+			case <-p.done:
+				return
+			}
+		}
+	}()
+}
+
+// Values returns the feature flags last provided
+func (p *Poller) Values() map[string]bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return maps.Clone(p.values)
+}
+
+// Close stops polling, waiting for a poll in progress to finish
+func (p *Poller) Close() error {
+	close(p.done)
+	<-p.stopped
+	return nil
+}
+
+func (p *Poller) poll(ctx context.Context) {
+	pollCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	values, err := p.provider.Flags(pollCtx)
+	cancel()
+	if err != nil {
+		log.Warn(ctx, "failed to poll feature flags, keeping the last values", log.Data{"error": err.Error()})
+		return
+	}
+
+	p.mu.Lock()
+	changed := !maps.Equal(p.values, values)
+	if changed {
+		p.values = values
+	}
+	p.mu.Unlock()
+
+	if changed {
+		log.Info(ctx, "feature flags changed", log.Data{"flags": values})
+		p.onChange(ctx)
+	}
+}
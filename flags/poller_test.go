@@ -0,0 +1,59 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeProvider provides the flags it is set to, or an error
+type fakeProvider struct {
+	mu     sync.Mutex
+	values map[string]bool
+	err    error
+}
+
+func (p *fakeProvider) Flags(ctx context.Context) (map[string]bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.values, p.err
+}
+
+func (p *fakeProvider) set(values map[string]bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.values, p.err = values, err
+}
+
+func TestPoller(t *testing.T) {
+	Convey("Given a poller of a flag provider", t, func() {
+		provider := &fakeProvider{values: map[string]bool{"SEARCH_ROUTES_ENABLED": false}}
+		changes := make(chan map[string]bool, 10)
+		var poller *Poller
+		poller = NewPoller(provider, 10*time.Millisecond, time.Second, func(ctx context.Context) {
+			changes <- poller.Values()
+		})
+		poller.Start(context.Background())
+		defer poller.Close()
+
+		Convey("Then the flags are polled straight away", func() {
+			So(<-changes, ShouldResemble, map[string]bool{"SEARCH_ROUTES_ENABLED": false})
+
+			Convey("And a change to them is reported", func() {
+				provider.set(map[string]bool{"SEARCH_ROUTES_ENABLED": true}, nil)
+				So(<-changes, ShouldResemble, map[string]bool{"SEARCH_ROUTES_ENABLED": true})
+			})
+
+			Convey("And the last values are kept while the provider fails", func() {
+				provider.set(nil, errors.New("unreachable"))
+				time.Sleep(50 * time.Millisecond)
+				So(changes, ShouldBeEmpty)
+				So(poller.Values(), ShouldResemble, map[string]bool{"SEARCH_ROUTES_ENABLED": false})
+			})
+		})
+	})
+}
@@ -0,0 +1,51 @@
+package geoip
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Locator looks up the country that IPs are in
+type Locator interface {
+	// Country returns the ISO 3166-1 alpha-2 code of the country ip is in, or an empty string if it is not known
+	Country(ip netip.Addr) (string, error)
+}
+
+var _ Locator = &DB{}
+
+// DB looks up countries in a MaxMind database, such as GeoIP2 or GeoLite2 Country or City
+type DB struct {
+	reader *maxminddb.Reader
+}
+
+// record is the part of a database record holding the country
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// Open opens the MaxMind database at path
+func Open(path string) (*DB, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening geoip database %q: %w", path, err)
+	}
+	return &DB{reader: reader}, nil
+}
+
+// Country returns the ISO code of the country ip is in, or an empty string if the database does not hold it
+func (db *DB) Country(ip netip.Addr) (string, error) {
+	var r record
+	if err := db.reader.Lookup(ip.AsSlice(), &r); err != nil {
+		return "", err
+	}
+	return r.Country.ISOCode, nil
+}
+
+// Close closes the database
+func (db *DB) Close() error {
+	return db.reader.Close()
+}
@@ -0,0 +1,30 @@
+package geoip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOpen(t *testing.T) {
+	Convey("Given a path with no database", t, func() {
+		_, err := Open(filepath.Join(t.TempDir(), "GeoLite2-Country.mmdb"))
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a file that is not a MaxMind database", t, func() {
+		path := filepath.Join(t.TempDir(), "GeoLite2-Country.mmdb")
+		So(os.WriteFile(path, []byte("not a database"), 0o600), ShouldBeNil)
+
+		_, err := Open(path)
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
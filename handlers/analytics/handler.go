@@ -2,9 +2,16 @@ package analytics
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"net/netip"
+	"path/filepath"
+	"time"
 
 	"github.com/ONSdigital/dp-frontend-router/analytics"
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+	"github.com/ONSdigital/dp-frontend-router/ratelimit"
 	"github.com/ONSdigital/log.go/v2/log"
 )
 
@@ -13,32 +20,311 @@ type httpRedirector func(w http.ResponseWriter, r *http.Request, urlStr string,
 type searchHandler struct {
 	service    analytics.Service
 	redirector httpRedirector
+
+	// limiter, if set, limits the rate at which each client's analytics data is stored. Limited requests are either
+	// rejected, or redirected by limitedService without their data being stored.
+	limiter        *ratelimit.Limiter
+	limitedService analytics.Service
+	rejectLimited  bool
+	trustedProxies []netip.Prefix
+
+	// backend is closed on shutdown if it holds data that has not yet been stored
+	backend io.Closer
+}
+
+// Config holds the configuration for the search handler
+type Config struct {
+	SQSAnalyticsURL string
+	RedirectSecret  string
+
+	// AllowedRedirectDomains are the domains, along with their subdomains, that users may be redirected to. Paths on this
+	// site are always allowed, and any other URL is rejected.
+	AllowedRedirectDomains []string
+
+	// SQSAnalyticsQueuesByListType maps list types to the SQS queue their data is sent to, instead of SQSAnalyticsURL
+	SQSAnalyticsQueuesByListType map[string]string
+
+	// SQSBatchEnabled buffers analytics data and sends it to SQS in batches, every SQSBatchFlushInterval or once a batch
+	// is full, in the background. Failed batches are not retried, so AsyncEnabled does not apply.
+	SQSBatchEnabled       bool
+	SQSBatchFlushInterval time.Duration
+
+	// KafkaAnalytics produces analytics data to a Kafka topic instead of SQS, if it has any brokers
+	KafkaAnalytics analytics.KafkaConfig
+
+	// FirehoseAnalyticsStream puts analytics data on a Kinesis Firehose delivery stream instead of SQS, if set
+	FirehoseAnalyticsStream string
+
+	// WebhookAnalytics posts analytics data to an HTTP endpoint instead of SQS, if it has a URL
+	WebhookAnalytics analytics.WebhookConfig
+
+	// AnalyticsFile writes analytics data as JSON lines to a file, or to stdout if it is analytics.StdoutPath, instead of
+	// any other backend, so the analytics flow can be used locally without any cloud services
+	AnalyticsFile string
+
+	// AsyncEnabled queues analytics data for a pool of AsyncMaxInFlight workers to store, retrying failed sends, rather
+	// than blocking the redirect. Data is dropped if AsyncQueueSize stores are already queued.
+	AsyncEnabled     bool
+	AsyncMaxInFlight int
+	AsyncQueueSize   int
+	AsyncMaxRetries  int
+	AsyncTimeout     time.Duration
+
+	// DedupEnabled skips storing an event that has already been sent within DedupTTL, when AsyncEnabled is set. The
+	// sent events are shared by every async worker, remembering up to DedupMaxEntries.
+	DedupEnabled    bool
+	DedupTTL        time.Duration
+	DedupMaxEntries int
+
+	// SpoolDir, if set when AsyncEnabled is, keeps analytics data that failed to store after retrying in a file in the
+	// directory for each backend, from which it is sent again every SpoolInterval, rather than dropping it. Each file
+	// holds up to SpoolMaxEvents events.
+	SpoolDir       string
+	SpoolMaxEvents int
+	SpoolInterval  time.Duration
+
+	// ClampEnabled clamps the page index, link index and page size to zero and the maximums below
+	ClampEnabled bool
+	MaxPageIndex int
+	MaxLinkIndex int
+	MaxPageSize  int
+
+	// SamplingEnabled stores only SampleRate, from 0 to 1, of the events, to control the volume of data stored during
+	// traffic spikes. Events are sampled by session cookie, so each session's events are either all stored or not at all.
+	SamplingEnabled bool
+	SampleRate      float64
+
+	// ScrubEnabled removes email and IP addresses, and anything matching ScrubPatterns, from the term and URL of events
+	// before they are logged or stored. Matches are replaced with a hash keyed with ScrubHashKey, if set.
+	ScrubEnabled  bool
+	ScrubPatterns []string
+	ScrubHashKey  string
+
+	// MaxTermLength, MaxURLLength and MaxListTypeLength are the lengths the string fields are truncated to, if not zero
+	MaxTermLength     int
+	MaxURLLength      int
+	MaxListTypeLength int
+
+	// RateLimiter, if set, limits the rate of requests for which each client's data is stored. Requests beyond that are
+	// still redirected without storing their data, unless RateLimitReject is set, in which case they get a 429. The
+	// limiter is shared rather than created here, so that its limit can be changed while running.
+	RateLimiter     *ratelimit.Limiter
+	RateLimitReject bool
+
+	// TrustedProxies are the proxies that clients are identified through for rate limiting, as for the IP filter
+	TrustedProxies []netip.Prefix
+
+	// Metrics, if set, is the registry that the count of dropped analytics data is registered with
+	Metrics *metrics.Registry
+
+	// Experiments are the experiments whose bucket, read from the user's cookie, is recorded in their analytics data
+	Experiments []analytics.Experiment
+
+	// FanOutEnabled stores analytics data in every configured backend, such as both SQS and Kafka during a migration,
+	// rather than only the first by precedence
+	FanOutEnabled bool
 }
 
 // NewSearchHandler creates a new search handler
-func NewSearchHandler(ctx context.Context, sqSanalyticsURL, redirectSecret string) (http.Handler, error) {
-	var b analytics.ServiceBackend
-	var err error
+func NewSearchHandler(ctx context.Context, cfg Config) (http.Handler, error) {
+	storeBackends, err := newStoreBackends(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var asyncMetrics *analytics.AsyncMetrics
+	if cfg.AsyncEnabled {
+		asyncMetrics = analytics.NewAsyncMetrics(cfg.Metrics)
+	}
 
-	if len(sqSanalyticsURL) > 0 {
-		b, err = analytics.NewSQSBackend(ctx, sqSanalyticsURL)
+	backends := make([]analytics.ServiceBackend, 0, len(storeBackends))
+	for _, sb := range storeBackends {
+		backend, err := withAsync(cfg, asyncMetrics, sb)
 		if err != nil {
 			return nil, err
 		}
+		backends = append(backends, backend)
+	}
+
+	var b analytics.ServiceBackend
+	switch len(backends) {
+	case 0:
+	case 1:
+		b = backends[0]
+	default:
+		b = analytics.NewFanOutBackend(backends...)
+	}
+
+	service, err := newService(cfg, b)
+	if err != nil {
+		return nil, err
 	}
 
 	sh := &searchHandler{
-		service:    analytics.NewServiceImpl(b, redirectSecret),
+		service:    service,
 		redirector: http.Redirect,
 	}
+	if closer, ok := b.(io.Closer); ok {
+		sh.backend = closer
+	}
+
+	if cfg.RateLimiter != nil {
+		sh.limiter = cfg.RateLimiter
+		// limited requests are logged without being stored, so their data is checked and scrubbed just the same
+		if sh.limitedService, err = newService(cfg, nil); err != nil {
+			return nil, err
+		}
+		sh.rejectLimited = cfg.RateLimitReject
+		sh.trustedProxies = cfg.TrustedProxies
+	}
 	return sh, nil
 }
 
+// newService creates the analytics service that stores data in backend, which may be nil to only log it
+func newService(cfg Config, backend analytics.ServiceBackend) (*analytics.ServiceImpl, error) {
+	service := analytics.NewServiceImpl(backend, cfg.RedirectSecret).WithAllowedRedirectDomains(cfg.AllowedRedirectDomains)
+	if cfg.ClampEnabled {
+		service = service.WithLimits(analytics.Limits{
+			MaxPageIndex: float64(cfg.MaxPageIndex),
+			MaxLinkIndex: float64(cfg.MaxLinkIndex),
+			MaxPageSize:  float64(cfg.MaxPageSize),
+		})
+	}
+	if cfg.SamplingEnabled {
+		service = service.WithSampleRate(cfg.SampleRate)
+	}
+	if cfg.ScrubEnabled {
+		scrubber, err := analytics.NewScrubber(cfg.ScrubPatterns, cfg.ScrubHashKey)
+		if err != nil {
+			return nil, err
+		}
+		service = service.WithScrubber(scrubber)
+	}
+	if len(cfg.Experiments) > 0 {
+		service = service.WithExperiments(cfg.Experiments)
+	}
+	return service, nil
+}
+
+// storeBackend is a backend that analytics data is stored in, with its name for logs and metrics
+type storeBackend struct {
+	name    string
+	backend analytics.ServiceBackend
+}
+
+// withAsync wraps the backend of sb in its own async backend, if enabled and the backend does not already store data in
+// the background. Each backend gets its own queue and dedup cache, so that a backend that is failing or slow does not
+// hold up, or cause data to be skipped for, the others.
+func withAsync(cfg Config, asyncMetrics *analytics.AsyncMetrics, sb storeBackend) (analytics.ServiceBackend, error) {
+	if _, isBatch := sb.backend.(*analytics.BatchSQSBackend); isBatch || !cfg.AsyncEnabled {
+		return sb.backend, nil
+	}
+
+	asyncBackend := analytics.NewAsyncBackend(
+		sb.backend, cfg.AsyncMaxInFlight, cfg.AsyncQueueSize, cfg.AsyncMaxRetries, cfg.AsyncTimeout,
+	).WithMetrics(asyncMetrics, sb.name)
+	if cfg.DedupEnabled {
+		asyncBackend = asyncBackend.WithDedup(analytics.NewDedupCache(cfg.DedupMaxEntries, cfg.DedupTTL))
+	}
+	if cfg.SpoolDir != "" {
+		spool, err := analytics.NewSpool(
+			sb.backend, filepath.Join(cfg.SpoolDir, sb.name+".jsonl"), cfg.SpoolMaxEvents, cfg.SpoolInterval, cfg.AsyncTimeout,
+		)
+		if err != nil {
+			return nil, err
+		}
+		asyncBackend = asyncBackend.WithSpool(spool)
+	}
+	return asyncBackend, nil
+}
+
+// newStoreBackends creates the backends that analytics data is stored in. In order of precedence, these are a local
+// file if one is set, Kafka if it has brokers, Firehose if it has a delivery stream, a webhook if it has a URL, and SQS
+// if it has a queue, batching the data sent to SQS if enabled. Only the first configured backend is created, unless
+// FanOutEnabled is set, in which case they all are.
+func newStoreBackends(ctx context.Context, cfg Config) ([]storeBackend, error) {
+	fieldLimits := analytics.FieldLimits{
+		Term:     cfg.MaxTermLength,
+		URL:      cfg.MaxURLLength,
+		ListType: cfg.MaxListTypeLength,
+	}
+
+	candidates := []struct {
+		name       string
+		configured bool
+		create     func() (analytics.ServiceBackend, error)
+	}{
+		{"file", len(cfg.AnalyticsFile) > 0, func() (analytics.ServiceBackend, error) {
+			return analytics.NewFileBackend(cfg.AnalyticsFile, fieldLimits)
+		}},
+		{"kafka", len(cfg.KafkaAnalytics.Brokers) > 0, func() (analytics.ServiceBackend, error) {
+			return analytics.NewKafkaBackend(cfg.KafkaAnalytics, fieldLimits)
+		}},
+		{"firehose", len(cfg.FirehoseAnalyticsStream) > 0, func() (analytics.ServiceBackend, error) {
+			return analytics.NewFirehoseBackend(ctx, cfg.FirehoseAnalyticsStream, fieldLimits)
+		}},
+		{"webhook", len(cfg.WebhookAnalytics.URL) > 0, func() (analytics.ServiceBackend, error) {
+			return analytics.NewWebhookBackend(cfg.WebhookAnalytics, fieldLimits)
+		}},
+		{"sqs", len(cfg.SQSAnalyticsURL) > 0, func() (analytics.ServiceBackend, error) {
+			return newSQSBackend(ctx, cfg, fieldLimits)
+		}},
+	}
+
+	var backends []storeBackend
+	for _, c := range candidates {
+		if !c.configured {
+			continue
+		}
+		backend, err := c.create()
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, storeBackend{name: c.name, backend: backend})
+		if !cfg.FanOutEnabled {
+			break
+		}
+	}
+	return backends, nil
+}
+
+// newSQSBackend creates the backend that sends analytics data to SQS, in batches if enabled, and to a queue by list
+// type if there are any
+func newSQSBackend(ctx context.Context, cfg Config, fieldLimits analytics.FieldLimits) (analytics.ServiceBackend, error) {
+	switch {
+	case cfg.SQSBatchEnabled:
+		return analytics.NewBatchSQSBackend(ctx, cfg.SQSAnalyticsURL, cfg.SQSAnalyticsQueuesByListType, fieldLimits, cfg.SQSBatchFlushInterval)
+	case len(cfg.SQSAnalyticsQueuesByListType) > 0:
+		return analytics.NewListTypeSQSBackend(ctx, cfg.SQSAnalyticsURL, cfg.SQSAnalyticsQueuesByListType, fieldLimits)
+	default:
+		return analytics.NewSQSBackend(ctx, cfg.SQSAnalyticsURL, fieldLimits)
+	}
+}
+
+// Close sends any analytics data that the backend is holding to store later, such as queued data or a partial SQS batch
+func (sh searchHandler) Close() error {
+	if sh.backend == nil {
+		return nil
+	}
+	return sh.backend.Close()
+}
+
 // HandleSearch - http Handler func for dealing with Babbage Search requests. Captures search analytics data and redirects
 // the user to the requested resource.
 func (sh searchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	service := sh.service
+	if sh.limiter != nil && !sh.limiter.Allow(ipfilter.ClientKey(r, sh.trustedProxies)) {
+		if sh.rejectLimited {
+			log.Warn(r.Context(), "rejecting rate limited analytics request")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		log.Warn(r.Context(), "analytics request rate limited, redirecting without storing data")
+		service = sh.limitedService
+	}
+
 	log.Info(r.Context(), "capturing search analytics data")
-	redirectURL, err := sh.service.CaptureAnalyticsData(r)
+	redirectURL, err := service.CaptureAnalyticsData(r)
 
 	if err != nil {
 		log.Error(r.Context(), "error capturing analytics data", err)
@@ -1,12 +1,22 @@
 package analytics
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/ONSdigital/dp-frontend-router/analytics"
+	"github.com/ONSdigital/dp-frontend-router/ratelimit"
+	"github.com/ONSdigital/log.go/v2/log"
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/gorilla/mux"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -108,3 +118,190 @@ func TestHandleSearch(t *testing.T) {
 		})
 	})
 }
+
+func TestHandleSearchRateLimit(t *testing.T) {
+	requestedURL, _ := url.Parse(validURL)
+	Convey("Given a handler limited to one stored request per client", t, func() {
+		serviceMock := &mockAnalyticsService{
+			args:          make([]url.Values, 0),
+			mockBehaviour: successBehavior,
+		}
+		limitedServiceMock := &mockAnalyticsService{
+			args:          make([]url.Values, 0),
+			mockBehaviour: successBehavior,
+		}
+		mockRedir := &MockHTTPRedir{args: make([]*MockRedirArgs, 0)}
+		sh := &searchHandler{
+			service:        serviceMock,
+			redirector:     mockRedir.mockRedirector,
+			limiter:        ratelimit.New(0.001, 1, 10),
+			limitedService: limitedServiceMock,
+		}
+
+		serveForwarded := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+			resp := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", requestedURL.RequestURI(), http.NoBody)
+			req.RemoteAddr = remoteAddr
+			if forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+			}
+			sh.ServeHTTP(resp, req)
+			return resp
+		}
+		serve := func(remoteAddr string) *httptest.ResponseRecorder {
+			return serveForwarded(remoteAddr, "")
+		}
+
+		Convey("When a client exceeds the limit", func() {
+			serve("203.0.113.7:1000")
+			serve("203.0.113.7:1001")
+
+			Convey("Then both requests are redirected", func() {
+				So(len(mockRedir.args), ShouldEqual, 2)
+			})
+
+			Convey("Then only the request within the limit is captured by the storing service", func() {
+				So(len(serviceMock.args), ShouldEqual, 1)
+				So(len(limitedServiceMock.args), ShouldEqual, 1)
+			})
+
+			Convey("Then another client is limited separately", func() {
+				serve("198.51.100.2:1000")
+				So(len(serviceMock.args), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When a client that is not a trusted proxy sends a different X-Forwarded-For with each request", func() {
+			serveForwarded("203.0.113.7:1000", "192.0.2.1")
+			serveForwarded("203.0.113.7:1001", "192.0.2.2")
+
+			Convey("Then it is still limited by its own address", func() {
+				So(len(serviceMock.args), ShouldEqual, 1)
+				So(len(limitedServiceMock.args), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When clients behind a trusted proxy exceed the limit", func() {
+			sh.trustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+			serveForwarded("10.0.0.1:1000", "203.0.113.7")
+			serveForwarded("10.0.0.1:1001", "192.0.2.1, 203.0.113.7")
+			serveForwarded("10.0.0.1:1002", "198.51.100.2")
+
+			Convey("Then each is limited by the address the proxy forwarded", func() {
+				So(len(serviceMock.args), ShouldEqual, 2)
+				So(len(limitedServiceMock.args), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When a client exceeds the limit, and limited requests are rejected", func() {
+			sh.rejectLimited = true
+			serve("203.0.113.7:1000")
+			resp := serve("203.0.113.7:1001")
+
+			Convey("Then the limited request gets a 429 and is not redirected", func() {
+				So(resp.Code, ShouldEqual, http.StatusTooManyRequests)
+				So(len(mockRedir.args), ShouldEqual, 1)
+				So(len(serviceMock.args), ShouldEqual, 1)
+				So(len(limitedServiceMock.args), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestNewSearchHandlerRateLimit(t *testing.T) {
+	Convey("Given a handler that scrubs personal data and is limited to one stored request per client", t, func() {
+		handler, err := NewSearchHandler(context.Background(), Config{
+			RedirectSecret: "secret",
+			ScrubEnabled:   true,
+			RateLimiter:    ratelimit.New(0.001, 1, 10),
+		})
+		So(err, ShouldBeNil)
+		router := mux.NewRouter()
+		router.Handle("/redir/{data:.*}", handler)
+
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"term": "jo.bloggs@example.com",
+			"uri":  "/economy",
+		}).SignedString([]byte("secret"))
+		So(err, ShouldBeNil)
+		serve := func() *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/redir/"+token, http.NoBody)
+			req.RemoteAddr = "203.0.113.9:1234"
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When a client exceeds the limit", func() {
+			serve()
+			var logs bytes.Buffer
+			log.SetDestination(&logs, nil)
+			defer log.SetDestination(os.Stdout, nil)
+			w := serve()
+
+			Convey("Then the limited request is redirected, logging the scrubbed term rather than the personal data", func() {
+				So(w.Code, ShouldEqual, http.StatusTemporaryRedirect)
+				So(logs.String(), ShouldContainSubstring, "redirecting without storing data")
+				So(logs.String(), ShouldContainSubstring, "[redacted]")
+				So(logs.String(), ShouldNotContainSubstring, "jo.bloggs@example.com")
+			})
+		})
+	})
+}
+
+func TestNewStoreBackends(t *testing.T) {
+	Convey("Given a file, Kafka and a webhook are all configured as analytics backends", t, func() {
+		cfg := Config{
+			AnalyticsFile:    filepath.Join(t.TempDir(), "analytics.jsonl"),
+			KafkaAnalytics:   analytics.KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "search-analytics"},
+			WebhookAnalytics: analytics.WebhookConfig{URL: "https://collector.example/events"},
+		}
+		names := func(backends []storeBackend) []string {
+			var names []string
+			for _, sb := range backends {
+				names = append(names, sb.name)
+			}
+			return names
+		}
+
+		Convey("When fan-out is disabled", func() {
+			backends, err := newStoreBackends(context.Background(), cfg)
+			So(err, ShouldBeNil)
+
+			Convey("Then only the backend with the highest precedence is created", func() {
+				So(names(backends), ShouldResemble, []string{"file"})
+			})
+		})
+
+		Convey("When fan-out is enabled", func() {
+			cfg.FanOutEnabled = true
+			backends, err := newStoreBackends(context.Background(), cfg)
+			So(err, ShouldBeNil)
+
+			Convey("Then every configured backend is created", func() {
+				So(names(backends), ShouldResemble, []string{"file", "kafka", "webhook"})
+			})
+
+			Convey("Then each gets its own async backend", func() {
+				cfg.AsyncEnabled, cfg.AsyncMaxInFlight, cfg.AsyncQueueSize = true, 1, 1
+				metrics := analytics.NewAsyncMetrics(nil)
+				first, err := withAsync(cfg, metrics, backends[0])
+				So(err, ShouldBeNil)
+				second, err := withAsync(cfg, metrics, backends[1])
+				So(err, ShouldBeNil)
+				So(first, ShouldHaveSameTypeAs, &analytics.AsyncBackend{})
+				So(first, ShouldNotPointTo, second)
+			})
+		})
+
+		Convey("When a backend cannot be created", func() {
+			cfg.FanOutEnabled = true
+			cfg.KafkaAnalytics.SASLMechanism = "GSSAPI"
+			_, err := newStoreBackends(context.Background(), cfg)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,115 @@
+package canary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+)
+
+// Definition describes a canary as configured: the route it applies to, the URL of the upstream serving the canary, the
+// percentage of visitors to that route who are sent to it, and optionally the cookie that pins visitors to their side
+type Definition struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	URL     string `json:"url"`
+	Percent int    `json:"percent"`
+	Cookie  string `json:"cookie,omitempty"`
+}
+
+// ParseDefinitions parses canary definitions from a JSON array, as read from config
+func ParseDefinitions(s string) ([]Definition, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var defs []Definition
+	if err := json.Unmarshal([]byte(s), &defs); err != nil {
+		return nil, fmt.Errorf("invalid canary definitions: %w", err)
+	}
+
+	for _, def := range defs {
+		if def.Name == "" || def.Path == "" || def.URL == "" {
+			return nil, errors.New("invalid canary definitions: name, path and url are required")
+		}
+		if def.Percent < 0 || def.Percent > 100 {
+			return nil, fmt.Errorf("invalid canary definitions: percent for %q must be between 0 and 100", def.Name)
+		}
+	}
+	return defs, nil
+}
+
+// Values of the cookie that pins a visitor to a side of a canary
+const (
+	sideCanary = "canary"
+	sideStable = "stable"
+)
+
+// Canary serves Percent of the visitors to the route with the path template Path with Handler, instead of the route's
+// own handler. If Cookie is set, visitors are pinned to the side they were first served by in that cookie.
+type Canary struct {
+	Name    string
+	Path    string
+	Handler http.Handler
+	Percent int
+	Cookie  string
+}
+
+// Handler serves c.Percent of visitors with the canary, and all other visitors with stable. Visitors are identified by
+// idCookie, if set and present, or their client IP through trustedProxies, so that each visitor is served by the same
+// handler on every request, and raising the percentage only moves visitors from stable to canary.
+func Handler(c Canary, stable http.Handler, idCookie string, trustedProxies []netip.Prefix) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if c.serves(w, req, ipfilter.VisitorID(req, idCookie, trustedProxies)) {
+			c.Handler.ServeHTTP(w, req)
+			return
+		}
+		stable.ServeHTTP(w, req)
+	})
+}
+
+// serves reports whether the canary serves the visitor making req. With a cookie, the visitor stays on the side they
+// were first served by for the rest of their browser session, even if their client IP or the percentage changes, so
+// that they do not flip between old and new pages mid-journey. The cookie is ignored at 0 and 100 percent, so that a
+// canary can always be rolled back or completed for everyone.
+func (c Canary) serves(w http.ResponseWriter, req *http.Request, visitor string) bool {
+	if c.Cookie == "" || c.Percent <= 0 || c.Percent >= 100 {
+		return inCanary(c.Name, visitor, c.Percent)
+	}
+
+	if pinned, err := req.Cookie(c.Cookie); err == nil {
+		switch pinned.Value {
+		case sideCanary:
+			return true
+		case sideStable:
+			return false
+		}
+	}
+
+	served := inCanary(c.Name, visitor, c.Percent)
+	side := sideStable
+	if served {
+		side = sideCanary
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.Cookie,
+		Value:    side,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return served
+}
+
+// inCanary hashes the visitor to one of 100 slots, so that the visitors in the canary at a percentage are a subset of
+// those in it at any higher percentage
+func inCanary(name, visitor string, percent int) bool {
+	h := fnv.New32a()
+	h.Write([]byte(name + "|" + visitor))
+	return int(h.Sum32()%100) < percent
+}
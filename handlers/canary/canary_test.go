@@ -0,0 +1,168 @@
+package canary
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseDefinitions(t *testing.T) {
+	Convey("Given canary definitions as configured", t, func() {
+		defs, err := ParseDefinitions(`[{"name":"new-datasets","path":"/datasets/{uri:.*}","url":"http://localhost:20201","percent":5}]`)
+
+		Convey("Then they are parsed", func() {
+			So(err, ShouldBeNil)
+			So(defs, ShouldResemble, []Definition{{Name: "new-datasets", Path: "/datasets/{uri:.*}", URL: "http://localhost:20201", Percent: 5}})
+		})
+	})
+
+	Convey("Given a canary definition that pins visitors with a cookie", t, func() {
+		defs, err := ParseDefinitions(
+			`[{"name":"new-datasets","path":"/datasets/{uri:.*}","url":"http://localhost:20201","percent":5,"cookie":"canary_datasets"}]`)
+
+		Convey("Then the cookie is parsed", func() {
+			So(err, ShouldBeNil)
+			So(defs[0].Cookie, ShouldEqual, "canary_datasets")
+		})
+	})
+
+	Convey("Given no canary definitions", t, func() {
+		defs, err := ParseDefinitions(" ")
+
+		Convey("Then there are no canaries", func() {
+			So(err, ShouldBeNil)
+			So(defs, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a canary definition without a url", t, func() {
+		_, err := ParseDefinitions(`[{"name":"new-datasets","path":"/datasets/{uri:.*}","percent":5}]`)
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldBeError, "invalid canary definitions: name, path and url are required")
+		})
+	})
+
+	Convey("Given a canary definition with a percentage over 100", t, func() {
+		_, err := ParseDefinitions(`[{"name":"new-datasets","path":"/datasets/{uri:.*}","url":"http://localhost:20201","percent":101}]`)
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldBeError, `invalid canary definitions: percent for "new-datasets" must be between 0 and 100`)
+		})
+	})
+}
+
+func TestHandler(t *testing.T) {
+	served := func(h http.Handler, visitor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/datasets/cpih", http.NoBody)
+		req.AddCookie(&http.Cookie{Name: "_ga", Value: visitor})
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+		return res.Code
+	}
+	stable := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusOK) })
+	canary := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusAccepted) })
+
+	Convey("Given a canary for no visitors", t, func() {
+		h := Handler(Canary{Name: "new-datasets", Handler: canary, Percent: 0}, stable, "_ga", nil)
+
+		Convey("Then every visitor is served by the stable handler", func() {
+			for i := 0; i < 100; i++ {
+				So(served(h, fmt.Sprint(i)), ShouldEqual, http.StatusOK)
+			}
+		})
+	})
+
+	Convey("Given a canary for every visitor", t, func() {
+		h := Handler(Canary{Name: "new-datasets", Handler: canary, Percent: 100}, stable, "_ga", nil)
+
+		Convey("Then every visitor is served by the canary", func() {
+			for i := 0; i < 100; i++ {
+				So(served(h, fmt.Sprint(i)), ShouldEqual, http.StatusAccepted)
+			}
+		})
+	})
+
+	Convey("Given a canary for a share of visitors", t, func() {
+		h := Handler(Canary{Name: "new-datasets", Handler: canary, Percent: 20}, stable, "_ga", nil)
+		wider := Handler(Canary{Name: "new-datasets", Handler: canary, Percent: 50}, stable, "_ga", nil)
+
+		Convey("Then roughly that share of visitors is served by the canary, each on every request", func() {
+			inCanary := 0
+			for i := 0; i < 1000; i++ {
+				code := served(h, fmt.Sprint(i))
+				So(served(h, fmt.Sprint(i)), ShouldEqual, code)
+				if code == http.StatusAccepted {
+					inCanary++
+					So(served(wider, fmt.Sprint(i)), ShouldEqual, http.StatusAccepted)
+				}
+			}
+			So(inCanary, ShouldBeBetween, 150, 250)
+		})
+	})
+
+	Convey("Given a canary for a share of visitors that pins them with a cookie", t, func() {
+		c := Canary{Name: "new-datasets", Handler: canary, Percent: 20, Cookie: "canary_datasets"}
+		serve := func(h http.Handler, ip string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/datasets/cpih", http.NoBody)
+			req.RemoteAddr = ip + ":1234"
+			for _, cookie := range cookies {
+				req.AddCookie(cookie)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			return w
+		}
+		pinnedTo := func(side string) *http.Cookie {
+			return &http.Cookie{Name: "canary_datasets", Value: side}
+		}
+
+		Convey("When a visitor is first served", func() {
+			w := serve(Handler(c, stable, "", nil), "203.0.113.1")
+
+			Convey("Then they are pinned to the side that served them for the session", func() {
+				cookies := w.Result().Cookies()
+				So(cookies, ShouldHaveLength, 1)
+				So(cookies[0].Name, ShouldEqual, "canary_datasets")
+				So(cookies[0].MaxAge, ShouldEqual, 0)
+				if w.Code == http.StatusAccepted {
+					So(cookies[0].Value, ShouldEqual, "canary")
+				} else {
+					So(cookies[0].Value, ShouldEqual, "stable")
+				}
+			})
+		})
+
+		Convey("When pinned visitors are served again from another IP, or at another percentage", func() {
+			wider := c
+			wider.Percent = 50
+
+			Convey("Then they stay on the side they are pinned to", func() {
+				for i := 0; i < 100; i++ {
+					ip := fmt.Sprintf("203.0.113.%d", i)
+					So(serve(Handler(c, stable, "", nil), ip, pinnedTo("canary")).Code, ShouldEqual, http.StatusAccepted)
+					So(serve(Handler(wider, stable, "", nil), ip, pinnedTo("stable")).Code, ShouldEqual, http.StatusOK)
+				}
+			})
+
+			Convey("Then they are not pinned again", func() {
+				w := serve(Handler(c, stable, "", nil), "203.0.113.1", pinnedTo("canary"))
+				So(w.Result().Cookies(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the canary is rolled back", func() {
+			rolledBack := c
+			rolledBack.Percent = 0
+			w := serve(Handler(rolledBack, stable, "", nil), "203.0.113.1", pinnedTo("canary"))
+
+			Convey("Then visitors pinned to the canary are served by the stable handler", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Result().Cookies(), ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,19 @@
+package cdn
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Redirect returns a handler that redirects requests to the same path, and query, under the CDN's baseURL, so
+// that assets are served by the CDN rather than proxied through the router
+func Redirect(baseURL string, status int) http.Handler {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		location := baseURL + req.URL.EscapedPath()
+		if req.URL.RawQuery != "" {
+			location += "?" + req.URL.RawQuery
+		}
+		http.Redirect(w, req, location, status)
+	})
+}
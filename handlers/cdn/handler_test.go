@@ -0,0 +1,41 @@
+package cdn
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRedirect(t *testing.T) {
+	Convey("Given a CDN redirect handler with a base URL ending in a slash", t, func() {
+		handler := Redirect("https://cdn.ons.gov.uk/assets/", http.StatusFound)
+
+		Convey("When an asset is requested with a query", func() {
+			req := httptest.NewRequest(http.MethodGet, "/img/logo.svg?v=2", http.NoBody)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			Convey("Then the request is redirected to the asset under the base URL, keeping the query", func() {
+				So(res.Code, ShouldEqual, http.StatusFound)
+				So(res.Header().Get("Location"), ShouldEqual, "https://cdn.ons.gov.uk/assets/img/logo.svg?v=2")
+			})
+		})
+	})
+
+	Convey("Given a permanent CDN redirect handler", t, func() {
+		handler := Redirect("https://cdn.ons.gov.uk", http.StatusMovedPermanently)
+
+		Convey("When an asset with an escaped path is requested", func() {
+			req := httptest.NewRequest(http.MethodGet, "/img/a%20logo.png", http.NoBody)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			Convey("Then the request is permanently redirected with the path left escaped", func() {
+				So(res.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(res.Header().Get("Location"), ShouldEqual, "https://cdn.ons.gov.uk/img/a%20logo.png")
+			})
+		})
+	})
+}
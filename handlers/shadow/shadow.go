@@ -0,0 +1,233 @@
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Header is set on mirrored requests to the name of the shadow, so that the shadow backend can tell them apart from
+// real traffic
+const Header = "X-Shadow-Request"
+
+// MaxInFlight is the number of mirrored requests to a shadow that can be in flight at once. Requests beyond it are not
+// mirrored, so that a slow shadow cannot build up a backlog in the router.
+const MaxInFlight = 100
+
+// Definition describes a shadow as configured: the route it applies to, the URL of the upstream serving the shadow,
+// the percentage of requests to that route that are mirrored to it and whether differences in the responses are logged
+type Definition struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	URL      string `json:"url"`
+	Percent  int    `json:"percent"`
+	LogDiffs bool   `json:"log_diffs"`
+}
+
+// ParseDefinitions parses shadow definitions from a JSON array, as read from config
+func ParseDefinitions(s string) ([]Definition, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var defs []Definition
+	if err := json.Unmarshal([]byte(s), &defs); err != nil {
+		return nil, fmt.Errorf("invalid shadow definitions: %w", err)
+	}
+	for _, def := range defs {
+		if def.Name == "" || def.Path == "" || def.URL == "" {
+			return nil, errors.New("invalid shadow definitions: name, path and url are required")
+		}
+		if def.Percent < 0 || def.Percent > 100 {
+			return nil, fmt.Errorf("invalid shadow definitions: percent for %q must be between 0 and 100", def.Name)
+		}
+	}
+	return defs, nil
+}
+
+// Shadow mirrors Percent of the requests to the route with the path template Path to Handler, discarding its
+// responses, so that a new backend can be load-tested with production traffic
+type Shadow struct {
+	Name     string
+	Path     string
+	Handler  http.Handler
+	Percent  int
+	LogDiffs bool
+}
+
+// randomSample returns a number in [0, 1) that is compared against the share of requests mirrored
+var randomSample = rand.Float64
+
+// Handler serves every request with primary, and mirrors s.Percent of GET and HEAD requests to the shadow once
+// primary has responded. Other methods are never mirrored, so that the shadow cannot repeat their side effects.
+// Mirrored requests are cancelled after timeout, and, if s.LogDiffs is set, the status and body of the two responses
+// are compared and any difference is logged.
+func Handler(s Shadow, primary http.Handler, timeout time.Duration) http.Handler {
+	inFlight := make(chan struct{}, MaxInFlight)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !mirrored(req, s.Percent) {
+			primary.ServeHTTP(w, req)
+			return
+		}
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			primary.ServeHTTP(w, req)
+			return
+		}
+		// the slot is handed to the mirror once primary has responded, and released here if primary panics first
+		mirroring := false
+		defer func() {
+			if !mirroring {
+				<-inFlight
+			}
+		}()
+
+		// the request is cloned before primary can modify it, and detached from it so that it outlives the response
+		mirror := req.Clone(context.WithoutCancel(req.Context()))
+		mirror.Body = http.NoBody
+		mirror.Header.Set(Header, s.Name)
+
+		var primaryRes *response
+		if s.LogDiffs {
+			rw := &recordingWriter{ResponseWriter: w, response: newResponse()}
+			primary.ServeHTTP(rw, req)
+			primaryRes = &rw.response
+		} else {
+			primary.ServeHTTP(w, req)
+		}
+
+		mirroring = true
+		go func() {
+			defer func() { <-inFlight }()
+			shadowRes := serveShadow(s, mirror, timeout)
+			if primaryRes != nil && shadowRes != nil {
+				logDiff(mirror.Context(), s.Name, mirror, primaryRes, shadowRes)
+			}
+		}()
+	})
+}
+
+func mirrored(req *http.Request, percent int) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return randomSample()*100 < float64(percent)
+}
+
+// serveShadow serves req with the shadow, returning its response, or nil if the shadow panicked
+func serveShadow(s Shadow, req *http.Request, timeout time.Duration) (res *response) {
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+
+	// a broken shadow must never take the router down with it
+	defer func() {
+		if p := recover(); p != nil {
+			log.Error(ctx, "shadow panicked", fmt.Errorf("%v", p), log.Data{"shadow": s.Name, "path": req.URL.Path})
+			res = nil
+		}
+	}()
+
+	dw := &discardWriter{header: make(http.Header), response: newResponse()}
+	s.Handler.ServeHTTP(dw, req.WithContext(ctx))
+	return &dw.response
+}
+
+func logDiff(ctx context.Context, name string, req *http.Request, primary, shadow *response) {
+	statusDiffers := primary.status != shadow.status
+	bodyDiffers := !bytes.Equal(primary.hash.Sum(nil), shadow.hash.Sum(nil))
+	if !statusDiffers && !bodyDiffers {
+		return
+	}
+	log.Info(ctx, "shadow response differs", log.Data{
+		"shadow":         name,
+		"path":           req.URL.Path,
+		"query":          req.URL.RawQuery,
+		"primary_status": primary.status,
+		"shadow_status":  shadow.status,
+		"primary_bytes":  primary.size,
+		"shadow_bytes":   shadow.size,
+		"body_differs":   bodyDiffers,
+	})
+}
+
+// response records the status of a response and a hash of its body, so that responses can be compared without
+// holding them in memory
+type response struct {
+	status      int
+	hash        hash.Hash
+	size        int64
+	wroteHeader bool
+}
+
+func newResponse() response {
+	return response{status: http.StatusOK, hash: sha256.New()}
+}
+
+func (r *response) writeHeader(code int) {
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		r.status = code
+	}
+}
+
+func (r *response) write(b []byte) {
+	r.writeHeader(http.StatusOK)
+	r.hash.Write(b)
+	r.size += int64(len(b))
+}
+
+// recordingWriter records the response that primary writes to the client
+type recordingWriter struct {
+	http.ResponseWriter
+	response
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.writeHeader(code)
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.write(b[:n])
+	return n, err
+}
+
+func (rw *recordingWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// discardWriter records the response of the shadow and discards it
+type discardWriter struct {
+	header http.Header
+	response
+}
+
+func (dw *discardWriter) Header() http.Header {
+	return dw.header
+}
+
+func (dw *discardWriter) WriteHeader(code int) {
+	dw.writeHeader(code)
+}
+
+func (dw *discardWriter) Write(b []byte) (int, error) {
+	dw.write(b)
+	return len(b), nil
+}
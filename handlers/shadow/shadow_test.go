@@ -0,0 +1,201 @@
+package shadow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// waitForMirror returns the request mirrored to the shadow, or nil if none is mirrored in time
+func waitForMirror(mirrored <-chan *http.Request) *http.Request {
+	select {
+	case req := <-mirrored:
+		return req
+	case <-time.After(100 * time.Millisecond):
+		return nil
+	}
+}
+
+func TestParseDefinitions(t *testing.T) {
+	Convey("Given shadow definitions as configured", t, func() {
+		defs, err := ParseDefinitions(
+			`[{"name":"new-relcal","path":"/releasecalendar","url":"http://localhost:27700","percent":10,"log_diffs":true}]`)
+
+		Convey("Then they are parsed", func() {
+			So(err, ShouldBeNil)
+			So(defs, ShouldResemble, []Definition{
+				{Name: "new-relcal", Path: "/releasecalendar", URL: "http://localhost:27700", Percent: 10, LogDiffs: true},
+			})
+		})
+	})
+
+	Convey("Given no shadow definitions", t, func() {
+		defs, err := ParseDefinitions("")
+
+		Convey("Then there are no shadows", func() {
+			So(err, ShouldBeNil)
+			So(defs, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a shadow definition without a path", t, func() {
+		_, err := ParseDefinitions(`[{"name":"new-relcal","url":"http://localhost:27700","percent":10}]`)
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldBeError, "invalid shadow definitions: name, path and url are required")
+		})
+	})
+
+	Convey("Given a shadow definition with a negative percentage", t, func() {
+		_, err := ParseDefinitions(`[{"name":"new-relcal","path":"/releasecalendar","url":"http://localhost:27700","percent":-1}]`)
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldBeError, `invalid shadow definitions: percent for "new-relcal" must be between 0 and 100`)
+		})
+	})
+}
+
+func TestHandler(t *testing.T) {
+	primary := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("release calendar"))
+	})
+
+	Convey("Given a shadow for every request", t, func() {
+		mirroredReqs := make(chan *http.Request, 1)
+		status := http.StatusOK
+		shadowHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte("release calendar"))
+			mirroredReqs <- req
+		})
+		h := Handler(Shadow{Name: "new-relcal", Path: "/releasecalendar", Handler: shadowHandler, Percent: 100, LogDiffs: true},
+			primary, time.Second)
+
+		Convey("When a GET request is served", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/releasecalendar?query=cpi", http.NoBody))
+
+			Convey("Then the client gets the primary response", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "release calendar")
+			})
+
+			Convey("Then the request is mirrored to the shadow, marked as a shadow request", func() {
+				mirrored := waitForMirror(mirroredReqs)
+				So(mirrored, ShouldNotBeNil)
+				So(mirrored.URL.String(), ShouldEqual, "/releasecalendar?query=cpi")
+				So(mirrored.Header.Get(Header), ShouldEqual, "new-relcal")
+			})
+		})
+
+		Convey("When the shadow fails", func() {
+			status = http.StatusInternalServerError
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/releasecalendar", http.NoBody))
+			So(waitForMirror(mirroredReqs), ShouldNotBeNil)
+
+			Convey("Then the client still gets the primary response", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("When a POST request is served", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/releasecalendar", strings.NewReader("q=cpi")))
+
+			Convey("Then it is not mirrored", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(waitForMirror(mirroredReqs), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a shadow for no requests", t, func() {
+		mirroredReqs := make(chan *http.Request, 1)
+		shadowHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mirroredReqs <- req
+		})
+		h := Handler(Shadow{Name: "new-relcal", Path: "/releasecalendar", Handler: shadowHandler}, primary, time.Second)
+
+		Convey("Then requests are not mirrored", func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/releasecalendar", http.NoBody))
+			So(waitForMirror(mirroredReqs), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a shadow in front of a primary that panics until it is fixed", t, func() {
+		fixed := false
+		panicking := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !fixed {
+				panic("primary is broken")
+			}
+		})
+		mirroredReqs := make(chan *http.Request, 1)
+		shadowHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mirroredReqs <- req
+		})
+		h := Handler(Shadow{Name: "new-relcal", Path: "/releasecalendar", Handler: shadowHandler, Percent: 100}, panicking, time.Second)
+
+		Convey("When more requests panic than can be mirrored at once, and the primary is then fixed", func() {
+			for i := 0; i < MaxInFlight+1; i++ {
+				So(func() {
+					h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/releasecalendar", http.NoBody))
+				}, ShouldPanic)
+			}
+			fixed = true
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/releasecalendar", http.NoBody))
+
+			Convey("Then the slots of the requests that panicked were released, and requests are mirrored again", func() {
+				So(waitForMirror(mirroredReqs), ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given a shadow that panics", t, func() {
+		done := make(chan struct{})
+		shadowHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			defer close(done)
+			panic("shadow is broken")
+		})
+		h := Handler(Shadow{Name: "new-relcal", Path: "/releasecalendar", Handler: shadowHandler, Percent: 100}, primary, time.Second)
+
+		Convey("Then the panic is recovered and the client gets the primary response", func() {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/releasecalendar", http.NoBody))
+			<-done
+			So(w.Code, ShouldEqual, http.StatusOK)
+		})
+	})
+}
+
+func TestResponse(t *testing.T) {
+	Convey("Given two recorded responses", t, func() {
+		primary, shadow := newResponse(), newResponse()
+		primary.write([]byte("release calendar"))
+
+		Convey("When they match", func() {
+			shadow.write([]byte("release calendar"))
+
+			Convey("Then they have the same status and body hash", func() {
+				So(shadow.status, ShouldEqual, primary.status)
+				So(shadow.hash.Sum(nil), ShouldResemble, primary.hash.Sum(nil))
+			})
+		})
+
+		Convey("When the shadow responds differently", func() {
+			shadow.writeHeader(http.StatusNotFound)
+			shadow.write([]byte("not found"))
+
+			Convey("Then the status of the first header written is kept, and the body hashes differ", func() {
+				So(shadow.status, ShouldEqual, http.StatusNotFound)
+				So(shadow.size, ShouldEqual, 9)
+				So(shadow.hash.Sum(nil), ShouldNotResemble, primary.hash.Sum(nil))
+			})
+		})
+	})
+}
@@ -2,6 +2,8 @@ package helpers
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"strings"
 )
 
@@ -14,3 +16,15 @@ func ReturnSecondSegmentFromPath(path string) (secondSegment string, err error)
 	}
 	return subs[2], nil
 }
+
+// ClientIP returns the IP of the client making the request, taken from the first X-Forwarded-For entry if the request
+// has passed through a proxy, or the remote address otherwise
+func ClientIP(req *http.Request) string {
+	if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
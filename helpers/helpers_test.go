@@ -1,6 +1,8 @@
 package helpers
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -25,3 +27,19 @@ func TestUnitHelpers(t *testing.T) {
 		})
 	})
 }
+
+func TestClientIP(t *testing.T) {
+	Convey("ClientIP", t, func() {
+		Convey("returns the first forwarded address when the request has been proxied", func() {
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+			So(ClientIP(req), ShouldEqual, "203.0.113.7")
+		})
+
+		Convey("returns the remote address without its port otherwise", func() {
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.RemoteAddr = "198.51.100.2:54321"
+			So(ClientIP(req), ShouldEqual, "198.51.100.2")
+		})
+	})
+}
@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"sort"
+	"strings"
+)
+
+// PrefixMap maps path prefixes to values, so that a path can be matched to the value of its longest matching prefix
+type PrefixMap[V any] struct {
+	prefixes []string
+	values   map[string]V
+}
+
+// NewPrefixMap creates a PrefixMap of values by path prefix
+func NewPrefixMap[V any](values map[string]V) PrefixMap[V] {
+	prefixes := make([]string, 0, len(values))
+	for prefix := range values {
+		prefixes = append(prefixes, prefix)
+	}
+	return PrefixMap[V]{
+		prefixes: LongestFirst(prefixes, func(prefix string) string { return prefix }),
+		values:   values,
+	}
+}
+
+// Match returns the longest prefix of path in the map and its value, or false if path matches no prefix
+func (m PrefixMap[V]) Match(path string) (prefix string, value V, ok bool) {
+	prefix, ok = MatchPrefix(m.prefixes, func(prefix string) string { return prefix }, path)
+	if !ok {
+		return "", value, false
+	}
+	return prefix, m.values[prefix], true
+}
+
+// LongestFirst returns a copy of items sorted by their path prefix, longest first, so that the first item matching a
+// path is the one with the most specific prefix. Items with prefixes of the same length keep their order.
+func LongestFirst[T any](items []T, prefix func(T) string) []T {
+	sorted := make([]T, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(prefix(sorted[i])) > len(prefix(sorted[j]))
+	})
+	return sorted
+}
+
+// MatchPrefix returns the first of items, sorted by LongestFirst, whose prefix path starts with
+func MatchPrefix[T any](items []T, prefix func(T) string, path string) (T, bool) {
+	for _, item := range items {
+		if strings.HasPrefix(path, prefix(item)) {
+			return item, true
+		}
+	}
+	var none T
+	return none, false
+}
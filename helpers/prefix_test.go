@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPrefixMap(t *testing.T) {
+	Convey("Given a prefix map with nested prefixes", t, func() {
+		m := NewPrefixMap(map[string]int{"/": 1, "/economy": 2, "/economy/inflation": 3})
+
+		Convey("Then a path is matched to the value of its longest matching prefix", func() {
+			prefix, value, ok := m.Match("/economy/inflation/cpi")
+			So(ok, ShouldBeTrue)
+			So(prefix, ShouldEqual, "/economy/inflation")
+			So(value, ShouldEqual, 3)
+
+			prefix, value, ok = m.Match("/economy/gdp")
+			So(ok, ShouldBeTrue)
+			So(prefix, ShouldEqual, "/economy")
+			So(value, ShouldEqual, 2)
+
+			_, value, _ = m.Match("/census")
+			So(value, ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given an empty prefix map", t, func() {
+		m := NewPrefixMap[int](nil)
+
+		Convey("Then no path is matched", func() {
+			prefix, value, ok := m.Match("/economy")
+			So(ok, ShouldBeFalse)
+			So(prefix, ShouldBeEmpty)
+			So(value, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestLongestFirst(t *testing.T) {
+	type rule struct{ prefix, name string }
+	prefix := func(r rule) string { return r.prefix }
+
+	Convey("Given rules with nested and repeated prefixes", t, func() {
+		rules := []rule{{"/", "all"}, {"/economy", "first"}, {"/economy/gdp", "gdp"}, {"/economy", "second"}}
+		sorted := LongestFirst(rules, prefix)
+
+		Convey("Then they are sorted longest first, keeping the order of those of the same length", func() {
+			So(sorted, ShouldResemble, []rule{{"/economy/gdp", "gdp"}, {"/economy", "first"}, {"/economy", "second"}, {"/", "all"}})
+		})
+
+		Convey("Then the rules passed in are left as they were", func() {
+			So(rules[0].name, ShouldEqual, "all")
+		})
+
+		Convey("Then the first rule matching a path is the most specific", func() {
+			r, ok := MatchPrefix(sorted, prefix, "/economy/inflation")
+			So(ok, ShouldBeTrue)
+			So(r.name, ShouldEqual, "first")
+
+			_, ok = MatchPrefix(sorted[:3], prefix, "/census")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,54 @@
+package helpers
+
+import "net/http"
+
+// StatusWriter passes a response through, recording its status and the size of its body, for middleware that logs or
+// measures responses once they have been served
+type StatusWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+// NewStatusWriter creates a StatusWriter writing to w
+func NewStatusWriter(w http.ResponseWriter) *StatusWriter {
+	return &StatusWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status returns the status of the response, which is 200 if none was written
+func (sw *StatusWriter) Status() int {
+	return sw.status
+}
+
+// Size returns the number of bytes of the body written
+func (sw *StatusWriter) Size() int64 {
+	return sw.size
+}
+
+func (sw *StatusWriter) WriteHeader(code int) {
+	if !sw.wroteHeader {
+		sw.wroteHeader = true
+		sw.status = code
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *StatusWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.size += int64(n)
+	return n, err
+}
+
+func (sw *StatusWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (sw *StatusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStatusWriter(t *testing.T) {
+	Convey("Given a status writer", t, func() {
+		w := httptest.NewRecorder()
+		sw := NewStatusWriter(w)
+
+		Convey("When a response is written without a status", func() {
+			sw.Write([]byte("economy"))
+
+			Convey("Then its status is 200 and its size is recorded", func() {
+				So(sw.Status(), ShouldEqual, http.StatusOK)
+				So(sw.Size(), ShouldEqual, 7)
+				So(w.Body.String(), ShouldEqual, "economy")
+			})
+		})
+
+		Convey("When a status is written more than once", func() {
+			sw.WriteHeader(http.StatusNotFound)
+			sw.WriteHeader(http.StatusInternalServerError)
+			sw.Write([]byte("not found"))
+
+			Convey("Then the first is recorded, as it is the one sent", func() {
+				So(sw.Status(), ShouldEqual, http.StatusNotFound)
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+
+		Convey("When nothing is written", func() {
+			Convey("Then its status is 200", func() {
+				So(sw.Status(), ShouldEqual, http.StatusOK)
+				So(sw.Size(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("Then the response can be flushed and controlled through it", func() {
+			sw.Flush()
+			So(w.Flushed, ShouldBeTrue)
+			So(http.NewResponseController(sw).Flush(), ShouldBeNil)
+			So(sw.Unwrap(), ShouldEqual, w)
+		})
+	})
+}
@@ -3,10 +3,17 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
-	"net/http/httputil"
+	"net/http/pprof"
+	"net/netip"
 	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"golang.org/x/net/netutil"
@@ -15,18 +22,60 @@ import (
 	"github.com/ONSdigital/dp-api-clients-go/v2/filter"
 	"github.com/ONSdigital/dp-api-clients-go/v2/health"
 	"github.com/ONSdigital/dp-api-clients-go/v2/zebedee"
+	analyticsbackend "github.com/ONSdigital/dp-frontend-router/analytics"
 	"github.com/ONSdigital/dp-frontend-router/assets"
+	"github.com/ONSdigital/dp-frontend-router/backendhealth"
+	"github.com/ONSdigital/dp-frontend-router/cache"
 	"github.com/ONSdigital/dp-frontend-router/config"
+	"github.com/ONSdigital/dp-frontend-router/flags"
+	"github.com/ONSdigital/dp-frontend-router/geoip"
 	"github.com/ONSdigital/dp-frontend-router/handlers/analytics"
+	"github.com/ONSdigital/dp-frontend-router/handlers/canary"
+	"github.com/ONSdigital/dp-frontend-router/handlers/shadow"
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/ONSdigital/dp-frontend-router/middleware/allRoutes"
+	"github.com/ONSdigital/dp-frontend-router/middleware/backendset"
+	"github.com/ONSdigital/dp-frontend-router/middleware/bodylimit"
+	"github.com/ONSdigital/dp-frontend-router/middleware/bots"
+	"github.com/ONSdigital/dp-frontend-router/middleware/cachecontrol"
+	"github.com/ONSdigital/dp-frontend-router/middleware/compression"
+	"github.com/ONSdigital/dp-frontend-router/middleware/cors"
+	"github.com/ONSdigital/dp-frontend-router/middleware/experiments"
+	"github.com/ONSdigital/dp-frontend-router/middleware/flagusage"
+	"github.com/ONSdigital/dp-frontend-router/middleware/georouting"
+	"github.com/ONSdigital/dp-frontend-router/middleware/internalonly"
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+	"github.com/ONSdigital/dp-frontend-router/middleware/maintenance"
+	"github.com/ONSdigital/dp-frontend-router/middleware/otelmetrics"
+	"github.com/ONSdigital/dp-frontend-router/middleware/probelog"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectmap"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectrules"
 	"github.com/ONSdigital/dp-frontend-router/middleware/redirects"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectusage"
+	"github.com/ONSdigital/dp-frontend-router/middleware/requestmetrics"
+	"github.com/ONSdigital/dp-frontend-router/middleware/responsecache"
+	"github.com/ONSdigital/dp-frontend-router/middleware/securecookies"
+	"github.com/ONSdigital/dp-frontend-router/middleware/securityheaders"
+	"github.com/ONSdigital/dp-frontend-router/middleware/slo"
+	"github.com/ONSdigital/dp-frontend-router/middleware/staleiferror"
+	"github.com/ONSdigital/dp-frontend-router/middleware/streaming"
+	"github.com/ONSdigital/dp-frontend-router/middleware/throttle"
+	"github.com/ONSdigital/dp-frontend-router/middleware/timeout"
+	"github.com/ONSdigital/dp-frontend-router/middleware/trailingslash"
+	"github.com/ONSdigital/dp-frontend-router/proxy"
+	"github.com/ONSdigital/dp-frontend-router/ratelimit"
+	"github.com/ONSdigital/dp-frontend-router/readiness"
+	"github.com/ONSdigital/dp-frontend-router/reload"
 	"github.com/ONSdigital/dp-frontend-router/router"
+	"github.com/ONSdigital/dp-frontend-router/telemetry"
+	"github.com/ONSdigital/dp-frontend-router/useragent"
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
 	dphttp "github.com/ONSdigital/dp-net/v2/http"
-	dpotelgo "github.com/ONSdigital/dp-otel-go"
 	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/metric"
 )
 
 var (
@@ -38,14 +87,29 @@ var (
 	Version string
 )
 
+// pageTypeCacheName is the name the page-type cache registers its statistics under
+const pageTypeCacheName = "page-type"
+
+// maxRateLimitedClients is the number of clients whose rate of requests is tracked at once, by each rate limiter
+const maxRateLimitedClients = 10000
+
 func main() {
 	log.Namespace = "dp-frontend-router"
 
 	ctx := context.Background()
 
+	if err := run(ctx); err != nil {
+		log.Fatal(ctx, "router failed", err)
+		os.Exit(1)
+	}
+}
+
+// run builds the router from config and serves requests until it is shut down. Any error in config, or in starting or
+// stopping the router, is returned rather than logged, so that startup never carries on with partial config.
+func run(ctx context.Context) error {
 	cfg, err := config.Get()
 	if err != nil {
-		log.Fatal(ctx, "unable to retrieve service configuration", err)
+		return fmt.Errorf("unable to retrieve service configuration: %w", err)
 	}
 
 	log.Info(ctx, "got service configuration", log.Data{"config": cfg})
@@ -53,120 +117,622 @@ func main() {
 	var otelShutdown func(context.Context) error
 	if cfg.OtelEnabled {
 		// Set up OpenTelemetry
-		otelConfig := dpotelgo.Config{
-			OtelServiceName:          cfg.OTServiceName,
-			OtelExporterOtlpEndpoint: cfg.OTExporterOTLPEndpoint,
-			OtelBatchTimeout:         cfg.OTBatchTimeout,
+		otelConfig := telemetry.Config{
+			ServiceName:        cfg.OTServiceName,
+			Endpoint:           cfg.OTExporterOTLPEndpoint,
+			Headers:            cfg.OTExporterHeaders,
+			Insecure:           cfg.OTExporterInsecure,
+			SampleRatio:        cfg.OTSampleRatio,
+			BatchTimeout:       cfg.OTBatchTimeout,
+			MaxQueueSize:       cfg.OTBatchMaxQueueSize,
+			MaxExportBatchSize: cfg.OTBatchMaxExportSize,
 		}
 
-		otelShutdown, oErr := dpotelgo.SetupOTelSDK(ctx, otelConfig)
+		var oErr error
+		otelShutdown, oErr = telemetry.Setup(ctx, otelConfig)
 		if oErr != nil {
 			log.Error(ctx, "error setting up OpenTelemetry - hint: ensure OTEL_EXPORTER_OTLP_ENDPOINT is set", oErr)
 		}
-		// Handle shutdown properly so nothing leaks.
-		defer func() {
-			err = errors.Join(err, otelShutdown(context.Background()))
-		}()
-
 	}
 
-	cookiesControllerURL, _ := parseURL(ctx, cfg.CookiesControllerURL, "CookiesControllerURL")
-	datasetControllerURL, _ := parseURL(ctx, cfg.DatasetControllerURL, "DatasetControllerURL")
+	urls := &urlParser{}
+	cookiesControllerURL := urls.parse(cfg.CookiesControllerURL, "CookiesControllerURL")
+	datasetControllerURL := urls.parse(cfg.DatasetControllerURL, "DatasetControllerURL")
 	prefixedDatasetURL := cfg.DatasetControllerURL + "/dataset"
-	prefixDatasetControllerURL, _ := parseURL(ctx, prefixedDatasetURL, "DatasetControllerURL")
-	filterDatasetControllerURL, _ := parseURL(ctx, cfg.FilterDatasetControllerURL, "FilterDatasetControllerURL")
-	homepageControllerURL, _ := parseURL(ctx, cfg.HomepageControllerURL, "HomepageControllerURL")
-	searchControllerURL, _ := parseURL(ctx, cfg.SearchControllerURL, "SearchControllerURL")
-	relcalControllerURL, _ := parseURL(ctx, cfg.ReleaseCalendarControllerURL, "ReleaseCalendarControllerURL")
-	legacyCacheProxyURL, _ := parseURL(ctx, cfg.LegacyCacheProxyURL, "LegacyCacheProxyURL")
-	babbageURL, _ := parseURL(ctx, cfg.BabbageURL, "BabbageURL")
-	downloaderURL, _ := parseURL(ctx, cfg.DownloaderURL, "DownloaderURL")
-	feedbackControllerURL, _ := parseURL(ctx, cfg.FeedbackControllerURL, "FeedbackControllerURL")
-	areaProfileControllerURL, _ := parseURL(ctx, cfg.AreaProfilesControllerURL, "AreaProfileControllerURL")
-	filterFlexDatasetServiceURL, _ := parseURL(ctx, cfg.FilterFlexDatasetServiceURL, "FilterFlexDatasetServiceURL")
-	censusAtlasURL := urlFromConfig(ctx, "CensusAtlas", cfg.CensusAtlasURL)
+	prefixDatasetControllerURL := urls.parse(prefixedDatasetURL, "DatasetControllerURL")
+	filterDatasetControllerURL := urls.parse(cfg.FilterDatasetControllerURL, "FilterDatasetControllerURL")
+	homepageControllerURL := urls.parse(cfg.HomepageControllerURL, "HomepageControllerURL")
+	searchControllerURL := urls.parse(cfg.SearchControllerURL, "SearchControllerURL")
+	relcalControllerURL := urls.parse(cfg.ReleaseCalendarControllerURL, "ReleaseCalendarControllerURL")
+	legacyCacheProxyURL := urls.parse(cfg.LegacyCacheProxyURL, "LegacyCacheProxyURL")
+	babbageURL := urls.parse(cfg.BabbageURL, "BabbageURL")
+	downloaderURL := urls.parse(cfg.DownloaderURL, "DownloaderURL")
+	feedbackControllerURL := urls.parse(cfg.FeedbackControllerURL, "FeedbackControllerURL")
+	areaProfileControllerURL := urls.parse(cfg.AreaProfilesControllerURL, "AreaProfileControllerURL")
+	filterFlexDatasetServiceURL := urls.parse(cfg.FilterFlexDatasetServiceURL, "FilterFlexDatasetServiceURL")
+	censusAtlasURL := urls.parse(cfg.CensusAtlasURL, "CensusAtlas")
+	if urls.err != nil {
+		return urls.err
+	}
 
 	redirects.Init(assets.Asset)
 
+	// identify the router's outbound calls to Zebedee, Babbage and the Dataset API, if configured
+	userAgent := strings.ReplaceAll(cfg.OutboundUserAgent, "{version}", Version)
+
 	// create ZebedeeClient proxying calls through the API Router
-	hcClienter := dphttp.NewClient()
+	hcClienter := useragent.NewClienter(dphttp.NewClient(), userAgent)
 	hcClienter.SetMaxRetries(cfg.ZebedeeRequestMaximumRetries)
 	hcClienter.SetTimeout(cfg.ZebedeeRequestMaximumTimeout)
 
 	zebedeeClient := zebedee.NewClientWithClienter(cfg.APIRouterURL, hcClienter)
 
-	hcClient := health.NewClient("api-router", cfg.APIRouterURL)
+	// optionally fail over to a secondary Zebedee when the primary is unavailable
+	var pageTypeClient allRoutes.ZebedeeClient = zebedeeClient
+	if cfg.ZebedeeSecondaryURL != "" {
+		secondaryClienter := useragent.NewClienter(dphttp.NewClient(), userAgent)
+		secondaryClienter.SetMaxRetries(0)
+		secondaryClienter.SetTimeout(cfg.ZebedeeSecondaryTimeout)
+		secondaryClient := zebedee.NewClientWithClienter(cfg.ZebedeeSecondaryURL, secondaryClienter)
+		pageTypeClient = allRoutes.NewFailoverClient(zebedeeClient, secondaryClient, cfg.ZebedeeSecondaryTimeout)
+	}
+
+	hcClient := health.NewClientWithClienter("api-router", cfg.APIRouterURL, useragent.NewClienter(dphttp.NewClient(), userAgent))
 	filterClient := filter.NewWithHealthClient(hcClient)
 	datasetClient := dataset.NewWithHealthClient(hcClient)
 
 	// Healthcheck API
 	versionInfo, err := healthcheck.NewVersionInfo(BuildTime, GitCommit, Version)
 	if err != nil {
-		log.Fatal(ctx, "Failed to obtain VersionInfo for healthcheck", err)
+		return fmt.Errorf("failed to obtain VersionInfo for healthcheck: %w", err)
 	}
 	hc := healthcheck.New(versionInfo, cfg.HealthcheckCriticalTimeout, cfg.HealthcheckInterval)
 	if err = hc.AddCheck("API router", zebedeeClient.Checker); err != nil {
-		log.Fatal(ctx, "Failed to add api router checker to healthcheck", err)
+		return fmt.Errorf("failed to add api router checker to healthcheck: %w", err)
+	}
+	// backends are checked by the healthcheck and verified before the router is ready, if enabled
+	backendClienter := useragent.NewClienter(dphttp.NewClient(), userAgent)
+	backendClienter.SetMaxRetries(0)
+	backendClienter.SetTimeout(cfg.ProxyTimeout)
+	checkedBackends, err := healthcheckedBackends(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.BackendHealthchecksEnabled {
+		for _, backend := range checkedBackends {
+			if err = hc.AddCheck(backend.Name, backendhealth.Checker(backendClienter, backend)); err != nil {
+				return fmt.Errorf("failed to add %s backend checker to healthcheck: %w", backend.Name, err)
+			}
+		}
+	}
+
+	// clients are identified through the same trusted proxies wherever they are filtered or limited
+	ipFilterRules, err := parseIPFilterRules(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid ip filter rules: %w", err)
+	}
+
+	// the limiter allows every request at a rate of zero, so is always created in case a limit is set on reload
+	analyticsLimiter := ratelimit.New(cfg.AnalyticsRateLimit, cfg.AnalyticsRateLimitBurst, maxRateLimitedClients)
+
+	var analyticsMetrics *metrics.Registry
+	if cfg.MetricsEnabled {
+		analyticsMetrics = metrics.DefaultRegistry
+	}
+
+	experimentDefinitions, err := experiments.ParseDefinitions(cfg.Experiments)
+	if err != nil {
+		return fmt.Errorf("invalid experiments: %w", err)
+	}
+
+	analyticsHandler, err := analytics.NewSearchHandler(ctx, analytics.Config{
+		SQSAnalyticsURL:              cfg.SQSAnalyticsURL,
+		RedirectSecret:               cfg.RedirectSecret,
+		AllowedRedirectDomains:       cfg.RedirectAllowedDomains,
+		SQSAnalyticsQueuesByListType: cfg.SQSAnalyticsQueuesByListType,
+		SQSBatchEnabled:              cfg.SQSAnalyticsBatchEnabled,
+		SQSBatchFlushInterval:        cfg.SQSAnalyticsBatchInterval,
+		KafkaAnalytics: analyticsbackend.KafkaConfig{
+			Brokers:       cfg.KafkaAnalyticsBrokers,
+			Topic:         cfg.KafkaAnalyticsTopic,
+			TLSEnabled:    cfg.KafkaAnalyticsTLSEnabled,
+			SASLMechanism: cfg.KafkaAnalyticsSASLMechanism,
+			SASLUsername:  cfg.KafkaAnalyticsSASLUsername,
+			SASLPassword:  cfg.KafkaAnalyticsSASLPassword,
+		},
+		FirehoseAnalyticsStream: cfg.FirehoseAnalyticsStream,
+		WebhookAnalytics: analyticsbackend.WebhookConfig{
+			URL:        cfg.WebhookAnalyticsURL,
+			AuthHeader: cfg.WebhookAnalyticsAuthHeader,
+			AuthToken:  cfg.WebhookAnalyticsAuthToken,
+			MaxRetries: cfg.WebhookAnalyticsMaxRetries,
+			Timeout:    cfg.WebhookAnalyticsTimeout,
+		},
+		AnalyticsFile:     cfg.AnalyticsFile,
+		AsyncEnabled:      cfg.AnalyticsAsyncEnabled,
+		AsyncMaxInFlight:  cfg.AnalyticsAsyncMaxInFlight,
+		AsyncQueueSize:    cfg.AnalyticsAsyncQueueSize,
+		AsyncMaxRetries:   cfg.AnalyticsAsyncMaxRetries,
+		AsyncTimeout:      cfg.AnalyticsAsyncTimeout,
+		DedupEnabled:      cfg.AnalyticsDedupEnabled,
+		DedupTTL:          cfg.AnalyticsDedupTTL,
+		DedupMaxEntries:   cfg.AnalyticsDedupMaxEntries,
+		SpoolDir:          cfg.AnalyticsSpoolDir,
+		SpoolMaxEvents:    cfg.AnalyticsSpoolMaxEvents,
+		SpoolInterval:     cfg.AnalyticsSpoolRetryInterval,
+		ClampEnabled:      cfg.AnalyticsClampEnabled,
+		MaxPageIndex:      cfg.AnalyticsMaxPageIndex,
+		MaxLinkIndex:      cfg.AnalyticsMaxLinkIndex,
+		MaxPageSize:       cfg.AnalyticsMaxPageSize,
+		SamplingEnabled:   cfg.AnalyticsSamplingEnabled,
+		SampleRate:        cfg.AnalyticsSampleRate,
+		ScrubEnabled:      cfg.AnalyticsScrubEnabled,
+		ScrubPatterns:     cfg.AnalyticsScrubPatterns,
+		ScrubHashKey:      cfg.AnalyticsScrubHashKey,
+		MaxTermLength:     cfg.AnalyticsMaxTermLength,
+		MaxURLLength:      cfg.AnalyticsMaxURLLength,
+		MaxListTypeLength: cfg.AnalyticsMaxListTypeLength,
+		RateLimiter:       analyticsLimiter,
+		RateLimitReject:   cfg.AnalyticsRateLimitReject,
+		TrustedProxies:    ipFilterRules.TrustedProxies,
+		Metrics:           analyticsMetrics,
+		FanOutEnabled:     cfg.AnalyticsFanOutEnabled,
+		Experiments:       analyticsExperiments(experimentDefinitions),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating search analytics handler: %w", err)
+	}
+
+	// count the requests proxied to each backend, so that backend error rates can be scraped
+	var backendMetrics *proxy.BackendMetrics
+	if cfg.MetricsEnabled {
+		backendMetrics = proxy.NewBackendMetrics(metrics.DefaultRegistry)
 	}
 
-	analyticsHandler, err := analytics.NewSearchHandler(ctx, cfg.SQSAnalyticsURL, cfg.RedirectSecret)
+	// with OpenTelemetry enabled, backend errors are also recorded through the global meter provider
+	var otelMeter metric.Meter
+	if cfg.OtelEnabled {
+		otelMeter = otel.Meter(otelmetrics.MeterName)
+	}
+
+	retryBudgets, err := proxy.ParseRetryBudgets(cfg.ProxyRetryBudgetRoutes)
 	if err != nil {
-		log.Fatal(ctx, "error creating search analytics handler", err)
+		return fmt.Errorf("invalid retry budgets: %w", err)
 	}
 
-	downloadHandler := createReverseProxy("download", downloaderURL)
-	cookieHandler := createReverseProxy("cookies", cookiesControllerURL)
-	datasetHandler := createReverseProxy("datasets", datasetControllerURL)
-	prefixDatasetHandler := createReverseProxy("datasets", prefixDatasetControllerURL)
-	filterHandler := createReverseProxy("filters", filterDatasetControllerURL)
-	feedbackHandler := createReverseProxy("feedback", feedbackControllerURL)
-	searchHandler := createReverseProxy("search", searchControllerURL)
-	relcalHandler := createReverseProxy("relcal", relcalControllerURL)
-	homepageHandler := createReverseProxy("homepage", homepageControllerURL)
+	proxyOptions := proxy.Options{
+		UpstreamCacheHeader:     cfg.UpstreamCacheHeaderEnabled,
+		UserAgent:               userAgent,
+		CircuitBreakerThreshold: cfg.CircuitBreakerThreshold,
+		CircuitBreakerCooldown:  cfg.CircuitBreakerCooldown,
+		RetryMaxAttempts:        cfg.ProxyRetryMaxAttempts,
+		RetryBackoff:            cfg.ProxyRetryBackoff,
+		RetryBudgetRatio:        cfg.ProxyRetryBudgetRatio,
+		RetryBudgetRoutes:       retryBudgets,
+		Metrics:                 backendMetrics,
+		Meter:                   otelMeter,
+	}
+
+	// backends with instances configured are balanced across them by the router, rather than by a load balancer alone
+	backendInstances, err := proxy.ParseInstances(cfg.BackendInstances)
+	if err != nil {
+		return fmt.Errorf("invalid backend instances: %w", err)
+	}
+	backends := &backendProxies{
+		instances:     backendInstances,
+		strategy:      cfg.BackendBalancing,
+		checkInterval: cfg.BackendInstanceCheckInterval,
+		client:        backendClienter,
+	}
+	defer backends.close()
+
+	downloadHandler := backends.create(ctx, "download", downloaderURL, proxyOptions, false)
+	cookieHandler := backends.create(ctx, "cookies", cookiesControllerURL, proxyOptions, false)
+	datasetHandler := backends.create(ctx, "datasets", datasetControllerURL, proxyOptions, false)
+	prefixDatasetHandler := backends.create(ctx, "datasets", prefixDatasetControllerURL, proxyOptions, false)
+	filterHandler := backends.create(ctx, "filters", filterDatasetControllerURL, proxyOptions, false)
+	feedbackHandler := backends.create(ctx, "feedback", feedbackControllerURL, proxyOptions, false)
+	searchHandler := backends.create(ctx, "search", searchControllerURL, proxyOptions, false)
+	relcalHandler := backends.create(ctx, "relcal", relcalControllerURL, proxyOptions, false)
+	homepageHandler := backends.create(ctx, "homepage", homepageControllerURL, proxyOptions, false)
+	babbageProxyOptions := proxy.Options{
+		RewriteHost:             cfg.BabbageRewriteHost,
+		ForwardedHeaders:        cfg.BabbageXForwardedEnabled,
+		UpstreamCacheHeader:     cfg.UpstreamCacheHeaderEnabled,
+		UserAgent:               userAgent,
+		CircuitBreakerThreshold: cfg.CircuitBreakerThreshold,
+		CircuitBreakerCooldown:  cfg.CircuitBreakerCooldown,
+		RetryMaxAttempts:        cfg.ProxyRetryMaxAttempts,
+		RetryBackoff:            cfg.ProxyRetryBackoff,
+		RetryBudgetRatio:        cfg.ProxyRetryBudgetRatio,
+		RetryBudgetRoutes:       retryBudgets,
+		Metrics:                 backendMetrics,
+		Meter:                   otelMeter,
+	}
 	var babbageHandler http.Handler
 	if cfg.LegacyCacheProxyEnabled {
-		babbageHandler = createReverseProxy("legacyCacheProxy", legacyCacheProxyURL)
+		babbageHandler = backends.create(ctx, "legacyCacheProxy", legacyCacheProxyURL, babbageProxyOptions, false)
 	} else {
-		babbageHandler = createReverseProxy("babbage", babbageURL)
+		babbageHandler = backends.create(ctx, "babbage", babbageURL, babbageProxyOptions, true)
+	}
+	cacheCookiePolicy := cache.CookiePolicy{
+		VaryCookies:   cfg.CacheVaryCookies,
+		BypassCookies: cfg.CacheBypassCookies,
+	}
+	if cfg.StaleIfErrorEnabled {
+		staleIfError := staleiferror.New(cfg.StaleIfErrorMaxEntries, cfg.StaleIfErrorWindow, cacheCookiePolicy)
+		cache.Register("stale-if-error", staleIfError)
+		babbageHandler = staleIfError.Handler(babbageHandler)
+	}
+	// the response cache is outermost, so that cache hits skip the origin and stale responses are never cached
+	if cfg.ResponseCacheEnabled {
+		responseCache := responsecache.New(cfg.ResponseCacheMaxEntries, cfg.ResponseCacheDefaultTTL, cfg.ResponseCacheMaxTTL, cacheCookiePolicy)
+		cache.Register("babbage-responses", responseCache)
+		babbageHandler = responseCache.Handler(babbageHandler)
+	}
+	areaProfileHandler := backends.create(ctx, "areas", areaProfileControllerURL, proxyOptions, false)
+	filterFlexHandler := backends.create(ctx, "flex", filterFlexDatasetServiceURL, proxyOptions, false)
+	var censusAtlasHandler http.Handler
+	if cfg.CensusAtlasURL != "" {
+		censusAtlasHandler = backends.create(ctx, "censusAtlas", censusAtlasURL, proxyOptions, true)
+	}
+	if backends.err != nil {
+		return backends.err
+	}
+	backends.warnUnused(ctx)
+
+	// requests that choose a backend set are served by the set's backends in place of the live ones, caches included
+	backendSets, err := backendset.ParseSets(cfg.BackendSets)
+	if err != nil {
+		return fmt.Errorf("invalid backend sets: %w", err)
+	}
+	err = withBackendSets(ctx, backendSets, proxyOptions, babbageProxyOptions, map[string]*http.Handler{
+		"babbage":     &babbageHandler,
+		"homepage":    &homepageHandler,
+		"download":    &downloadHandler,
+		"cookies":     &cookieHandler,
+		"datasets":    &datasetHandler,
+		"filters":     &filterHandler,
+		"flex":        &filterFlexHandler,
+		"feedback":    &feedbackHandler,
+		"search":      &searchHandler,
+		"relcal":      &relcalHandler,
+		"areas":       &areaProfileHandler,
+		"censusAtlas": &censusAtlasHandler,
+	})
+	if err != nil {
+		return err
+	}
+	err = withBackendSets(ctx, prefixedBackendSets(backendSets, "datasets", "/dataset"), proxyOptions, babbageProxyOptions,
+		map[string]*http.Handler{"datasets": &prefixDatasetHandler})
+	if err != nil {
+		return err
+	}
+
+	exps, err := createExperiments(experimentDefinitions, proxyOptions)
+	if err != nil {
+		return fmt.Errorf("invalid experiments: %w", err)
+	}
+
+	canaryDefinitions, err := canary.ParseDefinitions(cfg.CanaryRoutes)
+	if err != nil {
+		return fmt.Errorf("invalid canary routes: %w", err)
+	}
+	canaries, err := createCanaries(canaryDefinitions, proxyOptions)
+	if err != nil {
+		return fmt.Errorf("invalid canary routes: %w", err)
+	}
+
+	shadowDefinitions, err := shadow.ParseDefinitions(cfg.ShadowRoutes)
+	if err != nil {
+		return fmt.Errorf("invalid shadow routes: %w", err)
+	}
+	shadows, err := createShadows(shadowDefinitions, proxyOptions)
+	if err != nil {
+		return fmt.Errorf("invalid shadow routes: %w", err)
+	}
+
+	routeTimeouts, err := timeout.ParseTimeouts(cfg.RouteTimeouts)
+	if err != nil {
+		return fmt.Errorf("invalid route timeouts: %w", err)
+	}
+
+	rateLimits, err := throttle.ParseLimits(cfg.RateLimits)
+	if err != nil {
+		return fmt.Errorf("invalid rate limits: %w", err)
+	}
+
+	corsRules, err := cors.ParseRules(cfg.CORSRules)
+	if err != nil {
+		return fmt.Errorf("invalid cors rules: %w", err)
+	}
+
+	bodyLimits, err := bodylimit.ParseLimits(cfg.RequestBodyLimits)
+	if err != nil {
+		return fmt.Errorf("invalid request body limits: %w", err)
+	}
+
+	cachePolicies, err := cachecontrol.ParsePolicies(cfg.CacheControlPolicies)
+	if err != nil {
+		return fmt.Errorf("invalid cache control policies: %w", err)
+	}
+
+	botRules, err := createBotRules(cfg, ipFilterRules.TrustedProxies, proxyOptions)
+	if err != nil {
+		return fmt.Errorf("invalid bot detection rules: %w", err)
+	}
+
+	geoRules, err := createGeoRules(cfg, ipFilterRules.TrustedProxies, proxyOptions)
+	if err != nil {
+		return fmt.Errorf("invalid geo routing rules: %w", err)
+	}
+
+	redirectRules, err := redirectrules.ParseRules(cfg.RedirectRules)
+	if err != nil {
+		return fmt.Errorf("invalid redirect rules: %w", err)
+	}
+
+	// the redirect map is either a local file or fetched from Zebedee or S3, so the publishing team can manage it
+	var redirectSource redirectmap.Source
+	if cfg.RedirectMapFile != "" {
+		redirectSource = redirectmap.NewFileSource(cfg.RedirectMapFile)
+	} else if cfg.RedirectMapURL != "" {
+		redirectSource, err = redirectmap.NewRemoteSource(ctx, cfg.RedirectMapURL, useragent.NewClienter(dphttp.NewClient(), userAgent))
+		if err != nil {
+			return fmt.Errorf("invalid redirect map source: %w", err)
+		}
+	}
+
+	var redirectMap *redirectmap.Map
+	if redirectSource != nil {
+		redirectMap, err = redirectmap.New(ctx, redirectSource, cfg.RedirectMapReloadInterval)
+		if err != nil {
+			return fmt.Errorf("invalid redirect map: %w", err)
+		}
+		redirectMap.Start(ctx)
+		defer redirectMap.Close()
+	}
+
+	// admin and debug endpoints are restricted to internal clients, identified through the same trusted proxies
+	adminRanges, err := ipfilter.ParseRanges(cfg.AdminAllowedRanges)
+	if err != nil {
+		return fmt.Errorf("invalid admin allowed ranges: %w", err)
+	}
+	adminGuard := internalonly.Guard{
+		Ranges:         adminRanges,
+		TrustedProxies: ipFilterRules.TrustedProxies,
+		SecretHeader:   cfg.AdminSecretHeader,
+		Secret:         cfg.AdminSecret,
+	}
+
+	trailingSlashPolicies, err := trailingslash.ParsePolicies(cfg.TrailingSlashPolicies)
+	if err != nil {
+		return fmt.Errorf("invalid trailing slash policies: %w", err)
+	}
+
+	probeLogMode, err := probelog.ParseMode(cfg.ProbeLogMode)
+	if err != nil {
+		return fmt.Errorf("invalid probe logging mode: %w", err)
+	}
+
+	cdnAssetRedirectStatus := http.StatusFound
+	if cfg.CDNAssetRedirectPermanent {
+		cdnAssetRedirectStatus = http.StatusMovedPermanently
+	}
+
+	// maintenance mode is kept when the router is rebuilt, and can be switched on and off from the admin listener
+	maintenanceMode := maintenance.New(cfg.MaintenanceEnabled)
+
+	securityHeaders := securityheaders.Headers{
+		HSTSMaxAge:            cfg.HSTSMaxAge,
+		HSTSIncludeSubdomains: cfg.HSTSIncludeSubdomains,
+		HSTSPreload:           cfg.HSTSPreload,
+		ContentTypeOptions:    cfg.ContentTypeOptions,
+		ReferrerPolicy:        cfg.ReferrerPolicy,
+		PermissionsPolicy:     cfg.PermissionsPolicy,
+	}
+
+	var cookiePolicy securecookies.Policy
+	if cfg.CookieHardeningEnabled {
+		cookiePolicy = securecookies.Policy{
+			Secure:             cfg.CookieSecure,
+			HTTPOnly:           cfg.CookieHTTPOnly,
+			SameSite:           cfg.CookieSameSite,
+			HTTPOnlyExclusions: cfg.CookieHTTPOnlyExclusions,
+		}
+	}
+
+	var compressionOptions compression.Options
+	if cfg.CompressionEnabled {
+		compressionOptions = compression.Options{MinSize: cfg.CompressionMinSize, ContentTypes: cfg.CompressionContentTypes}
+	}
+
+	// the limiters are created once, rather than by the router, so that their state is kept when the routes are reloaded
+	var streamingLimiter *streaming.Limiter
+	if cfg.StreamingMaxConnections > 0 {
+		streamingLimiter = streaming.New(cfg.StreamingMaxConnections, cfg.StreamingPaths)
+	}
+	var rateLimiter *throttle.Limiter
+	if len(rateLimits) > 0 {
+		rateLimiter = throttle.New(rateLimits, maxRateLimitedClients, ipFilterRules.TrustedProxies)
 	}
-	areaProfileHandler := createReverseProxy("areas", areaProfileControllerURL)
-	filterFlexHandler := createReverseProxy("flex", filterFlexDatasetServiceURL)
-	censusAtlasHandler := createReverseProxy("censusAtlas", censusAtlasURL)
 
 	routerConfig := router.Config{
-		AnalyticsHandler:             analyticsHandler,
-		AreaProfileEnabled:           cfg.AreaProfilesRoutesEnabled,
-		AreaProfileHandler:           areaProfileHandler,
-		DownloadHandler:              downloadHandler,
-		CookieHandler:                cookieHandler,
-		DatasetHandler:               datasetHandler,
-		NewDatasetRoutingEnabled:     cfg.NewDatasetRoutingEnabled,
-		PrefixDatasetHandler:         prefixDatasetHandler,
-		DatasetClient:                datasetClient,
-		HealthCheckHandler:           hc.Handler,
-		FilterHandler:                filterHandler,
-		FilterClient:                 filterClient,
-		FeedbackHandler:              feedbackHandler,
-		FilterFlexHandler:            filterFlexHandler,
-		LegacySearchRedirectsEnabled: cfg.LegacySearchRedirectsEnabled,
-		DataAggregationPagesEnabled:  cfg.DataAggregationPagesEnabled,
-		SearchRoutesEnabled:          cfg.SearchRoutesEnabled,
-		SearchHandler:                searchHandler,
-		RelCalHandler:                relcalHandler,
-		RelCalEnabled:                cfg.ReleaseCalendarEnabled,
-		RelCalRoutePrefix:            cfg.ReleaseCalendarRoutePrefix,
-		UseNewReleaseCalendar:        cfg.UseNewReleaseCalendar,
-		SiteDomain:                   cfg.SiteDomain,
-		HomepageHandler:              homepageHandler,
-		BabbageHandler:               babbageHandler,
-		ZebedeeClient:                zebedeeClient,
-		ContentTypeByteLimit:         cfg.ContentTypeByteLimit,
-		CensusAtlasHandler:           censusAtlasHandler,
-		CensusAtlasEnabled:           cfg.CensusAtlasRoutesEnabled,
-		DatasetFinderEnabled:         cfg.DatasetFinderEnabled,
-	}
-
-	httpHandler := router.New(routerConfig)
+		AnalyticsHandler:            analyticsHandler,
+		AreaProfileEnabled:          cfg.AreaProfilesRoutesEnabled,
+		AreaProfileHandler:          areaProfileHandler,
+		DownloadHandler:             downloadHandler,
+		CookieHandler:               cookieHandler,
+		DatasetHandler:              datasetHandler,
+		NewDatasetRoutingEnabled:    cfg.NewDatasetRoutingEnabled,
+		PrefixDatasetHandler:        prefixDatasetHandler,
+		DatasetClient:               datasetClient,
+		HealthCheckHandler:          hc.Handler,
+		FilterHandler:               filterHandler,
+		FilterClient:                filterClient,
+		FeedbackHandler:             feedbackHandler,
+		FilterFlexHandler:           filterFlexHandler,
+		SearchHandler:               searchHandler,
+		RelCalHandler:               relcalHandler,
+		RelCalRoutePrefix:           cfg.ReleaseCalendarRoutePrefix,
+		UseNewReleaseCalendar:       cfg.UseNewReleaseCalendar,
+		SiteDomain:                  cfg.SiteDomain,
+		HomepageHandler:             homepageHandler,
+		BabbageHandler:              babbageHandler,
+		ZebedeeClient:               pageTypeClient,
+		ContentTypeByteLimit:        cfg.ContentTypeByteLimit,
+		CensusAtlasHandler:          censusAtlasHandler,
+		RetiredPaths:                cfg.RetiredPaths,
+		RetiredPathsBody:            cfg.RetiredPathsBody,
+		IPFilterRules:               ipFilterRules,
+		BotRules:                    botRules,
+		Maintenance:                 maintenanceMode,
+		MaintenanceBody:             cfg.MaintenanceBody,
+		MaintenanceRetryAfter:       cfg.MaintenanceRetryAfter,
+		Streaming:                   streamingLimiter,
+		URIValidationEnabled:        cfg.URIValidationEnabled,
+		SecurityHeaders:             securityHeaders,
+		CachePolicies:               cachePolicies,
+		CookiePolicy:                cookiePolicy,
+		SecurityHeaderProfiles:      cfg.SecurityHeaderProfilesEnabled,
+		ContentSecurityPolicy:       cfg.ContentSecurityPolicy,
+		CSPOverrides:                cfg.CSPOverrides,
+		CSPReportOnly:               cfg.CSPReportOnly,
+		TrailingSlashPolicies:       trailingSlashPolicies,
+		PathTraversalBlockEnabled:   cfg.PathTraversalBlockEnabled,
+		RoutingTableLogEnabled:      cfg.RoutingTableLogEnabled,
+		RoutingTableFile:            cfg.RoutingTableFile,
+		AccessLogSampleRate:         cfg.AccessLogSuccessSampleRate,
+		ProbeLogMode:                probeLogMode,
+		ProbeLogPaths:               cfg.ProbeLogPaths,
+		Experiments:                 exps,
+		ExperimentIDCookie:          cfg.ExperimentIDCookie,
+		GeoRules:                    geoRules,
+		Canaries:                    canaries,
+		Shadows:                     shadows,
+		ShadowTimeout:               cfg.ShadowTimeout,
+		RouteTimeouts:               routeTimeouts,
+		RouteTimeoutBody:            cfg.RouteTimeoutBody,
+		RateLimiter:                 rateLimiter,
+		CORSRules:                   corsRules,
+		BodyLimits:                  bodylimit.Limits{Default: cfg.RequestBodyMaxBytes, Prefixes: bodyLimits},
+		BackendSets:                 backendSelector(cfg, backendSets),
+		Compression:                 compressionOptions,
+		PreconnectOrigin:            cfg.PreconnectOrigin,
+		PreconnectPaths:             cfg.PreconnectPaths,
+		RedirectMaxHops:             cfg.RedirectMaxHops,
+		RedirectMap:                 redirectMap,
+		RedirectRules:               redirectRules,
+		LegacySearchRedirectStatus:  cfg.LegacySearchRedirectStatus,
+		LegacySearchQueryParams:     cfg.LegacySearchQueryParams,
+		CensusAtlasEmptyURIRedirect: cfg.CensusAtlasEmptyURIRedirect,
+		CDNAssetBaseURL:             cfg.CDNAssetBaseURL,
+		CDNAssetPrefixes:            cfg.CDNAssetPrefixes,
+		CDNAssetRedirectStatus:      cdnAssetRedirectStatus,
+		ForwardedProtoCheckEnabled:  cfg.ForwardedProtoCheckEnabled,
+		ForwardedProtoReject:        cfg.ForwardedProtoReject,
+		APIDescriptionPath:          cfg.APIDescriptionPath,
+		APIDescriptionVersion:       Version,
+	}
+
+	// optionally share page types between router instances through Redis, behind recently used page types in memory
+	var pageTypeCache allRoutes.PageTypeCache
+	if cfg.PageTypeRedisURL != "" {
+		redisOptions, err := redis.ParseURL(cfg.PageTypeRedisURL)
+		if err != nil {
+			return fmt.Errorf("invalid page type redis url: %w", err)
+		}
+		pageTypeCache = allRoutes.NewRedisCache(redis.NewClient(redisOptions), cfg.PageTypeRedisTTL)
+	}
+	if cfg.PageTypeCacheTTL > 0 {
+		memoryCache := allRoutes.NewMemoryCache(cfg.PageTypeCacheMaxEntries, cfg.PageTypeCacheTTL, pageTypeCache)
+		cache.Register(pageTypeCacheName, memoryCache)
+		pageTypeCache = memoryCache
+	}
+	routerConfig.PageTypeCache = pageTypeCache
+	routerConfig.PageTypeCacheBypassCookies = cfg.CacheBypassCookies
+
+	// the router is ready once its routes are built and, if enabled, its backends verified and its page-type cache warm
+	ready := readiness.New()
+	routesBuilt := readiness.NewGate("routes have not been built")
+	ready.AddCheck("routes", routesBuilt.Check)
+	running := readiness.NewGate("router is shutting down")
+	running.Open()
+	ready.AddCheck("shutdown", running.Check)
+	if cfg.ReadinessBackendsEnabled {
+		for _, backend := range checkedBackends {
+			ready.AddCheck(backend.Name+" backend", readiness.Once(backendhealth.Reachable(backendClienter, backend)))
+		}
+	}
+	if cfg.ReadinessCacheWarmthEnabled {
+		ready.AddCheck("page-type cache warmth", readiness.CacheWarmth(cache.DefaultRegistry, pageTypeCacheName,
+			cfg.ReadinessCacheMinEntries, cfg.ReadinessWarmupGracePeriod, time.Now()))
+	}
+	if cfg.ReadinessCheckSelection {
+		ready.EnableCheckSelection()
+	}
+	routerConfig.ReadinessHandler = ready
+
+	if cfg.SLOMetricsEnabled {
+		thresholds, err := slo.ParseThresholds(cfg.SLOLatencyThresholds)
+		if err != nil {
+			return fmt.Errorf("invalid SLO latency thresholds: %w", err)
+		}
+		routerConfig.SLOMiddleware = slo.NewRecorder(metrics.DefaultRegistry, cfg.SLODefaultLatencyThreshold, thresholds).Handler
+	}
+
+	if cfg.FeatureFlagMetricsEnabled {
+		routerConfig.FeatureFlagUsage = flagusage.NewRecorder(metrics.DefaultRegistry)
+	}
+
+	if cfg.RedirectMetricsEnabled {
+		legacyPatterns, err := redirectusage.ParsePatterns(cfg.LegacyURLPatterns)
+		if err != nil {
+			return fmt.Errorf("invalid legacy URL patterns: %w", err)
+		}
+		routerConfig.RedirectUsage = redirectusage.NewRecorder(metrics.DefaultRegistry, legacyPatterns)
+	}
+
+	if cfg.MetricsEnabled {
+		routerConfig.RequestMetrics = requestmetrics.NewRecorder(metrics.DefaultRegistry)
+		// metrics are scraped from the admin bind address when there is one, rather than being served publicly
+		if cfg.AdminBindAddr == "" {
+			routerConfig.MetricsHandler = metrics.Handler(metrics.DefaultRegistry)
+		}
+	}
+
+	if cfg.CacheStatsEnabled {
+		routerConfig.CacheStatsHandler = cache.StatsHandler(cache.DefaultRegistry)
+	}
+	// the public admin endpoints are only served to the internal clients allowed by the guard, and not at all without one
+	routerConfig.AdminGuard = adminGuard
+
+	routerConfig.Backends = map[string]http.Handler{
+		"babbage":  babbageHandler,
+		"homepage": homepageHandler,
+		"download": downloadHandler,
+		"cookies":  cookieHandler,
+		"datasets": datasetHandler,
+		"filters":  filterHandler,
+		"flex":     filterFlexHandler,
+		"feedback": feedbackHandler,
+		"search":   searchHandler,
+		"relcal":   relcalHandler,
+		"areas":    areaProfileHandler,
+	}
+	if censusAtlasHandler != nil {
+		routerConfig.Backends["censusAtlas"] = censusAtlasHandler
+	}
+
+	routerConfig, err = withRoutes(routerConfig, cfg)
+	if err != nil {
+		return fmt.Errorf("invalid router configuration: %w", err)
+	}
+	routesBuilt.Open()
+
+	// the router is rebuilt when the routes are reloaded, so it is swapped in without dropping in-flight requests
+	routes := router.NewSwapHandler(router.New(routerConfig))
+	var httpHandler http.Handler = routes
 
 	if cfg.OtelEnabled {
 		httpHandler = otelhttp.NewHandler(httpHandler, "/")
@@ -181,71 +747,548 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	currentConfig := func() *config.Config { return cfg }
+	if cfg.ConfigReloadEnabled || cfg.FeatureFlagsURL != "" {
+		// the flags from the flag service, if configured, are applied on top of the environment on each reload
+		var flagPoller *flags.Poller
+		reloader := reload.New(cfg, func() (*config.Config, error) {
+			loaded, err := config.Load(cfg.ConfigReloadFile)
+			if err != nil || flagPoller == nil {
+				return loaded, err
+			}
+			if unknown := flags.Apply(loaded, flagPoller.Values()); len(unknown) > 0 {
+				log.Warn(ctx, "ignoring unknown feature flags", log.Data{"flags": unknown})
+			}
+			return loaded, nil
+		})
+		reloader.OnReload([]string{"AnalyticsRateLimit", "AnalyticsRateLimitBurst"}, func(ctx context.Context, c *config.Config) {
+			analyticsLimiter.SetLimit(c.AnalyticsRateLimit, c.AnalyticsRateLimitBurst)
+		})
+		if botRules.Limiter != nil {
+			reloader.OnReload([]string{"BotRateLimit", "BotRateLimitBurst"}, func(ctx context.Context, c *config.Config) {
+				botRules.Limiter.SetLimit(c.BotRateLimit, c.BotRateLimitBurst)
+			})
+		}
+		reloader.OnReload(routeSettings, func(ctx context.Context, c *config.Config) {
+			reloaded, err := withRoutes(routerConfig, c)
+			if err != nil {
+				log.Error(ctx, "error reloading routes, keeping the running routes", err)
+				return
+			}
+			routes.Swap(router.New(reloaded))
+			log.Info(ctx, "routes reloaded")
+		})
+		currentConfig = reloader.Current
+		if cfg.FeatureFlagsURL != "" {
+			flagProvider := flags.NewHTTPProvider(useragent.NewClienter(dphttp.NewClient(), userAgent), cfg.FeatureFlagsURL)
+			flagPoller = flags.NewPoller(flagProvider, cfg.FeatureFlagsPollInterval, cfg.FeatureFlagsTimeout, func(ctx context.Context) {
+				_ = reloader.Reload(ctx)
+			})
+			flagPoller.Start(ctx)
+			defer flagPoller.Close()
+		}
+		if cfg.ConfigReloadEnabled {
+			stopReloading := reloader.Listen(ctx)
+			defer stopReloading()
+		}
+	}
+
+	if cfg.AdminBindAddr != "" {
+		go serveAdmin(ctx, cfg, adminGuard, currentConfig, routes, pageTypeCache, maintenanceMode)
+	} else if cfg.PprofEnabled {
+		log.Warn(ctx, "profiling is only served on the admin listener, which is disabled as ADMIN_BIND_ADDR is not set")
+	}
+
 	// Start health check
 	hc.Start(ctx)
 
 	// Create a LimitListener to cap concurrent http connections
 	l, err := net.Listen("tcp", cfg.BindAddr)
 	if err != nil {
-		log.Fatal(ctx, "error starting tcp listener", err)
+		return fmt.Errorf("error starting tcp listener: %w", err)
 	}
 
 	if maxC := cfg.HTTPMaxConnections; maxC > 0 {
 		l = netutil.LimitListener(l, maxC)
 	}
 
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		drainOnSignal(ctx, s, running, cfg.GracefulShutdownTimeout)
+	}()
+
 	// Start server
-	if err := s.Serve(l); err != nil && err != http.ErrServerClosed {
-		log.Fatal(ctx, "error starting server", err)
+	serveErr := s.Serve(l)
+	if errors.Is(serveErr, http.ErrServerClosed) {
+		// Serve returns as soon as shutdown starts, so wait for in-flight requests to finish
+		serveErr = nil
+		<-drained
+	} else {
+		serveErr = fmt.Errorf("error starting server: %w", serveErr)
 	}
 	l.Close()
+	hc.Stop()
 
-	if cfg.OtelEnabled {
-		err = otelShutdown(ctx)
+	// send analytics data that is still buffered, now that no more requests will be served
+	if closer, ok := analyticsHandler.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error(ctx, "error closing search analytics handler", err)
+		}
+	}
+
+	if closer, ok := geoRules.Locator.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Error(ctx, "error closing geoip database", err)
+		}
+	}
+
+	if otelShutdown != nil {
+		if err := otelShutdown(ctx); err != nil {
+			return errors.Join(serveErr, fmt.Errorf("error shutting down opentelemetry: %w", err))
+		}
+	}
+	return serveErr
+}
+
+// drainOnSignal waits for a signal to stop, then reports the router as not ready, stops accepting connections and lets
+// in-flight requests, such as long downloads, finish for up to gracePeriod before closing their connections
+func drainOnSignal(ctx context.Context, s *http.Server, running *readiness.Gate, gracePeriod time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	signal.Stop(signals)
+
+	log.Info(ctx, "shutting down, draining in-flight requests", log.Data{"signal": sig.String(), "grace_period": gracePeriod.String()})
+	running.Close()
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, gracePeriod)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		log.Warn(ctx, "in-flight requests did not finish within the grace period, closing their connections", log.Data{"error": err.Error()})
+		if err := s.Close(); err != nil {
+			log.Error(ctx, "error closing server", err)
+		}
+	}
+}
+
+// serveAdmin serves the admin endpoints on the private bind address, separate from public traffic. Cached page types
+// can be invalidated on publish if they are cached, and metrics are scraped and profiles captured from here if enabled.
+// The running config, as returned by currentConfig, is served with its secrets redacted, and maintenance mode switched.
+// Every endpoint is restricted to the internal clients allowed by guard.
+func serveAdmin(
+	ctx context.Context, cfg *config.Config, guard internalonly.Guard, currentConfig func() *config.Config,
+	routes *router.SwapHandler, pageTypes allRoutes.PageTypeCache, maintenanceMode *maintenance.Mode,
+) {
+	adminRouter := http.NewServeMux()
+	adminRouter.Handle("/routes", router.RoutesHandler(routes))
+	adminRouter.Handle("/config", config.Handler(currentConfig))
+	adminRouter.Handle("/maintenance", maintenanceMode.AdminHandler())
+	if invalidator, ok := pageTypes.(allRoutes.Invalidator); ok {
+		adminRouter.Handle("/page-types/invalidate", allRoutes.InvalidateHandler(invalidator))
+	}
+	if cfg.MetricsEnabled {
+		adminRouter.Handle("/metrics", metrics.Handler(metrics.DefaultRegistry))
+	}
+	if cfg.PprofEnabled {
+		adminRouter.HandleFunc("/debug/pprof/", pprof.Index)
+		adminRouter.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		adminRouter.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		adminRouter.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		adminRouter.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	s := &http.Server{
+		Addr:              cfg.AdminBindAddr,
+		Handler:           guard.Handler(adminRouter),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	if err := s.ListenAndServe(); err != nil {
+		log.Error(ctx, "error serving admin endpoints", err, log.Data{"bind_addr": cfg.AdminBindAddr})
+	}
+}
+
+// routeSettings are the config settings that the router is rebuilt from when the config is reloaded
+var routeSettings = []string{
+	"RouteConfigFile",
+	"CensusAtlasRoutesEnabled",
+	"DatasetFinderEnabled",
+	"LegacySearchRedirectsEnabled",
+	"DataAggregationPagesEnabled",
+	"SearchRoutesEnabled",
+	"ReleaseCalendarEnabled",
+}
+
+// withRoutes returns routerConfig with the route table and route feature flags of cfg, re-reading the route table file
+func withRoutes(routerConfig router.Config, cfg *config.Config) (router.Config, error) {
+	routerConfig.CensusAtlasEnabled = cfg.CensusAtlasRoutesEnabled
+	routerConfig.DatasetFinderEnabled = cfg.DatasetFinderEnabled
+	routerConfig.LegacySearchRedirectsEnabled = cfg.LegacySearchRedirectsEnabled
+	routerConfig.DataAggregationPagesEnabled = cfg.DataAggregationPagesEnabled
+	routerConfig.SearchRoutesEnabled = cfg.SearchRoutesEnabled
+	routerConfig.RelCalEnabled = cfg.ReleaseCalendarEnabled
+
+	routerConfig.RouteTable = nil
+	if cfg.RouteConfigFile != "" {
+		table, err := router.LoadRouteTable(cfg.RouteConfigFile)
 		if err != nil {
-			log.Fatal(ctx, "error shutting down opentelemettry", err)
+			return routerConfig, err
 		}
+		routerConfig.RouteTable = table
 	}
+	return routerConfig, routerConfig.Validate()
 }
 
-func parseURL(ctx context.Context, cfgValue, configName string) (*url.URL, error) {
-	parsedURL, err := url.Parse(cfgValue)
+// healthcheckedBackends are the backends that cfg routes requests to, checked by the healthcheck when enabled. Zebedee is
+// always checked through the API router.
+func healthcheckedBackends(cfg *config.Config) ([]backendhealth.Backend, error) {
+	var backends []backendhealth.Backend
+	urls := &urlParser{}
+	add := func(name, configName, backendURL string, headOnly bool) {
+		if u := urls.parse(backendURL, configName); u != nil {
+			backends = append(backends, backendhealth.Backend{Name: name, URL: u, HeadOnly: headOnly})
+		}
+	}
+
+	add("cookies", "CookiesControllerURL", cfg.CookiesControllerURL, false)
+	add("datasets", "DatasetControllerURL", cfg.DatasetControllerURL, false)
+	add("filters", "FilterDatasetControllerURL", cfg.FilterDatasetControllerURL, false)
+	add("flex", "FilterFlexDatasetServiceURL", cfg.FilterFlexDatasetServiceURL, false)
+	add("homepage", "HomepageControllerURL", cfg.HomepageControllerURL, false)
+	add("search", "SearchControllerURL", cfg.SearchControllerURL, false)
+	add("download", "DownloaderURL", cfg.DownloaderURL, false)
+	if cfg.LegacyCacheProxyEnabled {
+		add("legacyCacheProxy", "LegacyCacheProxyURL", cfg.LegacyCacheProxyURL, false)
+	} else {
+		add("babbage", "BabbageURL", cfg.BabbageURL, true)
+	}
+	if cfg.FeedbackEnabled {
+		add("feedback", "FeedbackControllerURL", cfg.FeedbackControllerURL, false)
+	}
+	if cfg.ReleaseCalendarEnabled {
+		add("relcal", "ReleaseCalendarControllerURL", cfg.ReleaseCalendarControllerURL, false)
+	}
+	if cfg.AreaProfilesRoutesEnabled {
+		add("areas", "AreaProfileControllerURL", cfg.AreaProfilesControllerURL, false)
+	}
+	if cfg.CensusAtlasRoutesEnabled && cfg.CensusAtlasURL != "" {
+		add("censusAtlas", "CensusAtlas", cfg.CensusAtlasURL, true)
+	}
+	return backends, urls.err
+}
+
+// parseIPFilterRules parses the IP ranges to deny, to restrict paths to and of the trusted proxies from cfg
+func parseIPFilterRules(cfg *config.Config) (ipfilter.Rules, error) {
+	var rules ipfilter.Rules
+	var err error
+	if rules.Deny, err = ipfilter.ParseRanges(cfg.IPDenyList); err != nil {
+		return rules, err
+	}
+	if rules.Allow, err = ipfilter.ParseAllowLists(cfg.IPAllowLists); err != nil {
+		return rules, err
+	}
+	if rules.TrustedProxies, err = ipfilter.ParseRanges(cfg.IPTrustedProxies); err != nil {
+		return rules, err
+	}
+	return rules, nil
+}
+
+// createBotRules creates the rules for detecting crawlers, with a reverse proxy to the crawler backend if configured and a
+// limiter of their requests, if bot detection is enabled. Crawlers are identified through the same trusted proxies as
+// the IP filter.
+func createBotRules(cfg *config.Config, trustedProxies []netip.Prefix, proxyOptions proxy.Options) (bots.Rules, error) {
+	if !cfg.BotDetectionEnabled {
+		return bots.Rules{}, nil
+	}
+
+	ranges, err := ipfilter.ParseRanges(cfg.BotIPRanges)
 	if err != nil {
-		log.Fatal(ctx, "configuration value is invalid", err, log.Data{"config_name": configName, "value": cfgValue})
-		return nil, err
+		return bots.Rules{}, err
+	}
+	rules := bots.Rules{
+		UserAgents:     cfg.BotUserAgents,
+		Ranges:         ranges,
+		TrustedProxies: trustedProxies,
+		BackendPaths:   cfg.BotBackendPaths,
+		Limiter:        ratelimit.New(cfg.BotRateLimit, cfg.BotRateLimitBurst, maxRateLimitedClients),
+	}
+	if len(rules.UserAgents) == 0 {
+		rules.UserAgents = bots.DefaultUserAgents
 	}
-	return parsedURL, nil
+	if cfg.BotBackendURL != "" {
+		backendURL, err := urlFromConfig("BotBackendURL", cfg.BotBackendURL)
+		if err != nil {
+			return bots.Rules{}, err
+		}
+		rules.Backend = createReverseProxy("bots", backendURL, proxyOptions)
+	}
+	return rules, nil
+}
+
+// createGeoRules opens the geoip database and creates the geo routes defined in config, with a reverse proxy serving
+// each route that is not a redirect, if a database is configured. Clients are identified through the same trusted
+// proxies as the IP filter.
+func createGeoRules(cfg *config.Config, trustedProxies []netip.Prefix, proxyOptions proxy.Options) (georouting.Rules, error) {
+	if cfg.GeoIPDBPath == "" {
+		return georouting.Rules{}, nil
+	}
+
+	defs, err := georouting.ParseDefinitions(cfg.GeoRoutes)
+	if err != nil {
+		return georouting.Rules{}, err
+	}
+	routes := make([]georouting.Route, 0, len(defs))
+	for _, def := range defs {
+		route := georouting.Route{
+			Name:       def.Name,
+			PathPrefix: def.PathPrefix,
+			Countries:  def.Countries,
+			Except:     def.Except,
+			Redirect:   def.Redirect,
+		}
+		if def.URL != "" {
+			routeURL, err := urlFromConfig("GeoRoutes", def.URL)
+			if err != nil {
+				return georouting.Rules{}, err
+			}
+			route.Handler = createReverseProxy("geo-"+def.Name, routeURL, proxyOptions)
+		}
+		routes = append(routes, route)
+	}
+
+	db, err := geoip.Open(cfg.GeoIPDBPath)
+	if err != nil {
+		return georouting.Rules{}, err
+	}
+	return georouting.Rules{
+		Locator:        db,
+		Routes:         routes,
+		CountryHeader:  cfg.GeoCountryHeader,
+		TrustedProxies: trustedProxies,
+	}, nil
+}
+
+// urlParser parses the URLs of backends from config, keeping the first error so that it can be checked once they are
+// all parsed
+type urlParser struct {
+	err error
+}
+
+func (p *urlParser) parse(cfgValue, configName string) *url.URL {
+	parsedURL, err := urlFromConfig(configName, cfgValue)
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+	return parsedURL
+}
+
+func createReverseProxy(proxyName string, proxyURL *url.URL, opts proxy.Options) http.Handler {
+	return proxy.NewReverseProxy(proxyName, proxyURL, opts)
+}
+
+// backendProxies creates the reverse proxies to backends, balancing the requests for backends that have instances
+// configured across them, and checking the health of each instance every checkInterval
+type backendProxies struct {
+	instances     map[string][]*url.URL
+	strategy      string
+	checkInterval time.Duration
+	client        dphttp.Clienter
+	balancers     []*proxy.Balancer
+	used          map[string]bool
+	// err is the first error creating a proxy, checked once every proxy is created
+	err error
+}
+
+// create creates the reverse proxy to the backend called name at backendURL. If the backend has instances, requests
+// are balanced across them instead, each instance replacing the scheme and host of backendURL. Instances are checked
+// as the healthcheck checks backends, by a HEAD of their root if headOnly is set. If the balancer cannot be created, the
+// error is kept in err.
+func (p *backendProxies) create(ctx context.Context, name string, backendURL *url.URL, opts proxy.Options, headOnly bool) http.Handler {
+	instanceURLs, ok := p.instances[name]
+	if !ok {
+		return createReverseProxy(name, backendURL, opts)
+	}
+
+	instances := make([]proxy.Instance, 0, len(instanceURLs))
+	for _, instanceURL := range instanceURLs {
+		target := *backendURL
+		target.Scheme, target.Host = instanceURL.Scheme, instanceURL.Host
+		backend := backendhealth.Backend{Name: name + " instance " + instanceURL.Host, URL: instanceURL, HeadOnly: headOnly}
+		instances = append(instances, proxy.Instance{URL: &target, Check: backendhealth.Reachable(p.client, backend)})
+	}
+	balancer, err := proxy.NewBalancer(name, instances, p.strategy, opts)
+	if err != nil {
+		if p.err == nil {
+			p.err = fmt.Errorf("invalid backend instances for %s: %w", name, err)
+		}
+		return createReverseProxy(name, backendURL, opts)
+	}
+
+	if p.used == nil {
+		p.used = make(map[string]bool)
+	}
+	p.used[name] = true
+	balancer.Start(ctx, p.checkInterval)
+	p.balancers = append(p.balancers, balancer)
+	return balancer
+}
+
+// warnUnused logs the backends that have instances configured but are not proxied to, as their instances are never
+// sent requests
+func (p *backendProxies) warnUnused(ctx context.Context) {
+	for name := range p.instances {
+		if !p.used[name] {
+			log.Warn(ctx, "backend instances configured for a backend that is not proxied to", log.Data{"backend": name})
+		}
+	}
+}
+
+// close stops checking the health of the instances
+func (p *backendProxies) close() {
+	for _, balancer := range p.balancers {
+		balancer.Close()
+	}
+}
+
+// createExperiments creates the experiments defined in config, with a reverse proxy serving each non-control bucket
+func createExperiments(defs []experiments.Definition, proxyOptions proxy.Options) ([]experiments.Experiment, error) {
+	exps := make([]experiments.Experiment, 0, len(defs))
+	for _, def := range defs {
+		variants := make(map[string]http.Handler, len(def.Buckets))
+		for bucket, rawURL := range def.Buckets {
+			if rawURL == "" {
+				variants[bucket] = nil
+				continue
+			}
+			bucketURL, err := urlFromConfig("Experiments", rawURL)
+			if err != nil {
+				return nil, err
+			}
+			variants[bucket] = createReverseProxy("experiment-"+def.Name+"-"+bucket, bucketURL, proxyOptions)
+		}
+		exps = append(exps, experiments.Experiment{
+			Name:         def.Name,
+			Cookie:       def.Cookie,
+			PathPrefixes: def.PathPrefixes,
+			Variants:     variants,
+		})
+	}
+	return exps, nil
 }
 
-func createReverseProxy(proxyName string, proxyURL *url.URL) http.Handler {
-	proxy := httputil.NewSingleHostReverseProxy(proxyURL)
-	director := proxy.Director
-	proxy.Transport = &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   5 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       180 * time.Second,
-		TLSHandshakeTimeout:   5 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
+// analyticsExperiments returns the experiments defined in config whose buckets are recorded in analytics data
+func analyticsExperiments(defs []experiments.Definition) []analyticsbackend.Experiment {
+	exps := make([]analyticsbackend.Experiment, 0, len(defs))
+	for _, def := range defs {
+		buckets := make([]string, 0, len(def.Buckets))
+		for bucket := range def.Buckets {
+			buckets = append(buckets, bucket)
+		}
+		exps = append(exps, analyticsbackend.Experiment{Name: def.Name, Cookie: def.Cookie, Buckets: buckets})
+	}
+	return exps
+}
+
+// createCanaries creates the canaries defined in config, with a reverse proxy serving each
+func createCanaries(defs []canary.Definition, proxyOptions proxy.Options) ([]canary.Canary, error) {
+	canaries := make([]canary.Canary, 0, len(defs))
+	for _, def := range defs {
+		canaryURL, err := urlFromConfig("CanaryRoutes", def.URL)
+		if err != nil {
+			return nil, err
+		}
+		canaries = append(canaries, canary.Canary{
+			Name:    def.Name,
+			Path:    def.Path,
+			Handler: createReverseProxy("canary-"+def.Name, canaryURL, proxyOptions),
+			Percent: def.Percent,
+			Cookie:  def.Cookie,
+		})
 	}
-	proxy.Director = func(req *http.Request) {
-		log.Info(req.Context(), "proxying request", log.HTTP(req, 0, 0, nil, nil), log.Data{
-			"destination": proxyURL,
-			"proxy_name":  proxyName,
+	return canaries, nil
+}
+
+// withBackendSets wraps each of the live backends in a handler that serves requests that choose a backend set with the
+// set's replacement for it, proxied to in the same way as the live backend. Sets that replace a backend that is not
+// live are logged and ignored.
+func withBackendSets(ctx context.Context, sets map[string]map[string]string, proxyOptions, babbageProxyOptions proxy.Options,
+	live map[string]*http.Handler) error {
+	replacements := make(map[string]map[string]http.Handler)
+	for set, backends := range sets {
+		for backend, backendURL := range backends {
+			if h, ok := live[backend]; !ok || *h == nil {
+				log.Warn(ctx, "backend set replaces a backend that is not live", log.Data{"backend_set": set, "backend": backend})
+				continue
+			}
+			opts := proxyOptions
+			if backend == "babbage" {
+				opts = babbageProxyOptions
+			}
+			if replacements[backend] == nil {
+				replacements[backend] = make(map[string]http.Handler)
+			}
+			setURL, err := urlFromConfig("BackendSets", backendURL)
+			if err != nil {
+				return err
+			}
+			replacements[backend][set] = createReverseProxy(backend+"-"+set, setURL, opts)
+		}
+	}
+	for backend, h := range live {
+		*h = backendset.Handler(*h, replacements[backend])
+	}
+	return nil
+}
+
+// backendSelector returns the selector that lets requests choose one of sets, with the headers and secret from config
+func backendSelector(cfg *config.Config, sets map[string]map[string]string) backendset.Selector {
+	names := make([]string, 0, len(sets))
+	for set := range sets {
+		names = append(names, set)
+	}
+	return backendset.Selector{
+		Header:       cfg.BackendSetHeader,
+		SecretHeader: cfg.BackendSetSecretHeader,
+		Secret:       cfg.BackendSetSecret,
+		Sets:         names,
+	}
+}
+
+// prefixedBackendSets returns the replacements for backend in sets with prefix added to their URLs, for the routes
+// that are proxied to a path of the backend rather than its root
+func prefixedBackendSets(sets map[string]map[string]string, backend, prefix string) map[string]map[string]string {
+	prefixed := make(map[string]map[string]string)
+	for set, backends := range sets {
+		if backendURL, ok := backends[backend]; ok {
+			prefixed[set] = map[string]string{backend: backendURL + prefix}
+		}
+	}
+	return prefixed
+}
+
+// createShadows creates the shadows defined in config, with a reverse proxy serving each
+func createShadows(defs []shadow.Definition, proxyOptions proxy.Options) ([]shadow.Shadow, error) {
+	shadows := make([]shadow.Shadow, 0, len(defs))
+	for _, def := range defs {
+		shadowURL, err := urlFromConfig("ShadowRoutes", def.URL)
+		if err != nil {
+			return nil, err
+		}
+		shadows = append(shadows, shadow.Shadow{
+			Name:     def.Name,
+			Path:     def.Path,
+			Handler:  createReverseProxy("shadow-"+def.Name, shadowURL, proxyOptions),
+			Percent:  def.Percent,
+			LogDiffs: def.LogDiffs,
 		})
-		otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
-		director(req)
 	}
-	return proxy
+	return shadows, nil
 }
 
-func urlFromConfig(ctx context.Context, serviceName, serviceURL string) *url.URL {
+func urlFromConfig(serviceName, serviceURL string) (*url.URL, error) {
 	configuredServiceURL, err := url.Parse(serviceURL)
 	if err != nil {
-		log.Fatal(ctx, "configuration value is invalid", err, log.Data{"config_name": serviceName, "value": serviceURL})
+		return nil, fmt.Errorf("configuration value %s is invalid: %w", serviceName, err)
 	}
-	return configuredServiceURL
+	return configuredServiceURL, nil
 }
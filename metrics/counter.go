@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var _ Collector = &CounterVec{}
+
+// CounterVec is a set of counters with the same name, partitioned by label values
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]*labelledValue
+}
+
+type labelledValue struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec creates a CounterVec whose counters are identified by values for each of labelNames
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]*labelledValue),
+	}
+}
+
+// Name returns the name of the counters
+func (c *CounterVec) Name() string {
+	return c.name
+}
+
+// Inc increments the counter with the given label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the counter with the given label values
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if len(labelValues) != len(c.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", c.name, len(c.labelNames), len(labelValues)))
+	}
+	if v < 0 {
+		panic(fmt.Sprintf("metric %s is a counter and cannot be decreased", c.name))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lv, ok := c.values[key]
+	if !ok {
+		lv = &labelledValue{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = lv
+	}
+	lv.value += v
+}
+
+// Value returns the value of the counter with the given label values
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if lv, ok := c.values[strings.Join(labelValues, "\xff")]; ok {
+		return lv.value
+	}
+	return 0
+}
+
+// Write writes the counters in the Prometheus text exposition format, ordered by label values
+func (c *CounterVec) Write(w io.Writer) error {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lv := c.values[key]
+		lines = append(lines, c.name+formatLabels(c.labelNames, lv.labelValues)+" "+formatValue(lv.value)+"\n")
+	}
+	c.mu.Unlock()
+
+	if err := writeHeader(w, c.name, c.help, "counter"); err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var _ Collector = &HistogramVec{}
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of latency histograms
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// HistogramVec is a set of histograms with the same name and buckets, partitioned by label values
+type HistogramVec struct {
+	name       string
+	help       string
+	buckets    []float64
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]*histogram
+}
+
+type histogram struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// NewHistogramVec creates a HistogramVec with the given ascending bucket upper bounds, whose histograms are identified
+// by values for each of labelNames
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return &HistogramVec{
+		name:       name,
+		help:       help,
+		buckets:    buckets,
+		labelNames: labelNames,
+		values:     make(map[string]*histogram),
+	}
+}
+
+// Name returns the name of the histograms
+func (h *HistogramVec) Name() string {
+	return h.name
+}
+
+// Observe adds v to the histogram with the given label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	if len(labelValues) != len(h.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", h.name, len(h.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hv, ok := h.values[key]
+	if !ok {
+		hv = &histogram{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		hv.counts[i]++
+	}
+	hv.count++
+	hv.sum += v
+}
+
+// Count returns the number of observations in the histogram with the given label values
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hv, ok := h.values[strings.Join(labelValues, "\xff")]; ok {
+		return hv.count
+	}
+	return 0
+}
+
+// Write writes the histograms in the Prometheus text exposition format, ordered by label values, with cumulative
+// bucket counts
+func (h *HistogramVec) Write(w io.Writer) error {
+	h.mu.Lock()
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys)*(len(h.buckets)+3))
+	for _, key := range keys {
+		lines = append(lines, h.lines(h.values[key])...)
+	}
+	h.mu.Unlock()
+
+	if err := writeHeader(w, h.name, h.help, "histogram"); err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lines formats the bucket, sum and count lines of hv. The lock must be held.
+func (h *HistogramVec) lines(hv *histogram) []string {
+	bucketLabels := append(append([]string(nil), h.labelNames...), "le")
+	lines := make([]string, 0, len(h.buckets)+3)
+	var cumulative uint64
+	for i, upper := range h.buckets {
+		cumulative += hv.counts[i]
+		labels := formatLabels(bucketLabels, append(append([]string(nil), hv.labelValues...), formatValue(upper)))
+		lines = append(lines, fmt.Sprintf("%s_bucket%s %d\n", h.name, labels, cumulative))
+	}
+	labels := formatLabels(bucketLabels, append(append([]string(nil), hv.labelValues...), "+Inf"))
+	lines = append(lines,
+		fmt.Sprintf("%s_bucket%s %d\n", h.name, labels, hv.count),
+		h.name+"_sum"+formatLabels(h.labelNames, hv.labelValues)+" "+formatValue(hv.sum)+"\n",
+		fmt.Sprintf("%s_count%s %d\n", h.name, formatLabels(h.labelNames, hv.labelValues), hv.count),
+	)
+	return lines
+}
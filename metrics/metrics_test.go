@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCounterVec(t *testing.T) {
+	Convey("Given a counter vec with two labels", t, func() {
+		c := NewCounterVec("requests_total", "Total requests.", "route", "result")
+
+		Convey("When counters are incremented", func() {
+			c.Inc("/search", "good")
+			c.Inc("/search", "good")
+			c.Add(3, "/search", "bad")
+
+			Convey("Then each label combination is counted separately", func() {
+				So(c.Value("/search", "good"), ShouldEqual, 2)
+				So(c.Value("/search", "bad"), ShouldEqual, 3)
+				So(c.Value("/datasets", "good"), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the wrong number of label values is given", func() {
+			Convey("Then it panics", func() {
+				So(func() { c.Inc("/search") }, ShouldPanic)
+			})
+		})
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given a registry with two counter vecs", t, func() {
+		r := NewRegistry()
+		requests := NewCounterVec("requests_total", "Total requests.", "route")
+		requests.Inc(`/a"b`)
+		requests.Inc("/")
+		errs := NewCounterVec("errors_total", "Total errors.")
+		errs.Inc()
+		r.Register(requests)
+		r.Register(errs)
+
+		Convey("When the metrics are scraped", func() {
+			w := httptest.NewRecorder()
+			Handler(r).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+			Convey("Then they are written in the Prometheus text format, ordered by name and labels", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, contentType)
+				So(w.Body.String(), ShouldEqual, `# HELP errors_total Total errors.
+# TYPE errors_total counter
+errors_total 1
+# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{route="/"} 1
+requests_total{route="/a\"b"} 1
+`)
+			})
+		})
+	})
+}
+
+func TestHistogramVec(t *testing.T) {
+	Convey("Given a histogram vec with one label", t, func() {
+		h := NewHistogramVec("duration_seconds", "Request duration.", []float64{0.25, 1}, "method")
+
+		Convey("When values are observed", func() {
+			h.Observe(0.125, "GET")
+			h.Observe(0.25, "GET")
+			h.Observe(0.5, "GET")
+			h.Observe(2, "GET")
+			h.Observe(0.5, "POST")
+
+			Convey("Then each label value is counted separately", func() {
+				So(h.Count("GET"), ShouldEqual, 4)
+				So(h.Count("POST"), ShouldEqual, 1)
+				So(h.Count("HEAD"), ShouldEqual, 0)
+			})
+
+			Convey("Then they are written with cumulative buckets, inclusive of their upper bounds", func() {
+				var buf strings.Builder
+				So(h.Write(&buf), ShouldBeNil)
+				So(buf.String(), ShouldEqual, `# HELP duration_seconds Request duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{method="GET",le="0.25"} 2
+duration_seconds_bucket{method="GET",le="1"} 3
+duration_seconds_bucket{method="GET",le="+Inf"} 4
+duration_seconds_sum{method="GET"} 2.875
+duration_seconds_count{method="GET"} 4
+duration_seconds_bucket{method="POST",le="0.25"} 0
+duration_seconds_bucket{method="POST",le="1"} 1
+duration_seconds_bucket{method="POST",le="+Inf"} 1
+duration_seconds_sum{method="POST"} 0.5
+duration_seconds_count{method="POST"} 1
+`)
+			})
+		})
+
+		Convey("When the wrong number of label values is given", func() {
+			Convey("Then it panics", func() {
+				So(func() { h.Observe(1) }, ShouldPanic)
+			})
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package accesslog
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/dp-frontend-router/middleware/requestmetrics"
+	dprequest "github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// logRequest writes the access log line for a request once it has been served
+var logRequest = func(ctx context.Context, req *http.Request, status int, size int64, started, ended time.Time, backend string) {
+	log.Info(ctx, "http request", log.HTTP(req, status, size, &started, &ended), log.Data{
+		"backend":    backend,
+		"request_id": dprequest.GetRequestId(ctx),
+	})
+}
+
+// sample returns a number in [0, 1) that is compared against the sample rate
+var sample = rand.Float64
+
+// Handler logs a single line for each request once it has been served, with its method, path, status, duration,
+// backend and request ID. Successful responses are logged at successSampleRate, from 0 to 1, to control the volume of
+// logs, while every other response is logged.
+func Handler(successSampleRate float64) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			started := time.Now()
+			req, labels := requestmetrics.Track(req)
+			sw := helpers.NewStatusWriter(w)
+			h.ServeHTTP(sw, req)
+
+			if isSuccess(sw.Status()) && sample() >= successSampleRate {
+				return
+			}
+			_, backend := labels()
+			logRequest(req.Context(), req, sw.Status(), sw.Size(), started, time.Now(), backend)
+		})
+	}
+}
+
+func isSuccess(status int) bool {
+	return status >= http.StatusOK && status < http.StatusMultipleChoices
+}
@@ -0,0 +1,87 @@
+package accesslog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/requestmetrics"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type logged struct {
+	method  string
+	path    string
+	status  int
+	size    int64
+	backend string
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given the access log in front of a handler", t, func() {
+		var lines []logged
+		logRequest = func(ctx context.Context, req *http.Request, status int, size int64, started, ended time.Time, backend string) {
+			So(ended, ShouldHappenOnOrAfter, started)
+			lines = append(lines, logged{req.Method, req.URL.Path, status, size, backend})
+		}
+		sampled := 0.5
+		sample = func() float64 { return sampled }
+
+		status := http.StatusOK
+		backend := ""
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if backend != "" {
+				requestmetrics.SetBackend(req.Context(), backend)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte("hello"))
+		})
+		serve := func(rate float64, method, path string) {
+			Handler(rate)(h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, http.NoBody))
+		}
+
+		Convey("When a request is proxied to a backend", func() {
+			backend = "search"
+			serve(1, http.MethodGet, "/search")
+
+			Convey("Then a single line is logged with its method, path, status, size and backend", func() {
+				So(lines, ShouldResemble, []logged{{http.MethodGet, "/search", http.StatusOK, 5, "search"}})
+			})
+		})
+
+		Convey("When a request is served by the router itself", func() {
+			status = http.StatusMovedPermanently
+			serve(1, http.MethodGet, "/old")
+
+			Convey("Then it is logged against the router", func() {
+				So(lines, ShouldHaveLength, 1)
+				So(lines[0].backend, ShouldEqual, requestmetrics.RouterBackend)
+			})
+		})
+
+		Convey("When successful requests are sampled", func() {
+			serve(0.25, http.MethodGet, "/dropped")
+			sampled = 0.1
+			serve(0.25, http.MethodGet, "/kept")
+
+			Convey("Then only those within the sample rate are logged", func() {
+				So(lines, ShouldHaveLength, 1)
+				So(lines[0].path, ShouldEqual, "/kept")
+			})
+		})
+
+		Convey("When unsuccessful requests are served while successful requests are not logged", func() {
+			for _, status = range []int{http.StatusNotFound, http.StatusBadGateway} {
+				serve(0, http.MethodGet, "/missing")
+			}
+
+			Convey("Then every one of them is logged", func() {
+				So(lines, ShouldHaveLength, 2)
+				So(lines[0].status, ShouldEqual, http.StatusNotFound)
+				So(lines[1].status, ShouldEqual, http.StatusBadGateway)
+			})
+		})
+	})
+}
@@ -0,0 +1,24 @@
+//nolint:revive,stylecheck // ignore, Package name "allRoutes" is kept for compatibility.
+package allRoutes
+
+import "context"
+
+// PageType is the outcome of a Zebedee lookup that the middleware chooses a handler from. A zero PageType falls
+// through to the default handler.
+type PageType struct {
+	PageType  string `json:"page_type,omitempty"`
+	Type      string `json:"type,omitempty"`
+	DatasetID string `json:"dataset_id,omitempty"`
+}
+
+// PageTypeCache caches page types against the content path they were looked up with, which includes any collection
+type PageTypeCache interface {
+	Get(ctx context.Context, contentPath string) (PageType, bool)
+	Set(ctx context.Context, contentPath string, pageType PageType)
+}
+
+// noCache is the PageTypeCache used when none is configured, so that every request is looked up
+type noCache struct{}
+
+func (noCache) Get(context.Context, string) (PageType, bool) { return PageType{}, false }
+func (noCache) Set(context.Context, string, PageType)        {}
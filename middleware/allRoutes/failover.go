@@ -0,0 +1,60 @@
+//nolint:revive,stylecheck // ignore, Package name "allRoutes" is kept for compatibility.
+package allRoutes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+var _ ZebedeeClient = &FailoverClient{}
+
+// statusCoder is implemented by Zebedee client errors that carry the status code Zebedee responded with
+type statusCoder interface {
+	Code() int
+}
+
+// FailoverClient is a ZebedeeClient that tries a secondary Zebedee when the primary is unavailable. Errors that Zebedee
+// responded with, such as a 404 for content that does not exist, are returned without trying the secondary.
+type FailoverClient struct {
+	primary          ZebedeeClient
+	secondary        ZebedeeClient
+	secondaryTimeout time.Duration
+}
+
+// NewFailoverClient creates a FailoverClient. Requests to the secondary are cancelled after secondaryTimeout, if set.
+func NewFailoverClient(primary, secondary ZebedeeClient, secondaryTimeout time.Duration) *FailoverClient {
+	return &FailoverClient{
+		primary:          primary,
+		secondary:        secondary,
+		secondaryTimeout: secondaryTimeout,
+	}
+}
+
+// GetWithHeaders gets path from the primary Zebedee, or from the secondary if the primary is unavailable
+func (c *FailoverClient) GetWithHeaders(ctx context.Context, userAccessToken, path string) ([]byte, http.Header, error) {
+	b, headers, err := c.primary.GetWithHeaders(ctx, userAccessToken, path)
+	if err == nil || !isUnavailable(err) || ctx.Err() != nil {
+		return b, headers, err
+	}
+
+	log.Warn(ctx, "primary zebedee unavailable, trying secondary", log.Data{"error": err.Error(), "path": path})
+	if c.secondaryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.secondaryTimeout)
+		defer cancel()
+	}
+	return c.secondary.GetWithHeaders(ctx, userAccessToken, path)
+}
+
+// isUnavailable returns true unless err is a response from Zebedee below 500, as the secondary would respond the same
+func isUnavailable(err error) bool {
+	var sc statusCoder
+	if errors.As(err, &sc) {
+		return sc.Code() >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package allRoutes_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/allRoutes"
+	"github.com/ONSdigital/dp-frontend-router/middleware/allRoutes/allroutestest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// statusError is an error that Zebedee responded with
+type statusError int
+
+func (e statusError) Error() string { return http.StatusText(int(e)) }
+func (e statusError) Code() int     { return int(e) }
+
+func zebedeeMock(body string, pageType string, err error) *allroutestest.ZebedeeClientMock {
+	return &allroutestest.ZebedeeClientMock{
+		GetWithHeadersFunc: func(ctx context.Context, userAccessToken string, path string) ([]byte, http.Header, error) {
+			if err != nil {
+				return nil, nil, err
+			}
+			headers := http.Header{}
+			headers.Set(allRoutes.HeaderOnsPageType, pageType)
+			return []byte(body), headers, nil
+		},
+	}
+}
+
+func TestFailoverClient(t *testing.T) {
+	Convey("Given a page type handler behind the allRoutes middleware with a failover Zebedee client", t, func() {
+		var pageTypeServed, babbageServed int
+		pageTypeHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { pageTypeServed++ })
+		babbage := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { babbageServed++ })
+		serve := func(client allRoutes.ZebedeeClient) {
+			handler := allRoutes.Handler(map[string]http.Handler{"dataset_landing_page": pageTypeHandler}, client, 5000, nil, nil)(babbage)
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/some/page", http.NoBody))
+		}
+
+		Convey("When the primary succeeds", func() {
+			primary := zebedeeMock(`{"type":"dataset_landing_page"}`, "dataset_landing_page", nil)
+			secondary := zebedeeMock(`{}`, "", nil)
+			serve(allRoutes.NewFailoverClient(primary, secondary, time.Second))
+
+			Convey("Then the secondary is not called and the page type handler is used", func() {
+				So(primary.GetWithHeadersCalls(), ShouldHaveLength, 1)
+				So(secondary.GetWithHeadersCalls(), ShouldHaveLength, 0)
+				So(pageTypeServed, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the primary errors and the secondary succeeds", func() {
+			primary := zebedeeMock("", "", errors.New("connection refused"))
+			secondary := zebedeeMock(`{"type":"dataset_landing_page"}`, "dataset_landing_page", nil)
+			serve(allRoutes.NewFailoverClient(primary, secondary, time.Second))
+
+			Convey("Then the secondary's page type is used", func() {
+				So(secondary.GetWithHeadersCalls(), ShouldHaveLength, 1)
+				So(secondary.GetWithHeadersCalls()[0].Path, ShouldEqual, "/data?uri=/some/page")
+				So(pageTypeServed, ShouldEqual, 1)
+				So(babbageServed, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When both the primary and the secondary error", func() {
+			primary := zebedeeMock("", "", statusError(http.StatusBadGateway))
+			secondary := zebedeeMock("", "", errors.New("connection refused"))
+			serve(allRoutes.NewFailoverClient(primary, secondary, time.Second))
+
+			Convey("Then the request falls back to Babbage", func() {
+				So(secondary.GetWithHeadersCalls(), ShouldHaveLength, 1)
+				So(pageTypeServed, ShouldEqual, 0)
+				So(babbageServed, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the primary responds that the content is not found", func() {
+			primary := zebedeeMock("", "", statusError(http.StatusNotFound))
+			secondary := zebedeeMock(`{"type":"dataset_landing_page"}`, "dataset_landing_page", nil)
+			serve(allRoutes.NewFailoverClient(primary, secondary, time.Second))
+
+			Convey("Then the secondary is not tried and the request falls back to Babbage", func() {
+				So(secondary.GetWithHeadersCalls(), ShouldHaveLength, 0)
+				So(babbageServed, ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given a failover client whose secondary is slower than its timeout", t, func() {
+		primary := zebedeeMock("", "", errors.New("connection refused"))
+		secondary := &allroutestest.ZebedeeClientMock{
+			GetWithHeadersFunc: func(ctx context.Context, userAccessToken string, path string) ([]byte, http.Header, error) {
+				<-ctx.Done()
+				return nil, nil, ctx.Err()
+			},
+		}
+		client := allRoutes.NewFailoverClient(primary, secondary, 10*time.Millisecond)
+
+		Convey("Then the request to the secondary is cancelled after the timeout", func() {
+			_, _, err := client.GetWithHeaders(context.Background(), "", "/data?uri=/")
+			So(err, ShouldEqual, context.DeadlineExceeded)
+		})
+	})
+}
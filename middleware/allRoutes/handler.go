@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/ONSdigital/dp-frontend-router/cache"
 	dprequest "github.com/ONSdigital/dp-net/v2/request"
 	"github.com/ONSdigital/log.go/v2/log"
 )
@@ -21,7 +22,19 @@ type ZebedeeClient interface {
 
 // Handler implements the middleware for dp-frontend-router. It sets the locale code, obtains the necessary cookies for the request path and access_token,
 // authenticates with Zebedee if required,  and obtains the "ONS-Page-Type" header to use the handler for the page type, if present.
-func Handler(routesHandler map[string]http.Handler, zebedeeClient ZebedeeClient, contentTypeByteLimit int) func(h http.Handler) http.Handler {
+// Page types are looked up in pageTypeCache, if not nil, before asking Zebedee, unless the request has a collection or
+// access_token cookie, or any of bypassCookies.
+func Handler(routesHandler map[string]http.Handler, zebedeeClient ZebedeeClient, contentTypeByteLimit int,
+	pageTypeCache PageTypeCache, bypassCookies []string) func(h http.Handler) http.Handler {
+	if pageTypeCache == nil {
+		pageTypeCache = noCache{}
+	}
+	pageTypes := &pageTypeLookup{
+		zebedeeClient:        zebedeeClient,
+		cache:                pageTypeCache,
+		cookies:              cache.CookiePolicy{BypassCookies: append(append([]string{}, privateCookies...), bypassCookies...)},
+		contentTypeByteLimit: contentTypeByteLimit,
+	}
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			path := req.URL.Path
@@ -32,61 +45,75 @@ func Handler(routesHandler map[string]http.Handler, zebedeeClient ZebedeeClient,
 			// Construct contentPath with any collection if present in cookie
 			contentPath := constructContentPath(req, path)
 
-			// FIXME We should be doing a HEAD request but Restolino doesn't allow it - either wait for the
-			// new Content API (https://github.com/ONSdigital/dp-content-api) to be in prod or update Restolino
-			/// Update: Is this still needed when using the Zebedee client?
-
-			// Obtain access_token from cookie
-			userAccessToken := ""
-			if c, err := req.Cookie(`access_token`); err == nil && len(c.Value) > 0 {
-				userAccessToken = c.Value
-				log.Info(req.Context(), "Obtained access_token Cookie")
-			}
-
-			// Do the GET call using Zebedee Client and providing any access_token from cookie
-			b, headers, err := zebedeeClient.GetWithHeaders(req.Context(), userAccessToken, contentPath)
+			pageType, err := pageTypes.get(req, contentPath)
 			if err != nil {
-				// intentionally log as info with the error in log.data to prevent the full stack trace being logged as zebedee 404's are common
-				log.Info(req.Context(), "Zebedee GET failed", log.Data{"error": err.Error(), "path": path})
 				h.ServeHTTP(w, req)
 				return
 			}
 
-			if len(b) > contentTypeByteLimit {
-				log.Warn(req.Context(), "Response exceeds acceptable byte limit for assessing content-type. Falling through to default handling")
-				h.ServeHTTP(w, req)
+			if len(pageType.DatasetID) > 0 && pageType.Type == "api_dataset_landing_page" {
+				http.Redirect(w, req, fmt.Sprintf("/datasets/%s", pageType.DatasetID), 302)
 				return
 			}
 
-			var zebResp struct {
-				Type      string `json:"type"`
-				DatasetID string `json:"apiDatasetId"`
-			}
-
-			if err := json.Unmarshal(b, &zebResp); err != nil {
-				log.Error(req.Context(), "json unmarshal error", err)
-				h.ServeHTTP(w, req)
+			if routesH, ok := routesHandler[pageType.PageType]; ok {
+				log.Info(req.Context(), "Using handler for page type", log.Data{"pageType": pageType.PageType, "path": contentPath})
+				routesH.ServeHTTP(w, req)
 				return
 			}
 
-			log.Info(req.Context(), "zebedee response", log.Data{"type": zebResp.Type, "datasetID": zebResp.DatasetID})
+			h.ServeHTTP(w, req)
+		})
+	}
+}
 
-			pageType := headers.Get(HeaderOnsPageType)
+// lookupPageType gets the page type of contentPath from Zebedee. Content larger than contentTypeByteLimit is given the
+// zero PageType, so that it falls through to default handling.
+func lookupPageType(ctx context.Context, zebedeeClient ZebedeeClient, userAccessToken, contentPath string,
+	contentTypeByteLimit int) (PageType, error) {
+	// FIXME We should be doing a HEAD request but Restolino doesn't allow it - either wait for the
+	// new Content API (https://github.com/ONSdigital/dp-content-api) to be in prod or update Restolino
+	/// Update: Is this still needed when using the Zebedee client?
+
+	// Do the GET call using Zebedee Client and providing any access_token from cookie
+	b, headers, err := zebedeeClient.GetWithHeaders(ctx, userAccessToken, contentPath)
+	if err != nil {
+		// intentionally log as info with the error in log.data to prevent the full stack trace being logged as zebedee 404's are common
+		log.Info(ctx, "Zebedee GET failed", log.Data{"error": err.Error(), "path": contentPath})
+		return PageType{}, err
+	}
 
-			if len(zebResp.DatasetID) > 0 && zebResp.Type == "api_dataset_landing_page" {
-				http.Redirect(w, req, fmt.Sprintf("/datasets/%s", zebResp.DatasetID), 302)
-				return
-			}
+	if len(b) > contentTypeByteLimit {
+		log.Warn(ctx, "Response exceeds acceptable byte limit for assessing content-type. Falling through to default handling")
+		return PageType{}, nil
+	}
 
-			if routesH, ok := routesHandler[pageType]; ok {
-				log.Info(req.Context(), "Using handler for page type", log.Data{"pageType": pageType, "path": contentPath})
-				routesH.ServeHTTP(w, req)
-				return
-			}
+	var zebResp struct {
+		Type      string `json:"type"`
+		DatasetID string `json:"apiDatasetId"`
+	}
 
-			h.ServeHTTP(w, req)
-		})
+	if err := json.Unmarshal(b, &zebResp); err != nil {
+		log.Error(ctx, "json unmarshal error", err)
+		return PageType{}, err
 	}
+
+	log.Info(ctx, "zebedee response", log.Data{"type": zebResp.Type, "datasetID": zebResp.DatasetID})
+
+	return PageType{
+		PageType:  headers.Get(HeaderOnsPageType),
+		Type:      zebResp.Type,
+		DatasetID: zebResp.DatasetID,
+	}, nil
+}
+
+// accessToken returns the access_token cookie of req, if any
+func accessToken(req *http.Request) string {
+	if c, err := req.Cookie(`access_token`); err == nil && len(c.Value) > 0 {
+		log.Info(req.Context(), "Obtained access_token Cookie")
+		return c.Value
+	}
+	return ""
 }
 
 func constructContentPath(req *http.Request, path string) string {
@@ -95,7 +122,12 @@ func constructContentPath(req *http.Request, path string) string {
 		contentPath += "/" + c.Value + "?uri=" + path
 		log.Info(req.Context(), "generated from 'collection' cookie", log.Data{"contentPath": contentPath})
 	} else {
-		contentPath += "?uri=" + path
+		contentPath = publishedContentPath(path)
 	}
 	return contentPath
 }
+
+// publishedContentPath returns the Zebedee content path of the published content at uri
+func publishedContentPath(uri string) string {
+	return "/data?uri=" + uri
+}
@@ -0,0 +1,46 @@
+//nolint:revive,stylecheck // ignore, Package name "allRoutes" is kept for compatibility.
+package allRoutes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Invalidator removes cached page types, so that changes to published content are picked up before they expire
+type Invalidator interface {
+	Invalidate(ctx context.Context, uris ...string) error
+}
+
+// invalidateRequest is the body of a request to InvalidateHandler, listing the uris of newly published content
+type invalidateRequest struct {
+	URIs []string `json:"uris"`
+}
+
+// InvalidateHandler removes the cached page types of the uris POSTed to it, to be called when a collection is published
+func InvalidateHandler(invalidator Invalidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body invalidateRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := invalidator.Invalidate(req.Context(), body.URIs...); err != nil {
+			log.Error(req.Context(), "error invalidating page types", err, log.Data{"uris": body.URIs})
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		log.Info(req.Context(), "invalidated page types", log.Data{"uris": body.URIs})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,61 @@
+//nolint:revive,stylecheck // ignore, Package name "allRoutes" is kept for compatibility.
+package allRoutes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ONSdigital/dp-frontend-router/cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// privateCookies make a lookup private to the requester, as it is made with their access token and may be for
+// unpublished content in their collection. Lookups with either cookie are never cached, so that the page type is never
+// given to another requester without Zebedee checking their own token.
+var privateCookies = []string{"collection", "access_token"}
+
+// pageTypeLookup gets page types from the cache, or else from Zebedee, sharing a single Zebedee call between concurrent
+// requests for the same content with the same access token
+type pageTypeLookup struct {
+	zebedeeClient        ZebedeeClient
+	cache                PageTypeCache
+	cookies              cache.CookiePolicy
+	contentTypeByteLimit int
+	inFlight             singleflight.Group
+}
+
+// get returns the page type of contentPath for req, bypassing the cache if req has a bypass cookie. A shared Zebedee call is not cancelled when the request that
+// started it is, as other requests may be waiting on it, so is bounded only by the Zebedee client's timeout. Each
+// request stops waiting when it is cancelled.
+func (l *pageTypeLookup) get(req *http.Request, contentPath string) (PageType, error) {
+	ctx := req.Context()
+	key, cacheable := l.cookies.Key(req, contentPath)
+	if cacheable {
+		if pageType, ok := l.cache.Get(ctx, key); ok {
+			return pageType, nil
+		}
+	}
+
+	userAccessToken := accessToken(req)
+	lookupCtx := context.WithoutCancel(ctx)
+	results := l.inFlight.DoChan(userAccessToken+" "+contentPath, func() (interface{}, error) {
+		pageType, err := lookupPageType(lookupCtx, l.zebedeeClient, userAccessToken, contentPath, l.contentTypeByteLimit)
+		if err != nil {
+			return nil, err
+		}
+		if cacheable {
+			l.cache.Set(lookupCtx, key, pageType)
+		}
+		return pageType, nil
+	})
+
+	select {
+	case res := <-results:
+		if res.Err != nil {
+			return PageType{}, res.Err
+		}
+		return res.Val.(PageType), nil
+	case <-ctx.Done():
+		return PageType{}, ctx.Err()
+	}
+}
@@ -0,0 +1,95 @@
+package allRoutes_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/allRoutes"
+	"github.com/ONSdigital/dp-frontend-router/middleware/allRoutes/allroutestest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConcurrentLookups(t *testing.T) {
+	Convey("Given the allRoutes middleware with a Zebedee that responds once released", t, func() {
+		release := make(chan struct{})
+		zebedee := &allroutestest.ZebedeeClientMock{
+			GetWithHeadersFunc: func(ctx context.Context, userAccessToken string, path string) ([]byte, http.Header, error) {
+				<-release
+				headers := http.Header{}
+				headers.Set(allRoutes.HeaderOnsPageType, "dataset_landing_page")
+				return []byte(`{"type":"dataset_landing_page"}`), headers, nil
+			},
+		}
+		var pageTypeServed int32
+		pageTypeHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { atomic.AddInt32(&pageTypeServed, 1) })
+		handler := allRoutes.Handler(map[string]http.Handler{"dataset_landing_page": pageTypeHandler}, zebedee, 5000, nil, nil)(http.NotFoundHandler())
+
+		serveConcurrently := func(reqs ...*http.Request) *sync.WaitGroup {
+			var wg sync.WaitGroup
+			for _, req := range reqs {
+				wg.Add(1)
+				go func(req *http.Request) {
+					defer wg.Done()
+					handler.ServeHTTP(httptest.NewRecorder(), req)
+				}(req)
+			}
+			return &wg
+		}
+
+		Convey("When a burst of requests for the same page arrives", func() {
+			reqs := make([]*http.Request, 10)
+			for i := range reqs {
+				reqs[i] = httptest.NewRequest(http.MethodGet, "/some/page", http.NoBody)
+			}
+			wg := serveConcurrently(reqs...)
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			Convey("Then they share a single Zebedee call and all use the page type handler", func() {
+				So(zebedee.GetWithHeadersCalls(), ShouldHaveLength, 1)
+				So(atomic.LoadInt32(&pageTypeServed), ShouldEqual, 10)
+			})
+		})
+
+		Convey("When concurrent requests are for different pages, or with different access tokens", func() {
+			withToken := httptest.NewRequest(http.MethodGet, "/some/page", http.NoBody)
+			withToken.AddCookie(&http.Cookie{Name: "access_token", Value: "token"})
+			wg := serveConcurrently(
+				httptest.NewRequest(http.MethodGet, "/some/page", http.NoBody),
+				httptest.NewRequest(http.MethodGet, "/another/page", http.NoBody),
+				withToken,
+			)
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			Convey("Then each makes its own Zebedee call", func() {
+				So(zebedee.GetWithHeadersCalls(), ShouldHaveLength, 3)
+			})
+		})
+
+		Convey("When the request that started a shared call is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancelled := serveConcurrently(httptest.NewRequest(http.MethodGet, "/some/page", http.NoBody).WithContext(ctx))
+			time.Sleep(20 * time.Millisecond)
+			waiting := serveConcurrently(httptest.NewRequest(http.MethodGet, "/some/page", http.NoBody))
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+			cancelled.Wait()
+
+			Convey("Then it stops waiting, and the other request still gets the page type", func() {
+				So(atomic.LoadInt32(&pageTypeServed), ShouldEqual, 0)
+				close(release)
+				waiting.Wait()
+				So(zebedee.GetWithHeadersCalls(), ShouldHaveLength, 1)
+				So(atomic.LoadInt32(&pageTypeServed), ShouldEqual, 1)
+			})
+		})
+	})
+}
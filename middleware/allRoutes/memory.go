@@ -0,0 +1,72 @@
+//nolint:revive,stylecheck // ignore, Package name "allRoutes" is kept for compatibility.
+package allRoutes
+
+import (
+	"context"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/cache"
+)
+
+var (
+	_ PageTypeCache       = &MemoryCache{}
+	_ Invalidator         = &MemoryCache{}
+	_ cache.StatsReporter = &MemoryCache{}
+)
+
+// MemoryCache is a PageTypeCache that holds recently looked up page types in memory, in front of an optional shared
+// cache such as Redis
+type MemoryCache struct {
+	cache *cache.Cache[PageType]
+	next  PageTypeCache
+}
+
+// NewMemoryCache creates a MemoryCache of at most maxEntries page types, each held for no longer than ttl. Page types
+// not held in memory are looked up in next, if not nil.
+func NewMemoryCache(maxEntries int, ttl time.Duration, next PageTypeCache) *MemoryCache {
+	return &MemoryCache{
+		cache: cache.New[PageType](maxEntries, ttl),
+		next:  next,
+	}
+}
+
+// Get returns the page type cached against contentPath in memory, or in the next cache
+func (c *MemoryCache) Get(ctx context.Context, contentPath string) (PageType, bool) {
+	if pageType, ok := c.cache.Get(contentPath); ok {
+		return pageType, true
+	}
+	if c.next == nil {
+		return PageType{}, false
+	}
+
+	pageType, ok := c.next.Get(ctx, contentPath)
+	if ok {
+		c.cache.Set(contentPath, pageType)
+	}
+	return pageType, ok
+}
+
+// Set caches pageType against contentPath in memory and in the next cache
+func (c *MemoryCache) Set(ctx context.Context, contentPath string, pageType PageType) {
+	c.cache.Set(contentPath, pageType)
+	if c.next != nil {
+		c.next.Set(ctx, contentPath, pageType)
+	}
+}
+
+// Invalidate removes the published page types of uris from memory, and from the next cache if it can be invalidated.
+// Other router instances keep their page types in memory until they expire.
+func (c *MemoryCache) Invalidate(ctx context.Context, uris ...string) error {
+	for _, uri := range uris {
+		c.cache.Delete(publishedContentPath(uri))
+	}
+	if next, ok := c.next.(Invalidator); ok {
+		return next.Invalidate(ctx, uris...)
+	}
+	return nil
+}
+
+// Stats returns the statistics of the page types held in memory
+func (c *MemoryCache) Stats() cache.Stats {
+	return c.cache.Stats()
+}
@@ -0,0 +1,82 @@
+package allRoutes_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/allRoutes"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	pageType := allRoutes.PageType{PageType: "dataset_landing_page", Type: "dataset_landing_page"}
+
+	Convey("Given a page type cache in memory on its own", t, func() {
+		c := allRoutes.NewMemoryCache(2, time.Minute, nil)
+
+		Convey("When a page type is set", func() {
+			c.Set(ctx, "/data?uri=/economy", pageType)
+
+			Convey("Then it is returned for the same content path", func() {
+				got, ok := c.Get(ctx, "/data?uri=/economy")
+				So(ok, ShouldBeTrue)
+				So(got, ShouldResemble, pageType)
+				So(c.Stats().Hits, ShouldEqual, 1)
+			})
+
+			Convey("Then it is no longer returned once its uri is invalidated", func() {
+				So(c.Invalidate(ctx, "/economy"), ShouldBeNil)
+				_, ok := c.Get(ctx, "/data?uri=/economy")
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When more page types are set than it holds", func() {
+			c.Set(ctx, "/data?uri=/a", pageType)
+			c.Set(ctx, "/data?uri=/b", pageType)
+			c.Set(ctx, "/data?uri=/c", pageType)
+
+			Convey("Then the least recently used is evicted", func() {
+				_, ok := c.Get(ctx, "/data?uri=/a")
+				So(ok, ShouldBeFalse)
+				So(c.Stats().Size, ShouldEqual, 2)
+				So(c.Stats().Evictions, ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given a page type cache in memory in front of Redis", t, func() {
+		client := newFakeRedis()
+		shared := allRoutes.NewRedisCache(client, time.Minute)
+		c := allRoutes.NewMemoryCache(10, time.Minute, shared)
+
+		Convey("When a page type is set", func() {
+			c.Set(ctx, "/data?uri=/economy", pageType)
+
+			Convey("Then it is also set in Redis", func() {
+				got, ok := shared.Get(ctx, "/data?uri=/economy")
+				So(ok, ShouldBeTrue)
+				So(got, ShouldResemble, pageType)
+			})
+
+			Convey("Then invalidating its uri removes it from Redis too", func() {
+				So(c.Invalidate(ctx, "/economy"), ShouldBeNil)
+				_, ok := shared.Get(ctx, "/data?uri=/economy")
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a page type is only in Redis, as another instance looked it up", func() {
+			shared.Set(ctx, "/data?uri=/economy", pageType)
+
+			Convey("Then it is returned and held in memory", func() {
+				got, ok := c.Get(ctx, "/data?uri=/economy")
+				So(ok, ShouldBeTrue)
+				So(got, ShouldResemble, pageType)
+				So(c.Stats().Size, ShouldEqual, 1)
+			})
+		})
+	})
+}
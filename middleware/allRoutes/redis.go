@@ -0,0 +1,82 @@
+//nolint:revive,stylecheck // ignore, Package name "allRoutes" is kept for compatibility.
+package allRoutes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ PageTypeCache = &RedisCache{}
+
+// redisKeyPrefix namespaces the page types in Redis, as the instance may be shared with other services
+const redisKeyPrefix = "dp-frontend-router:page-type:"
+
+// RedisClient is the subset of the Redis client that RedisCache uses
+type RedisClient interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+}
+
+// RedisCache is a PageTypeCache shared by all router instances using the same Redis. Redis errors are logged and
+// treated as cache misses, so that page types are looked up in Zebedee while Redis is unavailable.
+type RedisCache struct {
+	client RedisClient
+	ttl    time.Duration
+}
+
+// NewRedisCache creates a RedisCache that caches page types for ttl
+func NewRedisCache(client RedisClient, ttl time.Duration) *RedisCache {
+	return &RedisCache{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+// Get returns the page type cached against contentPath, if present
+func (c *RedisCache) Get(ctx context.Context, contentPath string) (PageType, bool) {
+	var pageType PageType
+	b, err := c.client.Get(ctx, redisKeyPrefix+contentPath).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Warn(ctx, "error getting page type from redis", log.Data{"error": err.Error(), "path": contentPath})
+		}
+		return pageType, false
+	}
+
+	if err := json.Unmarshal(b, &pageType); err != nil {
+		log.Warn(ctx, "invalid page type in redis", log.Data{"error": err.Error(), "path": contentPath})
+		return pageType, false
+	}
+	return pageType, true
+}
+
+// Set caches pageType against contentPath
+func (c *RedisCache) Set(ctx context.Context, contentPath string, pageType PageType) {
+	b, err := json.Marshal(pageType)
+	if err != nil {
+		log.Warn(ctx, "error marshalling page type", log.Data{"error": err.Error(), "path": contentPath})
+		return
+	}
+	if err := c.client.Set(ctx, redisKeyPrefix+contentPath, b, c.ttl).Err(); err != nil {
+		log.Warn(ctx, "error setting page type in redis", log.Data{"error": err.Error(), "path": contentPath})
+	}
+}
+
+// Invalidate removes the published page types of uris, so that they are looked up again once content is published.
+// Page types cached for a collection are left to expire, as the collection no longer exists once published.
+func (c *RedisCache) Invalidate(ctx context.Context, uris ...string) error {
+	if len(uris) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		keys = append(keys, redisKeyPrefix+publishedContentPath(uri))
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
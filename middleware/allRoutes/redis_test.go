@@ -0,0 +1,241 @@
+package allRoutes_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/allRoutes"
+	"github.com/redis/go-redis/v9"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeRedis is an in-memory stand-in for Redis, which fails every command with err if set
+type fakeRedis struct {
+	values map[string]string
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (f *fakeRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	if f.err != nil {
+		return redis.NewStringResult("", f.err)
+	}
+	v, ok := f.values[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(v, nil)
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	if f.err != nil {
+		return redis.NewStatusResult("", f.err)
+	}
+	f.values[key] = string(value.([]byte))
+	f.ttls[key] = expiration
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	if f.err != nil {
+		return redis.NewIntResult(0, f.err)
+	}
+	var n int64
+	for _, key := range keys {
+		if _, ok := f.values[key]; ok {
+			delete(f.values, key)
+			n++
+		}
+	}
+	return redis.NewIntResult(n, nil)
+}
+
+func TestRedisCache(t *testing.T) {
+	Convey("Given a page type cache in Redis", t, func() {
+		ctx := context.Background()
+		client := newFakeRedis()
+		c := allRoutes.NewRedisCache(client, time.Minute)
+		pageType := allRoutes.PageType{PageType: "dataset_landing_page", Type: "dataset_landing_page"}
+
+		Convey("When a page type is set", func() {
+			c.Set(ctx, "/data?uri=/economy", pageType)
+
+			Convey("Then it is returned for the same content path, and expires after the ttl", func() {
+				got, ok := c.Get(ctx, "/data?uri=/economy")
+				So(ok, ShouldBeTrue)
+				So(got, ShouldResemble, pageType)
+				So(client.ttls, ShouldContainKey, "dp-frontend-router:page-type:/data?uri=/economy")
+				So(client.ttls["dp-frontend-router:page-type:/data?uri=/economy"], ShouldEqual, time.Minute)
+			})
+
+			Convey("Then it is not returned for the same path in a collection", func() {
+				_, ok := c.Get(ctx, "/data/collection-1?uri=/economy")
+				So(ok, ShouldBeFalse)
+			})
+
+			Convey("Then it is no longer returned once its uri is invalidated", func() {
+				So(c.Invalidate(ctx, "/economy", "/not-cached"), ShouldBeNil)
+				_, ok := c.Get(ctx, "/data?uri=/economy")
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When Redis is unavailable", func() {
+			client.err = errors.New("connection refused")
+			c.Set(ctx, "/data?uri=/economy", pageType)
+
+			Convey("Then page types are cache misses", func() {
+				_, ok := c.Get(ctx, "/data?uri=/economy")
+				So(ok, ShouldBeFalse)
+			})
+
+			Convey("Then invalidating returns the error", func() {
+				So(c.Invalidate(ctx, "/economy"), ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestHandlerPageTypeCache(t *testing.T) {
+	Convey("Given the allRoutes middleware with a page type cache", t, func() {
+		var pageTypeServed, babbageServed int
+		pageTypeHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { pageTypeServed++ })
+		babbage := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { babbageServed++ })
+		c := allRoutes.NewRedisCache(newFakeRedis(), time.Minute)
+		serve := func(client allRoutes.ZebedeeClient, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+			handler := allRoutes.Handler(map[string]http.Handler{"dataset_landing_page": pageTypeHandler}, client, 5000, c, nil)(babbage)
+			req := httptest.NewRequest(http.MethodGet, "/some/page", http.NoBody)
+			for _, cookie := range cookies {
+				req.AddCookie(cookie)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When the same page is requested twice", func() {
+			zebedee := zebedeeMock(`{"type":"dataset_landing_page"}`, "dataset_landing_page", nil)
+			serve(zebedee)
+			serve(zebedee)
+
+			Convey("Then Zebedee is only asked once and the page type handler is used both times", func() {
+				So(zebedee.GetWithHeadersCalls(), ShouldHaveLength, 1)
+				So(pageTypeServed, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When the same page is requested in and out of a collection", func() {
+			zebedee := zebedeeMock(`{"type":"dataset_landing_page"}`, "dataset_landing_page", nil)
+			serve(zebedee)
+			serve(zebedee, &http.Cookie{Name: "collection", Value: "collection-1"})
+
+			Convey("Then Zebedee is asked for each", func() {
+				So(zebedee.GetWithHeadersCalls(), ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When a page in a collection is previewed, then requested with the collection cookie but no access token", func() {
+			zebedee := zebedeeMock(`{"type":"api_dataset_landing_page","apiDatasetId":"unpublished"}`, "", nil)
+			serve(zebedee, &http.Cookie{Name: "collection", Value: "collection-1"}, &http.Cookie{Name: "access_token", Value: "token"})
+			serve(zebedee, &http.Cookie{Name: "collection", Value: "collection-1"})
+
+			Convey("Then the anonymous request misses the cache, and Zebedee is asked without a token", func() {
+				So(zebedee.GetWithHeadersCalls(), ShouldHaveLength, 2)
+				So(zebedee.GetWithHeadersCalls()[1].UserAccessToken, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When an api dataset landing page is requested twice", func() {
+			zebedee := zebedeeMock(`{"type":"api_dataset_landing_page","apiDatasetId":"cpih01"}`, "", nil)
+			serve(zebedee)
+			w := serve(zebedee)
+
+			Convey("Then the cached page type still redirects to the dataset", func() {
+				So(zebedee.GetWithHeadersCalls(), ShouldHaveLength, 1)
+				So(w.Code, ShouldEqual, http.StatusFound)
+				So(w.Header().Get("Location"), ShouldEqual, "/datasets/cpih01")
+			})
+		})
+
+		Convey("When content larger than the byte limit is requested twice", func() {
+			zebedee := zebedeeMock(`{"type":"dataset_landing_page","description":"`+strings.Repeat("a", 5000)+`"}`, "dataset_landing_page", nil)
+			serve(zebedee)
+			serve(zebedee)
+
+			Convey("Then Zebedee is only asked once and babbage is used both times", func() {
+				So(zebedee.GetWithHeadersCalls(), ShouldHaveLength, 1)
+				So(babbageServed, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When Zebedee errors", func() {
+			zebedee := zebedeeMock("", "", statusError(http.StatusNotFound))
+			serve(zebedee)
+			serve(zebedee)
+
+			Convey("Then the error is not cached", func() {
+				So(zebedee.GetWithHeadersCalls(), ShouldHaveLength, 2)
+				So(babbageServed, ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestInvalidateHandler(t *testing.T) {
+	Convey("Given the invalidate handler for a page type cache", t, func() {
+		ctx := context.Background()
+		client := newFakeRedis()
+		c := allRoutes.NewRedisCache(client, time.Minute)
+		c.Set(ctx, "/data?uri=/economy", allRoutes.PageType{PageType: "dataset_landing_page"})
+		handler := allRoutes.InvalidateHandler(c)
+
+		Convey("When the uris of a published collection are posted", func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/page-types/invalidate", strings.NewReader(`{"uris":["/economy"]}`)))
+
+			Convey("Then their page types are removed", func() {
+				So(w.Code, ShouldEqual, http.StatusNoContent)
+				_, ok := c.Get(ctx, "/data?uri=/economy")
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When the body is invalid", func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/page-types/invalidate", strings.NewReader(`uris`)))
+
+			Convey("Then the response is bad request", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+			})
+		})
+
+		Convey("When the request is not a POST", func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/page-types/invalidate", http.NoBody))
+
+			Convey("Then the method is not allowed", func() {
+				So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+				So(w.Header().Get("Allow"), ShouldEqual, http.MethodPost)
+			})
+		})
+
+		Convey("When Redis is unavailable", func() {
+			client.err = errors.New("connection refused")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/page-types/invalidate", strings.NewReader(`{"uris":["/economy"]}`)))
+
+			Convey("Then the response is an internal server error", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+	})
+}
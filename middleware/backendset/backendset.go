@@ -0,0 +1,146 @@
+package backendset
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// ParseSets parses backend sets from a JSON object, as read from config. Each set maps the names of the backends it
+// replaces to the URL of the upstream serving them in the set.
+func ParseSets(s string) (map[string]map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var sets map[string]map[string]string
+	if err := json.Unmarshal([]byte(s), &sets); err != nil {
+		return nil, fmt.Errorf("invalid backend sets: %w", err)
+	}
+	for set, backends := range sets {
+		if set == "" || len(backends) == 0 {
+			return nil, fmt.Errorf("invalid backend sets: set %q must be named and replace at least one backend", set)
+		}
+		for backend, backendURL := range backends {
+			if backend == "" || backendURL == "" {
+				return nil, fmt.Errorf("invalid backend sets: set %q has a backend without a name or url", set)
+			}
+		}
+	}
+	return sets, nil
+}
+
+// Selector lets requests that send Secret in SecretHeader choose, in Header, one of Sets to be served by instead of the
+// live backends, so that a new deployment can be smoke-tested behind the live router before it takes traffic
+type Selector struct {
+	Header       string
+	SecretHeader string
+	Secret       string
+	Sets         []string
+}
+
+// Enabled reports whether any request can choose a backend set
+func (s Selector) Enabled() bool {
+	return s.Secret != "" && len(s.Sets) > 0
+}
+
+type setKey struct{}
+
+// FromContext returns the backend set chosen for the request with ctx, if any
+func FromContext(ctx context.Context) (string, bool) {
+	set, ok := ctx.Value(setKey{}).(string)
+	return set, ok
+}
+
+// Handler records the backend set chosen by the request in its context, for Handler to route on. The chosen set is
+// echoed in the response, which is marked as not to be stored, so that a response from a set never reaches a cache. A
+// request that chooses a set without the secret, or chooses an unknown set, is served by the live backends. Either way,
+// the selector's headers are removed from the request, so that they are never forwarded to a backend.
+func (s Selector) Handler(h http.Handler) http.Handler {
+	if !s.Enabled() {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		set := req.Header.Get(s.Header)
+		if set == "" {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		secret := req.Header.Get(s.SecretHeader)
+		req = req.Clone(req.Context())
+		req.Header.Del(s.Header)
+		req.Header.Del(s.SecretHeader)
+
+		// compared in constant time, so that the secret cannot be guessed from how long it takes to be rejected
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(s.Secret)) != 1 {
+			log.Warn(req.Context(), "backend set chosen without a valid secret", log.Data{"backend_set": set, "path": req.URL.Path})
+			h.ServeHTTP(w, req)
+			return
+		}
+		if !slices.Contains(s.Sets, set) {
+			log.Warn(req.Context(), "unknown backend set chosen", log.Data{"backend_set": set, "path": req.URL.Path})
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		w.Header().Set(s.Header, set)
+		h.ServeHTTP(&noStoreWriter{ResponseWriter: w}, req.WithContext(context.WithValue(req.Context(), setKey{}, set)))
+	})
+}
+
+// Handler serves requests that chose a backend set with the handler for that set in sets, and all other requests,
+// including those that chose a set that does not replace this backend, with live
+func Handler(live http.Handler, sets map[string]http.Handler) http.Handler {
+	if len(sets) == 0 {
+		return live
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if set, ok := FromContext(req.Context()); ok {
+			if h, ok := sets[set]; ok {
+				h.ServeHTTP(w, req)
+				return
+			}
+		}
+		live.ServeHTTP(w, req)
+	})
+}
+
+// noStoreWriter overrides the Cache-Control header of the response as it is written, whatever the backend or later
+// middleware set it to
+type noStoreWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (nw *noStoreWriter) WriteHeader(code int) {
+	if !nw.wroteHeader {
+		nw.wroteHeader = true
+		nw.Header().Set("Cache-Control", "private, no-store")
+	}
+	nw.ResponseWriter.WriteHeader(code)
+}
+
+func (nw *noStoreWriter) Write(b []byte) (int, error) {
+	if !nw.wroteHeader {
+		nw.WriteHeader(http.StatusOK)
+	}
+	return nw.ResponseWriter.Write(b)
+}
+
+func (nw *noStoreWriter) Flush() {
+	if f, ok := nw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (nw *noStoreWriter) Unwrap() http.ResponseWriter {
+	return nw.ResponseWriter
+}
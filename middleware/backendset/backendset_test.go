@@ -0,0 +1,131 @@
+package backendset
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseSets(t *testing.T) {
+	Convey("Given backend sets as configured", t, func() {
+		sets, err := ParseSets(`{"green":{"search":"http://green-search:25000","babbage":"http://green-babbage:8080"}}`)
+
+		Convey("Then they are parsed", func() {
+			So(err, ShouldBeNil)
+			So(sets, ShouldResemble, map[string]map[string]string{
+				"green": {"search": "http://green-search:25000", "babbage": "http://green-babbage:8080"},
+			})
+		})
+	})
+
+	Convey("Given no backend sets", t, func() {
+		sets, err := ParseSets(" ")
+
+		Convey("Then there are no sets", func() {
+			So(err, ShouldBeNil)
+			So(sets, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given backend sets that are not valid", t, func() {
+		for _, s := range []string{`["green"]`, `{"green":{}}`, `{"green":{"search":""}}`} {
+			_, err := ParseSets(s)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestSelector(t *testing.T) {
+	Convey("Given a selector of backend sets behind a backend with a green set", t, func() {
+		selector := Selector{Header: "X-Router-Backend", SecretHeader: "X-Router-Backend-Secret", Secret: "s3cret", Sets: []string{"green"}}
+
+		var forwarded http.Header
+		served := func(name string) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				forwarded = req.Header
+				w.Header().Set("Cache-Control", "public, max-age=60")
+				w.Write([]byte(name))
+			})
+		}
+		handler := selector.Handler(Handler(served("live"), map[string]http.Handler{"green": served("green")}))
+
+		serve := func(headers map[string]string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When a request chooses the green set with the secret", func() {
+			w := serve(map[string]string{"X-Router-Backend": "green", "X-Router-Backend-Secret": "s3cret"})
+
+			Convey("Then it is served by the green backend, and the response is not to be stored", func() {
+				So(w.Body.String(), ShouldEqual, "green")
+				So(w.Header().Get("X-Router-Backend"), ShouldEqual, "green")
+				So(w.Header().Get("Cache-Control"), ShouldEqual, "private, no-store")
+			})
+
+			Convey("Then the selector's headers are not forwarded to the backend", func() {
+				So(forwarded.Get("X-Router-Backend"), ShouldBeEmpty)
+				So(forwarded.Get("X-Router-Backend-Secret"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a request chooses the green set without the secret", func() {
+			w := serve(map[string]string{"X-Router-Backend": "green", "X-Router-Backend-Secret": "guess"})
+
+			Convey("Then it is served by the live backend", func() {
+				So(w.Body.String(), ShouldEqual, "live")
+				So(w.Header().Get("X-Router-Backend"), ShouldBeEmpty)
+				So(w.Header().Get("Cache-Control"), ShouldEqual, "public, max-age=60")
+				So(forwarded.Get("X-Router-Backend-Secret"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a request chooses an unknown set", func() {
+			w := serve(map[string]string{"X-Router-Backend": "blue", "X-Router-Backend-Secret": "s3cret"})
+
+			Convey("Then it is served by the live backend", func() {
+				So(w.Body.String(), ShouldEqual, "live")
+			})
+		})
+
+		Convey("When a request does not choose a set", func() {
+			w := serve(nil)
+
+			Convey("Then it is served by the live backend", func() {
+				So(w.Body.String(), ShouldEqual, "live")
+			})
+		})
+	})
+
+	Convey("Given a backend that the green set does not replace", t, func() {
+		selector := Selector{Header: "X-Router-Backend", SecretHeader: "X-Router-Backend-Secret", Secret: "s3cret", Sets: []string{"green"}}
+		live := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("live")) })
+		handler := selector.Handler(Handler(live, nil))
+
+		Convey("Then requests that choose the green set are served by the live backend", func() {
+			req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			req.Header.Set("X-Router-Backend", "green")
+			req.Header.Set("X-Router-Backend-Secret", "s3cret")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			So(w.Body.String(), ShouldEqual, "live")
+		})
+	})
+
+	Convey("Given a selector without a secret", t, func() {
+		selector := Selector{Header: "X-Router-Backend", Sets: []string{"green"}}
+		h := http.RedirectHandler("/", http.StatusFound)
+
+		Convey("Then it is not enabled, and requests cannot choose a set", func() {
+			So(selector.Enabled(), ShouldBeFalse)
+			So(selector.Handler(h), ShouldEqual, h)
+		})
+	})
+}
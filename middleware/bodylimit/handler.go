@@ -0,0 +1,145 @@
+package bodylimit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Limits is the maximum size in bytes of request bodies: Default for every request other than POST requests under a
+// prefix in Prefixes, such as form submissions and uploads, which get the limit of the longest matching prefix
+// instead. A limit of zero is no limit.
+type Limits struct {
+	Default  int64
+	Prefixes map[string]int64
+}
+
+// Enabled reports whether any request body is limited
+func (l Limits) Enabled() bool {
+	return l.Default > 0 || len(l.Prefixes) > 0
+}
+
+// ParseLimits converts a map of path prefix to size in bytes, as read from config, into limits
+func ParseLimits(limits map[string]string) (map[string]int64, error) {
+	parsed := make(map[string]int64, len(limits))
+	for prefix, value := range limits {
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body limit %q for prefix %q: %w", value, prefix, err)
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid body limit %q for prefix %q: must be positive", value, prefix)
+		}
+		parsed[prefix] = n
+	}
+	return parsed, nil
+}
+
+// Handler returns 413 Request Entity Too Large for requests with a body larger than their limit. Requests declaring a
+// larger Content-Length are refused before anything is read, and other bodies are cut off once they pass the limit,
+// so that oversized uploads are never streamed through to backends.
+func Handler(limits Limits) func(h http.Handler) http.Handler {
+	prefixes := helpers.NewPrefixMap(limits.Prefixes)
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			limit := limits.forRequest(req, prefixes)
+			if limit <= 0 || req.Body == nil || req.Body == http.NoBody {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			if req.ContentLength > limit {
+				tooLarge(w, req, limit)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, req.Body, limit)}
+			req.Body = body
+			h.ServeHTTP(&limitWriter{ResponseWriter: w, req: req, body: body, limit: limit}, req)
+		})
+	}
+}
+
+// forRequest returns the limit for req, given the limits of the prefixes
+func (l Limits) forRequest(req *http.Request, prefixes helpers.PrefixMap[int64]) int64 {
+	if req.Method == http.MethodPost {
+		if _, limit, ok := prefixes.Match(req.URL.Path); ok {
+			return limit
+		}
+	}
+	return l.Default
+}
+
+func tooLarge(w http.ResponseWriter, req *http.Request, limit int64) {
+	log.Info(req.Context(), "request body too large", log.Data{"path": req.URL.Path, "content_length": req.ContentLength, "limit": limit})
+	w.Header().Set("Connection", "close")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+}
+
+// limitedBody records whether the body was cut off for passing its limit
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// limitWriter replaces the error that a reverse proxy writes when the body it is streaming to a backend is cut off
+// with a 413 Request Entity Too Large
+type limitWriter struct {
+	http.ResponseWriter
+	req         *http.Request
+	body        *limitedBody
+	limit       int64
+	replaced    bool
+	wroteHeader bool
+}
+
+func (w *limitWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if status < http.StatusBadRequest || !w.body.exceeded {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.replaced = true
+	tooLarge(w.ResponseWriter, w.req, w.limit)
+}
+
+// Write discards anything written in place of the 413
+func (w *limitWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *limitWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *limitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
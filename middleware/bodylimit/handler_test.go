@@ -0,0 +1,98 @@
+package bodylimit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseLimits(t *testing.T) {
+	Convey("Given body limits as configured", t, func() {
+		limits, err := ParseLimits(map[string]string{"/feedback": "1048576", "/filters": " 2048 "})
+
+		Convey("Then they are parsed into sizes in bytes", func() {
+			So(err, ShouldBeNil)
+			So(limits, ShouldResemble, map[string]int64{"/feedback": 1048576, "/filters": 2048})
+		})
+	})
+
+	Convey("Given body limits that are not valid", t, func() {
+		for _, value := range []string{"1MB", "0", "-1"} {
+			_, err := ParseLimits(map[string]string{"/feedback": value})
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given a body limit with a larger limit for feedback", t, func() {
+		var handled bool
+		handler := Handler(Limits{Default: 10, Prefixes: map[string]int64{"/feedback": 100}})(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				handled = true
+				if _, err := io.ReadAll(req.Body); err != nil {
+					w.WriteHeader(http.StatusBadGateway)
+					_, _ = w.Write([]byte("bad gateway"))
+					return
+				}
+				_, _ = w.Write([]byte("body"))
+			}))
+		serve := func(method, path string, body io.Reader, contentLength int64) *httptest.ResponseRecorder {
+			handled = false
+			req := httptest.NewRequest(method, path, body)
+			req.ContentLength = contentLength
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When a request has a body within the limit", func() {
+			w := serve(http.MethodPost, "/search", strings.NewReader("0123456789"), 10)
+
+			Convey("Then it is passed on", func() {
+				So(handled, ShouldBeTrue)
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "body")
+			})
+		})
+
+		Convey("When a request declares a body over the limit", func() {
+			w := serve(http.MethodPost, "/search", strings.NewReader("01234567890"), 11)
+
+			Convey("Then 413 is returned without the body being read", func() {
+				So(handled, ShouldBeFalse)
+				So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+			})
+		})
+
+		Convey("When a request streams a body over the limit", func() {
+			w := serve(http.MethodPost, "/search", strings.NewReader("01234567890"), -1)
+
+			Convey("Then the body is cut off and the error replaced by 413", func() {
+				So(handled, ShouldBeTrue)
+				So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+				So(w.Body.String(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When feedback is posted over the default limit", func() {
+			w := serve(http.MethodPost, "/feedback/thanks", strings.NewReader(strings.Repeat("a", 50)), 50)
+
+			Convey("Then it gets the larger limit", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("When feedback is sent over the default limit by another method", func() {
+			w := serve(http.MethodPut, "/feedback", strings.NewReader(strings.Repeat("a", 50)), 50)
+
+			Convey("Then it gets the default limit", func() {
+				So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+			})
+		})
+	})
+}
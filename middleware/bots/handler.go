@@ -0,0 +1,122 @@
+package bots
+
+import (
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+	"github.com/ONSdigital/dp-frontend-router/ratelimit"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// DefaultUserAgents are the User-Agent substrings of the crawlers that are detected if none are configured
+var DefaultUserAgents = []string{
+	"googlebot", "bingbot", "yandexbot", "baiduspider", "duckduckbot", "applebot", "slurp", "petalbot", "bytespider",
+	"ahrefsbot", "semrushbot", "mj12bot", "dotbot", "gptbot", "ccbot",
+}
+
+// Rules are how crawlers are detected and what is done with them. A request is from a crawler if its User-Agent
+// contains one of UserAgents, case insensitively, or its client, identified through TrustedProxies as for the IP
+// filter, is in Ranges. Crawlers' GET and HEAD requests to BackendPaths, or to any path if there are none, are served
+// by Backend if set, and crawlers' requests are limited per client by Limiter if set.
+type Rules struct {
+	UserAgents     []string
+	Ranges         []netip.Prefix
+	TrustedProxies []netip.Prefix
+	Backend        http.Handler
+	BackendPaths   []string
+	Limiter        *ratelimit.Limiter
+}
+
+// IsBot reports whether req is from a crawler
+func (r Rules) IsBot(req *http.Request) bool {
+	userAgent := strings.ToLower(req.UserAgent())
+	if userAgent != "" {
+		for _, ua := range r.UserAgents {
+			if ua != "" && strings.Contains(userAgent, strings.ToLower(ua)) {
+				return true
+			}
+		}
+	}
+
+	if len(r.Ranges) == 0 {
+		return false
+	}
+	clientIP, ok := ipfilter.ClientIP(req, r.TrustedProxies)
+	if !ok {
+		return false
+	}
+	for _, prefix := range r.Ranges {
+		if prefix.Contains(clientIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Limit limits crawlers' requests per client according to rules, so that crawl storms do not use up other clients'
+// limits. Crawlers over their rate limit get 429 Too Many Requests with a Retry-After header, and every other request
+// is passed on as it is.
+func Limit(rules Rules) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if rules.Limiter == nil || !rules.IsBot(req) {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			clientIP := ipfilter.ClientKey(req, rules.TrustedProxies)
+			if !rules.Limiter.Allow(clientIP) {
+				log.Info(req.Context(), "rate limited crawler request",
+					log.Data{"client_ip": clientIP, "user_agent": req.UserAgent(), "path": req.URL.Path})
+				w.Header().Set("Retry-After", retryAfter(rules.Limiter.Rate()))
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
+// Divert serves crawlers' requests by the crawler backend according to rules, so that crawl storms are kept away from
+// the live backends. It belongs after the redirects and retired paths, so that crawlers see the same redirects and 410s
+// as everyone else. Every other request is passed on as it is.
+func Divert(rules Rules) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if rules.Backend != nil && rules.routed(req) && rules.IsBot(req) {
+				rules.Backend.ServeHTTP(w, req)
+				return
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
+// routed reports whether a crawler's request is served by the crawler backend. Only reads are, so that crawlers cannot
+// change anything through a backend meant for rendering pages.
+func (r Rules) routed(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if len(r.BackendPaths) == 0 {
+		return true
+	}
+	for _, prefix := range r.BackendPaths {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfter is the number of whole seconds until a crawler that has used up its burst is allowed another request
+func retryAfter(rate float64) string {
+	if rate <= 0 {
+		return "1"
+	}
+	return strconv.Itoa(int(math.Ceil(1 / rate)))
+}
@@ -0,0 +1,132 @@
+package bots
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/ratelimit"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const googlebot = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+
+func request(method, path, userAgent, remoteAddr string) *http.Request {
+	req := httptest.NewRequest(method, path, http.NoBody)
+	req.Header.Set("User-Agent", userAgent)
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+func TestIsBot(t *testing.T) {
+	rules := Rules{
+		UserAgents:     DefaultUserAgents,
+		Ranges:         []netip.Prefix{netip.MustParsePrefix("66.249.64.0/19")},
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}
+
+	Convey("A request with a crawler's User-Agent is from a bot, whatever its case", t, func() {
+		So(rules.IsBot(request(http.MethodGet, "/", googlebot, "203.0.113.9:1234")), ShouldBeTrue)
+		So(rules.IsBot(request(http.MethodGet, "/", "BINGBOT/2.0", "203.0.113.9:1234")), ShouldBeTrue)
+	})
+
+	Convey("A request from a crawler's IP range is from a bot, whatever its User-Agent", t, func() {
+		So(rules.IsBot(request(http.MethodGet, "/", "Mozilla/5.0", "66.249.66.1:1234")), ShouldBeTrue)
+	})
+
+	Convey("A request through a trusted proxy is from a bot if the client it was forwarded for is", t, func() {
+		req := request(http.MethodGet, "/", "Mozilla/5.0", "10.0.0.1:1234")
+		req.Header.Set("X-Forwarded-For", "66.249.66.1")
+		So(rules.IsBot(req), ShouldBeTrue)
+	})
+
+	Convey("A request from a browser is not from a bot", t, func() {
+		So(rules.IsBot(request(http.MethodGet, "/", "Mozilla/5.0", "203.0.113.9:1234")), ShouldBeFalse)
+		So(rules.IsBot(request(http.MethodGet, "/", "", "203.0.113.9:1234")), ShouldBeFalse)
+	})
+}
+
+func TestDivert(t *testing.T) {
+	Convey("Given crawlers are served by a crawler backend for some paths", t, func() {
+		var live, backend bool
+		handler := Divert(Rules{
+			UserAgents:   DefaultUserAgents,
+			Backend:      http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { backend = true }),
+			BackendPaths: []string{"/economy"},
+		})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { live = true }))
+		serve := func(req *http.Request) {
+			live, backend = false, false
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		Convey("When a crawler requests one of the paths", func() {
+			serve(request(http.MethodGet, "/economy/inflation", googlebot, "203.0.113.9:1234"))
+
+			Convey("Then it is served by the crawler backend", func() {
+				So(backend, ShouldBeTrue)
+				So(live, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a crawler requests any other path", func() {
+			serve(request(http.MethodGet, "/search", googlebot, "203.0.113.9:1234"))
+
+			Convey("Then it is served by the live backends", func() {
+				So(live, ShouldBeTrue)
+				So(backend, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a crawler POSTs to one of the paths", func() {
+			serve(request(http.MethodPost, "/economy/inflation", googlebot, "203.0.113.9:1234"))
+
+			Convey("Then it is served by the live backends", func() {
+				So(live, ShouldBeTrue)
+			})
+		})
+
+		Convey("When a browser requests one of the paths", func() {
+			serve(request(http.MethodGet, "/economy/inflation", "Mozilla/5.0", "203.0.113.9:1234"))
+
+			Convey("Then it is served by the live backends", func() {
+				So(live, ShouldBeTrue)
+				So(backend, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestLimit(t *testing.T) {
+	Convey("Given crawlers are rate limited", t, func() {
+		handler := Limit(Rules{
+			UserAgents: DefaultUserAgents,
+			Limiter:    ratelimit.New(0.5, 1, 100),
+		})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+		serve := func(userAgent, remoteAddr string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request(http.MethodGet, "/economy", userAgent, remoteAddr))
+			return w
+		}
+
+		Convey("When a crawler makes requests over its limit", func() {
+			first := serve(googlebot, "203.0.113.9:1234")
+			second := serve(googlebot, "203.0.113.9:1234")
+
+			Convey("Then the requests over it get 429 Too Many Requests with Retry-After", func() {
+				So(first.Code, ShouldEqual, http.StatusOK)
+				So(second.Code, ShouldEqual, http.StatusTooManyRequests)
+				So(second.Header().Get("Retry-After"), ShouldEqual, "2")
+			})
+		})
+
+		Convey("When a browser makes as many requests", func() {
+			serve("Mozilla/5.0", "203.0.113.9:1234")
+			second := serve("Mozilla/5.0", "203.0.113.9:1234")
+
+			Convey("Then it is not limited", func() {
+				So(second.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+	})
+}
@@ -0,0 +1,111 @@
+package cachecontrol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+)
+
+// Policy is the caching headers set on responses to paths under PathPrefix. CacheControl and SurrogateControl are only
+// set on responses without their own, unless Override is set, in which case they replace any set by the backend. An
+// empty value leaves the header as it is.
+type Policy struct {
+	PathPrefix       string `json:"path_prefix"`
+	CacheControl     string `json:"cache_control"`
+	SurrogateControl string `json:"surrogate_control"`
+	Override         bool   `json:"override"`
+}
+
+// ParsePolicies parses caching policies from a JSON array, as read from config
+func ParsePolicies(s string) ([]Policy, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var policies []Policy
+	if err := json.Unmarshal([]byte(s), &policies); err != nil {
+		return nil, fmt.Errorf("invalid cache control policies: %w", err)
+	}
+
+	for _, policy := range policies {
+		if policy.PathPrefix == "" {
+			return nil, errors.New("invalid cache control policies: path_prefix is required")
+		}
+		if policy.CacheControl == "" && policy.SurrogateControl == "" {
+			return nil, fmt.Errorf("invalid cache control policies: cache_control or surrogate_control is required for %q", policy.PathPrefix)
+		}
+	}
+	return policies, nil
+}
+
+// Handler applies the policy with the longest path prefix matching each request to its response, so that how browsers
+// and the CDN cache responses is controlled in one place, including for backends that send no caching headers. Error
+// responses keep the headers the backend set, so that errors are never cached for longer than the backend intends.
+func Handler(policies []Policy) func(h http.Handler) http.Handler {
+	sorted := helpers.LongestFirst(policies, pathPrefix)
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			policy, ok := helpers.MatchPrefix(sorted, pathPrefix, req.URL.Path)
+			if !ok {
+				h.ServeHTTP(w, req)
+				return
+			}
+			h.ServeHTTP(&policyWriter{ResponseWriter: w, policy: policy}, req)
+		})
+	}
+}
+
+func pathPrefix(p Policy) string {
+	return p.PathPrefix
+}
+
+// apply sets the policy's headers on a response with status
+func (p Policy) apply(header http.Header, status int) {
+	if status >= http.StatusBadRequest {
+		return
+	}
+	for name, value := range map[string]string{"Cache-Control": p.CacheControl, "Surrogate-Control": p.SurrogateControl} {
+		if value != "" && (p.Override || header.Get(name) == "") {
+			header.Set(name, value)
+		}
+	}
+}
+
+// policyWriter applies the policy once the response headers are written
+type policyWriter struct {
+	http.ResponseWriter
+	policy      Policy
+	wroteHeader bool
+}
+
+func (pw *policyWriter) WriteHeader(code int) {
+	if !pw.wroteHeader && code >= http.StatusOK {
+		pw.wroteHeader = true
+		pw.policy.apply(pw.Header(), code)
+	}
+	pw.ResponseWriter.WriteHeader(code)
+}
+
+func (pw *policyWriter) Write(b []byte) (int, error) {
+	if !pw.wroteHeader {
+		pw.WriteHeader(http.StatusOK)
+	}
+	return pw.ResponseWriter.Write(b)
+}
+
+// Flush allows streamed responses to be flushed through to the client
+func (pw *policyWriter) Flush() {
+	if f, ok := pw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (pw *policyWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
@@ -0,0 +1,84 @@
+package cachecontrol
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParsePolicies(t *testing.T) {
+	Convey("Given caching policies as configured", t, func() {
+		policies, err := ParsePolicies(`[{"path_prefix":"/economy","cache_control":"public, max-age=300",
+			"surrogate_control":"max-age=3600"},{"path_prefix":"/search","cache_control":"no-store","override":true}]`)
+
+		Convey("Then they are parsed", func() {
+			So(err, ShouldBeNil)
+			So(policies, ShouldResemble, []Policy{
+				{PathPrefix: "/economy", CacheControl: "public, max-age=300", SurrogateControl: "max-age=3600"},
+				{PathPrefix: "/search", CacheControl: "no-store", Override: true},
+			})
+		})
+	})
+
+	Convey("Given caching policies that are not valid", t, func() {
+		for _, s := range []string{
+			`{"path_prefix":"/economy"}`,
+			`[{"cache_control":"no-store"}]`,
+			`[{"path_prefix":"/economy"}]`,
+		} {
+			_, err := ParsePolicies(s)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given caching policies for legacy pages and search", t, func() {
+		status := http.StatusOK
+		backendHeader := http.Header{}
+		handler := Handler([]Policy{
+			{PathPrefix: "/", CacheControl: "public, max-age=300", SurrogateControl: "max-age=3600"},
+			{PathPrefix: "/search", CacheControl: "no-store", Override: true},
+		})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			for k, v := range backendHeader {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("body"))
+		}))
+		serve := func(path string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+			return w
+		}
+
+		Convey("When a backend sends no caching headers", func() {
+			w := serve("/economy")
+
+			Convey("Then the policy's are set", func() {
+				So(w.Header().Get("Cache-Control"), ShouldEqual, "public, max-age=300")
+				So(w.Header().Get("Surrogate-Control"), ShouldEqual, "max-age=3600")
+			})
+		})
+
+		Convey("When a backend sends its own caching headers", func() {
+			backendHeader.Set("Cache-Control", "public, max-age=60")
+
+			Convey("Then they are kept, unless the policy overrides them", func() {
+				So(serve("/economy").Header().Get("Cache-Control"), ShouldEqual, "public, max-age=60")
+				So(serve("/search").Header().Get("Cache-Control"), ShouldEqual, "no-store")
+			})
+		})
+
+		Convey("When a backend responds with an error", func() {
+			status = http.StatusInternalServerError
+			w := serve("/search")
+
+			Convey("Then the policy is not applied", func() {
+				So(w.Header().Get("Cache-Control"), ShouldBeEmpty)
+			})
+		})
+	})
+}
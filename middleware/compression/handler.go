@@ -0,0 +1,259 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/andybalholm/brotli"
+)
+
+// Encodings supported, in order of preference when a client accepts them equally
+const (
+	Brotli = "br"
+	Gzip   = "gzip"
+)
+
+// Options configures which responses are compressed: those of ContentTypes, which may include wildcards such as
+// "text/*", of at least MinSize bytes, that the backend has not already compressed
+type Options struct {
+	MinSize      int
+	ContentTypes []string
+}
+
+// Enabled reports whether any responses are compressed
+func (o Options) Enabled() bool {
+	return len(o.ContentTypes) > 0
+}
+
+// Handler compresses responses with the encoding negotiated from the request's Accept-Encoding, preferring Brotli
+// over gzip. Responses are buffered until MinSize bytes have been written, unless they declare their length, so that
+// small responses are sent as they are.
+func Handler(opts Options) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			encoding := Negotiate(req.Header.Get("Accept-Encoding"))
+			if encoding == "" || req.Method == http.MethodHead || req.Header.Get("Range") != "" {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, opts: opts, encoding: encoding}
+			defer func() {
+				if err := cw.Close(); err != nil {
+					log.Error(req.Context(), "error compressing response", err, log.Data{"path": req.URL.Path, "encoding": encoding})
+				}
+			}()
+			h.ServeHTTP(cw, req)
+		})
+	}
+}
+
+// Negotiate returns the supported encoding the Accept-Encoding header value prefers, or an empty string if the client
+// accepts neither
+func Negotiate(acceptEncoding string) string {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		name, value, ok := strings.Cut(strings.TrimSpace(params), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "q") {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		qualities[coding] = q
+	}
+
+	var best string
+	var bestQ float64
+	for _, encoding := range []string{Brotli, Gzip} {
+		q, ok := qualities[encoding]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// allowed reports whether responses of contentType may be compressed
+func (o Options) allowed(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return false
+	}
+	for _, allowed := range o.ContentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter decides whether to compress a response once its headers are written, buffering the start of bodies of
+// unknown length until it is clear whether they reach the minimum size
+type compressWriter struct {
+	http.ResponseWriter
+	opts     Options
+	encoding string
+
+	status      int
+	wroteHeader bool
+	buffering   bool
+	buf         bytes.Buffer
+	encoder     io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	// informational responses, such as 103 Early Hints, come before the final response headers
+	if status >= 100 && status < http.StatusOK && status != http.StatusSwitchingProtocols {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = status
+
+	header := cw.Header()
+	if !cw.compressible(status, header) {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	// the response depends on Accept-Encoding whether or not it ends up compressed
+	header.Add("Vary", "Accept-Encoding")
+
+	if length, err := strconv.Atoi(header.Get("Content-Length")); err == nil {
+		if length < cw.opts.MinSize {
+			cw.ResponseWriter.WriteHeader(status)
+			return
+		}
+		cw.startCompressing()
+		return
+	}
+	cw.buffering = true
+}
+
+// compressible reports whether a response with status and header may be compressed, given its size is large enough
+func (cw *compressWriter) compressible(status int, header http.Header) bool {
+	switch status {
+	case http.StatusSwitchingProtocols, http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	return cw.opts.allowed(header.Get("Content-Type"))
+}
+
+func (cw *compressWriter) startCompressing() {
+	header := cw.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", cw.encoding)
+	// the compressed body is a different representation, so a strong validator no longer applies to it
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	if cw.encoding == Brotli {
+		cw.encoder = brotli.NewWriterLevel(cw.ResponseWriter, brotli.DefaultCompression)
+	} else {
+		cw.encoder = gzip.NewWriter(cw.ResponseWriter)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.buffering {
+		cw.buf.Write(b)
+		if cw.buf.Len() < cw.opts.MinSize {
+			return len(b), nil
+		}
+		if err := cw.flushBuffer(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if cw.encoder != nil {
+		return cw.encoder.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// flushBuffer ends buffering, writing what has been buffered compressed or as it is
+func (cw *compressWriter) flushBuffer(compress bool) error {
+	cw.buffering = false
+	if compress {
+		cw.startCompressing()
+		_, err := cw.encoder.Write(cw.buf.Bytes())
+		return err
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+	return err
+}
+
+// Close writes any buffered response, which was too small to compress, and the end of any compressed response
+func (cw *compressWriter) Close() error {
+	if cw.buffering {
+		return cw.flushBuffer(false)
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	return nil
+}
+
+// Flush compresses streamed responses that are flushed before reaching the minimum size, and flushes what has been
+// compressed through to the client
+func (cw *compressWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.buffering {
+		if err := cw.flushBuffer(true); err != nil {
+			return
+		}
+	}
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return
+		}
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,137 @@
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNegotiate(t *testing.T) {
+	Convey("Brotli is preferred when accepted equally", t, func() {
+		So(Negotiate("gzip, deflate, br"), ShouldEqual, Brotli)
+	})
+
+	Convey("The encoding with the higher quality is chosen", t, func() {
+		So(Negotiate("br;q=0.5, gzip"), ShouldEqual, Gzip)
+	})
+
+	Convey("An encoding with a quality of zero is not used", t, func() {
+		So(Negotiate("br;q=0, gzip;q=0"), ShouldBeEmpty)
+		So(Negotiate("*;q=0.1, br;q=0"), ShouldEqual, Gzip)
+	})
+
+	Convey("Nothing is chosen when neither encoding is accepted", t, func() {
+		So(Negotiate(""), ShouldBeEmpty)
+		So(Negotiate("identity, deflate"), ShouldBeEmpty)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	body := strings.Repeat("<p>Office for National Statistics</p>", 100)
+	opts := Options{MinSize: 1024, ContentTypes: []string{"text/html", "application/*"}}
+
+	serve := func(acceptEncoding string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		Handler(opts)(handler).ServeHTTP(w, req)
+		return w
+	}
+	page := func(contentType, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("ETag", `"abc"`)
+			_, _ = w.Write([]byte(body))
+		}
+	}
+
+	Convey("Given a large HTML page from a backend that doesn't compress", t, func() {
+		Convey("When a client that accepts gzip requests it", func() {
+			w := serve("gzip", page("text/html; charset=utf-8", body))
+
+			Convey("Then it is gzipped", func() {
+				So(w.Header().Get("Content-Encoding"), ShouldEqual, Gzip)
+				So(w.Header().Get("Vary"), ShouldEqual, "Accept-Encoding")
+				So(w.Header().Get("ETag"), ShouldEqual, `W/"abc"`)
+				r, err := gzip.NewReader(w.Body)
+				So(err, ShouldBeNil)
+				b, err := io.ReadAll(r)
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, body)
+			})
+		})
+
+		Convey("When a client that accepts Brotli requests it", func() {
+			w := serve("gzip, br", page("text/html", body))
+
+			Convey("Then it is compressed with Brotli", func() {
+				So(w.Header().Get("Content-Encoding"), ShouldEqual, Brotli)
+				b, err := io.ReadAll(brotli.NewReader(w.Body))
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, body)
+			})
+		})
+
+		Convey("When a client that doesn't accept compression requests it", func() {
+			w := serve("", page("text/html", body))
+
+			Convey("Then it is sent as it is", func() {
+				So(w.Header().Get("Content-Encoding"), ShouldBeEmpty)
+				So(w.Body.String(), ShouldEqual, body)
+			})
+		})
+	})
+
+	Convey("Given a response declaring a length over the minimum size", t, func() {
+		w := serve("gzip", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(body))
+		})
+
+		Convey("Then it is compressed without the length", func() {
+			So(w.Header().Get("Content-Encoding"), ShouldEqual, Gzip)
+			So(w.Header().Get("Content-Length"), ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a response smaller than the minimum size", t, func() {
+		w := serve("gzip", page("text/html", "<p>small</p>"))
+
+		Convey("Then it is sent as it is", func() {
+			So(w.Header().Get("Content-Encoding"), ShouldBeEmpty)
+			So(w.Header().Get("Vary"), ShouldEqual, "Accept-Encoding")
+			So(w.Body.String(), ShouldEqual, "<p>small</p>")
+		})
+	})
+
+	Convey("Given a response of a content type that is not allowed", t, func() {
+		w := serve("gzip", page("image/png", body))
+
+		Convey("Then it is sent as it is", func() {
+			So(w.Header().Get("Content-Encoding"), ShouldBeEmpty)
+			So(w.Body.String(), ShouldEqual, body)
+		})
+	})
+
+	Convey("Given a response the backend has already compressed", t, func() {
+		w := serve("gzip", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Encoding", Brotli)
+			_, _ = w.Write([]byte(body))
+		})
+
+		Convey("Then it is sent as it is", func() {
+			So(w.Header().Get("Content-Encoding"), ShouldEqual, Brotli)
+			So(w.Body.String(), ShouldEqual, body)
+		})
+	})
+}
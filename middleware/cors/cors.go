@@ -0,0 +1,185 @@
+package cors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Rule is the cross-origin requests allowed to paths under PathPrefix: from Origins, or any origin if it includes "*",
+// using Methods and sending Headers. Credentials allows requests with cookies, and MaxAge is how many seconds browsers
+// may cache the answer to a preflight request for.
+type Rule struct {
+	PathPrefix  string   `json:"path_prefix"`
+	Origins     []string `json:"origins"`
+	Methods     []string `json:"methods"`
+	Headers     []string `json:"headers"`
+	Credentials bool     `json:"credentials"`
+	MaxAge      int      `json:"max_age"`
+}
+
+// ParseRules parses CORS rules from a JSON array, as read from config. Methods default to GET and HEAD.
+func ParseRules(s string) ([]Rule, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal([]byte(s), &rules); err != nil {
+		return nil, fmt.Errorf("invalid cors rules: %w", err)
+	}
+
+	for i, rule := range rules {
+		if rule.PathPrefix == "" || len(rule.Origins) == 0 {
+			return nil, errors.New("invalid cors rules: path_prefix and origins are required")
+		}
+		if rule.Credentials && rule.allowsAnyOrigin() {
+			return nil, fmt.Errorf("invalid cors rules: credentials cannot be allowed from any origin for %q", rule.PathPrefix)
+		}
+		if len(rule.Methods) == 0 {
+			rules[i].Methods = []string{http.MethodGet, http.MethodHead}
+		}
+	}
+	return rules, nil
+}
+
+func (r Rule) allowsAnyOrigin() bool {
+	for _, origin := range r.Origins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsOrigin reports whether requests from origin are allowed, comparing origins case insensitively
+func (r Rule) allowsOrigin(origin string) bool {
+	for _, allowed := range r.Origins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) allowsMethod(method string) bool {
+	for _, allowed := range r.Methods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler applies the CORS rule with the longest path prefix matching each request, answering preflight requests
+// itself and setting the CORS headers on other responses in place of any set by the backend, so that cross-origin
+// access is configured in one place. Preflight requests that are not allowed get 403 Forbidden. Requests to paths
+// without a rule are passed on as they are.
+func Handler(rules []Rule) func(h http.Handler) http.Handler {
+	sorted := helpers.LongestFirst(rules, pathPrefix)
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			rule, ok := helpers.MatchPrefix(sorted, pathPrefix, req.URL.Path)
+			if !ok {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			origin := req.Header.Get("Origin")
+			if req.Method == http.MethodOptions && origin != "" && req.Header.Get("Access-Control-Request-Method") != "" {
+				preflight(w, req, rule, origin)
+				return
+			}
+
+			h.ServeHTTP(&corsWriter{ResponseWriter: w, rule: rule, origin: origin}, req)
+		})
+	}
+}
+
+// preflight answers a preflight request, allowing it if both the origin and the requested method are allowed
+func preflight(w http.ResponseWriter, req *http.Request, rule Rule, origin string) {
+	w.Header().Add("Vary", "Origin")
+	method := req.Header.Get("Access-Control-Request-Method")
+	if !rule.allowsOrigin(origin) || !rule.allowsMethod(method) {
+		log.Info(req.Context(), "cross-origin request not allowed", log.Data{"origin": origin, "method": method, "path": req.URL.Path})
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	setAllowOrigin(w.Header(), rule, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(rule.Methods, ", "))
+	if len(rule.Headers) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(rule.Headers, ", "))
+	}
+	if rule.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(rule.MaxAge))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func setAllowOrigin(header http.Header, rule Rule, origin string) {
+	if rule.allowsAnyOrigin() {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if rule.Credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func pathPrefix(r Rule) string {
+	return r.PathPrefix
+}
+
+// corsWriter replaces any CORS headers set by the backend with those of the rule once the response headers are written
+type corsWriter struct {
+	http.ResponseWriter
+	rule        Rule
+	origin      string
+	wroteHeader bool
+}
+
+func (cw *corsWriter) WriteHeader(code int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		header := cw.Header()
+		for name := range header {
+			if strings.HasPrefix(name, "Access-Control-") {
+				header.Del(name)
+			}
+		}
+		// responses differ by origin, so caches must not serve one origin's response to another
+		header.Add("Vary", "Origin")
+		if cw.origin != "" && cw.rule.allowsOrigin(cw.origin) {
+			setAllowOrigin(header, cw.rule, cw.origin)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *corsWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush allows streamed responses to be flushed through to the client
+func (cw *corsWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *corsWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
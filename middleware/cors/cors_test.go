@@ -0,0 +1,125 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseRules(t *testing.T) {
+	Convey("Given CORS rules as configured", t, func() {
+		rules, err := ParseRules(`[{"path_prefix":"/feedback","origins":["https://census.gov.uk"],"methods":["POST"],
+			"headers":["Content-Type"],"credentials":true,"max_age":600},{"path_prefix":"/search","origins":["*"]}]`)
+
+		Convey("Then they are parsed, with methods defaulting to GET and HEAD", func() {
+			So(err, ShouldBeNil)
+			So(rules, ShouldResemble, []Rule{
+				{
+					PathPrefix:  "/feedback",
+					Origins:     []string{"https://census.gov.uk"},
+					Methods:     []string{"POST"},
+					Headers:     []string{"Content-Type"},
+					Credentials: true,
+					MaxAge:      600,
+				},
+				{PathPrefix: "/search", Origins: []string{"*"}, Methods: []string{"GET", "HEAD"}},
+			})
+		})
+	})
+
+	Convey("Given CORS rules that are not valid", t, func() {
+		for _, s := range []string{
+			`{"path_prefix":"/feedback"}`,
+			`[{"path_prefix":"/feedback"}]`,
+			`[{"path_prefix":"/feedback","origins":["*"],"credentials":true}]`,
+		} {
+			_, err := ParseRules(s)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given a CORS rule for feedback from a microsite", t, func() {
+		var handled bool
+		handler := Handler([]Rule{{
+			PathPrefix:  "/feedback",
+			Origins:     []string{"https://census.gov.uk"},
+			Methods:     []string{http.MethodPost},
+			Headers:     []string{"Content-Type"},
+			Credentials: true,
+			MaxAge:      600,
+		}})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handled = true
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			_, _ = w.Write([]byte("body"))
+		}))
+		serve := func(method, path, origin, requestMethod string) *httptest.ResponseRecorder {
+			handled = false
+			req := httptest.NewRequest(method, path, http.NoBody)
+			if origin != "" {
+				req.Header.Set("Origin", origin)
+			}
+			if requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", requestMethod)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When the microsite sends an allowed preflight request", func() {
+			w := serve(http.MethodOptions, "/feedback/thanks", "https://census.gov.uk", http.MethodPost)
+
+			Convey("Then it is answered by the router", func() {
+				So(handled, ShouldBeFalse)
+				So(w.Code, ShouldEqual, http.StatusNoContent)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldEqual, "https://census.gov.uk")
+				So(w.Header().Get("Access-Control-Allow-Methods"), ShouldEqual, "POST")
+				So(w.Header().Get("Access-Control-Allow-Headers"), ShouldEqual, "Content-Type")
+				So(w.Header().Get("Access-Control-Allow-Credentials"), ShouldEqual, "true")
+				So(w.Header().Get("Access-Control-Max-Age"), ShouldEqual, "600")
+			})
+		})
+
+		Convey("When a preflight request is sent for a method that is not allowed", func() {
+			w := serve(http.MethodOptions, "/feedback", "https://census.gov.uk", http.MethodDelete)
+
+			Convey("Then 403 Forbidden is returned without CORS headers", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the microsite sends a request", func() {
+			w := serve(http.MethodPost, "/feedback", "https://census.gov.uk", "")
+
+			Convey("Then the backend's CORS headers are replaced by the rule's", func() {
+				So(handled, ShouldBeTrue)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldEqual, "https://census.gov.uk")
+				So(w.Header().Get("Access-Control-Allow-Credentials"), ShouldEqual, "true")
+				So(w.Header().Get("Vary"), ShouldEqual, "Origin")
+			})
+		})
+
+		Convey("When any other site sends a request", func() {
+			w := serve(http.MethodPost, "/feedback", "https://example.com", "")
+
+			Convey("Then it has no CORS headers, so that the browser blocks it", func() {
+				So(handled, ShouldBeTrue)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a request is made to a path without a rule", func() {
+			w := serve(http.MethodGet, "/economy", "https://census.gov.uk", "")
+
+			Convey("Then it is passed on as it is", func() {
+				So(handled, ShouldBeTrue)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldEqual, "*")
+			})
+		})
+	})
+}
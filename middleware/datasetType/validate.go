@@ -0,0 +1,25 @@
+//nolint:revive,stylecheck // ignore var-naming Package name "datasetType" is kept for compatibility.
+package datasetType
+
+import (
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// ValidateURI is middleware that rejects requests with control characters in their path with a 400, before calls are
+// made upstream. It runs once mux has matched the route, by which point mux has already redirected any path with '.',
+// '..' or repeated slash segments to its cleaned form, so traversal is left to the path traversal middleware, which
+// runs before routing.
+func ValidateURI(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.IndexFunc(req.URL.Path, unicode.IsControl) >= 0 {
+			log.Warn(req.Context(), "rejecting request with control characters in the uri", log.Data{"path": req.URL.Path})
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,40 @@
+//nolint:revive,stylecheck // ignore var-naming Package name "datasetType" is kept for compatibility.
+package datasetType
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateURI(t *testing.T) {
+	Convey("Given the uri validation middleware", t, func() {
+		var handledPath string
+		handler := ValidateURI(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handledPath = req.URL.Path
+		}))
+
+		Convey("When a request is made with control characters in the uri", func() {
+			handledPath = ""
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/filters/abc%00def", http.NoBody))
+
+			Convey("Then 400 is returned and the request is not passed on", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(handledPath, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a request is made with a valid uri", func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/filters/abc-123/dimensions", http.NoBody))
+
+			Convey("Then the request is passed on", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(handledPath, ShouldEqual, "/filters/abc-123/dimensions")
+			})
+		})
+	})
+}
@@ -0,0 +1,126 @@
+package experiments
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// cookieMaxAge is how long a visitor stays in the bucket they were assigned
+const cookieMaxAge = 30 * 24 * time.Hour
+
+// Definition describes an experiment as configured: the requests it applies to, the cookie holding the visitor's
+// bucket, and the URL of the upstream serving each bucket. A bucket with an empty URL is a control bucket, served as if
+// there were no experiment.
+type Definition struct {
+	Name         string            `json:"name"`
+	Cookie       string            `json:"cookie"`
+	PathPrefixes []string          `json:"paths"`
+	Buckets      map[string]string `json:"buckets"`
+}
+
+// ParseDefinitions parses experiment definitions from a JSON array, as read from config
+func ParseDefinitions(s string) ([]Definition, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var defs []Definition
+	if err := json.Unmarshal([]byte(s), &defs); err != nil {
+		return nil, fmt.Errorf("invalid experiment definitions: %w", err)
+	}
+
+	for _, def := range defs {
+		if def.Name == "" || def.Cookie == "" || len(def.PathPrefixes) == 0 || len(def.Buckets) == 0 {
+			return nil, errors.New("invalid experiment definitions: name, cookie, paths and buckets are required")
+		}
+	}
+	return defs, nil
+}
+
+// Experiment routes requests for its path prefixes to the handler of the visitor's bucket
+type Experiment struct {
+	Name         string
+	Cookie       string
+	PathPrefixes []string
+	// Variants maps buckets to the handler serving them. A nil handler marks a control bucket.
+	Variants map[string]http.Handler
+}
+
+// buckets returns the experiment's buckets in a stable order, so that hashing to a bucket is deterministic
+func (e Experiment) buckets() []string {
+	buckets := make([]string, 0, len(e.Variants))
+	for bucket := range e.Variants {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+	return buckets
+}
+
+func (e Experiment) matches(path string) bool {
+	for _, prefix := range e.PathPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bucketFor returns the visitor's bucket, and whether it was newly assigned. A visitor with a valid bucket cookie keeps
+// that bucket, otherwise one is assigned from a hash of the visitor's identity.
+func (e Experiment) bucketFor(req *http.Request, idCookie string, trustedProxies []netip.Prefix) (bucket string, assigned bool) {
+	if c, err := req.Cookie(e.Cookie); err == nil {
+		if _, ok := e.Variants[c.Value]; ok {
+			return c.Value, false
+		}
+	}
+
+	buckets := e.buckets()
+	h := fnv.New32a()
+	h.Write([]byte(e.Name + "|" + ipfilter.VisitorID(req, idCookie, trustedProxies)))
+	return buckets[h.Sum32()%uint32(len(buckets))], true
+}
+
+// Handler routes requests matching each experiment to the handler for the visitor's bucket, setting a cookie so that the
+// visitor stays in that bucket. Experiments are applied in order, and the first one whose bucket has a handler serves
+// the request; requests in control buckets, or matching no experiment, are passed to the next handler. Visitors are
+// identified for bucketing by idCookie, if set and present, or their client IP through trustedProxies.
+func Handler(experiments []Experiment, idCookie string, trustedProxies []netip.Prefix) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			for _, e := range experiments {
+				if !e.matches(req.URL.Path) {
+					continue
+				}
+
+				bucket, assigned := e.bucketFor(req, idCookie, trustedProxies)
+				if assigned {
+					http.SetCookie(w, &http.Cookie{
+						Name:     e.Cookie,
+						Value:    bucket,
+						Path:     "/",
+						MaxAge:   int(cookieMaxAge.Seconds()),
+						HttpOnly: true,
+						SameSite: http.SameSiteLaxMode,
+					})
+				}
+
+				if variant := e.Variants[bucket]; variant != nil {
+					log.Info(req.Context(), "routing request to experiment variant", log.Data{"experiment": e.Name, "bucket": bucket})
+					variant.ServeHTTP(w, req)
+					return
+				}
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+}
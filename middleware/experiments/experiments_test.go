@@ -0,0 +1,129 @@
+package experiments
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func namedHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(name))
+	})
+}
+
+func TestParseDefinitions(t *testing.T) {
+	Convey("Given experiment definitions as JSON", t, func() {
+		defs, err := ParseDefinitions(`[{"name":"search","cookie":"exp_search","paths":["/search"],"buckets":{"a":"","b":"http://localhost:25001"}}]`)
+
+		Convey("Then they are parsed", func() {
+			So(err, ShouldBeNil)
+			So(defs, ShouldResemble, []Definition{{
+				Name:         "search",
+				Cookie:       "exp_search",
+				PathPrefixes: []string{"/search"},
+				Buckets:      map[string]string{"a": "", "b": "http://localhost:25001"},
+			}})
+		})
+	})
+
+	Convey("Given an experiment definition without buckets", t, func() {
+		_, err := ParseDefinitions(`[{"name":"search","cookie":"exp_search","paths":["/search"]}]`)
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given no experiment definitions", t, func() {
+		defs, err := ParseDefinitions("")
+
+		Convey("Then there are no experiments", func() {
+			So(err, ShouldBeNil)
+			So(defs, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given two experiments on different path prefixes", t, func() {
+		handler := Handler([]Experiment{
+			{
+				Name:         "search",
+				Cookie:       "exp_search",
+				PathPrefixes: []string{"/search"},
+				Variants:     map[string]http.Handler{"control": nil, "new": namedHandler("new search")},
+			},
+			{
+				Name:         "datasets",
+				Cookie:       "exp_datasets",
+				PathPrefixes: []string{"/datasets/"},
+				Variants:     map[string]http.Handler{"a": namedHandler("datasets a"), "b": namedHandler("datasets b")},
+			},
+		}, "_ga", nil)(namedHandler("default"))
+
+		serve := func(target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, target, http.NoBody)
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When visitors already bucketed make requests", func() {
+			Convey("Then each is routed to the variant for their bucket in each experiment", func() {
+				So(serve("/search", &http.Cookie{Name: "exp_search", Value: "new"}).Body.String(), ShouldEqual, "new search")
+				So(serve("/search", &http.Cookie{Name: "exp_search", Value: "control"}).Body.String(), ShouldEqual, "default")
+				So(serve("/datasets/cpih01", &http.Cookie{Name: "exp_datasets", Value: "a"}).Body.String(), ShouldEqual, "datasets a")
+				So(serve("/datasets/cpih01", &http.Cookie{Name: "exp_datasets", Value: "b"}).Body.String(), ShouldEqual, "datasets b")
+			})
+
+			Convey("Then no new bucket cookie is set", func() {
+				w := serve("/search", &http.Cookie{Name: "exp_search", Value: "new"})
+				So(w.Result().Cookies(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a request matches neither experiment", func() {
+			w := serve("/economy", &http.Cookie{Name: "exp_search", Value: "new"})
+
+			Convey("Then it is passed to the next handler without a bucket cookie", func() {
+				So(w.Body.String(), ShouldEqual, "default")
+				So(w.Result().Cookies(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a new visitor makes repeated requests", func() {
+			ga := &http.Cookie{Name: "_ga", Value: "GA1.2.12345.67890"}
+			first := serve("/datasets/cpih01", ga)
+			second := serve("/datasets/cpih01", ga)
+
+			Convey("Then they are deterministically assigned the same bucket, which is set in a cookie", func() {
+				So(first.Body.String(), ShouldEqual, second.Body.String())
+				cookies := first.Result().Cookies()
+				So(cookies, ShouldHaveLength, 1)
+				So(cookies[0].Name, ShouldEqual, "exp_datasets")
+				So(first.Body.String(), ShouldEqual, "datasets "+cookies[0].Value)
+			})
+
+			Convey("And when they return with the bucket cookie, they stay in that bucket", func() {
+				bucket := first.Result().Cookies()[0]
+				So(serve("/datasets/cpih01", &http.Cookie{Name: bucket.Name, Value: bucket.Value}).Body.String(), ShouldEqual, first.Body.String())
+			})
+		})
+
+		Convey("When a visitor has a bucket cookie for a bucket that no longer exists", func() {
+			w := serve("/datasets/cpih01", &http.Cookie{Name: "exp_datasets", Value: "retired"})
+
+			Convey("Then they are reassigned to an existing bucket", func() {
+				cookies := w.Result().Cookies()
+				So(cookies, ShouldHaveLength, 1)
+				So(cookies[0].Value, ShouldBeIn, []string{"a", "b"})
+			})
+		})
+	})
+}
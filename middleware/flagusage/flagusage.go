@@ -0,0 +1,66 @@
+package flagusage
+
+import (
+	"net/http"
+
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/gorilla/mux"
+)
+
+// Results a request for a feature-flag-gated route is classified as
+const (
+	Served   = "served"
+	Bypassed = "bypassed"
+)
+
+// Recorder counts requests for feature-flag-gated routes, as served by the route when its flag is enabled or as
+// bypassed when the flag is disabled and the request falls through to the routes after it. A nil Recorder records
+// nothing.
+type Recorder struct {
+	requests *metrics.CounterVec
+}
+
+// NewRecorder creates a Recorder, registering its counters with registry
+func NewRecorder(registry *metrics.Registry) *Recorder {
+	r := &Recorder{
+		requests: metrics.NewCounterVec("dp_frontend_router_feature_flag_route_requests_total",
+			"Requests for feature-flag-gated routes, by flag and route, as served or bypassed.", "flag", "route", "result"),
+	}
+	registry.Register(r.requests)
+	return r
+}
+
+// Handle registers h for the path template on router if enabled, counting the requests it serves against flag. If
+// disabled, requests that would have matched the path are counted as bypassed and left for the routes after it.
+func (r *Recorder) Handle(router *mux.Router, flag string, enabled bool, path string, h http.Handler) {
+	if enabled {
+		router.Handle(path, r.served(flag, path, h))
+		return
+	}
+	if r == nil {
+		return
+	}
+	// the route never matches, it only counts the requests that its path matches before they fall through
+	router.Path(path).MatcherFunc(func(req *http.Request, _ *mux.RouteMatch) bool {
+		r.requests.Inc(flag, path, Bypassed)
+		return false
+	}).Name("feature flag " + flag + " disabled")
+}
+
+// Value returns the number of requests for the route of flag with the given result
+func (r *Recorder) Value(flag, path, result string) float64 {
+	if r == nil {
+		return 0
+	}
+	return r.requests.Value(flag, path, result)
+}
+
+func (r *Recorder) served(flag, path string, h http.Handler) http.Handler {
+	if r == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.requests.Inc(flag, path, Served)
+		h.ServeHTTP(w, req)
+	})
+}
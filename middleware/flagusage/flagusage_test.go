@@ -0,0 +1,92 @@
+package flagusage
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecorder(t *testing.T) {
+	Convey("Given a recorder and a router with an enabled and a disabled flag-gated route", t, func() {
+		registry := metrics.NewRegistry()
+		recorder := NewRecorder(registry)
+
+		served := 0
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			served++
+		})
+		fallthroughs := 0
+		router := mux.NewRouter()
+		recorder.Handle(router, "on", true, "/on", handler)
+		recorder.Handle(router, "off", false, "/off/{uri:.*}", handler)
+		router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			fallthroughs++
+		})
+
+		Convey("When the enabled route is requested", func() {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/on", http.NoBody))
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/on", http.NoBody))
+
+			Convey("Then the route serves the requests and counts them as served", func() {
+				So(served, ShouldEqual, 2)
+				So(recorder.Value("on", "/on", Served), ShouldEqual, 2)
+				So(recorder.Value("on", "/on", Bypassed), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the disabled route is requested", func() {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/off/page", http.NoBody))
+
+			Convey("Then the request falls through to the next route and is counted as bypassed", func() {
+				So(served, ShouldEqual, 0)
+				So(fallthroughs, ShouldEqual, 1)
+				So(recorder.Value("off", "/off/{uri:.*}", Served), ShouldEqual, 0)
+				So(recorder.Value("off", "/off/{uri:.*}", Bypassed), ShouldEqual, 1)
+			})
+
+			Convey("Then the counts are exposed via the registry", func() {
+				var buf bytes.Buffer
+				So(registry.Write(&buf), ShouldBeNil)
+				So(buf.String(), ShouldContainSubstring,
+					`dp_frontend_router_feature_flag_route_requests_total{flag="off",route="/off/{uri:.*}",result="bypassed"} 1`)
+			})
+		})
+
+		Convey("When a path matching neither route is requested", func() {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other", http.NoBody))
+
+			Convey("Then nothing is counted", func() {
+				So(recorder.Value("on", "/on", Served), ShouldEqual, 0)
+				So(recorder.Value("off", "/off/{uri:.*}", Bypassed), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given a nil recorder", t, func() {
+		var recorder *Recorder
+		router := mux.NewRouter()
+		served := 0
+		recorder.Handle(router, "on", true, "/on", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			served++
+		}))
+		recorder.Handle(router, "off", false, "/off", http.NotFoundHandler())
+
+		Convey("Then enabled routes are still registered and disabled ones are not", func() {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/on", http.NoBody))
+			So(served, ShouldEqual, 1)
+
+			routes := 0
+			So(router.Walk(func(*mux.Route, *mux.Router, []*mux.Route) error {
+				routes++
+				return nil
+			}), ShouldBeNil)
+			So(routes, ShouldEqual, 1)
+			So(recorder.Value("on", "/on", Served), ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package forwardedproto
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// HeaderForwardedProto is the header a proxy uses to pass on the scheme that the client connected with
+const HeaderForwardedProto = "X-Forwarded-Proto"
+
+// Handler checks that the X-Forwarded-Proto header of each request agrees with whether the request arrived over TLS,
+// as a conflict indicates a misconfigured or spoofed proxy chain. Conflicting requests are logged and, if reject is
+// set, rejected with a 400. Requests without the header, or with a scheme other than http or https, are not checked.
+func Handler(reject bool) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if proto, ok := conflicts(req); ok {
+				log.Warn(req.Context(), "X-Forwarded-Proto conflicts with the TLS state of the request",
+					log.Data{"forwarded_proto": proto, "tls": req.TLS != nil, "remote_addr": req.RemoteAddr, "rejected": reject})
+				if reject {
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
+// conflicts returns the forwarded scheme of req, and whether it conflicts with the TLS state of req
+func conflicts(req *http.Request) (string, bool) {
+	header := req.Header.Get(HeaderForwardedProto)
+	if header == "" {
+		return "", false
+	}
+
+	// a chain of proxies may list a scheme per hop, the first being the one the client connected with
+	proto := strings.ToLower(strings.TrimSpace(strings.Split(header, ",")[0]))
+	switch proto {
+	case "https":
+		return proto, req.TLS == nil
+	case "http":
+		return proto, req.TLS != nil
+	default:
+		return proto, false
+	}
+}
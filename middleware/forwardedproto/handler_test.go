@@ -0,0 +1,82 @@
+package forwardedproto
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHandler(t *testing.T) {
+	Convey("Given requests with and without TLS and various X-Forwarded-Proto headers", t, func() {
+		newRequest := func(overTLS bool, forwardedProto string) *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			if overTLS {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if forwardedProto != "" {
+				req.Header.Set(HeaderForwardedProto, forwardedProto)
+			}
+			return req
+		}
+
+		Convey("When the states are consistent or unchecked, and conflicting requests are rejected", func() {
+			var nextCalled int
+			handler := Handler(true)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				nextCalled++
+			}))
+			requests := []*http.Request{
+				newRequest(true, "https"),
+				newRequest(false, "http"),
+				newRequest(true, "HTTPS"),
+				newRequest(false, "http, https"),
+				newRequest(true, ""),
+				newRequest(false, ""),
+				newRequest(false, "ws"),
+			}
+			for _, req := range requests {
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+				So(res.Code, ShouldEqual, http.StatusOK)
+			}
+
+			Convey("Then every request is passed on", func() {
+				So(nextCalled, ShouldEqual, len(requests))
+			})
+		})
+
+		Convey("When the states conflict and requests are only logged", func() {
+			var nextCalled int
+			handler := Handler(false)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				nextCalled++
+			}))
+			for _, req := range []*http.Request{newRequest(true, "http"), newRequest(false, "https")} {
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+				So(res.Code, ShouldEqual, http.StatusOK)
+			}
+
+			Convey("Then the requests are still passed on", func() {
+				So(nextCalled, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When the states conflict and requests are rejected", func() {
+			var nextCalled int
+			handler := Handler(true)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				nextCalled++
+			}))
+			for _, req := range []*http.Request{newRequest(true, "http"), newRequest(false, "https, http")} {
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+				So(res.Code, ShouldEqual, http.StatusBadRequest)
+			}
+
+			Convey("Then the requests are not passed on", func() {
+				So(nextCalled, ShouldEqual, 0)
+			})
+		})
+	})
+}
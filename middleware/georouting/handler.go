@@ -0,0 +1,148 @@
+package georouting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/ONSdigital/dp-frontend-router/geoip"
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Definition describes a geo route as configured: the path prefix it applies to, the countries it applies to, or all
+// other countries if Except is set, and either the URL to redirect those countries to or the URL of the upstream to
+// serve them from
+type Definition struct {
+	Name       string   `json:"name"`
+	PathPrefix string   `json:"path_prefix"`
+	Countries  []string `json:"countries"`
+	Except     bool     `json:"except"`
+	Redirect   string   `json:"redirect"`
+	URL        string   `json:"url"`
+}
+
+// ParseDefinitions parses geo route definitions from a JSON array, as read from config
+func ParseDefinitions(s string) ([]Definition, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var defs []Definition
+	if err := json.Unmarshal([]byte(s), &defs); err != nil {
+		return nil, fmt.Errorf("invalid geo routes: %w", err)
+	}
+
+	for _, def := range defs {
+		if def.Name == "" || def.PathPrefix == "" || len(def.Countries) == 0 {
+			return nil, errors.New("invalid geo routes: name, path_prefix and countries are required")
+		}
+		if (def.Redirect == "") == (def.URL == "") {
+			return nil, fmt.Errorf("invalid geo routes: exactly one of redirect and url is required for %q", def.Name)
+		}
+		if def.Redirect != "" {
+			if u, err := url.Parse(def.Redirect); err != nil || u.Scheme == "" || u.Host == "" {
+				return nil, fmt.Errorf("invalid geo routes: redirect for %q must be an absolute URL", def.Name)
+			}
+		}
+	}
+	return defs, nil
+}
+
+// Route sends requests to paths under PathPrefix from clients in Countries, or from clients in any other country if
+// Except is set, to Redirect, or to Handler if there is no redirect
+type Route struct {
+	Name       string
+	PathPrefix string
+	Countries  []string
+	Except     bool
+	Redirect   string
+	Handler    http.Handler
+}
+
+// applies reports whether the route applies to clients in country
+func (r Route) applies(country string) bool {
+	for _, c := range r.Countries {
+		if strings.EqualFold(c, country) {
+			return !r.Except
+		}
+	}
+	return r.Except
+}
+
+// Rules are the geo routes, and the header that the country of each client is passed to the backends in. Clients are
+// located by Locator, identified through TrustedProxies as for the IP filter.
+type Rules struct {
+	Locator        geoip.Locator
+	Routes         []Route
+	CountryHeader  string
+	TrustedProxies []netip.Prefix
+}
+
+// Enabled reports whether requests are routed or varied by country
+func (r Rules) Enabled() bool {
+	return r.Locator != nil && (len(r.Routes) > 0 || r.CountryHeader != "")
+}
+
+// Handler locates the client making each request, passes their country to the backends in the country header if set,
+// and redirects or serves requests by the route with the longest matching path prefix that applies to their country.
+// Requests from clients whose country is not known are served as they would be without geo routing. Redirects are not
+// cached, as they differ by country for the same URL.
+func Handler(rules Rules) func(h http.Handler) http.Handler {
+	routes := helpers.LongestFirst(rules.Routes, func(r Route) string { return r.PathPrefix })
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			country := locate(req, rules)
+			if rules.CountryHeader != "" {
+				// replace any value sent by the client, so that backends can trust it
+				req.Header.Del(rules.CountryHeader)
+				if country != "" {
+					req.Header.Set(rules.CountryHeader, country)
+				}
+			}
+
+			route, ok := matchRoute(routes, req.URL.Path, country)
+			switch {
+			case !ok:
+				h.ServeHTTP(w, req)
+			case route.Redirect != "":
+				w.Header().Set("Cache-Control", "no-store")
+				http.Redirect(w, req, strings.TrimSuffix(route.Redirect, "/")+req.URL.RequestURI(), http.StatusFound)
+			default:
+				route.Handler.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+// locate returns the country of the client making req, or an empty string if it cannot be located
+func locate(req *http.Request, rules Rules) string {
+	clientIP, ok := ipfilter.ClientIP(req, rules.TrustedProxies)
+	if !ok {
+		return ""
+	}
+	country, err := rules.Locator.Country(clientIP)
+	if err != nil {
+		log.Warn(req.Context(), "error looking up client country", log.Data{"client_ip": clientIP.String(), "error": err.Error()})
+		return ""
+	}
+	return country
+}
+
+func matchRoute(routes []Route, path, country string) (Route, bool) {
+	if country == "" {
+		return Route{}, false
+	}
+	for _, route := range routes {
+		if strings.HasPrefix(path, route.PathPrefix) && route.applies(country) {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
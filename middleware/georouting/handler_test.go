@@ -0,0 +1,140 @@
+package georouting
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// locatorMock locates clients by IP, failing for those it holds no country for
+type locatorMock map[string]string
+
+func (l locatorMock) Country(ip netip.Addr) (string, error) {
+	country, ok := l[ip.String()]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return country, nil
+}
+
+func TestParseDefinitions(t *testing.T) {
+	Convey("Given geo routes as configured", t, func() {
+		defs, err := ParseDefinitions(`[{"name":"international","path_prefix":"/visualisations","countries":["GB"],"except":true,
+			"redirect":"https://cdn.ons.gov.uk"}]`)
+
+		Convey("Then they are parsed", func() {
+			So(err, ShouldBeNil)
+			So(defs, ShouldResemble, []Definition{{
+				Name:       "international",
+				PathPrefix: "/visualisations",
+				Countries:  []string{"GB"},
+				Except:     true,
+				Redirect:   "https://cdn.ons.gov.uk",
+			}})
+		})
+	})
+
+	Convey("Given no geo routes", t, func() {
+		defs, err := ParseDefinitions(" ")
+
+		Convey("Then there are none", func() {
+			So(err, ShouldBeNil)
+			So(defs, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given geo routes that are not valid", t, func() {
+		for _, s := range []string{
+			`{"name":"international"}`,
+			`[{"name":"international","path_prefix":"/visualisations","redirect":"https://cdn.ons.gov.uk"}]`,
+			`[{"name":"international","path_prefix":"/visualisations","countries":["GB"]}]`,
+			`[{"name":"international","path_prefix":"/visualisations","countries":["GB"],"redirect":"https://cdn.ons.gov.uk",
+				"url":"http://localhost:20000"}]`,
+			`[{"name":"international","path_prefix":"/visualisations","countries":["GB"],"redirect":"/elsewhere"}]`,
+		} {
+			_, err := ParseDefinitions(s)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given international traffic for visualisations is redirected and US traffic for one is proxied", t, func() {
+		var live, variant bool
+		handler := Handler(Rules{
+			Locator: locatorMock{"192.0.2.1": "GB", "198.51.100.1": "FR", "203.0.113.1": "US"},
+			Routes: []Route{
+				{Name: "international", PathPrefix: "/visualisations", Countries: []string{"GB"}, Except: true, Redirect: "https://cdn.ons.gov.uk/"},
+				{
+					Name:       "us",
+					PathPrefix: "/visualisations/dvc",
+					Countries:  []string{"us"},
+					Handler:    http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { variant = true }),
+				},
+			},
+			CountryHeader: "X-Country-Code",
+		})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			live = true
+			w.Header().Set("X-Country-Code", req.Header.Get("X-Country-Code"))
+		}))
+		serve := func(path, remoteAddr string) *httptest.ResponseRecorder {
+			live, variant = false, false
+			req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("X-Country-Code", "ZZ")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When a client in the UK requests a visualisation", func() {
+			w := serve("/visualisations/dvc123?x=1", "192.0.2.1:1234")
+
+			Convey("Then it is served as usual, with its country passed to the backend", func() {
+				So(live, ShouldBeTrue)
+				So(w.Header().Get("X-Country-Code"), ShouldEqual, "GB")
+			})
+		})
+
+		Convey("When a client in France requests a visualisation", func() {
+			w := serve("/visualisations/dvc123?x=1", "198.51.100.1:1234")
+
+			Convey("Then it is redirected to the CDN, without the redirect being cached", func() {
+				So(live, ShouldBeFalse)
+				So(w.Code, ShouldEqual, http.StatusFound)
+				So(w.Header().Get("Location"), ShouldEqual, "https://cdn.ons.gov.uk/visualisations/dvc123?x=1")
+				So(w.Header().Get("Cache-Control"), ShouldEqual, "no-store")
+			})
+		})
+
+		Convey("When a client in the US requests a path under the more specific prefix", func() {
+			serve("/visualisations/dvc123", "203.0.113.1:1234")
+
+			Convey("Then it is served by the route for that prefix", func() {
+				So(variant, ShouldBeTrue)
+				So(live, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a client in France requests any other path", func() {
+			serve("/economy", "198.51.100.1:1234")
+
+			Convey("Then it is served as usual", func() {
+				So(live, ShouldBeTrue)
+			})
+		})
+
+		Convey("When a client that cannot be located requests a visualisation", func() {
+			w := serve("/visualisations/dvc123", "233.252.0.1:1234")
+
+			Convey("Then it is served as usual, without the country it claimed to be in", func() {
+				So(live, ShouldBeTrue)
+				So(w.Header().Get("X-Country-Code"), ShouldBeEmpty)
+			})
+		})
+	})
+}
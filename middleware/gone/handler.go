@@ -0,0 +1,55 @@
+package gone
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Handler returns 410 Gone, with an optional body, for requests to permanently retired paths. A path ending in '*' is
+// treated as a prefix, any other path must match exactly.
+func Handler(retiredPaths []string, body string) func(h http.Handler) http.Handler {
+	exact := make(map[string]bool)
+	var prefixes []string
+	for _, p := range retiredPaths {
+		if strings.HasSuffix(p, "*") {
+			prefixes = append(prefixes, strings.TrimSuffix(p, "*"))
+		} else {
+			exact[p] = true
+		}
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !isRetired(req.URL.Path, exact, prefixes) {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			log.Info(req.Context(), "request for retired content", log.Data{"path": req.URL.Path})
+			if body == "" {
+				w.WriteHeader(http.StatusGone)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusGone)
+			if _, err := w.Write([]byte(body)); err != nil {
+				log.Error(req.Context(), "error writing response", err)
+			}
+		})
+	}
+}
+
+func isRetired(path string, exact map[string]bool, prefixes []string) bool {
+	if exact[path] {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package gone
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHandler(t *testing.T) {
+	Convey("Given a gone handler configured with an exact path and a prefix", t, func() {
+		var handled bool
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handled = true
+		})
+		retiredPaths := []string{"/economy/retiredpage", "/ons/rel/*"}
+
+		Convey("When a request is made for the exact retired path", func() {
+			handled = false
+			w := httptest.NewRecorder()
+			Handler(retiredPaths, "")(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/economy/retiredpage", http.NoBody))
+
+			Convey("Then 410 Gone is returned without calling the next handler", func() {
+				So(w.Code, ShouldEqual, http.StatusGone)
+				So(w.Body.String(), ShouldBeEmpty)
+				So(handled, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a request is made under the retired prefix and a body is configured", func() {
+			handled = false
+			w := httptest.NewRecorder()
+			Handler(retiredPaths, "<h1>Gone</h1>")(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ons/rel/some/old/page", http.NoBody))
+
+			Convey("Then 410 Gone is returned with the configured body", func() {
+				So(w.Code, ShouldEqual, http.StatusGone)
+				So(w.Body.String(), ShouldEqual, "<h1>Gone</h1>")
+				So(handled, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a request is made for a path that is not retired", func() {
+			handled = false
+			w := httptest.NewRecorder()
+			Handler(retiredPaths, "")(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/economy/retiredpage/child", http.NoBody))
+
+			Convey("Then the request is passed to the next handler", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(handled, ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,66 @@
+package internalonly
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/netip"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Guard restricts admin and debug endpoints to internal clients: those in Ranges, identified through TrustedProxies as
+// for the IP filter, and those sending Secret in the SecretHeader header. Each restriction applies only if it is set,
+// and a request must pass every restriction that is, so a Guard with neither set allows every request.
+type Guard struct {
+	Ranges         []netip.Prefix
+	TrustedProxies []netip.Prefix
+	SecretHeader   string
+	Secret         string
+}
+
+// Enabled reports whether the guard restricts any requests
+func (g Guard) Enabled() bool {
+	return len(g.Ranges) > 0 || g.Secret != ""
+}
+
+// Handler returns 403 Forbidden for requests from clients that are not internal, without calling h
+func (g Guard) Handler(h http.Handler) http.Handler {
+	if !g.Enabled() {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !g.allowed(req) {
+			log.Warn(req.Context(), "request for internal endpoint from client that is not internal", log.Data{"path": req.URL.Path})
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+func (g Guard) allowed(req *http.Request) bool {
+	if len(g.Ranges) > 0 {
+		clientIP, ok := ipfilter.ClientIP(req, g.TrustedProxies)
+		if !ok || !inRanges(g.Ranges, clientIP) {
+			return false
+		}
+	}
+	if g.Secret != "" {
+		// compared in constant time, so that the secret cannot be guessed from how long it takes to be rejected
+		if subtle.ConstantTimeCompare([]byte(req.Header.Get(g.SecretHeader)), []byte(g.Secret)) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func inRanges(ranges []netip.Prefix, ip netip.Addr) bool {
+	for _, r := range ranges {
+		if r.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
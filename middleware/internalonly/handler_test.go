@@ -0,0 +1,94 @@
+package internalonly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGuard(t *testing.T) {
+	Convey("Given an admin endpoint", t, func() {
+		var handled bool
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handled = true
+		})
+		serve := func(guard Guard, remoteAddr, secret string) int {
+			handled = false
+			req := httptest.NewRequest(http.MethodGet, "/routes", http.NoBody)
+			req.RemoteAddr = remoteAddr
+			if secret != "" {
+				req.Header.Set("X-Admin-Secret", secret)
+			}
+			w := httptest.NewRecorder()
+			guard.Handler(next).ServeHTTP(w, req)
+			return w.Code
+		}
+		ranges := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+		Convey("When it is not guarded", func() {
+			code := serve(Guard{}, "203.0.113.9:1234", "")
+
+			Convey("Then every request is allowed", func() {
+				So(code, ShouldEqual, http.StatusOK)
+				So(handled, ShouldBeTrue)
+			})
+		})
+
+		Convey("When it is restricted to internal networks", func() {
+			guard := Guard{Ranges: ranges}
+
+			Convey("Then requests from internal networks are allowed", func() {
+				So(serve(guard, "10.1.2.3:1234", ""), ShouldEqual, http.StatusOK)
+				So(handled, ShouldBeTrue)
+			})
+
+			Convey("Then requests from any other network get 403 Forbidden", func() {
+				So(serve(guard, "203.0.113.9:1234", ""), ShouldEqual, http.StatusForbidden)
+				So(handled, ShouldBeFalse)
+			})
+		})
+
+		Convey("When it is restricted to internal networks behind a trusted proxy", func() {
+			guard := Guard{Ranges: ranges, TrustedProxies: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}}
+			forwarded := func(forwardedFor string) int {
+				req := httptest.NewRequest(http.MethodGet, "/routes", http.NoBody)
+				req.RemoteAddr = "192.0.2.1:1234"
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				w := httptest.NewRecorder()
+				guard.Handler(next).ServeHTTP(w, req)
+				return w.Code
+			}
+
+			Convey("Then requests are allowed or forbidden by the client the proxy forwarded them for", func() {
+				So(forwarded("10.1.2.3"), ShouldEqual, http.StatusOK)
+				So(forwarded("203.0.113.9"), ShouldEqual, http.StatusForbidden)
+			})
+		})
+
+		Convey("When it requires a shared secret", func() {
+			guard := Guard{SecretHeader: "X-Admin-Secret", Secret: "s3cret"}
+
+			Convey("Then requests with the secret are allowed", func() {
+				So(serve(guard, "203.0.113.9:1234", "s3cret"), ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Then requests with the wrong secret or none get 403 Forbidden", func() {
+				So(serve(guard, "203.0.113.9:1234", "guess"), ShouldEqual, http.StatusForbidden)
+				So(serve(guard, "203.0.113.9:1234", ""), ShouldEqual, http.StatusForbidden)
+			})
+		})
+
+		Convey("When it is restricted to internal networks and requires a shared secret", func() {
+			guard := Guard{Ranges: ranges, SecretHeader: "X-Admin-Secret", Secret: "s3cret"}
+
+			Convey("Then only requests from internal networks with the secret are allowed", func() {
+				So(serve(guard, "10.1.2.3:1234", "s3cret"), ShouldEqual, http.StatusOK)
+				So(serve(guard, "10.1.2.3:1234", ""), ShouldEqual, http.StatusForbidden)
+				So(serve(guard, "203.0.113.9:1234", "s3cret"), ShouldEqual, http.StatusForbidden)
+			})
+		})
+	})
+}
@@ -0,0 +1,150 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Rules are the IP ranges that are denied on every path, and the IP ranges that paths are restricted to, by path
+// prefix. Clients are identified through X-Forwarded-For only when the request comes through TrustedProxies, so that
+// clients cannot evade the rules by sending the header themselves.
+type Rules struct {
+	Deny           []netip.Prefix
+	Allow          map[string][]netip.Prefix
+	TrustedProxies []netip.Prefix
+}
+
+// Enabled reports whether there are any ranges to deny or paths to restrict
+func (r Rules) Enabled() bool {
+	return len(r.Deny) > 0 || len(r.Allow) > 0
+}
+
+// ParseRanges converts IP ranges, as read from config in CIDR notation or as single IPs, into prefixes
+func ParseRanges(ranges []string) ([]netip.Prefix, error) {
+	parsed := make([]netip.Prefix, 0, len(ranges))
+	for _, r := range ranges {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		if !strings.Contains(r, "/") {
+			addr, err := netip.ParseAddr(r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IP range %q: %w", r, err)
+			}
+			parsed = append(parsed, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP range %q: %w", r, err)
+		}
+		parsed = append(parsed, prefix.Masked())
+	}
+	return parsed, nil
+}
+
+// ParseAllowLists converts a map of path prefix to IP ranges, as read from config separated by spaces, into prefixes
+func ParseAllowLists(lists map[string]string) (map[string][]netip.Prefix, error) {
+	parsed := make(map[string][]netip.Prefix, len(lists))
+	for path, ranges := range lists {
+		prefixes, err := ParseRanges(strings.Fields(ranges))
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow list for prefix %q: %w", path, err)
+		}
+		parsed[path] = prefixes
+	}
+	return parsed, nil
+}
+
+// Handler returns 403 Forbidden for requests from clients in a denied range, and for requests to a path restricted by
+// the longest matching prefix from clients outside the ranges it is restricted to. Requests whose client cannot be
+// identified are only allowed on paths that are not restricted.
+func Handler(rules Rules) func(h http.Handler) http.Handler {
+	allow := helpers.NewPrefixMap(rules.Allow)
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			clientIP, ok := ClientIP(req, rules.TrustedProxies)
+			if ok && contains(rules.Deny, clientIP) {
+				log.Info(req.Context(), "request from denied IP", log.Data{"client_ip": clientIP.String(), "path": req.URL.Path})
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			if path, allowed, restricted := allow.Match(req.URL.Path); restricted && (!ok || !contains(allowed, clientIP)) {
+				log.Info(req.Context(), "request from IP not allowed on restricted path",
+					log.Data{"client_ip": clientIP.String(), "path": req.URL.Path, "restricted_prefix": path})
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
+// ClientIP returns the IP of the client making the request. If the request comes through one of trustedProxies, the
+// client is the last X-Forwarded-For entry that is not a trusted proxy itself, as the entries before it could have
+// been sent by the client. It returns false if the client cannot be identified.
+func ClientIP(req *http.Request, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	ip = ip.Unmap()
+
+	forwarded := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && contains(trustedProxies, ip); i-- {
+		entry := strings.TrimSpace(forwarded[i])
+		if entry == "" {
+			continue
+		}
+		forwardedIP, err := netip.ParseAddr(entry)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		ip = forwardedIP.Unmap()
+	}
+	return ip, true
+}
+
+// ClientKey identifies the client making the request as ClientIP does, for limiting its requests, falling back to the
+// remote address if it cannot be identified through trustedProxies
+func ClientKey(req *http.Request, trustedProxies []netip.Prefix) string {
+	if clientIP, ok := ClientIP(req, trustedProxies); ok {
+		return clientIP.String()
+	}
+	return req.RemoteAddr
+}
+
+// VisitorID identifies the visitor making the request, so that they can be bucketed consistently into experiments and
+// canaries. A visitor is identified by the idCookie if it is set and present, or as ClientKey identifies them otherwise,
+// so that a visitor cannot choose their bucket by sending their own X-Forwarded-For.
+func VisitorID(req *http.Request, idCookie string, trustedProxies []netip.Prefix) string {
+	if idCookie != "" {
+		if c, err := req.Cookie(idCookie); err == nil && c.Value != "" {
+			return c.Value
+		}
+	}
+	return ClientKey(req, trustedProxies)
+}
+
+func contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package ipfilter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseRanges(t *testing.T) {
+	Convey("Given IP ranges and single IPs as configured", t, func() {
+		ranges, err := ParseRanges([]string{"10.0.0.0/8", " 192.0.2.1 ", "2001:db8::/32", "198.51.100.7/24"})
+
+		Convey("Then they are parsed into prefixes, with single IPs matching only themselves", func() {
+			So(err, ShouldBeNil)
+			So(ranges, ShouldResemble, []netip.Prefix{
+				netip.MustParsePrefix("10.0.0.0/8"),
+				netip.MustParsePrefix("192.0.2.1/32"),
+				netip.MustParsePrefix("2001:db8::/32"),
+				netip.MustParsePrefix("198.51.100.0/24"),
+			})
+		})
+	})
+
+	Convey("Given an IP range that is not valid", t, func() {
+		_, err := ParseRanges([]string{"10.0.0.0/33"})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestParseAllowLists(t *testing.T) {
+	Convey("Given allow lists by path prefix as configured", t, func() {
+		lists, err := ParseAllowLists(map[string]string{"/admin": "10.0.0.0/8 192.0.2.1"})
+
+		Convey("Then their space separated ranges are parsed", func() {
+			So(err, ShouldBeNil)
+			So(lists, ShouldResemble, map[string][]netip.Prefix{
+				"/admin": {netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")},
+			})
+		})
+	})
+
+	Convey("Given an allow list with a range that is not valid", t, func() {
+		_, err := ParseAllowLists(map[string]string{"/admin": "10.0.0.0/8 office"})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	request := func(remoteAddr string, forwardedFor ...string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.RemoteAddr = remoteAddr
+		for _, f := range forwardedFor {
+			req.Header.Add("X-Forwarded-For", f)
+		}
+		return req
+	}
+
+	Convey("A request that does not come through a trusted proxy is from its remote address", t, func() {
+		ip, ok := ClientIP(request("203.0.113.9:1234", "192.0.2.1"), trusted)
+		So(ok, ShouldBeTrue)
+		So(ip.String(), ShouldEqual, "203.0.113.9")
+	})
+
+	Convey("A request through trusted proxies is from the last X-Forwarded-For entry that is not trusted", t, func() {
+		ip, ok := ClientIP(request("10.0.0.1:1234", "192.0.2.1, 203.0.113.9", "10.0.0.2"), trusted)
+		So(ok, ShouldBeTrue)
+		So(ip.String(), ShouldEqual, "203.0.113.9")
+	})
+
+	Convey("A request through trusted proxies only is from the first X-Forwarded-For entry", t, func() {
+		ip, ok := ClientIP(request("10.0.0.1:1234", "10.0.0.3, 10.0.0.2"), trusted)
+		So(ok, ShouldBeTrue)
+		So(ip.String(), ShouldEqual, "10.0.0.3")
+	})
+
+	Convey("A request through a trusted proxy with a malformed X-Forwarded-For entry cannot be identified", t, func() {
+		_, ok := ClientIP(request("10.0.0.1:1234", "unknown"), trusted)
+		So(ok, ShouldBeFalse)
+	})
+}
+
+func TestVisitorID(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	request := func(remoteAddr, forwardedFor string, cookies ...*http.Cookie) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		return req
+	}
+
+	Convey("A visitor with the id cookie is identified by it", t, func() {
+		req := request("203.0.113.9:1234", "", &http.Cookie{Name: "_ga", Value: "GA1.2.3"})
+		So(VisitorID(req, "_ga", trusted), ShouldEqual, "GA1.2.3")
+	})
+
+	Convey("A visitor without the id cookie is identified by their client IP, through the trusted proxies", t, func() {
+		So(VisitorID(request("10.0.0.1:1234", "203.0.113.9"), "_ga", trusted), ShouldEqual, "203.0.113.9")
+	})
+
+	Convey("A visitor cannot choose their identity by sending their own X-Forwarded-For", t, func() {
+		So(VisitorID(request("203.0.113.9:1234", "192.0.2.1"), "_ga", trusted), ShouldEqual, "203.0.113.9")
+		So(VisitorID(request("203.0.113.9:1234", "192.0.2.1"), "", trusted), ShouldEqual, "203.0.113.9")
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given an IP filter denying a range and restricting a path to another", t, func() {
+		var handled bool
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handled = true
+		})
+		handler := Handler(Rules{
+			Deny:           []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")},
+			Allow:          map[string][]netip.Prefix{"/admin": {netip.MustParsePrefix("192.0.2.0/24")}},
+			TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		})(next)
+		serve := func(path, forwardedFor string) *httptest.ResponseRecorder {
+			handled = false
+			req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When a client in the denied range makes a request", func() {
+			w := serve("/economy", "198.51.100.7")
+
+			Convey("Then 403 Forbidden is returned without calling the next handler", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(handled, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a client in the denied range claims to be forwarded for another", func() {
+			w := serve("/economy", "192.0.2.1, 198.51.100.7")
+
+			Convey("Then 403 Forbidden is still returned", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+			})
+		})
+
+		Convey("When a client outside the allowed range requests the restricted path", func() {
+			w := serve("/admin/users", "203.0.113.9")
+
+			Convey("Then 403 Forbidden is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(handled, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a client in the allowed range requests the restricted path", func() {
+			serve("/admin/users", "192.0.2.1")
+
+			Convey("Then the next handler is called", func() {
+				So(handled, ShouldBeTrue)
+			})
+		})
+
+		Convey("When any other client requests an unrestricted path", func() {
+			serve("/economy", "203.0.113.9")
+
+			Convey("Then the next handler is called", func() {
+				So(handled, ShouldBeTrue)
+			})
+		})
+	})
+}
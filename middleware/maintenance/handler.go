@@ -0,0 +1,103 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// defaultBody is served in maintenance mode when no body is configured
+const defaultBody = `<!DOCTYPE html><html lang="en"><head><title>Sorry, this service is unavailable</title></head>` +
+	`<body><h1>Sorry, this service is unavailable</h1><p>We are carrying out planned maintenance. Please try again later.</p>` +
+	`</body></html>`
+
+// Mode is whether the router is in maintenance mode, which can be switched on and off while running. It outlives the
+// router, so that it is kept when the router is rebuilt.
+type Mode struct {
+	enabled atomic.Bool
+}
+
+// New creates a Mode, in maintenance mode if enabled
+func New(enabled bool) *Mode {
+	m := &Mode{}
+	m.enabled.Store(enabled)
+	return m
+}
+
+// Enabled reports whether the router is in maintenance mode
+func (m *Mode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Set switches maintenance mode on or off
+func (m *Mode) Set(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// Handler returns 503 Service Unavailable with body, or a default page if body is empty, for every request while in
+// maintenance mode, telling clients to retry after retryAfter. Health probes must be answered before this handler, so
+// that instances in maintenance mode are not replaced as unhealthy.
+func (m *Mode) Handler(body string, retryAfter time.Duration) func(h http.Handler) http.Handler {
+	if body == "" {
+		body = defaultBody
+	}
+	retryAfterSeconds := strconv.Itoa(int(retryAfter.Seconds()))
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !m.Enabled() {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("Retry-After", retryAfterSeconds)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			if req.Method == http.MethodHead {
+				return
+			}
+			if _, err := w.Write([]byte(body)); err != nil {
+				log.Error(req.Context(), "error writing response", err)
+			}
+		})
+	}
+}
+
+// AdminHandler reports whether the router is in maintenance mode, as JSON, and switches it on or off when POSTed to
+// with enabled=true or enabled=false
+func (m *Mode) AdminHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			enabled, err := strconv.ParseBool(req.FormValue("enabled"))
+			if err != nil {
+				http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+				return
+			}
+			m.Set(enabled)
+			log.Info(req.Context(), "maintenance mode switched", log.Data{"enabled": enabled})
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		b, err := json.Marshal(map[string]bool{"enabled": m.Enabled()})
+		if err != nil {
+			log.Error(req.Context(), "error marshalling maintenance mode", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(b); err != nil {
+			log.Error(req.Context(), "error writing response", err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package maintenance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHandler(t *testing.T) {
+	Convey("Given a maintenance mode handler", t, func() {
+		var handled bool
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handled = true
+		})
+		mode := New(false)
+		handler := mode.Handler("", 10*time.Minute)(next)
+
+		Convey("When a request is made while not in maintenance mode", func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then the next handler is called", func() {
+				So(handled, ShouldBeTrue)
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("When a request is made once maintenance mode is switched on", func() {
+			mode.Set(true)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then the default maintenance page is returned with 503 and Retry-After", func() {
+				So(handled, ShouldBeFalse)
+				So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(w.Header().Get("Retry-After"), ShouldEqual, "600")
+				So(w.Header().Get("Cache-Control"), ShouldEqual, "no-store")
+				So(w.Body.String(), ShouldEqual, defaultBody)
+			})
+		})
+	})
+
+	Convey("Given a maintenance mode handler with a body, in maintenance mode", t, func() {
+		handler := New(true).Handler("<p>Back soon</p>", time.Minute)(http.NotFoundHandler())
+
+		Convey("When a request is made", func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then the body is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(w.Header().Get("Retry-After"), ShouldEqual, "60")
+				So(w.Body.String(), ShouldEqual, "<p>Back soon</p>")
+			})
+		})
+	})
+}
+
+func TestAdminHandler(t *testing.T) {
+	Convey("Given the maintenance mode admin handler", t, func() {
+		mode := New(false)
+		admin := mode.AdminHandler()
+		post := func(form url.Values) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/maintenance", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			admin.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When maintenance mode is requested", func() {
+			w := httptest.NewRecorder()
+			admin.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/maintenance", http.NoBody))
+
+			Convey("Then whether it is enabled is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"enabled":false}`)
+			})
+		})
+
+		Convey("When maintenance mode is switched on", func() {
+			w := post(url.Values{"enabled": {"true"}})
+
+			Convey("Then it is enabled", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"enabled":true}`)
+				So(mode.Enabled(), ShouldBeTrue)
+			})
+		})
+
+		Convey("When maintenance mode is switched to an invalid value", func() {
+			w := post(url.Values{"enabled": {"maybe"}})
+
+			Convey("Then 400 Bad Request is returned and it is left as it is", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(mode.Enabled(), ShouldBeFalse)
+			})
+		})
+
+		Convey("When any other method is used", func() {
+			w := httptest.NewRecorder()
+			admin.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/maintenance", http.NoBody))
+
+			Convey("Then 405 Method Not Allowed is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusMethodNotAllowed)
+				So(w.Header().Get("Allow"), ShouldEqual, "GET, POST")
+			})
+		})
+	})
+}
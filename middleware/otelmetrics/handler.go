@@ -0,0 +1,45 @@
+package otelmetrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+// MeterName identifies the instruments recorded by the router
+const MeterName = "github.com/ONSdigital/dp-frontend-router"
+
+// Handler counts the requests in flight as an OpenTelemetry up-down counter of meter. Request durations are recorded by
+// the otelhttp middleware, through the same meter provider.
+func Handler(meter metric.Meter) func(h http.Handler) http.Handler {
+	active, err := meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Number of requests being served by the router"))
+	if err != nil {
+		log.Warn(context.Background(), "error creating active requests instrument, requests in flight are not counted",
+			log.Data{"error": err.Error()})
+		return func(h http.Handler) http.Handler { return h }
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			attrs := metric.WithAttributes(semconv.HTTPMethod(methodAttribute(req.Method)))
+			active.Add(req.Context(), 1, attrs)
+			defer active.Add(req.Context(), -1, attrs)
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
+// methodAttribute returns method, or "_OTHER" for non-standard methods, so that clients cannot create unbounded series
+func methodAttribute(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "_OTHER"
+}
@@ -0,0 +1,73 @@
+package otelmetrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeMeter records the values added to its up-down counters by their method attribute
+type fakeMeter struct {
+	noop.Meter
+	mu     sync.Mutex
+	values map[string]int64
+}
+
+type fakeUpDownCounter struct {
+	noop.Int64UpDownCounter
+	meter *fakeMeter
+}
+
+func (m *fakeMeter) Int64UpDownCounter(name string, options ...metric.Int64UpDownCounterOption) (metric.Int64UpDownCounter, error) {
+	return fakeUpDownCounter{meter: m}, nil
+}
+
+func (c fakeUpDownCounter) Add(ctx context.Context, incr int64, options ...metric.AddOption) {
+	attrs := metric.NewAddConfig(options).Attributes()
+	method, _ := attrs.Value(semconv.HTTPMethodKey)
+	c.meter.mu.Lock()
+	defer c.meter.mu.Unlock()
+	c.meter.values[method.AsString()] += incr
+}
+
+func (m *fakeMeter) value(method string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.values[method]
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given the handler in front of a handler that reports the requests in flight", t, func() {
+		meter := &fakeMeter{values: map[string]int64{}}
+		var inFlight int64
+		handler := Handler(meter)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			inFlight = meter.value(http.MethodGet)
+		}))
+
+		Convey("When a request is served", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+			Convey("Then it is counted as active while it is served, and not once it has been", func() {
+				So(inFlight, ShouldEqual, 1)
+				So(meter.value(http.MethodGet), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a request has a non-standard method", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PURGE", "/", http.NoBody))
+
+			Convey("Then it is attributed to other methods", func() {
+				So(meter.values, ShouldContainKey, "_OTHER")
+				So(meter.values, ShouldNotContainKey, "PURGE")
+			})
+		})
+	})
+}
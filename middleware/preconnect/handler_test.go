@@ -0,0 +1,43 @@
+package preconnect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHandler(t *testing.T) {
+	Convey("Given a preconnect handler for the download origin on dataset pages", t, func() {
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("Link", "</css/main.css>; rel=\"preload\"")
+		})
+		handler := Handler("https://download.ons.gov.uk", []string{"/datasets/", "/file"})(next)
+
+		serve := func(target string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+			return w
+		}
+
+		Convey("When a configured page is requested", func() {
+			w := serve("/datasets/cpih01/editions/time-series/versions/1")
+
+			Convey("Then the preconnect header is added alongside any other Link headers", func() {
+				So(w.Header().Values("Link"), ShouldResemble, []string{
+					"<https://download.ons.gov.uk>; rel=\"preconnect\"",
+					"</css/main.css>; rel=\"preload\"",
+				})
+			})
+		})
+
+		Convey("When any other page is requested", func() {
+			w := serve("/economy")
+
+			Convey("Then no preconnect header is added", func() {
+				So(w.Header().Values("Link"), ShouldResemble, []string{"</css/main.css>; rel=\"preload\""})
+			})
+		})
+	})
+}
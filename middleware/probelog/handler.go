@@ -0,0 +1,76 @@
+package probelog
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Mode describes how requests from health and metrics probes are access logged
+type Mode string
+
+// Possible probe logging modes
+const (
+	// Full logs probe requests like any other request
+	Full Mode = "full"
+	// Minimal logs probe requests only when they fail, as there is no debug level to demote them to
+	Minimal Mode = "minimal"
+	// Suppress never logs probe requests
+	Suppress Mode = "suppress"
+)
+
+// logFailedProbe logs a probe request that failed in minimal mode
+var logFailedProbe = func(req *http.Request, status int, started, ended time.Time) {
+	log.Warn(req.Context(), "probe request failed", log.HTTP(req, status, 0, &started, &ended))
+}
+
+// ParseMode converts a mode name, as read from config, into a Mode
+func ParseMode(name string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case Full, Minimal, Suppress:
+		return mode, nil
+	case "":
+		return Full, nil
+	default:
+		return "", fmt.Errorf("invalid probe logging mode %q", name)
+	}
+}
+
+// Handler wraps accessLog so that requests for the probe paths are logged according to mode, while all other requests
+// are passed to accessLog as usual
+func Handler(probePaths []string, mode Mode, accessLog func(http.Handler) http.Handler) func(h http.Handler) http.Handler {
+	probes := make(map[string]bool, len(probePaths))
+	for _, p := range probePaths {
+		probes[p] = true
+	}
+
+	return func(h http.Handler) http.Handler {
+		logged := accessLog(h)
+		if mode == Full || mode == "" || len(probes) == 0 {
+			return logged
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !probes[req.URL.Path] {
+				logged.ServeHTTP(w, req)
+				return
+			}
+
+			if mode == Suppress {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			started := time.Now()
+			sw := helpers.NewStatusWriter(w)
+			h.ServeHTTP(sw, req)
+			if sw.Status() >= http.StatusBadRequest {
+				logFailedProbe(req, sw.Status(), started, time.Now())
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package probelog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseMode(t *testing.T) {
+	Convey("Given mode names from config", t, func() {
+		Convey("Then known and empty names are parsed, and unknown names are rejected", func() {
+			mode, err := ParseMode("Suppress")
+			So(err, ShouldBeNil)
+			So(mode, ShouldEqual, Suppress)
+
+			mode, err = ParseMode("")
+			So(err, ShouldBeNil)
+			So(mode, ShouldEqual, Full)
+
+			_, err = ParseMode("debug")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given an access logger and a handler", t, func() {
+		var accessLogged []string
+		accessLog := func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				accessLogged = append(accessLogged, req.URL.Path)
+				h.ServeHTTP(w, req)
+			})
+		}
+
+		var failedProbes []int
+		logFailedProbe = func(req *http.Request, status int, started, ended time.Time) {
+			failedProbes = append(failedProbes, status)
+		}
+
+		status := http.StatusOK
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(status)
+		})
+
+		serve := func(handler http.Handler, target string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+			return w
+		}
+
+		Convey("When probe logging is suppressed", func() {
+			handler := Handler([]string{"/health", "/metrics"}, Suppress, accessLog)(next)
+			health := serve(handler, "/health")
+			serve(handler, "/metrics")
+			serve(handler, "/economy")
+
+			Convey("Then probe requests are served without being logged, and normal requests are logged", func() {
+				So(health.Code, ShouldEqual, http.StatusOK)
+				So(accessLogged, ShouldResemble, []string{"/economy"})
+			})
+		})
+
+		Convey("When probe logging is minimal", func() {
+			handler := Handler([]string{"/health"}, Minimal, accessLog)(next)
+			serve(handler, "/health")
+			status = http.StatusServiceUnavailable
+			failed := serve(handler, "/health")
+			status = http.StatusOK
+			serve(handler, "/economy")
+
+			Convey("Then only the failed probe request is logged, outside the access log", func() {
+				So(failed.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(failedProbes, ShouldResemble, []int{http.StatusServiceUnavailable})
+				So(accessLogged, ShouldResemble, []string{"/economy"})
+			})
+		})
+
+		Convey("When probe logging is full", func() {
+			handler := Handler([]string{"/health"}, Full, accessLog)(next)
+			serve(handler, "/health")
+			serve(handler, "/economy")
+
+			Convey("Then probe requests are logged like any other", func() {
+				So(accessLogged, ShouldResemble, []string{"/health", "/economy"})
+			})
+		})
+	})
+}
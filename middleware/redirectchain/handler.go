@@ -0,0 +1,132 @@
+package redirectchain
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+var errMaxHops = errors.New("too many chained redirects")
+
+// Handler resolves redirects issued by the stages internally, following them through the stages again until the path
+// is no longer redirected. The visitor is then sent a single redirect to the final location, rather than being
+// bounced through each hop. If more than maxHops redirects are followed, the chain is assumed to loop and a 500 is
+// returned. Requests that no stage redirects are passed to the next handler untouched.
+func Handler(maxHops int, stages ...func(http.Handler) http.Handler) func(h http.Handler) http.Handler {
+	// the stages are run against a terminal handler that marks the request as passing through them unredirected
+	var chain http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.(*hopRecorder).passed = true
+	})
+	for i := len(stages) - 1; i >= 0; i-- {
+		chain = stages[i](chain)
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			current := req
+			status := http.StatusPermanentRedirect
+			for hops := 0; ; hops++ {
+				rec := &hopRecorder{header: make(http.Header)}
+				chain.ServeHTTP(rec, current)
+
+				location, redirected := rec.redirect(current)
+				if !redirected {
+					if hops == 0 {
+						if rec.passed {
+							h.ServeHTTP(w, req)
+							return
+						}
+						// a stage responded without redirecting, so its response is served as it was
+						chain.ServeHTTP(w, req)
+						return
+					}
+					log.Info(req.Context(), "resolved chained redirects", log.Data{"hops": hops, "location": current.URL.String()})
+					http.Redirect(w, req, current.URL.String(), status)
+					return
+				}
+
+				if hops >= maxHops {
+					log.Error(req.Context(), "maximum chained redirects exceeded", errMaxHops,
+						log.Data{"max_hops": maxHops, "path": req.URL.Path, "location": location.String()})
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					return
+				}
+
+				status = chainStatus(status, rec.status)
+
+				if location.Host != "" && location.Host != req.Host {
+					// an external redirect can't be followed internally, so it ends the chain
+					http.Redirect(w, req, location.String(), status)
+					return
+				}
+				current = current.Clone(current.Context())
+				current.URL = location
+				current.RequestURI = location.RequestURI()
+			}
+		})
+	}
+}
+
+// chainStatus returns the status of a chain of redirects with status so far that is followed by a hop with status hop.
+// The chain is only permanent if every hop is, and only preserves the request method and body if every hop does.
+func chainStatus(status, hop int) int {
+	permanent := isPermanent(status) && isPermanent(hop)
+	preservesMethod := isMethodPreserving(status) && isMethodPreserving(hop)
+	switch {
+	case permanent && preservesMethod:
+		return http.StatusPermanentRedirect
+	case preservesMethod:
+		return http.StatusTemporaryRedirect
+	case permanent:
+		return http.StatusMovedPermanently
+	default:
+		return http.StatusFound
+	}
+}
+
+func isPermanent(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
+
+func isMethodPreserving(status int) bool {
+	return status == http.StatusTemporaryRedirect || status == http.StatusPermanentRedirect
+}
+
+// hopRecorder records the redirect, if any, issued by the stages for a single hop, discarding the body
+type hopRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	passed      bool
+}
+
+func (r *hopRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *hopRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		r.status = code
+	}
+}
+
+func (r *hopRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return len(b), nil
+}
+
+// redirect returns the location redirected to, resolved against the request URL
+func (r *hopRecorder) redirect(req *http.Request) (*url.URL, bool) {
+	if r.passed || r.status < 300 || r.status >= 400 {
+		return nil, false
+	}
+
+	location, err := req.URL.Parse(r.header.Get("Location"))
+	if err != nil || r.header.Get("Location") == "" {
+		return nil, false
+	}
+	return location, true
+}
@@ -0,0 +1,136 @@
+package redirectchain
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// redirectStage redirects requests for the paths in redirects, with the given status
+func redirectStage(status int, redirects map[string]string) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if to, ok := redirects[req.URL.Path]; ok {
+				http.Redirect(w, req, to, status)
+				return
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
+// trailingSlashStage strips trailing slashes
+func trailingSlashStage(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(req.URL.Path) > 1 && strings.HasSuffix(req.URL.Path, "/") {
+			http.Redirect(w, req, strings.TrimSuffix(req.URL.Path, "/"), http.StatusMovedPermanently)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given a file redirect stage, a canonical redirect stage and a trailing slash stage", t, func() {
+		var nextPaths []string
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			nextPaths = append(nextPaths, req.URL.Path)
+		})
+
+		stages := []func(http.Handler) http.Handler{
+			redirectStage(http.StatusPermanentRedirect, map[string]string{"/submit": "/submissions/", "/form": "/forms"}),
+			redirectStage(http.StatusTemporaryRedirect, map[string]string{
+				"/old":       "/older/",
+				"/loop-a":    "/loop-b",
+				"/loop-b":    "/loop-a",
+				"/elsewhere": "https://www.example.com/page",
+			}),
+			redirectStage(http.StatusMovedPermanently, map[string]string{"/older": "/canonical/"}),
+			trailingSlashStage,
+		}
+
+		method := http.MethodGet
+		serve := func(maxHops int, target string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			Handler(maxHops, stages...)(next).ServeHTTP(w, httptest.NewRequest(method, target, http.NoBody))
+			return w
+		}
+
+		Convey("When a request is rewritten through several stages within the maximum hops", func() {
+			w := serve(5, "/old")
+
+			Convey("Then a single redirect is sent to the final location", func() {
+				So(w.Code, ShouldEqual, http.StatusFound)
+				So(w.Header().Get("Location"), ShouldEqual, "/canonical")
+				So(nextPaths, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When every hop in the chain is permanent", func() {
+			w := serve(5, "/older/")
+
+			Convey("Then the single redirect is permanent", func() {
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(w.Header().Get("Location"), ShouldEqual, "/canonical")
+			})
+		})
+
+		Convey("When a POST is redirected by a single hop that preserves the method", func() {
+			method = http.MethodPost
+			w := serve(5, "/form")
+
+			Convey("Then the redirect still preserves the method", func() {
+				So(w.Code, ShouldEqual, http.StatusPermanentRedirect)
+				So(w.Header().Get("Location"), ShouldEqual, "/forms")
+			})
+		})
+
+		Convey("When a chain has a hop that does not preserve the method", func() {
+			method = http.MethodPost
+			w := serve(5, "/submit")
+
+			Convey("Then the single redirect does not preserve it either", func() {
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(w.Header().Get("Location"), ShouldEqual, "/submissions")
+			})
+		})
+
+		Convey("When the same request needs more hops than the maximum", func() {
+			w := serve(2, "/old")
+
+			Convey("Then 500 is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+				So(nextPaths, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When redirects loop", func() {
+			w := serve(10, "/loop-a")
+
+			Convey("Then the cap is hit and 500 is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+
+		Convey("When a stage redirects to another host", func() {
+			w := serve(5, "/elsewhere")
+
+			Convey("Then the redirect is sent as it is", func() {
+				So(w.Code, ShouldEqual, http.StatusTemporaryRedirect)
+				So(w.Header().Get("Location"), ShouldEqual, "https://www.example.com/page")
+			})
+		})
+
+		Convey("When no stage redirects the request", func() {
+			w := serve(5, "/economy")
+
+			Convey("Then it is passed to the next handler", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(nextPaths, ShouldResemble, []string{"/economy"})
+			})
+		})
+	})
+}
@@ -0,0 +1,291 @@
+package redirectmap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of redirect maps
+const (
+	FormatCSV  = "csv"
+	FormatYAML = "yaml"
+)
+
+// DefaultStatus is the status of redirects that do not set their own, as redirect maps are for URLs that have moved
+// for good
+const DefaultStatus = http.StatusMovedPermanently
+
+// Redirect sends requests for the path From to To, with Status
+type Redirect struct {
+	From   string `yaml:"from"`
+	To     string `yaml:"to"`
+	Status int    `yaml:"status"`
+}
+
+// Parse parses redirects in format: CSV, with a from, to and optional status column in each row, or YAML, with a list
+// of redirects with from, to and optional status keys. The map is rejected if any redirect is invalid, or a path is
+// redirected more than once, so that mistakes are not silently shipped.
+func Parse(b []byte, format string) (map[string]Redirect, error) {
+	var redirects []Redirect
+	var err error
+	switch format {
+	case FormatCSV:
+		redirects, err = parseCSV(b)
+	case FormatYAML:
+		redirects, err = parseYAML(b)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]Redirect, len(redirects))
+	for i, redirect := range redirects {
+		if err := redirect.validate(); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		if _, ok := byPath[redirect.From]; ok {
+			return nil, fmt.Errorf("entry %d: %q is redirected more than once", i+1, redirect.From)
+		}
+		if redirect.Status == 0 {
+			redirect.Status = DefaultStatus
+		}
+		byPath[redirect.From] = redirect
+	}
+	return byPath, nil
+}
+
+// formatOf returns the format of a redirect map from the extension of its file name or path
+func formatOf(name string) (string, bool) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		return FormatCSV, true
+	case ".yaml", ".yml":
+		return FormatYAML, true
+	}
+	return "", false
+}
+
+func parseCSV(b []byte) ([]Redirect, error) {
+	reader := csv.NewReader(bytes.NewReader(b))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var redirects []Redirect
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return redirects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// a header row is allowed, so that the file can be edited in a spreadsheet
+		if line == 1 && strings.EqualFold(record[0], "from") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %d: expected from, to and an optional status", line)
+		}
+
+		redirect := Redirect{From: record[0], To: record[1]}
+		if len(record) == 3 && record[2] != "" {
+			if redirect.Status, err = strconv.Atoi(record[2]); err != nil {
+				return nil, fmt.Errorf("line %d: invalid status %q", line, record[2])
+			}
+		}
+		redirects = append(redirects, redirect)
+	}
+}
+
+func parseYAML(b []byte) ([]Redirect, error) {
+	var redirects []Redirect
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&redirects); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return redirects, nil
+}
+
+func (r Redirect) validate() error {
+	if !strings.HasPrefix(r.From, "/") {
+		return fmt.Errorf("from %q must be a path", r.From)
+	}
+	if r.To == "" {
+		return fmt.Errorf("no to for %q", r.From)
+	}
+	switch r.Status {
+	case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return nil
+	}
+	return fmt.Errorf("invalid status %d for %q", r.Status, r.From)
+}
+
+// Map holds the redirects loaded from a source, reloading them every interval if they have changed, so that URL
+// migrations can be shipped without a release. If a reload fails, the redirects already loaded are kept.
+type Map struct {
+	source   Source
+	interval time.Duration
+
+	mu        sync.RWMutex
+	redirects map[string]Redirect
+	version   string
+	checksum  [sha256.Size]byte
+
+	started bool
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// New creates a Map of the redirects from source, returning an error if they cannot be loaded. An interval of zero
+// loads the redirects once, without reloading them.
+func New(ctx context.Context, source Source, interval time.Duration) (*Map, error) {
+	m := &Map{
+		source:   source,
+		interval: interval,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	if _, err := m.reload(ctx); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Start checks the source for changes every interval, until Close is called
+func (m *Map) Start(ctx context.Context) {
+	if m.interval <= 0 {
+		return
+	}
+
+	m.started = true
+	go func() {
+		defer close(m.stopped)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.poll(ctx)
+			case <-m.done:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops checking the source for changes, waiting for a reload in progress to finish
+func (m *Map) Close() error {
+	close(m.done)
+	if m.started {
+		<-m.stopped
+	}
+	return nil
+}
+
+func (m *Map) poll(ctx context.Context) {
+	reloaded, err := m.reload(ctx)
+	if err != nil {
+		log.Error(ctx, "error reloading redirect map, keeping the redirects already loaded", err, log.Data{"source": m.source.String()})
+		return
+	}
+	if reloaded {
+		log.Info(ctx, "redirect map reloaded", log.Data{"source": m.source.String(), "redirects": m.Len()})
+	}
+}
+
+// reload loads the redirects if they have changed since they were last loaded, reporting whether it did
+func (m *Map) reload(ctx context.Context) (bool, error) {
+	m.mu.RLock()
+	version := m.version
+	m.mu.RUnlock()
+
+	content, err := m.source.Fetch(ctx, version)
+	if errors.Is(err, ErrNotModified) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// sources that cannot tell whether the map has changed return it every time, so it is only parsed if it has
+	checksum := sha256.Sum256(content.Body)
+	m.mu.Lock()
+	unchanged := m.redirects != nil && checksum == m.checksum
+	if unchanged {
+		m.version = content.Version
+	}
+	m.mu.Unlock()
+	if unchanged {
+		return false, nil
+	}
+
+	redirects, err := Parse(content.Body, content.Format)
+	if err != nil {
+		return false, fmt.Errorf("invalid redirect map %s: %w", m.source, err)
+	}
+
+	m.mu.Lock()
+	m.redirects = redirects
+	m.version = content.Version
+	m.checksum = checksum
+	m.mu.Unlock()
+	return true, nil
+}
+
+// Len returns the number of redirects loaded
+func (m *Map) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.redirects)
+}
+
+// Lookup returns the redirect for path, if there is one
+func (m *Map) Lookup(path string) (Redirect, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	redirect, ok := m.redirects[path]
+	return redirect, ok
+}
+
+// Handler redirects requests for the paths in the map, keeping the query of the request if the redirect has none of
+// its own, so that campaign parameters survive the move
+func (m *Map) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		redirect, ok := m.Lookup(req.URL.Path)
+		if !ok {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		location := redirect.To
+		if req.URL.RawQuery != "" {
+			if to, err := url.Parse(redirect.To); err == nil && to.RawQuery == "" {
+				to.RawQuery = req.URL.RawQuery
+				location = to.String()
+			}
+		}
+		log.Info(req.Context(), "redirect found", log.Data{"location": location, "status": redirect.Status}, log.HTTP(req, 0, 0, nil, nil))
+		http.Redirect(w, req, location, redirect.Status)
+	})
+}
@@ -0,0 +1,139 @@
+package redirectmap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestParse(t *testing.T) {
+	Convey("Given a CSV redirect map with a header row", t, func() {
+		b := []byte("from,to,status\n/old,/new\n/retired,https://example.com/moved,308\n")
+
+		Convey("Then the redirects are parsed, defaulting to a permanent redirect", func() {
+			redirects, err := Parse(b, FormatCSV)
+			So(err, ShouldBeNil)
+			So(redirects, ShouldResemble, map[string]Redirect{
+				"/old":     {From: "/old", To: "/new", Status: http.StatusMovedPermanently},
+				"/retired": {From: "/retired", To: "https://example.com/moved", Status: http.StatusPermanentRedirect},
+			})
+		})
+	})
+
+	Convey("Given a YAML redirect map", t, func() {
+		b := []byte("- from: /old\n  to: /new\n  status: 302\n")
+
+		Convey("Then the redirects are parsed", func() {
+			redirects, err := Parse(b, FormatYAML)
+			So(err, ShouldBeNil)
+			So(redirects, ShouldResemble, map[string]Redirect{"/old": {From: "/old", To: "/new", Status: http.StatusFound}})
+		})
+	})
+
+	Convey("Given redirect maps that are not valid", t, func() {
+		for _, m := range []struct{ content, format string }{
+			{"/old\n", FormatCSV},
+			{"old,/new\n", FormatCSV},
+			{"/old,/new,200\n", FormatCSV},
+			{"/old,/new\n/old,/newer\n", FormatCSV},
+			{"- from: /old\n  target: /new\n", FormatYAML},
+			{"/old,/new\n", "txt"},
+		} {
+			_, err := Parse([]byte(m.content), m.format)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestMap(t *testing.T) {
+	Convey("Given a redirect map that is reloaded", t, func() {
+		file := writeFile(t, t.TempDir(), "redirects.csv", "/old,/new\n")
+		m, err := New(context.Background(), NewFileSource(file), 10*time.Millisecond)
+		So(err, ShouldBeNil)
+		m.Start(context.Background())
+		defer m.Close()
+
+		var handled bool
+		handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handled = true
+		}))
+		serve := func(target string) *httptest.ResponseRecorder {
+			handled = false
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+			return w
+		}
+
+		Convey("When a path in the map is requested", func() {
+			w := serve("/old?utm_source=email")
+
+			Convey("Then it is redirected, keeping the query", func() {
+				So(handled, ShouldBeFalse)
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(w.Header().Get("Location"), ShouldEqual, "/new?utm_source=email")
+			})
+		})
+
+		Convey("When a path not in the map is requested", func() {
+			serve("/economy")
+
+			Convey("Then it is passed on", func() {
+				So(handled, ShouldBeTrue)
+			})
+		})
+
+		Convey("When the file is changed", func() {
+			writeFile(t, filepath.Dir(file), "redirects.csv", "/old,/newer\n/retired,/moved\n")
+			// make sure the modification time changes on filesystems with a coarse resolution
+			So(os.Chtimes(file, time.Now(), time.Now().Add(time.Minute)), ShouldBeNil)
+
+			Convey("Then the new redirects are loaded", func() {
+				So(waitFor(func() bool { return m.Len() == 2 }), ShouldBeTrue)
+				So(serve("/old").Header().Get("Location"), ShouldEqual, "/newer")
+			})
+		})
+
+		Convey("When the file is changed to be invalid", func() {
+			writeFile(t, filepath.Dir(file), "redirects.csv", "/old\n")
+			So(os.Chtimes(file, time.Now(), time.Now().Add(time.Minute)), ShouldBeNil)
+			time.Sleep(50 * time.Millisecond)
+
+			Convey("Then the redirects already loaded are kept", func() {
+				So(serve("/old").Header().Get("Location"), ShouldEqual, "/new")
+			})
+		})
+	})
+
+	Convey("Given a redirect map that cannot be loaded", t, func() {
+		_, err := New(context.Background(), NewFileSource(filepath.Join(t.TempDir(), "missing.csv")), time.Minute)
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func waitFor(condition func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package redirectmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ErrNotModified is returned by a Source whose redirect map has not changed since the version asked about
+var ErrNotModified = errors.New("redirect map not modified")
+
+// Content is a redirect map as fetched from a Source, in Format, with a Version identifying it
+type Content struct {
+	Body    []byte
+	Format  string
+	Version string
+}
+
+// Source is where a redirect map is loaded from
+type Source interface {
+	// Fetch returns the redirect map, or ErrNotModified if it is still at version. An empty version always fetches it.
+	Fetch(ctx context.Context, version string) (Content, error)
+	// String describes the source in logs
+	String() string
+}
+
+// NewRemoteSource returns the source of a redirect map at rawURL: an s3://bucket/key URL for an S3 object, or an http
+// or https URL, such as a Zebedee endpoint, fetched with client
+func NewRemoteSource(ctx context.Context, rawURL string, client dphttp.Clienter) (Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect map source %s: %w", rawURL, err)
+	}
+
+	switch u.Scheme {
+	case "s3":
+		return NewS3Source(ctx, u.Host, strings.TrimPrefix(u.Path, "/"))
+	case "http", "https":
+		return NewHTTPSource(client, rawURL), nil
+	}
+	return nil, fmt.Errorf("invalid redirect map source %s: must be an s3, http or https URL", rawURL)
+}
+
+// FileSource is a redirect map in a CSV or YAML file, versioned by its modification time
+type FileSource struct {
+	path string
+}
+
+// NewFileSource creates a Source for the redirect map in the file at path
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+// Fetch reads the file if it has been modified since version
+func (s *FileSource) Fetch(ctx context.Context, version string) (Content, error) {
+	format, ok := formatOf(s.path)
+	if !ok {
+		return Content{}, fmt.Errorf("invalid redirect map %s: must be a .csv, .yaml or .yml file", s.path)
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return Content{}, err
+	}
+	modified := info.ModTime().UTC().String()
+	if modified == version {
+		return Content{}, ErrNotModified
+	}
+
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return Content{}, err
+	}
+	return Content{Body: b, Format: format, Version: modified}, nil
+}
+
+func (s *FileSource) String() string {
+	return s.path
+}
+
+// HTTPSource is a redirect map served over HTTP, such as by a Zebedee endpoint, versioned by its ETag so that an
+// unchanged map is not downloaded again. Its format is taken from the extension of the URL path, or its content type.
+type HTTPSource struct {
+	client dphttp.Clienter
+	url    string
+}
+
+// NewHTTPSource creates a Source for the redirect map at url, fetched with client
+func NewHTTPSource(client dphttp.Clienter, url string) *HTTPSource {
+	return &HTTPSource{client: client, url: url}
+}
+
+// Fetch gets the redirect map, unless its ETag still matches version
+func (s *HTTPSource) Fetch(ctx context.Context, version string) (Content, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, http.NoBody)
+	if err != nil {
+		return Content{}, fmt.Errorf("error creating redirect map request: %w", err)
+	}
+	if version != "" {
+		req.Header.Set("If-None-Match", version)
+	}
+
+	resp, err := s.client.Do(ctx, req)
+	if err != nil {
+		return Content{}, fmt.Errorf("error getting redirect map: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return Content{}, ErrNotModified
+	default:
+		return Content{}, fmt.Errorf("redirect map source responded with %d", resp.StatusCode)
+	}
+
+	format, ok := formatOf(req.URL.Path)
+	if !ok {
+		format, ok = formatOfContentType(resp.Header.Get("Content-Type"))
+	}
+	if !ok {
+		return Content{}, fmt.Errorf("unknown format of redirect map %s with content type %q", s.url, resp.Header.Get("Content-Type"))
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Content{}, fmt.Errorf("error reading redirect map: %w", err)
+	}
+	return Content{Body: b, Format: format, Version: resp.Header.Get("ETag")}, nil
+}
+
+func (s *HTTPSource) String() string {
+	return s.url
+}
+
+func formatOfContentType(contentType string) (string, bool) {
+	switch {
+	case strings.Contains(contentType, "csv"):
+		return FormatCSV, true
+	case strings.Contains(contentType, "yaml"):
+		return FormatYAML, true
+	}
+	return "", false
+}
+
+// S3Client is the part of the S3 client used to get redirect maps
+type S3Client interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3Source is a redirect map in an S3 object, versioned by its ETag so that an unchanged map is not downloaded again.
+// Its format is taken from the extension of the key.
+type S3Source struct {
+	client S3Client
+	bucket string
+	key    string
+}
+
+// NewS3Source creates a Source for the redirect map in the S3 object at key in bucket, using the default AWS config
+func NewS3Source(ctx context.Context, bucket, key string) (*S3Source, error) {
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid redirect map source s3://%s/%s: bucket and key are required", bucket, key)
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Source{client: s3.NewFromConfig(cfg), bucket: bucket, key: key}, nil
+}
+
+// Fetch gets the object, unless its ETag still matches version
+func (s *S3Source) Fetch(ctx context.Context, version string) (Content, error) {
+	format, ok := formatOf(s.key)
+	if !ok {
+		return Content{}, fmt.Errorf("invalid redirect map %s: must be a .csv, .yaml or .yml object", s)
+	}
+
+	input := &s3.GetObjectInput{Bucket: &s.bucket, Key: &s.key}
+	if version != "" {
+		input.IfNoneMatch = &version
+	}
+	out, err := s.client.GetObject(ctx, input)
+	if err != nil {
+		var statusErr interface{ HTTPStatusCode() int }
+		if errors.As(err, &statusErr) && statusErr.HTTPStatusCode() == http.StatusNotModified {
+			return Content{}, ErrNotModified
+		}
+		return Content{}, fmt.Errorf("error getting redirect map: %w", err)
+	}
+	defer out.Body.Close()
+
+	b, err := io.ReadAll(out.Body)
+	if err != nil {
+		return Content{}, fmt.Errorf("error reading redirect map: %w", err)
+	}
+	content := Content{Body: b, Format: format}
+	if out.ETag != nil {
+		content.Version = *out.ETag
+	}
+	return content, nil
+}
+
+func (s *S3Source) String() string {
+	return "s3://" + s.bucket + "/" + s.key
+}
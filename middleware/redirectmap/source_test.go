@@ -0,0 +1,202 @@
+package redirectmap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFileSource(t *testing.T) {
+	Convey("Given a file source", t, func() {
+		source := NewFileSource(writeFile(t, t.TempDir(), "redirects.yml", "- from: /old\n  to: /new\n"))
+
+		Convey("Then the file is fetched, with its format and version", func() {
+			content, err := source.Fetch(context.Background(), "")
+			So(err, ShouldBeNil)
+			So(string(content.Body), ShouldEqual, "- from: /old\n  to: /new\n")
+			So(content.Format, ShouldEqual, FormatYAML)
+			So(content.Version, ShouldNotBeEmpty)
+
+			Convey("And it is not fetched again while it is unchanged", func() {
+				_, err := source.Fetch(context.Background(), content.Version)
+				So(err, ShouldEqual, ErrNotModified)
+			})
+		})
+	})
+
+	Convey("Given a file source with an unknown extension", t, func() {
+		source := NewFileSource(writeFile(t, t.TempDir(), "redirects.txt", "/old,/new\n"))
+
+		Convey("Then an error is returned", func() {
+			_, err := source.Fetch(context.Background(), "")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a file source for a file that does not exist", t, func() {
+		source := NewFileSource(filepath.Join(t.TempDir(), "missing.csv"))
+
+		Convey("Then an error is returned", func() {
+			_, err := source.Fetch(context.Background(), "")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestHTTPSource(t *testing.T) {
+	Convey("Given an HTTP source", t, func() {
+		var ifNoneMatch string
+		contentType := "text/csv; charset=utf-8"
+		client := &dphttp.ClienterMock{
+			DoFunc: func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				ifNoneMatch = req.Header.Get("If-None-Match")
+				if ifNoneMatch == `"v1"` {
+					return &http.Response{StatusCode: http.StatusNotModified, Header: http.Header{}, Body: http.NoBody}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Etag": {`"v1"`}, "Content-Type": {contentType}},
+					Body:       io.NopCloser(strings.NewReader("/old,/new\n")),
+				}, nil
+			},
+		}
+
+		Convey("When the redirect map is fetched", func() {
+			content, err := NewHTTPSource(client, "http://localhost:8082/redirects").Fetch(context.Background(), "")
+
+			Convey("Then its format is taken from its content type and its version from its ETag", func() {
+				So(err, ShouldBeNil)
+				So(ifNoneMatch, ShouldBeEmpty)
+				So(string(content.Body), ShouldEqual, "/old,/new\n")
+				So(content.Format, ShouldEqual, FormatCSV)
+				So(content.Version, ShouldEqual, `"v1"`)
+			})
+		})
+
+		Convey("When a redirect map with an extension is fetched", func() {
+			contentType = "text/plain"
+			content, err := NewHTTPSource(client, "http://localhost:8082/redirects.csv?lang=en").Fetch(context.Background(), "")
+
+			Convey("Then its format is taken from the extension", func() {
+				So(err, ShouldBeNil)
+				So(content.Format, ShouldEqual, FormatCSV)
+			})
+		})
+
+		Convey("When the redirect map is fetched at the version already loaded", func() {
+			_, err := NewHTTPSource(client, "http://localhost:8082/redirects").Fetch(context.Background(), `"v1"`)
+
+			Convey("Then it is not modified", func() {
+				So(ifNoneMatch, ShouldEqual, `"v1"`)
+				So(err, ShouldEqual, ErrNotModified)
+			})
+		})
+
+		Convey("When the format of the redirect map cannot be told", func() {
+			contentType = "application/octet-stream"
+			_, err := NewHTTPSource(client, "http://localhost:8082/redirects").Fetch(context.Background(), "")
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given an HTTP source that responds with an error", t, func() {
+		client := &dphttp.ClienterMock{
+			DoFunc: func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: http.NoBody}, nil
+			},
+		}
+
+		Convey("Then an error is returned", func() {
+			_, err := NewHTTPSource(client, "http://localhost:8082/redirects.csv").Fetch(context.Background(), "")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+type fakeS3Client struct {
+	input *s3.GetObjectInput
+	out   *s3.GetObjectOutput
+	err   error
+}
+
+func (c *fakeS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	c.input = params
+	return c.out, c.err
+}
+
+type statusError int
+
+func (e statusError) Error() string       { return http.StatusText(int(e)) }
+func (e statusError) HTTPStatusCode() int { return int(e) }
+
+func TestS3Source(t *testing.T) {
+	Convey("Given an S3 source", t, func() {
+		etag := `"v1"`
+		client := &fakeS3Client{out: &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader("/old,/new\n")), ETag: &etag}}
+		source := &S3Source{client: client, bucket: "redirects", key: "frontend/redirects.csv"}
+
+		Convey("When the object is fetched", func() {
+			content, err := source.Fetch(context.Background(), "")
+
+			Convey("Then its format is taken from the key and its version from its ETag", func() {
+				So(err, ShouldBeNil)
+				So(*client.input.Bucket, ShouldEqual, "redirects")
+				So(*client.input.Key, ShouldEqual, "frontend/redirects.csv")
+				So(client.input.IfNoneMatch, ShouldBeNil)
+				So(string(content.Body), ShouldEqual, "/old,/new\n")
+				So(content.Format, ShouldEqual, FormatCSV)
+				So(content.Version, ShouldEqual, `"v1"`)
+			})
+		})
+
+		Convey("When the object has not been modified", func() {
+			client.err = errors.Join(errors.New("operation error S3: GetObject"), statusError(http.StatusNotModified))
+			_, err := source.Fetch(context.Background(), `"v1"`)
+
+			Convey("Then ErrNotModified is returned", func() {
+				So(*client.input.IfNoneMatch, ShouldEqual, `"v1"`)
+				So(err, ShouldEqual, ErrNotModified)
+			})
+		})
+
+		Convey("When the object cannot be got", func() {
+			client.err = statusError(http.StatusForbidden)
+			_, err := source.Fetch(context.Background(), "")
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err, ShouldNotEqual, ErrNotModified)
+			})
+		})
+	})
+}
+
+func TestNewRemoteSource(t *testing.T) {
+	Convey("Given an http URL", t, func() {
+		source, err := NewRemoteSource(context.Background(), "http://localhost:8082/redirects", &dphttp.ClienterMock{})
+
+		Convey("Then an HTTP source is returned", func() {
+			So(err, ShouldBeNil)
+			So(source, ShouldHaveSameTypeAs, &HTTPSource{})
+			So(source.String(), ShouldEqual, "http://localhost:8082/redirects")
+		})
+	})
+
+	Convey("Given URLs that are not valid sources", t, func() {
+		for _, rawURL := range []string{"ftp://localhost/redirects.csv", "s3://redirects", "redirects.csv"} {
+			_, err := NewRemoteSource(context.Background(), rawURL, &dphttp.ClienterMock{})
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package redirectrules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Definition is a redirect rule as configured. Requests whose whole path matches the regular expression Pattern are
+// redirected to To, which may refer to the pattern's capture groups as $1 or ${name}. Alternatively, requests for
+// PathPrefix or a path under it are redirected to the rest of their path under the base path To, less its first
+// DropSegments segments, so that a whole section of the site can be moved. Either way they are redirected with Status.
+type Definition struct {
+	Pattern      string `json:"pattern"`
+	PathPrefix   string `json:"path_prefix"`
+	DropSegments int    `json:"drop_segments"`
+	To           string `json:"to"`
+	Status       int    `json:"status"`
+}
+
+// Rule is a redirect rule with its pattern compiled
+type Rule struct {
+	expr         string
+	pattern      *regexp.Regexp
+	prefix       string
+	dropSegments int
+	to           string
+	status       int
+}
+
+// ParseRules parses redirect rules from a JSON array, as read from config. Rules are permanent (301) redirects unless
+// they set a status.
+func ParseRules(s string) ([]Rule, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var definitions []Definition
+	if err := json.Unmarshal([]byte(s), &definitions); err != nil {
+		return nil, fmt.Errorf("invalid redirect rules: %w", err)
+	}
+
+	rules := make([]Rule, 0, len(definitions))
+	for _, def := range definitions {
+		rule, err := newRule(def)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redirect rules: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func newRule(def Definition) (Rule, error) {
+	if (def.Pattern == "") == (def.PathPrefix == "") || def.To == "" {
+		return Rule{}, errors.New("to and one of pattern or path_prefix are required")
+	}
+
+	rule := Rule{expr: def.Pattern, to: def.To, status: def.Status}
+	if def.PathPrefix != "" {
+		if !strings.HasPrefix(def.PathPrefix, "/") || def.DropSegments < 0 {
+			return Rule{}, fmt.Errorf("path_prefix %q must be a path, with drop_segments of 0 or more", def.PathPrefix)
+		}
+		rule.expr = def.PathPrefix
+		rule.prefix = strings.TrimSuffix(def.PathPrefix, "/")
+		rule.dropSegments = def.DropSegments
+		rule.to = strings.TrimSuffix(def.To, "/")
+	} else {
+		// the pattern must match the whole path, so that /ons/rel/(.*) does not also match /visualisations/ons/rel/
+		pattern, err := regexp.Compile(`^(?:` + def.Pattern + `)$`)
+		if err != nil {
+			return Rule{}, fmt.Errorf("pattern %q: %w", def.Pattern, err)
+		}
+		rule.pattern = pattern
+	}
+
+	switch rule.status {
+	case 0:
+		rule.status = http.StatusMovedPermanently
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return Rule{}, fmt.Errorf("invalid status %d for %q", def.Status, rule.expr)
+	}
+	return rule, nil
+}
+
+// Location returns where a request for path is redirected to by the rule, if it matches
+func (r Rule) Location(path string) (string, bool) {
+	if r.pattern == nil {
+		return r.rewrite(path)
+	}
+	match := r.pattern.FindStringSubmatchIndex(path)
+	if match == nil {
+		return "", false
+	}
+	return string(r.pattern.ExpandString(nil, r.to, path, match)), true
+}
+
+// rewrite returns path with the prefix of the rule replaced by its base path, less the segments it drops
+func (r Rule) rewrite(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, r.prefix)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+		return "", false
+	}
+
+	segments := strings.Split(strings.TrimPrefix(rest, "/"), "/")
+	if rest == "" || r.dropSegments >= len(segments) {
+		segments = nil
+	} else {
+		segments = segments[r.dropSegments:]
+	}
+	if len(segments) == 0 {
+		if r.to == "" {
+			return "/", true
+		}
+		return r.to, true
+	}
+	return r.to + "/" + strings.Join(segments, "/"), true
+}
+
+// String returns the pattern or path prefix of the rule as configured
+func (r Rule) String() string {
+	return r.expr
+}
+
+// Match returns the first rule that redirects path, and where to. A rule that would redirect a path to itself is
+// ignored.
+func Match(rules []Rule, path string) (Rule, string, bool) {
+	for _, rule := range rules {
+		if location, ok := rule.Location(path); ok && location != path {
+			return rule, location, true
+		}
+	}
+	return Rule{}, "", false
+}
+
+// Handler redirects requests according to the first rule that matches their path, keeping the query of the request
+// if the redirect has none of its own. A rule that would redirect a path to itself is ignored.
+func Handler(rules []Rule) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			rule, location, ok := Match(rules, req.URL.Path)
+			if !ok {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			if req.URL.RawQuery != "" {
+				if to, err := url.Parse(location); err == nil && to.RawQuery == "" {
+					to.RawQuery = req.URL.RawQuery
+					location = to.String()
+				}
+			}
+			log.Info(req.Context(), "redirect rule matched", log.Data{"pattern": rule.expr, "location": location})
+			http.Redirect(w, req, location, rule.status)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package redirectrules
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseRules(t *testing.T) {
+	Convey("Given redirect rules as configured", t, func() {
+		rules, err := ParseRules(`[{"pattern":"/ons/rel/(.*)","to":"/timeseries/$1"},
+			{"pattern":"/(?P<topic>[a-z]+)/bulletins/latest","to":"/${topic}/releases","status":302}]`)
+
+		Convey("Then they are parsed, defaulting to a permanent redirect", func() {
+			So(err, ShouldBeNil)
+			So(rules, ShouldHaveLength, 2)
+			So(rules[0].status, ShouldEqual, http.StatusMovedPermanently)
+			So(rules[1].status, ShouldEqual, http.StatusFound)
+		})
+
+		Convey("Then capture groups are substituted into the location", func() {
+			location, ok := rules[0].Location("/ons/rel/cpi/march-2024")
+			So(ok, ShouldBeTrue)
+			So(location, ShouldEqual, "/timeseries/cpi/march-2024")
+
+			location, ok = rules[1].Location("/economy/bulletins/latest")
+			So(ok, ShouldBeTrue)
+			So(location, ShouldEqual, "/economy/releases")
+		})
+
+		Convey("Then a pattern only matches the whole path", func() {
+			_, ok := rules[0].Location("/visualisations/ons/rel/cpi")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Then the first rule matching a path is found, with its pattern as configured", func() {
+			rule, location, ok := Match(rules, "/economy/bulletins/latest")
+			So(ok, ShouldBeTrue)
+			So(rule.String(), ShouldEqual, "/(?P<topic>[a-z]+)/bulletins/latest")
+			So(location, ShouldEqual, "/economy/releases")
+
+			_, _, ok = Match(rules, "/economy")
+			So(ok, ShouldBeFalse)
+		})
+	})
+
+	Convey("Given a redirect rule for a path prefix", t, func() {
+		rules, err := ParseRules(`[{"path_prefix":"/economy/inflationandpriceindices/","to":"/prices","status":308},
+			{"path_prefix":"/businessindustryandtrade/retailindustry","drop_segments":1,"to":"/retail/"}]`)
+
+		Convey("Then it is parsed", func() {
+			So(err, ShouldBeNil)
+			So(rules, ShouldHaveLength, 2)
+			So(rules[0].status, ShouldEqual, http.StatusPermanentRedirect)
+			So(rules[0].String(), ShouldEqual, "/economy/inflationandpriceindices/")
+		})
+
+		Convey("Then the rest of a path under the prefix is moved onto the new base path", func() {
+			location, ok := rules[0].Location("/economy/inflationandpriceindices/bulletins/consumerpriceinflation/march2024")
+			So(ok, ShouldBeTrue)
+			So(location, ShouldEqual, "/prices/bulletins/consumerpriceinflation/march2024")
+
+			location, ok = rules[0].Location("/economy/inflationandpriceindices")
+			So(ok, ShouldBeTrue)
+			So(location, ShouldEqual, "/prices")
+		})
+
+		Convey("Then the segments the rule drops are removed from the rest of the path", func() {
+			location, ok := rules[1].Location("/businessindustryandtrade/retailindustry/bulletins/retailsales")
+			So(ok, ShouldBeTrue)
+			So(location, ShouldEqual, "/retail/retailsales")
+
+			location, ok = rules[1].Location("/businessindustryandtrade/retailindustry/bulletins")
+			So(ok, ShouldBeTrue)
+			So(location, ShouldEqual, "/retail")
+		})
+
+		Convey("Then the prefix only matches whole segments", func() {
+			_, ok := rules[0].Location("/economy/inflationandpriceindicesarchive")
+			So(ok, ShouldBeFalse)
+			_, ok = rules[0].Location("/economy")
+			So(ok, ShouldBeFalse)
+		})
+	})
+
+	Convey("Given redirect rules that are not valid", t, func() {
+		for _, s := range []string{
+			`[{"pattern":"/ons/rel/(.*)","path_prefix":"/ons/rel","to":"/timeseries"}]`,
+			`[{"path_prefix":"ons/rel","to":"/timeseries"}]`,
+			`[{"path_prefix":"/ons/rel","drop_segments":-1,"to":"/timeseries"}]`,
+			`[{"path_prefix":"/ons/rel","to":"/timeseries","status":200}]`,
+			`{"pattern":"/ons/rel/(.*)","to":"/timeseries/$1"}`,
+			`[{"pattern":"/ons/rel/(.*"}]`,
+			`[{"pattern":"/ons/rel/(.*","to":"/timeseries/$1"}]`,
+			`[{"pattern":"/ons/rel/(.*)","to":"/timeseries/$1","status":200}]`,
+		} {
+			_, err := ParseRules(s)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given redirect rules", t, func() {
+		rules, err := ParseRules(`[{"pattern":"/ons/rel/(.*)","to":"/timeseries/$1"},{"pattern":"/ons/(.*)","to":"/ons/$1"},
+			{"path_prefix":"/economy/inflationandpriceindices","to":"/prices"}]`)
+		So(err, ShouldBeNil)
+
+		var handled bool
+		handler := Handler(rules)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handled = true
+		}))
+		serve := func(target string) *httptest.ResponseRecorder {
+			handled = false
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+			return w
+		}
+
+		Convey("When a matching path is requested", func() {
+			w := serve("/ons/rel/cpi?page=2")
+
+			Convey("Then it is redirected according to the first matching rule, keeping the query", func() {
+				So(handled, ShouldBeFalse)
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(w.Header().Get("Location"), ShouldEqual, "/timeseries/cpi?page=2")
+			})
+		})
+
+		Convey("When a path under a prefix is requested", func() {
+			w := serve("/economy/inflationandpriceindices/datasets/cpi?edition=2024")
+
+			Convey("Then it is redirected under the new base path, keeping the query", func() {
+				So(handled, ShouldBeFalse)
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(w.Header().Get("Location"), ShouldEqual, "/prices/datasets/cpi?edition=2024")
+			})
+		})
+
+		Convey("When a path that a rule would redirect to itself is requested", func() {
+			serve("/ons/about")
+
+			Convey("Then it is passed on", func() {
+				So(handled, ShouldBeTrue)
+			})
+		})
+
+		Convey("When a path that matches no rule is requested", func() {
+			serve("/economy")
+
+			Convey("Then it is passed on", func() {
+				So(handled, ShouldBeTrue)
+			})
+		})
+	})
+}
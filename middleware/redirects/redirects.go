@@ -5,17 +5,29 @@ import (
 	"context"
 	"encoding/csv"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/ONSdigital/log.go/v2/log"
 )
 
-var redirects = make(map[string]string)
+// DefaultStatus is the status of redirects in redirects.csv that do not set their own
+const DefaultStatus = http.StatusTemporaryRedirect
+
+type redirect struct {
+	to     string
+	status int
+}
+
+var redirects = make(map[string]redirect)
 
 // PanicOnInitError (when true) causes Init() to panic if redirects.csv
 // contains invalid data
 var PanicOnInitError = true
 
+// Init loads the redirects from redirects.csv, whose rows are a from and to URL and, optionally, the status to redirect
+// with: 301, 302, 303, 307 or 308. Redirects without a status are temporary (307).
 func Init(asset func(name string) ([]byte, error)) {
 	b, err := asset("redirects/redirects.csv")
 	if err != nil {
@@ -27,6 +39,8 @@ func Init(asset func(name string) ([]byte, error)) {
 	}
 
 	reader := csv.NewReader(bytes.NewReader(b))
+	// the status column is optional, so rows may have two or three fields
+	reader.FieldsPerRecord = -1
 	records, err := reader.ReadAll()
 	if err != nil {
 		log.Error(context.Background(), "error reading redirects.csv", err)
@@ -62,8 +76,17 @@ func handleRecords(records [][]string) {
 					continue
 				}
 
-				log.Info(context.Background(), "adding redirect", log.Data{"from": record[0], "to": record[1]})
-				redirects[record[0]] = record[1]
+				status, ok := parseStatus(record)
+				if !ok {
+					log.Warn(context.Background(), "redirect status invalid", log.Data{"line": line, "status": record[2]})
+					if PanicOnInitError {
+						panic("redirect status invalid, check logs")
+					}
+					continue
+				}
+
+				log.Info(context.Background(), "adding redirect", log.Data{"from": record[0], "to": record[1], "status": status})
+				redirects[record[0]] = redirect{to: record[1], status: status}
 			} else {
 				log.Warn(context.Background(), "redirect is missing 'to' value", log.Data{"line": line})
 				if PanicOnInitError {
@@ -74,26 +97,67 @@ func handleRecords(records [][]string) {
 	}
 }
 
-// Handler with temporary redirect
+// parseStatus returns the status in the optional third column of a record, or DefaultStatus if it is empty
+func parseStatus(record []string) (int, bool) {
+	if len(record) < 3 || record[2] == "" {
+		return DefaultStatus, true
+	}
+	status, err := strconv.Atoi(record[2])
+	if err != nil {
+		return 0, false
+	}
+	return status, ValidStatus(status)
+}
+
+// ValidStatus reports whether status is one that a redirect can be made with
+func ValidStatus(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// Handler redirects requests for the paths in redirects.csv, with the status of each redirect
 func Handler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if redirect, ok := redirects[req.URL.Path]; ok {
-			log.Info(req.Context(), "redirect found", log.Data{"location": redirect}, log.HTTP(req, 0, 0, nil, nil))
-			http.Redirect(w, req, redirect, http.StatusTemporaryRedirect)
+			log.Info(req.Context(), "redirect found", log.Data{"location": redirect.to, "status": redirect.status}, log.HTTP(req, 0, 0, nil, nil))
+			http.Redirect(w, req, redirect.to, redirect.status)
 			return
 		}
 		h.ServeHTTP(w, req)
 	})
 }
 
-// DynamicRedirectHandler redirects requests to the provide 'to' base path whilst keeping all the other information of the request url
-func DynamicRedirectHandler(redirectFrom, redirectTo string) http.Handler {
+// DynamicRedirectHandler redirects requests to the provide 'to' base path whilst keeping all the other information of the request url,
+// with status, e.g. a 301 or 308 for URLs that have been retired for good so that search engines transfer their ranking.
+// Query parameters named in params are renamed, so that the new page understands a query made for the old one; a
+// parameter renamed to "" is dropped.
+func DynamicRedirectHandler(redirectFrom, redirectTo string, status int, params map[string]string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		redirect := *req.URL
 		redirect.Path = strings.Replace(req.URL.Path, redirectFrom, redirectTo, 1)
+		if len(params) > 0 && redirect.RawQuery != "" {
+			redirect.RawQuery = renameParams(redirect.Query(), params).Encode()
+		}
 		redirectURL := redirect.String()
 
-		log.Info(req.Context(), "redirect found", log.Data{"location": redirectURL}, log.HTTP(req, 0, 0, nil, nil))
-		http.Redirect(w, req, redirectURL, http.StatusMovedPermanently)
+		log.Info(req.Context(), "redirect found", log.Data{"location": redirectURL, "status": status}, log.HTTP(req, 0, 0, nil, nil))
+		http.Redirect(w, req, redirectURL, status)
 	})
 }
+
+// renameParams renames the query parameters in params, adding their values to any the query already has under the new name
+func renameParams(query url.Values, params map[string]string) url.Values {
+	renamed := make(url.Values, len(query))
+	for name, values := range query {
+		if to, ok := params[name]; ok {
+			name = to
+		}
+		if name != "" {
+			renamed[name] = append(renamed[name], values...)
+		}
+	}
+	return renamed
+}
@@ -25,7 +25,8 @@ func TestRedirect(t *testing.T) {
 		handled = true
 	})
 
-	redirects["/redirect"] = "/redirected"
+	redirects["/redirect"] = redirect{to: "/redirected", status: DefaultStatus}
+	redirects["/retired"] = redirect{to: "/moved", status: http.StatusPermanentRedirect}
 
 	Convey("Test that a non redirect request reaches the handler", t, func() {
 		handled = false
@@ -45,6 +46,16 @@ func TestRedirect(t *testing.T) {
 		So(handled, ShouldBeFalse)
 		So(w.Header(), ShouldContainKey, "Location")
 	})
+
+	Convey("Test that a redirect request returns a redirect with the status of the redirect", t, func() {
+		handled = false
+		req, _ := http.NewRequest("GET", "/retired", http.NoBody)
+		w := httptest.NewRecorder()
+		testAlice.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, 308)
+		So(handled, ShouldBeFalse)
+		So(w.Header()["Location"], ShouldContain, "/moved")
+	})
 }
 
 func TestDynamicRedirect(t *testing.T) {
@@ -53,7 +64,10 @@ func TestDynamicRedirect(t *testing.T) {
 		Handler,
 	}
 	testAlice := alice.New(middleware...).Then(router)
-	router.Handle("/original{uri:.*}", DynamicRedirectHandler("/original", "/redirected"))
+	router.Handle("/original{uri:.*}", DynamicRedirectHandler("/original", "/redirected", http.StatusMovedPermanently, nil))
+	router.Handle("/retired{uri:.*}", DynamicRedirectHandler("/retired", "/redirected", http.StatusPermanentRedirect, nil))
+	params := map[string]string{"query": "q", "size": "limit", "sortBy": ""}
+	router.Handle("/renamed{uri:.*}", DynamicRedirectHandler("/renamed", "/redirected", http.StatusMovedPermanently, params))
 	router.HandleFunc("/redirected{uri:.*}", func(w http.ResponseWriter, req *http.Request) {
 	})
 
@@ -83,6 +97,32 @@ func TestDynamicRedirect(t *testing.T) {
 		So(w.Header(), ShouldContainKey, "Location")
 		So(w.Header()["Location"], ShouldContain, "/redirected?q=test&page=2")
 	})
+
+	Convey("Test that a redirect request is redirected with the status of the handler", t, func() {
+		req, _ := http.NewRequest("GET", "/retired/extension", http.NoBody)
+		w := httptest.NewRecorder()
+		testAlice.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, 308)
+		So(w.Header()["Location"], ShouldContain, "/redirected/extension")
+	})
+
+	Convey("Test that a redirect request with parameters is redirected with the parameters renamed", t, func() {
+		req, _ := http.NewRequest("GET", "/renamed?query=gdp&size=10&sortBy=relevance&page=2", http.NoBody)
+		w := httptest.NewRecorder()
+		testAlice.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, 301)
+		So(w.Header()["Location"], ShouldContain, "/redirected?limit=10&page=2&q=gdp")
+	})
+
+	Convey("Test that a renamed parameter is added to the values the query already has under the new name", t, func() {
+		req, _ := http.NewRequest("GET", "/renamed?q=cpi&query=gdp", http.NoBody)
+		w := httptest.NewRecorder()
+		testAlice.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, 301)
+		So(w.Header().Get("Location"), ShouldStartWith, "/redirected?q=")
+		So(w.Header().Get("Location"), ShouldContainSubstring, "q=cpi")
+		So(w.Header().Get("Location"), ShouldContainSubstring, "q=gdp")
+	})
 }
 
 func TestInit(t *testing.T) {
@@ -110,8 +150,7 @@ func TestInit(t *testing.T) {
 
 	Convey("Init should panic on invalid CSV", t, func() {
 		shouldError = false
-		returnBytes = []byte(`a,b
-a,b,c`)
+		returnBytes = []byte(`a,"b`)
 		So(func() { Init(asset) }, ShouldPanicWith, "Unable to read CSV")
 	})
 
@@ -128,9 +167,35 @@ a`)
 c,d`)
 		So(func() { Init(asset) }, ShouldNotPanic)
 		So(redirects, ShouldContainKey, "a")
-		So(redirects["a"], ShouldEqual, "b")
+		So(redirects["a"], ShouldResemble, redirect{to: "b", status: DefaultStatus})
 		So(redirects, ShouldContainKey, "c")
-		So(redirects["c"], ShouldEqual, "d")
+		So(redirects["c"], ShouldResemble, redirect{to: "d", status: DefaultStatus})
+	})
+
+	Convey("Init should add entries to redirects with their status", t, func() {
+		shouldError = false
+		returnBytes = []byte(`e,f,308
+g,h,`)
+		So(func() { Init(asset) }, ShouldNotPanic)
+		So(redirects["e"], ShouldResemble, redirect{to: "f", status: http.StatusPermanentRedirect})
+		So(redirects["g"], ShouldResemble, redirect{to: "h", status: DefaultStatus})
+	})
+
+	Convey("Init should add entries from a mix of rows with and without a status", t, func() {
+		shouldError = false
+		returnBytes = []byte(`i,j
+k,l,301
+m,n`)
+		So(func() { Init(asset) }, ShouldNotPanic)
+		So(redirects["i"], ShouldResemble, redirect{to: "j", status: DefaultStatus})
+		So(redirects["k"], ShouldResemble, redirect{to: "l", status: http.StatusMovedPermanently})
+		So(redirects["m"], ShouldResemble, redirect{to: "n", status: DefaultStatus})
+	})
+
+	Convey("Init should panic if redirect has an invalid status", t, func() {
+		shouldError = false
+		returnBytes = []byte(`a,b,200`)
+		So(func() { Init(asset) }, ShouldPanicWith, "redirect status invalid, check logs")
 	})
 
 	Convey("Init should panic if redirect has no from url", t, func() {
@@ -180,10 +245,10 @@ func BenchmarkWithoutRedirects(b *testing.B) {
 }
 
 func BenchmarkWith100Redirects(b *testing.B) {
-	redirects = make(map[string]string)
+	redirects = make(map[string]redirect)
 
 	for i := 0; i < 100; i++ {
-		redirects[fmt.Sprintf("/test/%d", i)] = "/"
+		redirects[fmt.Sprintf("/test/%d", i)] = redirect{to: "/", status: DefaultStatus}
 	}
 
 	router := mux.NewRouter()
@@ -203,10 +268,10 @@ func BenchmarkWith100Redirects(b *testing.B) {
 }
 
 func BenchmarkWith10000Redirects(b *testing.B) {
-	redirects = make(map[string]string)
+	redirects = make(map[string]redirect)
 
 	for i := 0; i < 10000; i++ {
-		redirects[fmt.Sprintf("/test/%d", i)] = "/"
+		redirects[fmt.Sprintf("/test/%d", i)] = redirect{to: "/", status: DefaultStatus}
 	}
 
 	router := mux.NewRouter()
@@ -226,10 +291,10 @@ func BenchmarkWith10000Redirects(b *testing.B) {
 }
 
 func BenchmarkWith1000000Redirects(b *testing.B) {
-	redirects = make(map[string]string)
+	redirects = make(map[string]redirect)
 
 	for i := 0; i < 1000000; i++ {
-		redirects[fmt.Sprintf("/test/%d", i)] = "/"
+		redirects[fmt.Sprintf("/test/%d", i)] = redirect{to: "/", status: DefaultStatus}
 	}
 
 	router := mux.NewRouter()
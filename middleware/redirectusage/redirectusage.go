@@ -0,0 +1,140 @@
+package redirectusage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Recorder counts the redirects served by each redirect rule, so that rules that never fire can be pruned, and logs
+// requests for legacy-looking URLs that no rule redirected, so that broken inbound links can be found. A nil Recorder
+// records nothing.
+type Recorder struct {
+	redirects *metrics.CounterVec
+	misses    *metrics.CounterVec
+	legacy    []*regexp.Regexp
+}
+
+// NewRecorder creates a Recorder, registering its counters with registry. Requests whose path matches one of legacy
+// and that are not found are logged as misses.
+func NewRecorder(registry *metrics.Registry, legacy []*regexp.Regexp) *Recorder {
+	r := &Recorder{
+		redirects: metrics.NewCounterVec("dp_frontend_router_redirects_total",
+			"Redirects served, by the source of the redirect and the rule that matched.", "source", "rule"),
+		misses: metrics.NewCounterVec("dp_frontend_router_legacy_url_misses_total",
+			"Requests for legacy-looking URLs that were not redirected and not found, by the pattern they matched.", "pattern"),
+		legacy: legacy,
+	}
+	registry.Register(r.redirects)
+	registry.Register(r.misses)
+	return r
+}
+
+// ParsePatterns compiles the regular expressions that legacy URL paths are recognised by
+func ParsePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid legacy URL pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+type passedKey struct{}
+
+// Stage counts the requests that the redirect middleware stage responds to itself, rather than passing on, against
+// source and the rule returned for the request
+func (r *Recorder) Stage(source string, rule func(*http.Request) string,
+	stage func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	if r == nil {
+		return stage
+	}
+	return func(h http.Handler) http.Handler {
+		// the stage is run against a handler that marks the request as passed on, through a flag in its context
+		staged := stage(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if passed, ok := req.Context().Value(passedKey{}).(*bool); ok {
+				*passed = true
+			}
+			h.ServeHTTP(w, req)
+		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			passed := false
+			staged.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), passedKey{}, &passed)))
+			if !passed {
+				r.redirects.Inc(source, rule(req))
+			}
+		})
+	}
+}
+
+// Redirect counts every request that h serves against source and rule
+func (r *Recorder) Redirect(source, rule string, h http.Handler) http.Handler {
+	if r == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.redirects.Inc(source, rule)
+		h.ServeHTTP(w, req)
+	})
+}
+
+// Value returns the number of redirects served by rule of source
+func (r *Recorder) Value(source, rule string) float64 {
+	if r == nil {
+		return 0
+	}
+	return r.redirects.Value(source, rule)
+}
+
+// Misses logs and counts the requests for legacy-looking URLs that are not found, with their referrer so that the
+// pages linking to them can be traced
+func (r *Recorder) Misses(h http.Handler) http.Handler {
+	if r == nil || len(r.legacy) == 0 {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		pattern := r.legacyPattern(req.URL.Path)
+		if pattern == nil {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		sw := helpers.NewStatusWriter(w)
+		h.ServeHTTP(sw, req)
+		if sw.Status() != http.StatusNotFound {
+			return
+		}
+		r.misses.Inc(pattern.String())
+		log.Info(req.Context(), "legacy URL not redirected", log.Data{
+			"path":     req.URL.Path,
+			"referrer": req.Referer(),
+			"pattern":  pattern.String(),
+		})
+	})
+}
+
+// MissValue returns the number of misses for legacy URLs matching pattern
+func (r *Recorder) MissValue(pattern string) float64 {
+	if r == nil {
+		return 0
+	}
+	return r.misses.Value(pattern)
+}
+
+func (r *Recorder) legacyPattern(path string) *regexp.Regexp {
+	for _, pattern := range r.legacy {
+		if pattern.MatchString(path) {
+			return pattern
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package redirectusage
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStage(t *testing.T) {
+	Convey("Given a redirect stage counted by a recorder", t, func() {
+		registry := metrics.NewRegistry()
+		recorder := NewRecorder(registry, nil)
+
+		stage := func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/old" {
+					http.Redirect(w, req, "/new", http.StatusMovedPermanently)
+					return
+				}
+				h.ServeHTTP(w, req)
+			})
+		}
+		byPath := func(req *http.Request) string { return req.URL.Path }
+		var handled bool
+		handler := recorder.Stage("redirect-map", byPath, stage)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handled = true
+		}))
+
+		Convey("When a request is redirected by the stage", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/old", http.NoBody))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/old", http.NoBody))
+
+			Convey("Then the redirects are counted against the rule", func() {
+				So(handled, ShouldBeFalse)
+				So(recorder.Value("redirect-map", "/old"), ShouldEqual, 2)
+			})
+
+			Convey("Then the counts are exposed via the registry", func() {
+				var buf bytes.Buffer
+				So(registry.Write(&buf), ShouldBeNil)
+				So(buf.String(), ShouldContainSubstring, `dp_frontend_router_redirects_total{source="redirect-map",rule="/old"} 2`)
+			})
+		})
+
+		Convey("When a request is passed on by the stage", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then it is not counted", func() {
+				So(handled, ShouldBeTrue)
+				So(recorder.Value("redirect-map", "/economy"), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given a nil recorder", t, func() {
+		var recorder *Recorder
+		h := http.RedirectHandler("/search", http.StatusMovedPermanently)
+
+		Convey("Then handlers are returned unwrapped", func() {
+			So(recorder.Redirect("legacy-search", "/searchdata", h), ShouldEqual, h)
+			So(recorder.Misses(h), ShouldEqual, h)
+			So(recorder.Value("legacy-search", "/searchdata"), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestRedirect(t *testing.T) {
+	Convey("Given a redirect handler counted by a recorder", t, func() {
+		recorder := NewRecorder(metrics.NewRegistry(), nil)
+		handler := recorder.Redirect("legacy-search", "/searchdata", http.RedirectHandler("/search", http.StatusMovedPermanently))
+
+		Convey("When it serves a request", func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/searchdata?q=gdp", http.NoBody))
+
+			Convey("Then the redirect is counted", func() {
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(recorder.Value("legacy-search", "/searchdata"), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestMisses(t *testing.T) {
+	Convey("Given a recorder of misses for legacy URLs", t, func() {
+		patterns, err := ParsePatterns([]string{"^/ons/", `\.xls$`})
+		So(err, ShouldBeNil)
+		recorder := NewRecorder(metrics.NewRegistry(), patterns)
+
+		status := http.StatusNotFound
+		handler := recorder.Misses(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(status)
+		}))
+		serve := func(target string) int {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+			return w.Code
+		}
+
+		Convey("When a legacy URL is not found", func() {
+			So(serve("/ons/rel/cpi/march-2010"), ShouldEqual, http.StatusNotFound)
+
+			Convey("Then it is counted against the pattern it matched", func() {
+				So(recorder.MissValue("^/ons/"), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When a legacy URL is found", func() {
+			status = http.StatusOK
+			serve("/ons/rel/cpi/march-2010")
+
+			Convey("Then it is not counted", func() {
+				So(recorder.MissValue("^/ons/"), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a URL that does not look like a legacy one is not found", func() {
+			serve("/economy/missing")
+
+			Convey("Then it is not counted", func() {
+				So(recorder.MissValue("^/ons/"), ShouldEqual, 0)
+				So(recorder.MissValue(`\.xls$`), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given legacy URL patterns that are not valid", t, func() {
+		_, err := ParsePatterns([]string{"^/ons/(rel"})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,78 @@
+package requestmetrics
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Labels of requests that matched no route, or were not proxied to a backend, such as redirects served by the router
+const (
+	UnmatchedRoute = "unmatched"
+	RouterBackend  = "router"
+)
+
+type labelsKey struct{}
+
+// labels are the route and backend of a request, filled in as the request passes through the router
+type labels struct {
+	mu      sync.Mutex
+	route   string
+	backend string
+}
+
+func (l *labels) get() (route, backend string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.route, l.backend
+}
+
+// Track returns req with labels in its context for the route and backend of the request to be recorded against, and a
+// function returning them once the request has been served. If the labels are already being tracked by an outer
+// middleware, they are shared rather than replaced.
+func Track(req *http.Request) (*http.Request, func() (route, backend string)) {
+	if l, ok := req.Context().Value(labelsKey{}).(*labels); ok {
+		return req, l.get
+	}
+	l := &labels{route: UnmatchedRoute, backend: RouterBackend}
+	return req.WithContext(context.WithValue(req.Context(), labelsKey{}, l)), l.get
+}
+
+// SetBackend records the backend that the request with ctx is proxied to. It does nothing if the request is not being
+// tracked.
+func SetBackend(ctx context.Context, backend string) {
+	if l, ok := ctx.Value(labelsKey{}).(*labels); ok {
+		l.mu.Lock()
+		l.backend = backend
+		l.mu.Unlock()
+	}
+}
+
+// RouteHandler is mux middleware recording the route a request matched, by its path template or, for matcher routes,
+// its name, rather than by its path, so that the number of series is bounded
+func RouteHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if l, ok := req.Context().Value(labelsKey{}).(*labels); ok {
+			l.mu.Lock()
+			l.route = routeName(req)
+			l.mu.Unlock()
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+func routeName(req *http.Request) string {
+	route := mux.CurrentRoute(req)
+	if route == nil {
+		return UnmatchedRoute
+	}
+	if tpl, err := route.GetPathTemplate(); err == nil {
+		return tpl
+	}
+	if name := route.GetName(); name != "" {
+		return name
+	}
+	return UnmatchedRoute
+}
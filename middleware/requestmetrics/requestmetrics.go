@@ -0,0 +1,57 @@
+package requestmetrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+)
+
+// Recorder counts the requests served by the router, and how long they took, by the route they matched and the backend
+// they were proxied to
+type Recorder struct {
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+	now      func() time.Time
+}
+
+// NewRecorder creates a Recorder, registering its metrics with registry
+func NewRecorder(registry *metrics.Registry) *Recorder {
+	r := &Recorder{
+		requests: metrics.NewCounterVec("dp_frontend_router_requests_total",
+			"Requests served by the router, by route, backend, method and status code.", "route", "backend", "method", "code"),
+		duration: metrics.NewHistogramVec("dp_frontend_router_request_duration_seconds",
+			"Time taken to serve requests, by route and backend.", metrics.DefaultBuckets, "route", "backend"),
+		now: time.Now,
+	}
+	registry.Register(r.requests)
+	registry.Register(r.duration)
+	return r
+}
+
+// Handler records the status code and duration of each request once it has been served, against the route and backend
+// recorded by RouteHandler and SetBackend
+func (r *Recorder) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		started := r.now()
+		req, labels := Track(req)
+		sw := helpers.NewStatusWriter(w)
+		h.ServeHTTP(sw, req)
+
+		route, backend := labels()
+		r.requests.Inc(route, backend, methodLabel(req.Method), strconv.Itoa(sw.Status()))
+		r.duration.Observe(r.now().Sub(started).Seconds(), route, backend)
+	})
+}
+
+// methodLabel returns method, or "other" for non-standard methods, so that clients cannot create unbounded series
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
@@ -0,0 +1,90 @@
+package requestmetrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecorder(t *testing.T) {
+	Convey("Given a recorder in front of a router with proxied and unproxied routes", t, func() {
+		registry := metrics.NewRegistry()
+		recorder := NewRecorder(registry)
+
+		// each request takes the latency it asks for, on a fake clock
+		var clock time.Time
+		recorder.now = func() time.Time { return clock }
+		var latency time.Duration
+		var status int
+		respond := func(w http.ResponseWriter) {
+			clock = clock.Add(latency)
+			if status != 0 {
+				w.WriteHeader(status)
+			}
+		}
+
+		router := mux.NewRouter()
+		router.Use(RouteHandler)
+		router.HandleFunc("/datasets/{uri:.*}", func(w http.ResponseWriter, req *http.Request) {
+			SetBackend(req.Context(), "datasets")
+			respond(w)
+		})
+		router.HandleFunc("/redirect", func(w http.ResponseWriter, req *http.Request) { respond(w) })
+		handler := recorder.Handler(router)
+
+		serve := func(method, target string, s int, l time.Duration) {
+			status, latency = s, l
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, http.NoBody))
+		}
+
+		Convey("When requests are served", func() {
+			serve(http.MethodGet, "/datasets/cpih01", 0, 100*time.Millisecond)
+			serve(http.MethodGet, "/datasets/cpih01/editions", http.StatusOK, 3*time.Second)
+			serve(http.MethodGet, "/datasets/cpih01", http.StatusBadGateway, time.Millisecond)
+			serve(http.MethodPost, "/redirect", http.StatusMovedPermanently, time.Millisecond)
+			serve(http.MethodGet, "/not-a-route", http.StatusNotFound, time.Millisecond)
+
+			Convey("Then they are counted by route template, backend, method and status code, defaulting to 200", func() {
+				So(recorder.requests.Value("/datasets/{uri:.*}", "datasets", http.MethodGet, "200"), ShouldEqual, 2)
+				So(recorder.requests.Value("/datasets/{uri:.*}", "datasets", http.MethodGet, "502"), ShouldEqual, 1)
+				So(recorder.requests.Value("/redirect", RouterBackend, http.MethodPost, "301"), ShouldEqual, 1)
+				So(recorder.requests.Value(UnmatchedRoute, RouterBackend, http.MethodGet, "404"), ShouldEqual, 1)
+			})
+
+			Convey("Then their durations are observed by route and backend", func() {
+				So(recorder.duration.Count("/datasets/{uri:.*}", "datasets"), ShouldEqual, 3)
+				So(recorder.duration.Count("/redirect", RouterBackend), ShouldEqual, 1)
+			})
+
+			Convey("Then the metrics are registered", func() {
+				w := httptest.NewRecorder()
+				metrics.Handler(registry).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+				So(w.Body.String(), ShouldContainSubstring,
+					`dp_frontend_router_requests_total{route="/datasets/{uri:.*}",backend="datasets",method="GET",code="502"} 1`)
+				So(w.Body.String(), ShouldContainSubstring,
+					`dp_frontend_router_request_duration_seconds_bucket{route="/datasets/{uri:.*}",backend="datasets",le="5"} 3`)
+			})
+		})
+
+		Convey("When a request has a non-standard method", func() {
+			serve("PURGE", "/redirect", http.StatusOK, time.Millisecond)
+
+			Convey("Then it is counted as other", func() {
+				So(recorder.requests.Value("/redirect", RouterBackend, "other", "200"), ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given a request that is not being measured", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+		Convey("Then setting its backend does nothing", func() {
+			So(func() { SetBackend(req.Context(), "babbage") }, ShouldNotPanic)
+		})
+	})
+}
@@ -0,0 +1,68 @@
+package responsecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// notModifiedHeaders are the headers sent with a 304 Not Modified, as they would be sent with the full response
+var notModifiedHeaders = []string{"Cache-Control", "Content-Location", "ETag", "Expires", "Last-Modified", "Vary"}
+
+// generateETag returns a strong validator for a response body, for responses from origins that do not set their own
+func generateETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified reports whether the client already has the response with header, according to the validators of req.
+// If-None-Match takes precedence over If-Modified-Since, which is only used when the request has no If-None-Match.
+func notModified(req *http.Request, header http.Header) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, header.Get("ETag"))
+	}
+
+	ifModifiedSince := req.Header.Get("If-Modified-Since")
+	lastModified := header.Get("Last-Modified")
+	if ifModifiedSince == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// etagMatches reports whether etag is in the If-None-Match list, using the weak comparison that If-None-Match calls
+// for, so that a validator weakened by compression still matches
+func etagMatches(ifNoneMatch, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// serveNotModified answers a conditional request for a cached response with 304 Not Modified and no body
+func serveNotModified(w http.ResponseWriter, cached *response, age time.Duration) {
+	header := w.Header()
+	for _, name := range notModifiedHeaders {
+		if values := cached.header.Values(name); len(values) > 0 {
+			header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+	setCacheHeaders(header, age)
+	w.WriteHeader(http.StatusNotModified)
+}
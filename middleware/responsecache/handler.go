@@ -0,0 +1,225 @@
+package responsecache
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/cache"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const (
+	// StatusHeader reports whether a response was served from the cache (HIT) or the origin (MISS)
+	StatusHeader = "X-Router-Cache"
+
+	// maxBodyBytes is the largest response body that will be cached
+	maxBodyBytes = 2 << 20
+)
+
+var _ cache.StatsReporter = &ResponseCache{}
+
+type response struct {
+	status  int
+	header  http.Header
+	body    []byte
+	stored  time.Time
+	expires time.Time
+}
+
+// ResponseCache caches successful GET responses for as long as the origin's Cache-Control allows, so that repeated
+// requests for popular pages are served without going to the origin
+type ResponseCache struct {
+	responses  *cache.Cache[*response]
+	cookies    cache.CookiePolicy
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+	now        func() time.Time
+}
+
+// New creates a ResponseCache holding at most maxEntries responses. Responses are cached for their Cache-Control
+// s-maxage or max-age, capped at maxTTL, or for defaultTTL if the origin gives neither. A defaultTTL of zero caches
+// only responses that the origin says may be cached. Responses are keyed on the encodings the request accepts, and
+// keyed, or not cached at all, according to the cookie policy.
+func New(maxEntries int, defaultTTL, maxTTL time.Duration, cookies cache.CookiePolicy) *ResponseCache {
+	return &ResponseCache{
+		responses:  cache.New[*response](maxEntries, maxTTL),
+		cookies:    cookies,
+		defaultTTL: defaultTTL,
+		maxTTL:     maxTTL,
+		now:        time.Now,
+	}
+}
+
+// Stats returns the statistics of the underlying response cache
+func (c *ResponseCache) Stats() cache.Stats {
+	return c.responses.Stats()
+}
+
+// Handler serves GET requests from the cache when it can, and caches the responses of h that can be cached otherwise.
+// Cached responses keep the origin's ETag, or are given one generated from the body, and conditional requests whose
+// validators match a cached response are answered with 304 Not Modified. Responses from the origin are passed through
+// as they are written, before the body is known, so only cache hits carry a generated ETag; a miss carries the
+// origin's ETag if it has one, and no ETag otherwise.
+func (c *ResponseCache) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		key, ok := c.cookies.Key(req, cache.EncodingKey(req, req.Host+req.URL.RequestURI()))
+		if !ok {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		if cached, ok := c.responses.Get(key); ok && c.now().Before(cached.expires) {
+			c.serveCached(w, req, cached)
+			return
+		}
+
+		w.Header().Set(StatusHeader, "MISS")
+		cw := &cacheWriter{ResponseWriter: w}
+		h.ServeHTTP(cw, req)
+
+		if !cw.capture {
+			return
+		}
+		if ttl, ok := c.ttl(cw.status, w.Header()); ok {
+			header := w.Header().Clone()
+			header.Del(StatusHeader)
+			if header.Get("ETag") == "" {
+				header.Set("ETag", generateETag(cw.buf.Bytes()))
+			}
+			now := c.now()
+			c.responses.SetWithTTL(key, &response{
+				status:  cw.status,
+				header:  header,
+				body:    cw.buf.Bytes(),
+				stored:  now,
+				expires: now.Add(ttl),
+			}, ttl)
+		}
+	})
+}
+
+func (c *ResponseCache) serveCached(w http.ResponseWriter, req *http.Request, cached *response) {
+	age := c.now().Sub(cached.stored)
+	if notModified(req, cached.header) {
+		serveNotModified(w, cached, age)
+		return
+	}
+
+	header := w.Header()
+	for k, v := range cached.header.Clone() {
+		header[k] = v
+	}
+	setCacheHeaders(header, age)
+	w.WriteHeader(cached.status)
+	if _, err := w.Write(cached.body); err != nil {
+		log.Error(req.Context(), "error writing cached response", err)
+	}
+}
+
+func setCacheHeaders(header http.Header, age time.Duration) {
+	header.Set("Age", strconv.Itoa(int(age.Seconds())))
+	header.Set(StatusHeader, "HIT")
+}
+
+// ttl returns how long a response may be cached for, if at all. Only 200 responses are cached, and not those that set
+// cookies, vary on anything other than the encoding, or are stale responses served in place of an error.
+func (c *ResponseCache) ttl(status int, header http.Header) (time.Duration, bool) {
+	if status != http.StatusOK || header.Get("Set-Cookie") != "" || header.Get("Warning") != "" {
+		return 0, false
+	}
+	if vary := header.Get("Vary"); vary != "" && !strings.EqualFold(strings.TrimSpace(vary), "Accept-Encoding") {
+		return 0, false
+	}
+
+	ttl, ok := maxAge(header.Values("Cache-Control"))
+	if !ok {
+		ttl = c.defaultTTL
+	}
+	if ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+	return ttl, ttl > 0
+}
+
+// maxAge returns how long a shared cache may keep a response according to its Cache-Control, preferring s-maxage to
+// max-age. A response marked no-store, no-cache or private may not be kept at all.
+func maxAge(cacheControl []string) (time.Duration, bool) {
+	var sMaxAge, maxAge string
+	for _, value := range cacheControl {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+			switch name {
+			case "no-store", "no-cache", "private":
+				return 0, true
+			case "s-maxage":
+				sMaxAge = arg
+			case "max-age":
+				maxAge = arg
+			}
+		}
+	}
+
+	for _, age := range []string{sMaxAge, maxAge} {
+		if age == "" {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(age, `"`))
+		if err != nil || seconds < 0 {
+			return 0, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
+
+// cacheWriter passes the origin response through, capturing its body if it is small enough to cache
+type cacheWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+	status      int
+	capture     bool
+	buf         bytes.Buffer
+}
+
+func (cw *cacheWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = code
+	cw.capture = code == http.StatusOK
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *cacheWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.capture {
+		if cw.buf.Len()+len(b) > maxBodyBytes {
+			cw.capture = false
+			cw.buf = bytes.Buffer{}
+		} else {
+			cw.buf.Write(b)
+		}
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+func (cw *cacheWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *cacheWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,275 @@
+package responsecache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/cache"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHandler(t *testing.T) {
+	Convey("Given a response cache in front of an origin", t, func() {
+		originCalls := 0
+		originStatus := http.StatusOK
+		originHeader := http.Header{"Cache-Control": {"public, max-age=60"}, "Content-Type": {"text/html"}}
+		origin := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			originCalls++
+			for k, v := range originHeader {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(originStatus)
+			w.Write([]byte("<html>economy</html>"))
+		})
+
+		now := time.Now()
+		c := New(10, 0, 5*time.Minute, cache.CookiePolicy{BypassCookies: []string{"access_token"}})
+		c.now = func() time.Time { return now }
+		handler := c.Handler(origin)
+
+		serve := func(req *http.Request) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+		get := func(target string) *httptest.ResponseRecorder {
+			return serve(httptest.NewRequest(http.MethodGet, target, http.NoBody))
+		}
+
+		Convey("When a cacheable page is requested twice", func() {
+			first := get("/economy")
+			now = now.Add(10 * time.Second)
+			second := get("/economy")
+
+			Convey("Then the first request goes to the origin, without a generated ETag", func() {
+				So(first.Header().Get(StatusHeader), ShouldEqual, "MISS")
+				So(first.Body.String(), ShouldEqual, "<html>economy</html>")
+				So(first.Header().Get("ETag"), ShouldBeEmpty)
+			})
+
+			Convey("Then the second is served from the cache, with its age", func() {
+				So(originCalls, ShouldEqual, 1)
+				So(second.Code, ShouldEqual, http.StatusOK)
+				So(second.Body.String(), ShouldEqual, "<html>economy</html>")
+				So(second.Header().Get("Content-Type"), ShouldEqual, "text/html")
+				So(second.Header().Get(StatusHeader), ShouldEqual, "HIT")
+				So(second.Header().Get("Age"), ShouldEqual, "10")
+			})
+
+			Convey("Then a different query is cached separately", func() {
+				get("/economy?page=2")
+				So(originCalls, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When a page that varies on its encoding is requested by a gzip client, then by a client without gzip", func() {
+			origin = func(w http.ResponseWriter, req *http.Request) {
+				originCalls++
+				w.Header().Set("Cache-Control", "public, max-age=60")
+				w.Header().Set("Vary", "Accept-Encoding")
+				if req.Header.Get("Accept-Encoding") == "gzip" {
+					w.Header().Set("Content-Encoding", "gzip")
+					w.Write([]byte("gzipped economy"))
+					return
+				}
+				w.Write([]byte("<html>economy</html>"))
+			}
+			handler = c.Handler(origin)
+			gzipReq := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			gzipReq.Header.Set("Accept-Encoding", "gzip")
+			serve(gzipReq)
+			identity := get("/economy")
+
+			Convey("Then the client without gzip is not served the gzipped page", func() {
+				So(originCalls, ShouldEqual, 2)
+				So(identity.Header().Get(StatusHeader), ShouldEqual, "MISS")
+				So(identity.Header().Get("Content-Encoding"), ShouldBeEmpty)
+				So(identity.Body.String(), ShouldEqual, "<html>economy</html>")
+			})
+
+			Convey("Then another gzip client is served the gzipped page from the cache", func() {
+				gzipReq := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+				gzipReq.Header.Set("Accept-Encoding", "gzip")
+				w := serve(gzipReq)
+				So(w.Header().Get(StatusHeader), ShouldEqual, "HIT")
+				So(w.Header().Get("Content-Encoding"), ShouldEqual, "gzip")
+			})
+		})
+
+		Convey("When a cached page is requested with a matching validator", func() {
+			get("/economy")
+			cached := get("/economy")
+			req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			req.Header.Set("If-None-Match", "W/"+cached.Header().Get("ETag"))
+			second := serve(req)
+
+			Convey("Then 304 Not Modified is returned from the cache without a body", func() {
+				So(cached.Header().Get("ETag"), ShouldNotBeEmpty)
+				So(originCalls, ShouldEqual, 1)
+				So(second.Code, ShouldEqual, http.StatusNotModified)
+				So(second.Body.String(), ShouldBeEmpty)
+				So(second.Header().Get("ETag"), ShouldNotBeEmpty)
+				So(second.Header().Get("Cache-Control"), ShouldEqual, "public, max-age=60")
+				So(second.Header().Get("Content-Type"), ShouldBeEmpty)
+				So(second.Header().Get(StatusHeader), ShouldEqual, "HIT")
+			})
+		})
+
+		Convey("When a cached page is requested with a validator that does not match", func() {
+			get("/economy")
+			req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			req.Header.Set("If-None-Match", `"other"`)
+			second := serve(req)
+
+			Convey("Then the full page is returned from the cache", func() {
+				So(originCalls, ShouldEqual, 1)
+				So(second.Code, ShouldEqual, http.StatusOK)
+				So(second.Body.String(), ShouldEqual, "<html>economy</html>")
+			})
+		})
+
+		Convey("When the origin sets its own ETag", func() {
+			originHeader.Set("ETag", `"v1"`)
+			first := get("/economy")
+			second := get("/economy")
+
+			Convey("Then it is passed through, on both the miss and the hit", func() {
+				So(first.Header().Get("ETag"), ShouldEqual, `"v1"`)
+				So(second.Header().Get("ETag"), ShouldEqual, `"v1"`)
+			})
+		})
+
+		Convey("When a page with a Last-Modified is requested with an If-Modified-Since", func() {
+			originHeader.Set("Last-Modified", "Mon, 05 Oct 2026 09:30:00 GMT")
+			get("/economy")
+			req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			req.Header.Set("If-Modified-Since", "Mon, 05 Oct 2026 09:30:00 GMT")
+			second := serve(req)
+
+			Convey("Then 304 Not Modified is returned if it has not been modified since", func() {
+				So(second.Code, ShouldEqual, http.StatusNotModified)
+			})
+		})
+
+		Convey("When a page is requested after its max-age has passed", func() {
+			get("/economy")
+			now = now.Add(61 * time.Second)
+			get("/economy")
+
+			Convey("Then it is fetched from the origin again", func() {
+				So(originCalls, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When a page has a max-age longer than the cache allows", func() {
+			originHeader.Set("Cache-Control", "max-age=3600")
+			get("/economy")
+			now = now.Add(6 * time.Minute)
+			get("/economy")
+
+			Convey("Then it is only cached for the maximum time to live", func() {
+				So(originCalls, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When a page is requested by a client with a bypass cookie", func() {
+			get("/economy")
+			req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			req.AddCookie(&http.Cookie{Name: "access_token", Value: "abc"})
+			serve(req)
+
+			Convey("Then it is not served from the cache", func() {
+				So(originCalls, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When a POST is made twice", func() {
+			serve(httptest.NewRequest(http.MethodPost, "/economy", http.NoBody))
+			serve(httptest.NewRequest(http.MethodPost, "/economy", http.NoBody))
+
+			Convey("Then both go to the origin", func() {
+				So(originCalls, ShouldEqual, 2)
+			})
+		})
+
+		for name, header := range map[string]http.Header{
+			"no max-age":        {},
+			"no-store":          {"Cache-Control": {"no-store"}},
+			"no-cache":          {"Cache-Control": {"max-age=60, no-cache"}},
+			"private":           {"Cache-Control": {"private, max-age=60"}},
+			"a cookie":          {"Cache-Control": {"max-age=60"}, "Set-Cookie": {"session=abc"}},
+			"a vary on cookies": {"Cache-Control": {"max-age=60"}, "Vary": {"Cookie"}},
+			"a stale warning":   {"Cache-Control": {"max-age=60"}, "Warning": {`110 - "Response is Stale"`}},
+		} {
+			header := header
+			Convey("When a page with "+name+" is requested twice", func() {
+				originHeader = header
+				get("/economy")
+				get("/economy")
+
+				Convey("Then it is not cached", func() {
+					So(originCalls, ShouldEqual, 2)
+				})
+			})
+		}
+
+		Convey("When the origin responds with an error", func() {
+			originStatus = http.StatusNotFound
+			get("/economy")
+			get("/economy")
+
+			Convey("Then it is not cached", func() {
+				So(originCalls, ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("Given a response cache with a default time to live", t, func() {
+		originCalls := 0
+		origin := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			originCalls++
+			w.Write([]byte("<html>economy</html>"))
+		})
+		handler := New(10, time.Minute, 5*time.Minute, cache.CookiePolicy{}).Handler(origin)
+
+		Convey("When a page without a max-age is requested twice", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then it is cached for the default time to live", func() {
+				So(originCalls, ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestETagMatches(t *testing.T) {
+	Convey("ETags are compared weakly", t, func() {
+		So(etagMatches(`"a", W/"b"`, `"b"`), ShouldBeTrue)
+		So(etagMatches(`W/"a"`, `"a"`), ShouldBeTrue)
+		So(etagMatches(`*`, `"a"`), ShouldBeTrue)
+		So(etagMatches(`"a"`, `"b"`), ShouldBeFalse)
+		So(etagMatches(`*`, ""), ShouldBeFalse)
+	})
+}
+
+func TestMaxAge(t *testing.T) {
+	Convey("s-maxage is preferred to max-age", t, func() {
+		ttl, ok := maxAge([]string{"max-age=60, s-maxage=300"})
+		So(ok, ShouldBeTrue)
+		So(ttl, ShouldEqual, 5*time.Minute)
+	})
+
+	Convey("A response without an age has no max age", t, func() {
+		_, ok := maxAge([]string{"public"})
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("An invalid age may not be cached", t, func() {
+		ttl, ok := maxAge([]string{"max-age=soon"})
+		So(ok, ShouldBeTrue)
+		So(ttl, ShouldEqual, 0)
+	})
+}
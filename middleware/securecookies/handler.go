@@ -0,0 +1,125 @@
+package securecookies
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Policy is the attributes enforced on every cookie set in a response. Secure and HttpOnly are added to cookies that
+// lack them if set, other than HttpOnly on the cookies named in HTTPOnlyExclusions, which scripts need to read. SameSite
+// is added to cookies without a valid SameSite attribute if set, while cookies that set their own keep it.
+type Policy struct {
+	Secure             bool
+	HTTPOnly           bool
+	SameSite           string
+	HTTPOnlyExclusions []string
+}
+
+// Enabled reports whether the policy enforces any attributes
+func (p Policy) Enabled() bool {
+	return p.Secure || p.HTTPOnly || p.SameSite != ""
+}
+
+// Handler enforces policy on the cookies set in each response, such as those set by proxied backends with inconsistent
+// attributes, once the response headers are written
+func Handler(policy Policy) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			h.ServeHTTP(&cookieWriter{ResponseWriter: w, policy: policy}, req)
+		})
+	}
+}
+
+// Harden returns the Set-Cookie header value with the attributes of the policy that it lacks added. An invalid SameSite
+// attribute is replaced by the policy's.
+func (p Policy) Harden(setCookie string) string {
+	parts := strings.Split(strings.TrimRight(setCookie, "; "), ";")
+	name, _, _ := strings.Cut(strings.TrimSpace(parts[0]), "=")
+
+	kept := []string{parts[0]}
+	var secure, httpOnly, sameSite bool
+	for _, part := range parts[1:] {
+		attr, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(attr) {
+		case "secure":
+			secure = true
+		case "httponly":
+			httpOnly = true
+		case "samesite":
+			if !validSameSite(value) && p.SameSite != "" {
+				continue
+			}
+			sameSite = true
+		}
+		kept = append(kept, part)
+	}
+
+	if p.Secure && !secure {
+		kept = append(kept, " Secure")
+	}
+	if p.HTTPOnly && !httpOnly && !p.excluded(name) {
+		kept = append(kept, " HttpOnly")
+	}
+	if p.SameSite != "" && !sameSite {
+		kept = append(kept, " SameSite="+p.SameSite)
+	}
+	return strings.Join(kept, ";")
+}
+
+func (p Policy) excluded(name string) bool {
+	for _, exclusion := range p.HTTPOnlyExclusions {
+		if exclusion == name {
+			return true
+		}
+	}
+	return false
+}
+
+func validSameSite(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "strict", "lax", "none":
+		return true
+	}
+	return false
+}
+
+type cookieWriter struct {
+	http.ResponseWriter
+	policy      Policy
+	wroteHeader bool
+}
+
+func (cw *cookieWriter) WriteHeader(code int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		header := cw.Header()
+		cookies := header.Values("Set-Cookie")
+		if len(cookies) > 0 {
+			hardened := make([]string, 0, len(cookies))
+			for _, cookie := range cookies {
+				hardened = append(hardened, cw.policy.Harden(cookie))
+			}
+			header["Set-Cookie"] = hardened
+		}
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *cookieWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush allows streamed responses to be flushed through to the client
+func (cw *cookieWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *cookieWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
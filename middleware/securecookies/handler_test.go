@@ -0,0 +1,59 @@
+package securecookies
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHarden(t *testing.T) {
+	policy := Policy{Secure: true, HTTPOnly: true, SameSite: "Lax", HTTPOnlyExclusions: []string{"cookies_policy"}}
+
+	Convey("A cookie without any attributes gets all of them", t, func() {
+		So(policy.Harden("lang=cy; Path=/"), ShouldEqual, "lang=cy; Path=/; Secure; HttpOnly; SameSite=Lax")
+	})
+
+	Convey("A cookie with some of the attributes keeps them, whatever their case, and gets the others", t, func() {
+		So(policy.Harden("access_token=abc; path=/; secure; samesite=strict"), ShouldEqual,
+			"access_token=abc; path=/; secure; samesite=strict; HttpOnly")
+	})
+
+	Convey("A cookie with an invalid SameSite gets the policy's instead", t, func() {
+		So(policy.Harden("lang=cy; HttpOnly; Secure; SameSite=sometimes;"), ShouldEqual, "lang=cy; HttpOnly; Secure; SameSite=Lax")
+	})
+
+	Convey("A cookie that scripts need to read does not get HttpOnly", t, func() {
+		So(policy.Harden(`cookies_policy={"usage":true}; Path=/`), ShouldEqual, `cookies_policy={"usage":true}; Path=/; Secure; SameSite=Lax`)
+	})
+
+	Convey("A policy enforcing nothing leaves cookies as they are", t, func() {
+		So(Policy{}.Enabled(), ShouldBeFalse)
+		So(Policy{}.Harden("lang=cy; Path=/"), ShouldEqual, "lang=cy; Path=/")
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given the cookie hardening middleware", t, func() {
+		handler := Handler(Policy{Secure: true, HTTPOnly: true, SameSite: "Lax"})(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Add("Set-Cookie", "lang=cy; Path=/")
+				w.Header().Add("Set-Cookie", "collection=abc; Path=/; Secure; HttpOnly; SameSite=Strict")
+				_, _ = w.Write([]byte("body"))
+			}))
+
+		Convey("When a backend sets cookies", func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+			Convey("Then every cookie has the enforced attributes", func() {
+				So(w.Header().Values("Set-Cookie"), ShouldResemble, []string{
+					"lang=cy; Path=/; Secure; HttpOnly; SameSite=Lax",
+					"collection=abc; Path=/; Secure; HttpOnly; SameSite=Strict",
+				})
+				So(w.Body.String(), ShouldEqual, "body")
+			})
+		})
+	})
+}
@@ -0,0 +1,161 @@
+package securityheaders
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+)
+
+// Profile is a set of header values applied to a response
+type Profile map[string]string
+
+// Profiles holds the header profiles applied to each kind of response, and the content security policy applied to HTML
+// responses
+type Profiles struct {
+	HTML     Profile
+	NonHTML  Profile
+	Download Profile
+	Policy   Policy
+}
+
+var downloadContentTypes = []string{
+	"application/octet-stream",
+	"application/zip",
+	"application/pdf",
+	"application/vnd.ms-excel",
+	"application/vnd.openxmlformats-officedocument",
+	"text/csv",
+}
+
+// DefaultProfiles returns the header profiles for HTML, non-HTML and download responses. The content security policy
+// is only applied to HTML responses.
+func DefaultProfiles(policy Policy) Profiles {
+	return Profiles{
+		HTML:    Profile{"X-Content-Type-Options": "nosniff"},
+		NonHTML: Profile{"X-Content-Type-Options": "nosniff"},
+		Download: Profile{
+			"X-Content-Type-Options": "nosniff",
+			"X-Download-Options":     "noopen",
+		},
+		Policy: policy,
+	}
+}
+
+// Headers are the security headers applied to every response, whatever its type. Strict-Transport-Security is sent
+// with HSTSMaxAge in whole seconds if it is positive, and each of the other headers is sent with its value if set.
+type Headers struct {
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	ContentTypeOptions    string
+	ReferrerPolicy        string
+	PermissionsPolicy     string
+}
+
+// Profile returns the headers that are set as a profile
+func (h Headers) Profile() Profile {
+	profile := Profile{}
+	if h.HSTSMaxAge > 0 {
+		hsts := "max-age=" + strconv.FormatInt(int64(h.HSTSMaxAge/time.Second), 10)
+		if h.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if h.HSTSPreload {
+			hsts += "; preload"
+		}
+		profile["Strict-Transport-Security"] = hsts
+	}
+	if h.ContentTypeOptions != "" {
+		profile["X-Content-Type-Options"] = h.ContentTypeOptions
+	}
+	if h.ReferrerPolicy != "" {
+		profile["Referrer-Policy"] = h.ReferrerPolicy
+	}
+	if h.PermissionsPolicy != "" {
+		profile["Permissions-Policy"] = h.PermissionsPolicy
+	}
+	return profile
+}
+
+// Always applies profile to every response once its headers are written, without overwriting headers already set
+func Always(profile Profile) func(h http.Handler) http.Handler {
+	return Handler(Profiles{HTML: profile, NonHTML: profile, Download: profile})
+}
+
+// Handler applies the header profile matching the content type of the response once it is known, and the content
+// security policy for the request path to HTML responses. Headers already set on the response, for example by a
+// proxied backend, are not overwritten.
+func Handler(profiles Profiles) func(h http.Handler) http.Handler {
+	overrides := helpers.NewPrefixMap(profiles.Policy.Overrides)
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			policy := profiles.Policy.forPath(req.URL.Path, overrides)
+			h.ServeHTTP(&profileWriter{ResponseWriter: w, profiles: profiles, policy: policy}, req)
+		})
+	}
+}
+
+// profileFor returns the profile for a response with the given headers, and whether it is an HTML response
+func (p Profiles) profileFor(header http.Header) (Profile, bool) {
+	if strings.HasPrefix(header.Get("Content-Disposition"), "attachment") {
+		return p.Download, false
+	}
+
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "text/html") {
+		return p.HTML, true
+	}
+	for _, downloadType := range downloadContentTypes {
+		if strings.HasPrefix(contentType, downloadType) {
+			return p.Download, false
+		}
+	}
+	return p.NonHTML, false
+}
+
+type profileWriter struct {
+	http.ResponseWriter
+	profiles    Profiles
+	policy      string
+	wroteHeader bool
+}
+
+func (pw *profileWriter) WriteHeader(code int) {
+	if !pw.wroteHeader {
+		pw.wroteHeader = true
+		header := pw.Header()
+		profile, html := pw.profiles.profileFor(header)
+		for name, value := range profile {
+			if header.Get(name) == "" {
+				header.Set(name, value)
+			}
+		}
+		if html && pw.policy != "" && header.Get(pw.profiles.Policy.Header()) == "" {
+			header.Set(pw.profiles.Policy.Header(), pw.policy)
+		}
+	}
+	pw.ResponseWriter.WriteHeader(code)
+}
+
+func (pw *profileWriter) Write(b []byte) (int, error) {
+	if !pw.wroteHeader {
+		pw.WriteHeader(http.StatusOK)
+	}
+	return pw.ResponseWriter.Write(b)
+}
+
+// Flush allows streamed responses to be flushed through to the client
+func (pw *profileWriter) Flush() {
+	if f, ok := pw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (pw *profileWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
@@ -0,0 +1,194 @@
+package securityheaders
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// respondWith returns a handler that responds with the given headers, in the way a proxied backend would
+func respondWith(headers map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for name, value := range headers {
+			w.Header().Set(name, value)
+		}
+		_, _ = w.Write([]byte("body"))
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given the security headers middleware with a content security policy", t, func() {
+		middleware := Handler(DefaultProfiles(Policy{Default: "default-src 'self'"}))
+		req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+
+		Convey("When an HTML response is served", func() {
+			w := httptest.NewRecorder()
+			middleware(respondWith(map[string]string{"Content-Type": "text/html; charset=utf-8"})).ServeHTTP(w, req)
+
+			Convey("Then the full set of headers is applied", func() {
+				So(w.Header().Get("X-Content-Type-Options"), ShouldEqual, "nosniff")
+				So(w.Header().Get("Content-Security-Policy"), ShouldEqual, "default-src 'self'")
+				So(w.Header().Get("X-Download-Options"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a binary download is served", func() {
+			w := httptest.NewRecorder()
+			middleware(respondWith(map[string]string{"Content-Type": "application/vnd.ms-excel"})).ServeHTTP(w, req)
+
+			Convey("Then the reduced download set of headers is applied", func() {
+				So(w.Header().Get("X-Content-Type-Options"), ShouldEqual, "nosniff")
+				So(w.Header().Get("X-Download-Options"), ShouldEqual, "noopen")
+				So(w.Header().Get("Content-Security-Policy"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a response is served as an attachment", func() {
+			w := httptest.NewRecorder()
+			middleware(respondWith(map[string]string{
+				"Content-Type":        "text/plain",
+				"Content-Disposition": `attachment; filename="data.txt"`,
+			})).ServeHTTP(w, req)
+
+			Convey("Then it is treated as a download", func() {
+				So(w.Header().Get("X-Download-Options"), ShouldEqual, "noopen")
+				So(w.Header().Get("Content-Security-Policy"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a non-HTML response is served", func() {
+			w := httptest.NewRecorder()
+			middleware(respondWith(map[string]string{"Content-Type": "application/json"})).ServeHTTP(w, req)
+
+			Convey("Then only the non-HTML headers are applied", func() {
+				So(w.Header().Get("X-Content-Type-Options"), ShouldEqual, "nosniff")
+				So(w.Header().Get("Content-Security-Policy"), ShouldBeEmpty)
+				So(w.Header().Get("X-Download-Options"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the backend sets its own content security policy", func() {
+			w := httptest.NewRecorder()
+			middleware(respondWith(map[string]string{
+				"Content-Type":            "text/html",
+				"Content-Security-Policy": "frame-ancestors *",
+			})).ServeHTTP(w, req)
+
+			Convey("Then it is not overwritten", func() {
+				So(w.Header().Get("Content-Security-Policy"), ShouldEqual, "frame-ancestors *")
+			})
+		})
+	})
+}
+
+func TestHandlerPolicyOverrides(t *testing.T) {
+	Convey("Given the security headers middleware with content security policy overrides for embeddable pages", t, func() {
+		middleware := Handler(DefaultProfiles(Policy{
+			Default: "default-src 'self'; frame-ancestors 'self'",
+			Overrides: map[string]string{
+				"/visualisations/":    "default-src 'self'; frame-ancestors *",
+				"/visualisations/dvc": "default-src 'self'; script-src 'self' 'unsafe-eval'; frame-ancestors *",
+			},
+		}))
+		serve := func(path, contentType string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+			middleware(respondWith(map[string]string{"Content-Type": contentType})).ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When an HTML page under an overridden prefix is served", func() {
+			w := serve("/visualisations/census", "text/html")
+
+			Convey("Then it gets the policy of that prefix", func() {
+				So(w.Header().Get("Content-Security-Policy"), ShouldEqual, "default-src 'self'; frame-ancestors *")
+			})
+		})
+
+		Convey("When an HTML page under more than one overridden prefix is served", func() {
+			w := serve("/visualisations/dvc123", "text/html")
+
+			Convey("Then it gets the policy of the longest prefix", func() {
+				So(w.Header().Get("Content-Security-Policy"), ShouldEqual,
+					"default-src 'self'; script-src 'self' 'unsafe-eval'; frame-ancestors *")
+			})
+		})
+
+		Convey("When any other HTML page is served", func() {
+			w := serve("/economy", "text/html")
+
+			Convey("Then it gets the default policy", func() {
+				So(w.Header().Get("Content-Security-Policy"), ShouldEqual, "default-src 'self'; frame-ancestors 'self'")
+			})
+		})
+
+		Convey("When a non-HTML response under an overridden prefix is served", func() {
+			w := serve("/visualisations/census/data.json", "application/json")
+
+			Convey("Then no policy is applied", func() {
+				So(w.Header().Get("Content-Security-Policy"), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given the security headers middleware with a report only content security policy", t, func() {
+		middleware := Handler(DefaultProfiles(Policy{Default: "default-src 'self'", ReportOnly: true}))
+
+		Convey("When an HTML page is served", func() {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			middleware(respondWith(map[string]string{"Content-Type": "text/html"})).ServeHTTP(w, req)
+
+			Convey("Then the policy is only reported", func() {
+				So(w.Header().Get("Content-Security-Policy-Report-Only"), ShouldEqual, "default-src 'self'")
+				So(w.Header().Get("Content-Security-Policy"), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestHeaders(t *testing.T) {
+	Convey("Given security headers for every response", t, func() {
+		headers := Headers{
+			HSTSMaxAge:            8760 * time.Hour,
+			HSTSIncludeSubdomains: true,
+			HSTSPreload:           true,
+			ContentTypeOptions:    "nosniff",
+			ReferrerPolicy:        "strict-origin-when-cross-origin",
+			PermissionsPolicy:     "camera=(), geolocation=()",
+		}
+
+		Convey("Then each is in the profile", func() {
+			So(headers.Profile(), ShouldResemble, Profile{
+				"Strict-Transport-Security": "max-age=31536000; includeSubDomains; preload",
+				"X-Content-Type-Options":    "nosniff",
+				"Referrer-Policy":           "strict-origin-when-cross-origin",
+				"Permissions-Policy":        "camera=(), geolocation=()",
+			})
+		})
+
+		Convey("When they are applied to a response that sets its own referrer policy", func() {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			Always(headers.Profile())(respondWith(map[string]string{
+				"Content-Type":    "application/json",
+				"Referrer-Policy": "no-referrer",
+			})).ServeHTTP(w, req)
+
+			Convey("Then the others are applied, without overwriting it", func() {
+				So(w.Header().Get("Strict-Transport-Security"), ShouldEqual, "max-age=31536000; includeSubDomains; preload")
+				So(w.Header().Get("Permissions-Policy"), ShouldEqual, "camera=(), geolocation=()")
+				So(w.Header().Get("Referrer-Policy"), ShouldEqual, "no-referrer")
+			})
+		})
+	})
+
+	Convey("Given no security headers are set", t, func() {
+		Convey("Then the profile is empty", func() {
+			So(Headers{}.Profile(), ShouldBeEmpty)
+		})
+	})
+}
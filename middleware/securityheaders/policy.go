@@ -0,0 +1,29 @@
+package securityheaders
+
+import "github.com/ONSdigital/dp-frontend-router/helpers"
+
+// Policy is the content security policy applied to HTML responses: Default for every path other than those under a
+// prefix in Overrides, such as embeddable pages that need relaxed frame-ancestors and script sources, which get the
+// policy of the longest matching prefix instead. An empty policy is not applied. If ReportOnly is set, policies are
+// sent in the report only header, so that violations are reported without anything being blocked.
+type Policy struct {
+	Default    string
+	Overrides  map[string]string
+	ReportOnly bool
+}
+
+// Header returns the name of the header the policy is sent in
+func (p Policy) Header() string {
+	if p.ReportOnly {
+		return "Content-Security-Policy-Report-Only"
+	}
+	return "Content-Security-Policy"
+}
+
+// forPath returns the policy for path, given the overrides by prefix
+func (p Policy) forPath(path string, overrides helpers.PrefixMap[string]) string {
+	if _, policy, ok := overrides.Match(path); ok {
+		return policy
+	}
+	return p.Default
+}
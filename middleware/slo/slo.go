@@ -0,0 +1,97 @@
+package slo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/gorilla/mux"
+)
+
+// Results a request is classified as
+const (
+	Good = "good"
+	Bad  = "bad"
+)
+
+// unmatchedRoute is the route label of requests that matched no route
+const unmatchedRoute = "unmatched"
+
+// ParseThresholds converts a map of path prefix to duration, as read from config, into latency thresholds
+func ParseThresholds(thresholds map[string]string) (map[string]time.Duration, error) {
+	parsed := make(map[string]time.Duration, len(thresholds))
+	for prefix, value := range thresholds {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid latency threshold %q for prefix %q: %w", value, prefix, err)
+		}
+		parsed[prefix] = d
+	}
+	return parsed, nil
+}
+
+// Recorder counts requests per route as good or bad for SLO tracking. A request is bad if it responds with a 5xx, or
+// takes longer than the latency threshold of its route group.
+type Recorder struct {
+	requests         *metrics.CounterVec
+	defaultThreshold time.Duration
+	thresholds       helpers.PrefixMap[time.Duration]
+	now              func() time.Time
+}
+
+// NewRecorder creates a Recorder, registering its counters with registry. Requests are held to the threshold of the
+// longest path prefix in thresholds that they match, or defaultThreshold if they match none.
+func NewRecorder(registry *metrics.Registry, defaultThreshold time.Duration, thresholds map[string]time.Duration) *Recorder {
+	r := &Recorder{
+		requests: metrics.NewCounterVec("dp_frontend_router_slo_requests_total",
+			"Requests by route, classified as good or bad against the route's SLO.", "route", "result"),
+		defaultThreshold: defaultThreshold,
+		thresholds:       helpers.NewPrefixMap(thresholds),
+		now:              time.Now,
+	}
+	registry.Register(r.requests)
+	return r
+}
+
+// Handler is mux middleware recording the result of each request against the route it matched
+func (r *Recorder) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		started := r.now()
+		sw := helpers.NewStatusWriter(w)
+		h.ServeHTTP(sw, req)
+		r.requests.Inc(routeName(req), r.classify(req.URL.Path, sw.Status(), r.now().Sub(started)))
+	})
+}
+
+// classify returns whether a request for path, with the given response status and latency, is good or bad
+func (r *Recorder) classify(path string, status int, latency time.Duration) string {
+	if status >= http.StatusInternalServerError || latency > r.thresholdFor(path) {
+		return Bad
+	}
+	return Good
+}
+
+func (r *Recorder) thresholdFor(path string) time.Duration {
+	if _, threshold, ok := r.thresholds.Match(path); ok {
+		return threshold
+	}
+	return r.defaultThreshold
+}
+
+// routeName identifies the route a request matched by its path template or, for matcher routes, its name
+func routeName(req *http.Request) string {
+	route := mux.CurrentRoute(req)
+	if route == nil {
+		return unmatchedRoute
+	}
+	if tpl, err := route.GetPathTemplate(); err == nil {
+		return tpl
+	}
+	if name := route.GetName(); name != "" {
+		return name
+	}
+	return unmatchedRoute
+}
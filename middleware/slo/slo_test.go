@@ -0,0 +1,95 @@
+package slo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseThresholds(t *testing.T) {
+	Convey("Given latency thresholds from config", t, func() {
+		Convey("Then valid durations are parsed and invalid ones are rejected", func() {
+			thresholds, err := ParseThresholds(map[string]string{"/search": "500ms"})
+			So(err, ShouldBeNil)
+			So(thresholds, ShouldResemble, map[string]time.Duration{"/search": 500 * time.Millisecond})
+
+			_, err = ParseThresholds(map[string]string{"/search": "fast"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestRecorder(t *testing.T) {
+	Convey("Given a recorder with a 1s default threshold and a 200ms threshold for search", t, func() {
+		registry := metrics.NewRegistry()
+		recorder := NewRecorder(registry, time.Second, map[string]time.Duration{"/search": 200 * time.Millisecond})
+
+		// each request takes the latency it asks for, on a fake clock
+		var clock time.Time
+		recorder.now = func() time.Time { return clock }
+		var latency time.Duration
+		var status int
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			clock = clock.Add(latency)
+			w.WriteHeader(status)
+		})
+
+		router := mux.NewRouter()
+		router.Use(recorder.Handler)
+		router.Handle("/search", handler)
+		router.Handle("/datasets/{uri:.*}", handler)
+
+		serve := func(target string, s int, l time.Duration) {
+			status, latency = s, l
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, http.NoBody))
+		}
+
+		Convey("When requests are fast and successful", func() {
+			serve("/search", http.StatusOK, 100*time.Millisecond)
+			serve("/datasets/cpih01", http.StatusNotFound, 900*time.Millisecond)
+
+			Convey("Then they are good, including client errors", func() {
+				So(recorder.requests.Value("/search", Good), ShouldEqual, 1)
+				So(recorder.requests.Value("/datasets/{uri:.*}", Good), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When a request responds with a 5xx", func() {
+			serve("/datasets/cpih01", http.StatusBadGateway, time.Millisecond)
+
+			Convey("Then it is bad", func() {
+				So(recorder.requests.Value("/datasets/{uri:.*}", Bad), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When requests exceed their group's latency threshold", func() {
+			serve("/search", http.StatusOK, 300*time.Millisecond)
+			serve("/datasets/cpih01", http.StatusOK, 1500*time.Millisecond)
+
+			Convey("Then they are bad", func() {
+				So(recorder.requests.Value("/search", Bad), ShouldEqual, 1)
+				So(recorder.requests.Value("/datasets/{uri:.*}", Bad), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When a request is slower than the search threshold but within the default", func() {
+			serve("/datasets/cpih01", http.StatusOK, 300*time.Millisecond)
+
+			Convey("Then it is good, as only search is held to the lower threshold", func() {
+				So(recorder.requests.Value("/datasets/{uri:.*}", Good), ShouldEqual, 1)
+			})
+		})
+
+		Convey("Then the counters are registered for scraping", func() {
+			serve("/search", http.StatusOK, time.Millisecond)
+			w := httptest.NewRecorder()
+			metrics.Handler(registry).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+			So(w.Body.String(), ShouldContainSubstring, `dp_frontend_router_slo_requests_total{route="/search",result="good"} 1`)
+		})
+	})
+}
@@ -0,0 +1,174 @@
+package staleiferror
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/cache"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const (
+	// WarningHeader is set on a stale response served in place of an upstream error
+	WarningHeader = "Warning"
+	// StaleWarning is the value of the warning header on a stale response
+	StaleWarning = `110 - "Response is Stale"`
+
+	// maxBodyBytes is the largest response body that will be cached
+	maxBodyBytes = 2 << 20
+)
+
+var _ cache.StatsReporter = &StaleIfError{}
+
+type response struct {
+	header http.Header
+	body   []byte
+}
+
+// StaleIfError caches successful HTML responses so that, when the upstream later fails with a 5xx, the cached copy can
+// be served instead of the error. Responses that are private, marked no-store or set cookies are never cached, and
+// responses are keyed on the encodings the client accepts, so that a compressed copy is only served to clients that
+// can decode it.
+type StaleIfError struct {
+	responses *cache.Cache[*response]
+	cookies   cache.CookiePolicy
+}
+
+// New creates a StaleIfError caching at most maxEntries responses, each of which can be served in place of an error
+// for up to window after it was cached. Responses are keyed, or not cached at all, according to the cookie policy.
+func New(maxEntries int, window time.Duration, cookies cache.CookiePolicy) *StaleIfError {
+	return &StaleIfError{
+		responses: cache.New[*response](maxEntries, window),
+		cookies:   cookies,
+	}
+}
+
+// Stats returns the statistics of the underlying response cache
+func (s *StaleIfError) Stats() cache.Stats {
+	return s.responses.Stats()
+}
+
+// Handler wraps h with stale-if-error behaviour for GET requests
+func (s *StaleIfError) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		key, ok := s.cookies.Key(req, cache.EncodingKey(req, req.Host+req.URL.RequestURI()))
+		if !ok {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		sw := &staleWriter{ResponseWriter: w, key: key, responses: s.responses}
+		h.ServeHTTP(sw, req)
+
+		switch {
+		case sw.stale != nil:
+			log.Warn(req.Context(), "upstream error, serving stale response", log.Data{"status": sw.status, "key": key})
+			serveStale(w, req, sw.stale)
+		case sw.capture:
+			s.responses.Set(key, &response{header: w.Header().Clone(), body: sw.buf.Bytes()})
+		}
+	})
+}
+
+func serveStale(w http.ResponseWriter, req *http.Request, stale *response) {
+	header := w.Header()
+	for k := range header {
+		delete(header, k)
+	}
+	for k, v := range stale.header {
+		header[k] = v
+	}
+	header.Set(WarningHeader, StaleWarning)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(stale.body); err != nil {
+		log.Error(req.Context(), "error writing stale response", err)
+	}
+}
+
+// staleWriter passes the upstream response through, capturing successful HTML bodies, unless the upstream fails with
+// a 5xx and a cached response is available, in which case the upstream response is discarded
+type staleWriter struct {
+	http.ResponseWriter
+	key         string
+	responses   *cache.Cache[*response]
+	wroteHeader bool
+	status      int
+	stale       *response
+	capture     bool
+	buf         bytes.Buffer
+}
+
+func (sw *staleWriter) WriteHeader(code int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+	sw.status = code
+
+	if code >= http.StatusInternalServerError {
+		if stale, ok := sw.responses.Get(sw.key); ok {
+			sw.stale = stale
+			return
+		}
+	}
+
+	sw.capture = code == http.StatusOK && isHTML(sw.Header().Get("Content-Type")) && storable(sw.Header())
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *staleWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if sw.stale != nil {
+		return len(b), nil
+	}
+	if sw.capture {
+		if sw.buf.Len()+len(b) > maxBodyBytes {
+			sw.capture = false
+			sw.buf = bytes.Buffer{}
+		} else {
+			sw.buf.Write(b)
+		}
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+func (sw *staleWriter) Flush() {
+	if sw.stale != nil {
+		return
+	}
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// storable reports whether a response may be kept to serve to other clients, that is, it does not set cookies and is
+// not marked private or no-store
+func storable(header http.Header) bool {
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+			if name == "private" || name == "no-store" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "text/html"
+}
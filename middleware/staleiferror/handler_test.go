@@ -0,0 +1,196 @@
+package staleiferror
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/cache"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHandler(t *testing.T) {
+	Convey("Given a stale-if-error handler in front of an upstream", t, func() {
+		upstreamStatus := http.StatusOK
+		upstreamBody := "<html>release</html>"
+		upstreamHeader := http.Header{}
+		upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			for k, v := range upstreamHeader {
+				w.Header()[k] = v
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if req.Header.Get("Accept-Encoding") == "gzip" {
+				w.Header().Set("Content-Encoding", "gzip")
+			}
+			w.WriteHeader(upstreamStatus)
+			w.Write([]byte(upstreamBody))
+		})
+
+		s := New(10, time.Minute, cache.CookiePolicy{})
+		handler := s.Handler(upstream)
+
+		serve := func(target string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+			return w
+		}
+
+		Convey("When a page is served successfully and the upstream then returns a 500", func() {
+			first := serve("/economy")
+			upstreamStatus = http.StatusInternalServerError
+			upstreamBody = "internal error"
+			second := serve("/economy")
+
+			Convey("Then the first response is passed through", func() {
+				So(first.Code, ShouldEqual, http.StatusOK)
+				So(first.Header().Get(WarningHeader), ShouldBeEmpty)
+			})
+
+			Convey("Then the cached 200 is served with a stale indicator instead of the error", func() {
+				So(second.Code, ShouldEqual, http.StatusOK)
+				So(second.Body.String(), ShouldEqual, "<html>release</html>")
+				So(second.Header().Get(WarningHeader), ShouldEqual, StaleWarning)
+				So(second.Header().Get("Content-Type"), ShouldEqual, "text/html; charset=utf-8")
+			})
+
+			Convey("Then the stale hit is recorded in the stats", func() {
+				So(s.Stats().Hits, ShouldEqual, 1)
+				So(s.Stats().Size, ShouldEqual, 1)
+			})
+		})
+
+		for name, header := range map[string]http.Header{
+			"sets a cookie":       {"Set-Cookie": {"session=abc"}},
+			"is private":          {"Cache-Control": {"private, max-age=60"}},
+			"is marked no-store":  {"Cache-Control": {"No-Store"}},
+			"has both directives": {"Cache-Control": {"max-age=60", "private"}},
+		} {
+			header := header
+			Convey("When a page that "+name+" is served successfully and the upstream then returns a 500", func() {
+				upstreamHeader = header
+				serve("/economy")
+				upstreamStatus = http.StatusInternalServerError
+				w := serve("/economy")
+
+				Convey("Then it was not cached, and the error is passed through", func() {
+					So(s.Stats().Size, ShouldEqual, 0)
+					So(w.Code, ShouldEqual, http.StatusInternalServerError)
+				})
+			})
+		}
+
+		Convey("When a page is served compressed to a gzip client and the upstream then returns a 500", func() {
+			gzipReq := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			gzipReq.Header.Set("Accept-Encoding", "gzip")
+			handler.ServeHTTP(httptest.NewRecorder(), gzipReq)
+			upstreamStatus = http.StatusInternalServerError
+			identity := serve("/economy")
+
+			Convey("Then the compressed copy is not served to a client without gzip", func() {
+				So(identity.Code, ShouldEqual, http.StatusInternalServerError)
+				So(identity.Header().Get("Content-Encoding"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the upstream returns a 500 for a page that has not been cached", func() {
+			upstreamStatus = http.StatusInternalServerError
+			w := serve("/economy")
+
+			Convey("Then the error is passed through", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+
+		Convey("When the upstream returns a 500 after the stale window has passed", func() {
+			handler = New(10, time.Millisecond, cache.CookiePolicy{}).Handler(upstream)
+			serve("/economy")
+			time.Sleep(5 * time.Millisecond)
+			upstreamStatus = http.StatusInternalServerError
+			w := serve("/economy")
+
+			Convey("Then the error is passed through", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+	})
+
+	Convey("Given a stale-if-error handler in front of an upstream serving non-HTML content", t, func() {
+		upstreamStatus := http.StatusOK
+		upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(upstreamStatus)
+		})
+		s := New(10, time.Minute, cache.CookiePolicy{})
+		handler := s.Handler(upstream)
+
+		Convey("When the upstream succeeds and then returns a 502", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/data", http.NoBody))
+			upstreamStatus = http.StatusBadGateway
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/data", http.NoBody))
+
+			Convey("Then the error is passed through as nothing was cached", func() {
+				So(w.Code, ShouldEqual, http.StatusBadGateway)
+				So(s.Stats().Size, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestHandlerCookiePolicy(t *testing.T) {
+	Convey("Given a stale-if-error handler varying on the lang cookie and bypassing on the collection cookie", t, func() {
+		upstreamStatus := http.StatusOK
+		upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(upstreamStatus)
+			if c, err := req.Cookie("lang"); err == nil {
+				w.Write([]byte(c.Value))
+			}
+		})
+		s := New(10, time.Minute, cache.CookiePolicy{VaryCookies: []string{"lang"}, BypassCookies: []string{"collection"}})
+		handler := s.Handler(upstream)
+
+		serve := func(cookies ...*http.Cookie) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/economy", http.NoBody)
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When pages are cached for each language and the upstream then fails", func() {
+			serve(&http.Cookie{Name: "lang", Value: "en"})
+			serve(&http.Cookie{Name: "lang", Value: "cy"})
+			upstreamStatus = http.StatusInternalServerError
+			en := serve(&http.Cookie{Name: "lang", Value: "en"})
+			cy := serve(&http.Cookie{Name: "lang", Value: "cy"})
+
+			Convey("Then each language is served its own cached copy", func() {
+				So(s.Stats().Size, ShouldEqual, 2)
+				So(en.Body.String(), ShouldEqual, "en")
+				So(cy.Body.String(), ShouldEqual, "cy")
+			})
+		})
+
+		Convey("When a page is requested with the bypass cookie", func() {
+			serve(&http.Cookie{Name: "collection", Value: "abc"})
+
+			Convey("Then it is not cached", func() {
+				So(s.Stats().Size, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a page is cached and then requested with the bypass cookie while the upstream fails", func() {
+			serve()
+			upstreamStatus = http.StatusInternalServerError
+			w := serve(&http.Cookie{Name: "collection", Value: "abc"})
+
+			Convey("Then the cached copy is not served", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+	})
+}
@@ -0,0 +1,136 @@
+package streaming
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const eventStreamContentType = "text/event-stream"
+
+var errConnectionLimitReached = errors.New("streaming connection limit reached")
+
+// Limiter caps the number of concurrent long-lived streaming responses. A response is treated as streaming if it is
+// served from one of the configured path prefixes, or if it is sent with an event stream content type.
+type Limiter struct {
+	slots    chan struct{}
+	prefixes []string
+}
+
+// New creates a Limiter allowing at most maxConnections concurrent streaming responses
+func New(maxConnections int, streamingPathPrefixes []string) *Limiter {
+	return &Limiter{
+		slots:    make(chan struct{}, maxConnections),
+		prefixes: streamingPathPrefixes,
+	}
+}
+
+// Active returns the number of streaming responses currently being served
+func (l *Limiter) Active() int {
+	return len(l.slots)
+}
+
+// Handler is the middleware enforcing the streaming connection limit, returning 503 once it has been reached
+func (l *Limiter) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if l.isStreamingPath(req.URL.Path) {
+			if !l.acquire() {
+				log.Warn(req.Context(), errConnectionLimitReached.Error(), log.Data{"path": req.URL.Path})
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			defer l.release()
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		sw := &streamWriter{ResponseWriter: w, limiter: l}
+		defer func() {
+			if sw.acquired {
+				l.release()
+			}
+		}()
+		h.ServeHTTP(sw, req)
+
+		if sw.rejected {
+			log.Warn(req.Context(), errConnectionLimitReached.Error(), log.Data{"path": req.URL.Path})
+		}
+	})
+}
+
+func (l *Limiter) isStreamingPath(path string) bool {
+	for _, prefix := range l.prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Limiter) acquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *Limiter) release() {
+	<-l.slots
+}
+
+// streamWriter detects streaming responses by their content type when the status is written, which is the first point
+// at which the content type of a proxied response is known
+type streamWriter struct {
+	http.ResponseWriter
+	limiter     *Limiter
+	wroteHeader bool
+	acquired    bool
+	rejected    bool
+}
+
+func (sw *streamWriter) WriteHeader(code int) {
+	if sw.wroteHeader {
+		return
+	}
+	sw.wroteHeader = true
+
+	if strings.HasPrefix(sw.Header().Get("Content-Type"), eventStreamContentType) {
+		if !sw.limiter.acquire() {
+			sw.rejected = true
+			sw.Header().Del("Content-Type")
+			sw.ResponseWriter.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		sw.acquired = true
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *streamWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if sw.rejected {
+		return 0, errConnectionLimitReached
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush allows streamed responses to be flushed through to the client
+func (sw *streamWriter) Flush() {
+	if sw.rejected {
+		return
+	}
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (sw *streamWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,116 @@
+package streaming
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// eventStreamHandler serves an event stream until the client disconnects
+var eventStreamHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("data: hello\n\n")); err != nil {
+		return
+	}
+	w.(http.Flusher).Flush()
+	<-req.Context().Done()
+})
+
+// serveUntilDisconnect starts serving a request in the background, returning the recorder, a function to disconnect
+// the client and a channel that is closed once the handler returns
+func serveUntilDisconnect(h http.Handler, path string) (*httptest.ResponseRecorder, context.CancelFunc, chan struct{}) {
+	ctx, disconnect := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, path, http.NoBody).WithContext(ctx)
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(w, req)
+	}()
+	return w, disconnect, done
+}
+
+func TestLimiter(t *testing.T) {
+	Convey("Given a limiter allowing one streaming connection", t, func() {
+		limiter := New(1, []string{"/stream/"})
+
+		Convey("When an event stream response is being served", func() {
+			h := limiter.Handler(eventStreamHandler)
+			w1, disconnect, done := serveUntilDisconnect(h, "/events")
+			So(waitFor(func() bool { return limiter.Active() == 1 }), ShouldBeTrue)
+
+			Convey("Then a second event stream is rejected with 503", func() {
+				w2 := httptest.NewRecorder()
+				h.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, "/events", http.NoBody))
+				So(w2.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(w2.Body.String(), ShouldBeEmpty)
+
+				Convey("And the slot is released once the first client disconnects", func() {
+					disconnect()
+					<-done
+					So(w1.Code, ShouldEqual, http.StatusOK)
+					So(limiter.Active(), ShouldEqual, 0)
+
+					w3, disconnect3, done3 := serveUntilDisconnect(h, "/events")
+					So(waitFor(func() bool { return limiter.Active() == 1 }), ShouldBeTrue)
+					disconnect3()
+					<-done3
+					So(w3.Code, ShouldEqual, http.StatusOK)
+				})
+			})
+
+			Convey("Then ordinary responses are not limited", func() {
+				w2 := httptest.NewRecorder()
+				limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					w.Header().Set("Content-Type", "text/html")
+					_, _ = w.Write([]byte("<html></html>"))
+				})).ServeHTTP(w2, httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+				So(w2.Code, ShouldEqual, http.StatusOK)
+			})
+
+			Reset(func() {
+				disconnect()
+				<-done
+			})
+		})
+
+		Convey("When a request is made to a configured streaming path", func() {
+			var served int32
+			h := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				atomic.AddInt32(&served, 1)
+				<-req.Context().Done()
+			}))
+			_, disconnect, done := serveUntilDisconnect(h, "/stream/one")
+			So(waitFor(func() bool { return limiter.Active() == 1 }), ShouldBeTrue)
+
+			Convey("Then further requests to the streaming path are rejected before reaching the handler", func() {
+				w2 := httptest.NewRecorder()
+				h.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, "/stream/two", http.NoBody))
+				So(w2.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(atomic.LoadInt32(&served), ShouldEqual, 1)
+			})
+
+			Reset(func() {
+				disconnect()
+				<-done
+				So(limiter.Active(), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func waitFor(condition func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
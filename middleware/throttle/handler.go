@@ -0,0 +1,92 @@
+package throttle
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+	"github.com/ONSdigital/dp-frontend-router/ratelimit"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Limit is the rate of requests that each client may make to a route group, in requests per second on average, and in
+// bursts of up to Burst requests
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimits converts a map of path prefix to limit, as read from config in the form "rate/burst", into limits
+func ParseLimits(limits map[string]string) (map[string]Limit, error) {
+	parsed := make(map[string]Limit, len(limits))
+	for prefix, value := range limits {
+		rate, burst, ok := strings.Cut(strings.TrimSpace(value), "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q for prefix %q, expected rate/burst", value, prefix)
+		}
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil || r <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q for prefix %q, rate must be a positive number", value, prefix)
+		}
+		b, err := strconv.Atoi(burst)
+		if err != nil || b < 1 {
+			return nil, fmt.Errorf("invalid rate limit %q for prefix %q, burst must be a positive integer", value, prefix)
+		}
+		parsed[prefix] = Limit{Rate: r, Burst: b}
+	}
+	return parsed, nil
+}
+
+// Limiter limits the rate of requests from each client, identified through trustedProxies as for the IP filter, to each
+// route group. A request belongs to the group of the longest matching path prefix, and requests over the group's limit
+// get 429 Too Many Requests with a Retry-After header. Paths that don't match any prefix are not limited.
+type Limiter struct {
+	limits         map[string]Limit
+	limiters       helpers.PrefixMap[*ratelimit.Limiter]
+	trustedProxies []netip.Prefix
+}
+
+// New creates a Limiter for limits, tracking at most maxClients clients per route group at once. The Limiter holds the
+// state of every client, so should be created once and shared by each router built from the same config.
+func New(limits map[string]Limit, maxClients int, trustedProxies []netip.Prefix) *Limiter {
+	limiters := make(map[string]*ratelimit.Limiter, len(limits))
+	for prefix, limit := range limits {
+		limiters[prefix] = ratelimit.New(limit.Rate, limit.Burst, maxClients)
+	}
+	return &Limiter{
+		limits:         limits,
+		limiters:       helpers.NewPrefixMap(limiters),
+		trustedProxies: trustedProxies,
+	}
+}
+
+// Handler is the middleware enforcing the rate limits
+func (l *Limiter) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		prefix, limiter, ok := l.limiters.Match(req.URL.Path)
+		if !ok {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		clientIP := ipfilter.ClientKey(req, l.trustedProxies)
+		if limiter.Allow(clientIP) {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		log.Info(req.Context(), "rate limited request", log.Data{"client_ip": clientIP, "route_group": prefix})
+		w.Header().Set("Retry-After", retryAfter(l.limits[prefix].Rate))
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+}
+
+// retryAfter is the number of whole seconds until a client that has used up its burst is allowed another request
+func retryAfter(rate float64) string {
+	return strconv.Itoa(int(math.Ceil(1 / rate)))
+}
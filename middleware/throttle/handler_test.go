@@ -0,0 +1,97 @@
+package throttle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseLimits(t *testing.T) {
+	Convey("Given rate limits by path prefix as configured", t, func() {
+		limits, err := ParseLimits(map[string]string{"/": "10/20", "/search": " 0.5/2 "})
+
+		Convey("Then they are parsed into limits", func() {
+			So(err, ShouldBeNil)
+			So(limits, ShouldResemble, map[string]Limit{"/": {Rate: 10, Burst: 20}, "/search": {Rate: 0.5, Burst: 2}})
+		})
+	})
+
+	Convey("Given a rate limit without a burst", t, func() {
+		_, err := ParseLimits(map[string]string{"/search": "10"})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldBeError, `invalid rate limit "10" for prefix "/search", expected rate/burst`)
+		})
+	})
+
+	Convey("Given a rate limit with a rate of zero", t, func() {
+		_, err := ParseLimits(map[string]string{"/search": "0/10"})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldBeError, `invalid rate limit "0/10" for prefix "/search", rate must be a positive number`)
+		})
+	})
+
+	Convey("Given a rate limit with a burst that is not a whole number", t, func() {
+		_, err := ParseLimits(map[string]string{"/search": "1/1.5"})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldBeError, `invalid rate limit "1/1.5" for prefix "/search", burst must be a positive integer`)
+		})
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given a rate limit for all paths and a stricter one for search, behind a trusted proxy", t, func() {
+		limits := map[string]Limit{"/": {Rate: 100, Burst: 3}, "/search": {Rate: 0.5, Burst: 1}}
+		trustedProxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+		handler := New(limits, 100, trustedProxies).Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+		request := func(path, forwardedFor string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+			req.RemoteAddr = "10.0.0.2:1234"
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		Convey("When a client exceeds the search limit", func() {
+			first := request("/search?q=cpi", "1.1.1.1")
+			second := request("/search?q=gdp", "1.1.1.1, 10.0.0.1")
+
+			Convey("Then requests over the limit get a 429 with the wait before the next is allowed", func() {
+				So(first.Code, ShouldEqual, http.StatusOK)
+				So(second.Code, ShouldEqual, http.StatusTooManyRequests)
+				So(second.Header().Get("Retry-After"), ShouldEqual, "2")
+			})
+
+			Convey("Then the client cannot get around the limit by sending its own X-Forwarded-For", func() {
+				So(request("/search", "203.0.113.9, 1.1.1.1").Code, ShouldEqual, http.StatusTooManyRequests)
+			})
+
+			Convey("Then other clients are limited separately", func() {
+				So(request("/search", "2.2.2.2").Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Then the client can still make requests to other route groups", func() {
+				So(request("/economy", "1.1.1.1").Code, ShouldEqual, http.StatusOK)
+			})
+		})
+	})
+
+	Convey("Given a rate limit for a single prefix", t, func() {
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+		handler := New(map[string]Limit{"/search": {Rate: 1, Burst: 1}}, 100, nil).Handler(next)
+
+		Convey("Then requests for other paths are not limited", func() {
+			for i := 0; i < 5; i++ {
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+				So(res.Code, ShouldEqual, http.StatusOK)
+			}
+		})
+	})
+}
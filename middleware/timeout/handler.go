@@ -0,0 +1,101 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// defaultBody is served when a request times out and no body is configured
+const defaultBody = `<!DOCTYPE html><html lang="en"><head><title>Sorry, this page is taking too long to load</title></head>` +
+	`<body><h1>Sorry, this page is taking too long to load</h1><p>Please try again in a few moments.</p></body></html>`
+
+// ParseTimeouts converts a map of path prefix to timeout, as read from config, into durations
+func ParseTimeouts(timeouts map[string]string) (map[string]time.Duration, error) {
+	parsed := make(map[string]time.Duration, len(timeouts))
+	for prefix, value := range timeouts {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q for prefix %q: %w", value, prefix, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q for prefix %q: must be positive", value, prefix)
+		}
+		parsed[prefix] = d
+	}
+	return parsed, nil
+}
+
+// Handler sets a deadline on requests, according to the timeout of the longest matching path prefix, and returns
+// 504 Gateway Timeout with body, or a default page if body is empty, when a backend does not respond in time. Paths
+// that don't match any prefix have no deadline. The deadline is enforced through the request context, so a response
+// that has already started, such as a download, is cut short rather than replaced.
+func Handler(timeouts map[string]time.Duration, body string) func(h http.Handler) http.Handler {
+	prefixes := helpers.NewPrefixMap(timeouts)
+	if body == "" {
+		body = defaultBody
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, timeout, ok := prefixes.Match(req.URL.Path)
+			if !ok {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+			req = req.WithContext(ctx)
+			h.ServeHTTP(&timeoutWriter{ResponseWriter: w, req: req, body: body}, req)
+		})
+	}
+}
+
+// timeoutWriter replaces the 502 Bad Gateway that a reverse proxy writes when its request is cancelled by the deadline
+// with a 504 Gateway Timeout
+type timeoutWriter struct {
+	http.ResponseWriter
+	req      *http.Request
+	body     string
+	timedOut bool
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	if status != http.StatusBadGateway || !errors.Is(w.req.Context().Err(), context.DeadlineExceeded) {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.timedOut = true
+	log.Warn(w.req.Context(), "request timed out", log.Data{"path": w.req.URL.Path})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	if _, err := w.ResponseWriter.Write([]byte(w.body)); err != nil {
+		log.Error(w.req.Context(), "error writing response", err)
+	}
+}
+
+// Write discards anything written after the timeout page
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if w.timedOut {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,118 @@
+package timeout
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseTimeouts(t *testing.T) {
+	Convey("Given timeouts by path prefix as configured", t, func() {
+		timeouts, err := ParseTimeouts(map[string]string{"/search": "5s", "/download": " 30s "})
+
+		Convey("Then they are parsed into durations", func() {
+			So(err, ShouldBeNil)
+			So(timeouts, ShouldResemble, map[string]time.Duration{"/search": 5 * time.Second, "/download": 30 * time.Second})
+		})
+	})
+
+	Convey("Given a timeout that is not a duration", t, func() {
+		_, err := ParseTimeouts(map[string]string{"/search": "5"})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a timeout of zero", t, func() {
+		_, err := ParseTimeouts(map[string]string{"/search": "0s"})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldBeError, `invalid timeout "0s" for prefix "/search": must be positive`)
+		})
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given a reverse proxy to a slow backend, behind timeouts for a prefix and a longer sub prefix", t, func() {
+		release := make(chan struct{})
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			select {
+			case <-time.After(200 * time.Millisecond):
+			case <-release:
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer backend.Close()
+		defer close(release)
+		backendURL, _ := url.Parse(backend.URL)
+		proxy := httputil.NewSingleHostReverseProxy(backendURL)
+
+		timeouts := map[string]time.Duration{"/search": 20 * time.Millisecond, "/search/slow": time.Second}
+
+		Convey("When a request for the prefix takes longer than its timeout", func() {
+			res := httptest.NewRecorder()
+			Handler(timeouts, "")(proxy).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/search?q=cpi", http.NoBody))
+
+			Convey("Then a 504 is returned with the default page", func() {
+				So(res.Code, ShouldEqual, http.StatusGatewayTimeout)
+				So(res.Header().Get("Content-Type"), ShouldEqual, "text/html; charset=utf-8")
+				So(res.Body.String(), ShouldEqual, defaultBody)
+			})
+		})
+
+		Convey("When a request for the prefix times out with a body configured", func() {
+			res := httptest.NewRecorder()
+			Handler(timeouts, "try again")(proxy).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/search", http.NoBody))
+
+			Convey("Then the configured body is returned", func() {
+				So(res.Code, ShouldEqual, http.StatusGatewayTimeout)
+				So(res.Body.String(), ShouldEqual, "try again")
+			})
+		})
+
+		Convey("When a request for the longer prefix responds within its timeout", func() {
+			res := httptest.NewRecorder()
+			Handler(timeouts, "")(proxy).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/search/slow", http.NoBody))
+
+			Convey("Then the timeout of the most specific prefix applies", func() {
+				So(res.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+	})
+
+	Convey("Given a backend that returns a 502 of its own", t, func() {
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+
+		Convey("When the request has a timeout that has not expired", func() {
+			res := httptest.NewRecorder()
+			handler := Handler(map[string]time.Duration{"/": time.Second}, "")(next)
+			handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then the 502 is passed on", func() {
+				So(res.Code, ShouldEqual, http.StatusBadGateway)
+				So(res.Body.String(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a request for a path with no timeout", t, func() {
+		var hasDeadline bool
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, hasDeadline = req.Context().Deadline()
+		})
+		Handler(map[string]time.Duration{"/search": time.Second}, "")(next).ServeHTTP(httptest.NewRecorder(),
+			httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+		Convey("Then the request has no deadline", func() {
+			So(hasDeadline, ShouldBeFalse)
+		})
+	})
+}
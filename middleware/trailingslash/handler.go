@@ -0,0 +1,86 @@
+package trailingslash
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Policy describes how a trailing slash on a path is treated
+type Policy string
+
+// Possible trailing slash policies
+const (
+	Require Policy = "require"
+	Forbid  Policy = "forbid"
+	Ignore  Policy = "ignore"
+)
+
+// ParsePolicies converts a map of path prefix to policy name, as read from config, into policies
+func ParsePolicies(policies map[string]string) (map[string]Policy, error) {
+	parsed := make(map[string]Policy, len(policies))
+	for prefix, name := range policies {
+		policy := Policy(strings.ToLower(strings.TrimSpace(name)))
+		switch policy {
+		case Require, Forbid, Ignore:
+			parsed[prefix] = policy
+		default:
+			return nil, fmt.Errorf("invalid trailing slash policy %q for prefix %q", name, prefix)
+		}
+	}
+	return parsed, nil
+}
+
+// Handler redirects requests to the canonical form of their path, according to the policy of the longest matching
+// path prefix. Paths that don't match any prefix, and the root path, are left as they are. Redirects are always to a
+// path on this site, however many slashes the path starts with.
+func Handler(policies map[string]Policy) func(h http.Handler) http.Handler {
+	prefixes := helpers.NewPrefixMap(policies)
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			path := req.URL.Path
+			canonical := canonicalPath(path, policyFor(path, prefixes))
+			if canonical == path {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			// collapse leading slashes, as a Location of //host or /\host would send the client to another site
+			target := "/" + strings.TrimLeft(canonical, `/\`)
+			if req.URL.RawQuery != "" {
+				target += "?" + req.URL.RawQuery
+			}
+			log.Info(req.Context(), "redirecting to canonical path", log.Data{"path": path, "target": target})
+			http.Redirect(w, req, target, http.StatusMovedPermanently)
+		})
+	}
+}
+
+func policyFor(path string, policies helpers.PrefixMap[Policy]) Policy {
+	if _, policy, ok := policies.Match(path); ok {
+		return policy
+	}
+	return Ignore
+}
+
+func canonicalPath(path string, policy Policy) string {
+	if path == "/" || path == "" {
+		return path
+	}
+
+	switch policy {
+	case Require:
+		if !strings.HasSuffix(path, "/") {
+			return path + "/"
+		}
+	case Forbid:
+		if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+			return trimmed
+		}
+	}
+	return path
+}
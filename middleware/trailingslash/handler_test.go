@@ -0,0 +1,144 @@
+package trailingslash
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParsePolicies(t *testing.T) {
+	Convey("Given valid policy names", t, func() {
+		policies, err := ParsePolicies(map[string]string{"/a": "require", "/b": "Forbid", "/c": " ignore "})
+
+		Convey("Then they are parsed without error", func() {
+			So(err, ShouldBeNil)
+			So(policies, ShouldResemble, map[string]Policy{"/a": Require, "/b": Forbid, "/c": Ignore})
+		})
+	})
+
+	Convey("Given an unknown policy name", t, func() {
+		_, err := ParsePolicies(map[string]string{"/a": "sometimes"})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestHandler(t *testing.T) {
+	Convey("Given a handler with a different policy for each prefix", t, func() {
+		next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		handler := Handler(map[string]Policy{
+			"/directory":       Require,
+			"/file":            Forbid,
+			"/file/anything":   Ignore,
+			"/file/directory/": Require,
+		})(next)
+
+		serve := func(target string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+			return w
+		}
+
+		Convey("When a path under a require prefix has no trailing slash", func() {
+			w := serve("/directory/economy?page=2")
+
+			Convey("Then it is redirected to the path with a trailing slash, keeping the query", func() {
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(w.Header().Get("Location"), ShouldEqual, "/directory/economy/?page=2")
+			})
+		})
+
+		Convey("When a path under a require prefix has a trailing slash", func() {
+			w := serve("/directory/economy/")
+
+			Convey("Then it is passed on", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("When a path under a forbid prefix has trailing slashes", func() {
+			w := serve("/file/data.csv//")
+
+			Convey("Then it is redirected to the path without them", func() {
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(w.Header().Get("Location"), ShouldEqual, "/file/data.csv")
+			})
+		})
+
+		Convey("When a path under a forbid prefix has no trailing slash", func() {
+			w := serve("/file/data.csv")
+
+			Convey("Then it is passed on", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("When a path is under an ignore prefix nested within a forbid prefix", func() {
+			withSlash := serve("/file/anything/")
+			withoutSlash := serve("/file/anything")
+
+			Convey("Then the more specific prefix applies and both forms are passed on", func() {
+				So(withSlash.Code, ShouldEqual, http.StatusOK)
+				So(withoutSlash.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("When a path is under a require prefix nested within a forbid prefix", func() {
+			w := serve("/file/directory/nested")
+
+			Convey("Then the more specific prefix applies", func() {
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(w.Header().Get("Location"), ShouldEqual, "/file/directory/nested/")
+			})
+		})
+
+		Convey("When a path matches no prefix", func() {
+			w := serve("/other/")
+
+			Convey("Then it is passed on unchanged", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+	})
+
+	for _, tc := range []struct {
+		policy   Policy
+		path     string
+		location string
+	}{
+		{Require, "//evil.com", "/evil.com/"},
+		{Forbid, "//evil.com/", "/evil.com"},
+		{Forbid, "/\\evil.com/", "/evil.com"},
+	} {
+		tc := tc
+		Convey("Given a "+string(tc.policy)+" policy on the root prefix, and a path of "+tc.path, t, func() {
+			handler := Handler(map[string]Policy{"/": tc.policy})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, http.NoBody))
+
+			Convey("Then it is redirected to a path on this site rather than another host", func() {
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(w.Header().Get("Location"), ShouldEqual, tc.location)
+			})
+		})
+	}
+
+	Convey("Given a forbid policy on the root prefix", t, func() {
+		handler := Handler(map[string]Policy{"/": Forbid})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+		Convey("When the root path is requested", func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+			Convey("Then it is not redirected", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+	})
+}
@@ -0,0 +1,47 @@
+package traversal
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Handler rejects with a 400 any request whose path changes when cleaned, which indicates an attempt at path traversal
+// using literal or encoded '.' and '..' segments. A trailing slash is not treated as a change.
+func Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if isTraversal(req.URL.Path) {
+			log.Warn(req.Context(), "rejecting request with path traversal", log.Data{"path": req.URL.Path, "raw_path": req.URL.RawPath})
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+func isTraversal(p string) bool {
+	if changedByCleaning(p) {
+		return true
+	}
+
+	// catch double encoded sequences such as %252e%252e, which are decoded to %2e%2e once and may be decoded again upstream
+	if decoded, err := url.PathUnescape(p); err == nil && decoded != p {
+		return changedByCleaning(decoded)
+	}
+	return false
+}
+
+func changedByCleaning(p string) bool {
+	if p == "" {
+		return false
+	}
+
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned != p
+}
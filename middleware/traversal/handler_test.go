@@ -0,0 +1,60 @@
+package traversal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHandler(t *testing.T) {
+	Convey("Given the traversal handler", t, func() {
+		var nextCalled bool
+		handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			nextCalled = true
+		}))
+
+		serve := func(target string) *httptest.ResponseRecorder {
+			nextCalled = false
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+			return w
+		}
+
+		Convey("When a request is made with literal traversal segments", func() {
+			for _, target := range []string{"/datasets/../../etc/passwd", "/filters/./abc", "/download/a/b/.."} {
+				w := serve(target)
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(nextCalled, ShouldBeFalse)
+			}
+		})
+
+		Convey("When a request is made with encoded traversal segments", func() {
+			for _, target := range []string{"/datasets/%2e%2e/secret", "/download/..%2f..%2fsecret", "/economy/%2E%2E/%2E%2E/secret"} {
+				w := serve(target)
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(nextCalled, ShouldBeFalse)
+			}
+		})
+
+		Convey("When a request is made with double encoded traversal segments", func() {
+			w := serve("/datasets/%252e%252e/secret")
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(nextCalled, ShouldBeFalse)
+		})
+
+		Convey("When a request is made for a legitimate deep path", func() {
+			for _, target := range []string{
+				"/datasets/cpih01/editions/time-series/versions/1",
+				"/economy/inflationandpriceindices/bulletins/consumerpriceinflation/latest/",
+				"/download/file.v1.2.csv?format=csv",
+				"/",
+			} {
+				w := serve(target)
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(nextCalled, ShouldBeTrue)
+			}
+		})
+	})
+}
@@ -0,0 +1,228 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Strategies for balancing requests across the instances of a backend
+const (
+	RoundRobin       = "round-robin"
+	LeastConnections = "least-connections"
+)
+
+// ParseInstances parses the instances of backends from a JSON object, as read from config, mapping the name of each
+// backend to the base URLs of its instances
+func ParseInstances(s string) (map[string][]*url.URL, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var raw map[string][]string
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, fmt.Errorf("invalid backend instances: %w", err)
+	}
+
+	instances := make(map[string][]*url.URL, len(raw))
+	for backend, rawURLs := range raw {
+		if len(rawURLs) == 0 {
+			return nil, fmt.Errorf("invalid backend instances: %q has no instances", backend)
+		}
+		for _, rawURL := range rawURLs {
+			u, err := url.Parse(rawURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("invalid backend instances: %q is not an absolute http or https URL", rawURL)
+			}
+			instances[backend] = append(instances[backend], u)
+		}
+	}
+	return instances, nil
+}
+
+// Instance is one instance of a backend that a Balancer sends requests to
+type Instance struct {
+	// URL is the URL requests are proxied to
+	URL *url.URL
+	// Check returns an error if the instance is not healthy. An instance without a check is always healthy.
+	Check func(ctx context.Context) error
+}
+
+// Balancer balances the requests for a backend across its instances, rather than relying on a load balancer in front of
+// them. Each instance has its own reverse proxy, and so its own circuit breaker. Instances that fail their health check
+// are not sent requests until they pass it again, unless every instance is failing, in which case requests are sent to
+// all of them rather than none.
+type Balancer struct {
+	name      string
+	strategy  string
+	instances []*instance
+	next      atomic.Uint64
+
+	started bool
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+type instance struct {
+	url     *url.URL
+	handler http.Handler
+	check   func(ctx context.Context) error
+	healthy atomic.Bool
+	active  atomic.Int64
+}
+
+// NewBalancer creates a Balancer of requests across instances with strategy, proxying to each as NewReverseProxy does
+// and logging each proxied request against proxyName
+func NewBalancer(proxyName string, instances []Instance, strategy string, opts Options) (*Balancer, error) {
+	handlers := make([]http.Handler, 0, len(instances))
+	for _, inst := range instances {
+		handlers = append(handlers, NewReverseProxy(proxyName, inst.URL, opts))
+	}
+	return newBalancer(proxyName, instances, handlers, strategy)
+}
+
+func newBalancer(name string, instances []Instance, handlers []http.Handler, strategy string) (*Balancer, error) {
+	if strategy != RoundRobin && strategy != LeastConnections {
+		return nil, fmt.Errorf("unknown balancing strategy %q", strategy)
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no instances of %s to balance", name)
+	}
+
+	b := &Balancer{
+		name:     name,
+		strategy: strategy,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	for i, inst := range instances {
+		in := &instance{url: inst.URL, handler: handlers[i], check: inst.Check}
+		// instances are assumed healthy until checked, so that requests are served from the start
+		in.healthy.Store(true)
+		b.instances = append(b.instances, in)
+	}
+	return b, nil
+}
+
+// ServeHTTP proxies req to the instance chosen by the balancing strategy
+func (b *Balancer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	in := b.choose()
+	in.active.Add(1)
+	defer in.active.Add(-1)
+	in.handler.ServeHTTP(w, req)
+}
+
+// choose returns the healthy instance to send the next request to. Instances are taken in turn for round-robin, and
+// for least-connections the instance with the fewest requests in flight is taken, starting from the next in turn so
+// that ties are spread across instances.
+func (b *Balancer) choose() *instance {
+	candidates := make([]*instance, 0, len(b.instances))
+	for _, in := range b.instances {
+		if in.healthy.Load() {
+			candidates = append(candidates, in)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.instances
+	}
+
+	start := int((b.next.Add(1) - 1) % uint64(len(candidates)))
+	if b.strategy == RoundRobin {
+		return candidates[start]
+	}
+
+	chosen := candidates[start]
+	for i := 1; i < len(candidates); i++ {
+		in := candidates[(start+i)%len(candidates)]
+		if in.active.Load() < chosen.active.Load() {
+			chosen = in
+		}
+	}
+	return chosen
+}
+
+// Start checks the health of every instance every interval, until Close is called. An interval of zero does not
+// check the instances, so that they are always sent requests.
+func (b *Balancer) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	b.started = true
+	go func() {
+		defer close(b.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		b.checkInstances(ctx, interval)
+		for {
+			select {
+			case <-ticker.C:
+				b.checkInstances(ctx, interval)
+			case <-b.done:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops checking the health of the instances, waiting for a check in progress to finish
+func (b *Balancer) Close() {
+	close(b.done)
+	if b.started {
+		<-b.stopped
+	}
+}
+
+// checkInstances checks every instance at once, logging those whose health has changed. Each check is given until the
+// next is due to complete.
+func (b *Balancer) checkInstances(ctx context.Context, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, in := range b.instances {
+		if in.check == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(in *instance) {
+			defer wg.Done()
+			b.checkInstance(ctx, in, timeout)
+		}(in)
+	}
+	wg.Wait()
+}
+
+func (b *Balancer) checkInstance(ctx context.Context, in *instance, timeout time.Duration) {
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := in.check(checkCtx)
+
+	healthy := err == nil
+	if in.healthy.Swap(healthy) == healthy {
+		return
+	}
+	logData := log.Data{"backend": b.name, "instance": in.url.String()}
+	if healthy {
+		log.Info(ctx, "backend instance is healthy again, sending it requests", logData)
+		return
+	}
+	logData["error"] = err.Error()
+	log.Warn(ctx, "backend instance is unhealthy, no longer sending it requests", logData)
+}
+
+// Healthy returns the number of instances that passed their last health check
+func (b *Balancer) Healthy() int {
+	healthy := 0
+	for _, in := range b.instances {
+		if in.healthy.Load() {
+			healthy++
+		}
+	}
+	return healthy
+}
@@ -0,0 +1,186 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseInstances(t *testing.T) {
+	Convey("Given backend instances as configured", t, func() {
+		instances, err := ParseInstances(`{"babbage":["http://babbage-1:8080","http://babbage-2:8080"]}`)
+
+		Convey("Then they are parsed", func() {
+			So(err, ShouldBeNil)
+			So(instances, ShouldHaveLength, 1)
+			So(instances["babbage"], ShouldHaveLength, 2)
+			So(instances["babbage"][1].Host, ShouldEqual, "babbage-2:8080")
+		})
+	})
+
+	Convey("Given no backend instances", t, func() {
+		instances, err := ParseInstances("")
+
+		Convey("Then there are none", func() {
+			So(err, ShouldBeNil)
+			So(instances, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given backend instances that are not valid", t, func() {
+		for _, s := range []string{`["http://babbage-1:8080"]`, `{"babbage":[]}`, `{"babbage":["babbage-1:8080"]}`} {
+			_, err := ParseInstances(s)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+// instanceHandlers returns a handler for each of n instances, each responding with 200 plus its index as the status
+// code, and holding requests for /slow until block is closed
+func instanceHandlers(n int, block chan struct{}) []http.Handler {
+	handlers := make([]http.Handler, 0, n)
+	for i := 0; i < n; i++ {
+		status := http.StatusOK + i
+		handlers = append(handlers, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if block != nil && req.URL.Path == "/slow" {
+				<-block
+			}
+			w.WriteHeader(status)
+		}))
+	}
+	return handlers
+}
+
+func testInstances(n int) []Instance {
+	instances := make([]Instance, 0, n)
+	for i := 0; i < n; i++ {
+		instances = append(instances, Instance{URL: &url.URL{Scheme: "http", Host: fmt.Sprintf("babbage-%d:8080", i+1)}})
+	}
+	return instances
+}
+
+func served(h http.Handler, path string) int {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+	return w.Code
+}
+
+func TestBalancer(t *testing.T) {
+	Convey("Given a round-robin balancer across three instances", t, func() {
+		b, err := newBalancer("babbage", testInstances(3), instanceHandlers(3, nil), RoundRobin)
+		So(err, ShouldBeNil)
+
+		Convey("Then requests are sent to each instance in turn", func() {
+			var statuses []int
+			for i := 0; i < 6; i++ {
+				statuses = append(statuses, served(b, "/economy"))
+			}
+			So(statuses, ShouldResemble, []int{200, 201, 202, 200, 201, 202})
+		})
+	})
+
+	Convey("Given a least-connections balancer across two instances", t, func() {
+		block := make(chan struct{})
+		b, err := newBalancer("babbage", testInstances(2), instanceHandlers(2, block), LeastConnections)
+		So(err, ShouldBeNil)
+
+		Convey("When one instance is busy with a slow request", func() {
+			slow := make(chan int)
+			go func() { slow <- served(b, "/slow") }()
+			So(waitFor(func() bool { return b.instances[0].active.Load() == 1 }), ShouldBeTrue)
+
+			Convey("Then requests are sent to the other instance", func() {
+				for i := 0; i < 4; i++ {
+					So(served(b, "/economy"), ShouldEqual, 201)
+				}
+				close(block)
+				So(<-slow, ShouldEqual, 200)
+			})
+		})
+	})
+
+	Convey("Given a balancer with an unknown strategy", t, func() {
+		_, err := newBalancer("babbage", testInstances(2), instanceHandlers(2, nil), "random")
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a balancer without instances", t, func() {
+		_, err := NewBalancer("babbage", nil, RoundRobin, Options{})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestBalancerHealthChecks(t *testing.T) {
+	Convey("Given a balancer across two instances whose health is checked", t, func() {
+		var unhealthy atomic.Bool
+		instances := testInstances(2)
+		instances[1].Check = func(ctx context.Context) error {
+			if unhealthy.Load() {
+				return errors.New("babbage-2 responded with 500")
+			}
+			return nil
+		}
+		b, err := newBalancer("babbage", instances, instanceHandlers(2, nil), RoundRobin)
+		So(err, ShouldBeNil)
+		b.Start(context.Background(), 10*time.Millisecond)
+		defer b.Close()
+
+		Convey("When an instance fails its health check", func() {
+			unhealthy.Store(true)
+			So(waitFor(func() bool { return b.Healthy() == 1 }), ShouldBeTrue)
+
+			Convey("Then requests are only sent to the healthy instance", func() {
+				for i := 0; i < 4; i++ {
+					So(served(b, "/economy"), ShouldEqual, 200)
+				}
+			})
+
+			Convey("And when it passes its health check again, it is sent requests again", func() {
+				unhealthy.Store(false)
+				So(waitFor(func() bool { return b.Healthy() == 2 }), ShouldBeTrue)
+				So([]int{served(b, "/economy"), served(b, "/economy")}, ShouldContain, 201)
+			})
+		})
+	})
+
+	Convey("Given a balancer whose every instance is unhealthy", t, func() {
+		instances := testInstances(2)
+		for i := range instances {
+			instances[i].Check = func(ctx context.Context) error { return errors.New("unreachable") }
+		}
+		b, err := newBalancer("babbage", instances, instanceHandlers(2, nil), RoundRobin)
+		So(err, ShouldBeNil)
+		b.Start(context.Background(), 10*time.Millisecond)
+		defer b.Close()
+		So(waitFor(func() bool { return b.Healthy() == 0 }), ShouldBeTrue)
+
+		Convey("Then requests are still sent to every instance", func() {
+			So([]int{served(b, "/economy"), served(b, "/economy")}, ShouldResemble, []int{200, 201})
+		})
+	})
+}
+
+func waitFor(condition func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
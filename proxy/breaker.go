@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// ErrCircuitOpen is returned for requests to an upstream whose circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// circuitBreaker stops sending requests to an upstream after threshold consecutive failures, so that a struggling
+// upstream is not sent more load. Once cooldown has passed, a single trial request is let through: if it succeeds the
+// circuit closes, otherwise it stays open for another cooldown.
+type circuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	transport http.RoundTripper
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	openedAt time.Time
+	trial    bool
+}
+
+func newCircuitBreaker(name string, threshold int, cooldown time.Duration, transport http.RoundTripper) *circuitBreaker {
+	return &circuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		transport: transport,
+		now:       time.Now,
+	}
+}
+
+func (b *circuitBreaker) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := b.transport.RoundTrip(req)
+	switch {
+	case errors.Is(req.Context().Err(), context.Canceled):
+		// the client went away, which says nothing about the upstream
+		b.release()
+	case err != nil || isUpstreamFailure(resp.StatusCode):
+		b.failure(req.Context())
+	default:
+		b.success(req.Context())
+	}
+	return resp, err
+}
+
+// isUpstreamFailure is true for the statuses of an upstream, or a gateway in front of it, that cannot serve requests.
+// Other 5xx statuses are usually specific to the requested page.
+func isUpstreamFailure(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// allow reports whether a request may be sent, letting a single trial request through once the cooldown has passed
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if b.trial || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// retryAfter returns the number of whole seconds until a trial request may be let through, rounded up and at least one
+func (b *circuitBreaker) retryAfter() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	remaining := b.cooldown - b.now().Sub(b.openedAt)
+	return max(1, int(math.Ceil(remaining.Seconds())))
+}
+
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+func (b *circuitBreaker) success(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+	if b.open {
+		b.open = false
+		log.Info(ctx, "circuit breaker closed", log.Data{"proxy_name": b.name})
+	}
+}
+
+func (b *circuitBreaker) failure(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.open {
+		// the trial request failed, so wait for another cooldown
+		b.trial = false
+		b.openedAt = b.now()
+		return
+	}
+	if b.failures >= b.threshold {
+		b.open = true
+		b.openedAt = b.now()
+		log.Warn(ctx, "circuit breaker opened", log.Data{"proxy_name": b.name, "failures": b.failures, "cooldown": b.cooldown.String()})
+	}
+}
+
+// circuitOpenErrorHandler serves 503 Service Unavailable for requests refused by an open circuit breaker, and the
+// reverse proxy's usual 502 Bad Gateway for other errors. Refused requests are told to retry once b may let a trial
+// request through.
+func circuitOpenErrorHandler(proxyName string, b *circuitBreaker) func(w http.ResponseWriter, req *http.Request, err error) {
+	return func(w http.ResponseWriter, req *http.Request, err error) {
+		if errors.Is(err, ErrCircuitOpen) {
+			w.Header().Set("Retry-After", strconv.Itoa(b.retryAfter()))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		log.Error(req.Context(), "error proxying request", err, log.Data{"proxy_name": proxyName})
+		w.WriteHeader(http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	Convey("Given a circuit breaker that trips after 3 consecutive failures", t, func() {
+		status, calls := http.StatusBadGateway, 0
+		upstream := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+		})
+		now := time.Now()
+		b := newCircuitBreaker("babbage", 3, 30*time.Second, upstream)
+		b.now = func() time.Time { return now }
+
+		roundTrip := func() error {
+			_, err := b.RoundTrip(httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+			return err
+		}
+
+		Convey("When fewer consecutive requests than the threshold fail", func() {
+			So(roundTrip(), ShouldBeNil)
+			So(roundTrip(), ShouldBeNil)
+			status = http.StatusOK
+			So(roundTrip(), ShouldBeNil)
+			status = http.StatusBadGateway
+			So(roundTrip(), ShouldBeNil)
+			So(roundTrip(), ShouldBeNil)
+
+			Convey("Then requests are still sent to the upstream", func() {
+				So(roundTrip(), ShouldBeNil)
+				So(calls, ShouldEqual, 6)
+			})
+		})
+
+		Convey("When the upstream responds with a page specific error", func() {
+			status = http.StatusInternalServerError
+			for i := 0; i < 5; i++ {
+				So(roundTrip(), ShouldBeNil)
+			}
+
+			Convey("Then the circuit stays closed", func() {
+				So(roundTrip(), ShouldBeNil)
+			})
+		})
+
+		Convey("When the threshold of consecutive failures is reached", func() {
+			for i := 0; i < 3; i++ {
+				So(roundTrip(), ShouldBeNil)
+			}
+
+			Convey("Then requests are refused without being sent upstream", func() {
+				So(roundTrip(), ShouldEqual, ErrCircuitOpen)
+				So(calls, ShouldEqual, 3)
+			})
+
+			Convey("Then they are told to retry once the rest of the cooldown has passed, in whole seconds", func() {
+				So(b.retryAfter(), ShouldEqual, 30)
+				now = now.Add(10200 * time.Millisecond)
+				So(b.retryAfter(), ShouldEqual, 20)
+				now = now.Add(19500 * time.Millisecond)
+				So(b.retryAfter(), ShouldEqual, 1)
+			})
+
+			Convey("And the cooldown passes", func() {
+				now = now.Add(30 * time.Second)
+
+				Convey("Then a successful trial request closes the circuit", func() {
+					status = http.StatusOK
+					So(roundTrip(), ShouldBeNil)
+					So(roundTrip(), ShouldBeNil)
+					So(calls, ShouldEqual, 5)
+				})
+
+				Convey("Then requests refused while a trial request is made are told to retry in a second", func() {
+					b.trial = true
+					So(roundTrip(), ShouldEqual, ErrCircuitOpen)
+					So(b.retryAfter(), ShouldEqual, 1)
+				})
+
+				Convey("Then a failed trial request keeps the circuit open for another cooldown", func() {
+					So(roundTrip(), ShouldBeNil)
+					So(roundTrip(), ShouldEqual, ErrCircuitOpen)
+					now = now.Add(29 * time.Second)
+					So(roundTrip(), ShouldEqual, ErrCircuitOpen)
+					So(calls, ShouldEqual, 4)
+				})
+			})
+		})
+
+		Convey("When requests fail because the client went away", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			for i := 0; i < 3; i++ {
+				_, err := b.RoundTrip(httptest.NewRequest(http.MethodGet, "/economy", http.NoBody).WithContext(ctx))
+				So(err, ShouldBeNil)
+			}
+
+			Convey("Then they are not counted as failures", func() {
+				So(roundTrip(), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a reverse proxy with a circuit breaker, to an upstream that cannot be reached", t, func() {
+		upstream := httptest.NewServer(http.NotFoundHandler())
+		upstreamURL, err := url.Parse(upstream.URL)
+		So(err, ShouldBeNil)
+		upstream.Close()
+
+		proxy := NewReverseProxy("babbage", upstreamURL, Options{CircuitBreakerThreshold: 1, CircuitBreakerCooldown: time.Minute})
+
+		Convey("When the first request fails", func() {
+			first := httptest.NewRecorder()
+			proxy.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then it is served a 502", func() {
+				So(first.Code, ShouldEqual, http.StatusBadGateway)
+			})
+
+			Convey("Then the next request is refused with a 503 until the cooldown has passed", func() {
+				next := httptest.NewRecorder()
+				proxy.ServeHTTP(next, httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+				So(next.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(next.Header().Get("Retry-After"), ShouldEqual, "60")
+			})
+		})
+
+		Convey("When the cooldown is less than a second", func() {
+			proxy := NewReverseProxy("babbage", upstreamURL, Options{CircuitBreakerThreshold: 1, CircuitBreakerCooldown: 500 * time.Millisecond})
+			proxy.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+			next := httptest.NewRecorder()
+			proxy.ServeHTTP(next, httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then refused requests are told to retry in a second rather than at once", func() {
+				So(next.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(next.Header().Get("Retry-After"), ShouldEqual, "1")
+			})
+		})
+	})
+}
+
+func TestIsUpstreamFailure(t *testing.T) {
+	Convey("Only gateway and unavailable statuses are upstream failures", t, func() {
+		So(isUpstreamFailure(http.StatusBadGateway), ShouldBeTrue)
+		So(isUpstreamFailure(http.StatusServiceUnavailable), ShouldBeTrue)
+		So(isUpstreamFailure(http.StatusGatewayTimeout), ShouldBeTrue)
+		So(isUpstreamFailure(http.StatusInternalServerError), ShouldBeFalse)
+		So(isUpstreamFailure(http.StatusNotFound), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// UpstreamCacheHeader is the header in which the cache status reported by the upstream is surfaced
+const UpstreamCacheHeader = "X-Router-Upstream-Cache"
+
+// Normalised upstream cache statuses
+const (
+	cacheStatusHit     = "hit"
+	cacheStatusMiss    = "miss"
+	cacheStatusStale   = "stale"
+	cacheStatusBypass  = "bypass"
+	cacheStatusUnknown = "unknown"
+	// cacheStatusOrigin is reported when the upstream gives no cache headers, so the response came from the origin
+	cacheStatusOrigin = "origin"
+)
+
+// upstreamCacheStatusHeaders are the headers that upstreams and their caches report cache status in, most specific first
+var upstreamCacheStatusHeaders = []string{"X-Cache-Status", "CF-Cache-Status", "X-Cache"}
+
+// setUpstreamCacheHeader sets a normalised summary of the upstream's cache headers on the response, in the form
+// "<status>[; age=<seconds>]; upstream=<proxy name>"
+func setUpstreamCacheHeader(resp *http.Response, proxyName string) {
+	status := cacheStatusOrigin
+	for _, h := range upstreamCacheStatusHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			status = normaliseCacheStatus(v)
+			break
+		}
+	}
+
+	age := resp.Header.Get("Age")
+	if age != "" && status == cacheStatusOrigin {
+		// a cache served the response without saying so
+		status = cacheStatusHit
+	}
+
+	value := status
+	if age != "" {
+		value += "; age=" + age
+	}
+	resp.Header.Set(UpstreamCacheHeader, value+"; upstream="+proxyName)
+}
+
+// normaliseCacheStatus maps the various forms of cache status, such as "HIT from varnish", "TCP_MISS" or "EXPIRED",
+// to one of a small set of statuses
+func normaliseCacheStatus(v string) string {
+	v = strings.ToUpper(v)
+	switch {
+	case strings.Contains(v, "STALE"), strings.Contains(v, "EXPIRED"), strings.Contains(v, "UPDATING"), strings.Contains(v, "REVALIDATED"):
+		return cacheStatusStale
+	case strings.Contains(v, "BYPASS"), strings.Contains(v, "DYNAMIC"), strings.Contains(v, "PASS"):
+		return cacheStatusBypass
+	case strings.Contains(v, "MISS"):
+		return cacheStatusMiss
+	case strings.Contains(v, "HIT"):
+		return cacheStatusHit
+	default:
+		return cacheStatusUnknown
+	}
+}
@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUpstreamCacheHeader(t *testing.T) {
+	Convey("Given an upstream server that responds with configurable cache headers", t, func() {
+		var upstreamHeaders map[string]string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			for k, v := range upstreamHeaders {
+				w.Header().Set(k, v)
+			}
+		}))
+		defer upstream.Close()
+
+		upstreamURL, err := url.Parse(upstream.URL)
+		So(err, ShouldBeNil)
+
+		serve := func(opts Options) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			NewReverseProxy("babbage", upstreamURL, opts).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+			return w
+		}
+
+		Convey("When the upstream cache header is disabled", func() {
+			upstreamHeaders = map[string]string{"X-Cache": "HIT"}
+			w := serve(Options{})
+
+			Convey("Then no upstream cache header is set", func() {
+				So(w.Header().Get(UpstreamCacheHeader), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the upstream cache header is enabled", func() {
+			opts := Options{UpstreamCacheHeader: true}
+
+			Convey("Then a hit with an age is normalised", func() {
+				upstreamHeaders = map[string]string{"X-Cache": "HIT from varnish", "Age": "120"}
+				So(serve(opts).Header().Get(UpstreamCacheHeader), ShouldEqual, "hit; age=120; upstream=babbage")
+			})
+
+			Convey("Then a miss is normalised", func() {
+				upstreamHeaders = map[string]string{"X-Cache": "TCP_MISS"}
+				So(serve(opts).Header().Get(UpstreamCacheHeader), ShouldEqual, "miss; upstream=babbage")
+			})
+
+			Convey("Then a stale response reported by X-Cache-Status takes precedence over X-Cache", func() {
+				upstreamHeaders = map[string]string{"X-Cache-Status": "EXPIRED", "X-Cache": "HIT"}
+				So(serve(opts).Header().Get(UpstreamCacheHeader), ShouldEqual, "stale; upstream=babbage")
+			})
+
+			Convey("Then a bypass is normalised", func() {
+				upstreamHeaders = map[string]string{"CF-Cache-Status": "DYNAMIC"}
+				So(serve(opts).Header().Get(UpstreamCacheHeader), ShouldEqual, "bypass; upstream=babbage")
+			})
+
+			Convey("Then an age without a cache status is reported as a hit", func() {
+				upstreamHeaders = map[string]string{"Age": "30"}
+				So(serve(opts).Header().Get(UpstreamCacheHeader), ShouldEqual, "hit; age=30; upstream=babbage")
+			})
+
+			Convey("Then a response without cache headers is reported as from the origin", func() {
+				upstreamHeaders = nil
+				So(serve(opts).Header().Get(UpstreamCacheHeader), ShouldEqual, "origin; upstream=babbage")
+			})
+
+			Convey("Then the upstream's own cache headers are passed through unchanged", func() {
+				upstreamHeaders = map[string]string{"X-Cache": "HIT"}
+				So(serve(opts).Header().Get("X-Cache"), ShouldEqual, "HIT")
+			})
+		})
+	})
+}
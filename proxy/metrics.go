@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+)
+
+// Results of proxied requests that did not get a response from the upstream
+const (
+	resultError       = "error"
+	resultCancelled   = "cancelled"
+	resultCircuitOpen = "circuit_open"
+)
+
+// BackendMetrics counts the requests proxied to each upstream by result, so that upstream error rates can be tracked
+type BackendMetrics struct {
+	requests *metrics.CounterVec
+}
+
+// NewBackendMetrics creates BackendMetrics, registering its counters with registry. One BackendMetrics is shared by all
+// proxies, which label their requests with their proxy name.
+func NewBackendMetrics(registry *metrics.Registry) *BackendMetrics {
+	m := &BackendMetrics{
+		requests: metrics.NewCounterVec("dp_frontend_router_backend_requests_total",
+			"Requests proxied to each backend, by status code, or error, cancelled or circuit_open if there was no response.",
+			"backend", "result"),
+	}
+	registry.Register(m.requests)
+	return m
+}
+
+// transport returns a RoundTripper counting the requests made through next against backend
+func (m *BackendMetrics) transport(backend string, next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		m.requests.Inc(backend, result(req, resp, err))
+		return resp, err
+	})
+}
+
+// result labels the outcome of a proxied request, distinguishing requests abandoned by the client from upstream errors
+func result(req *http.Request, resp *http.Response, err error) string {
+	switch {
+	case err == nil:
+		return strconv.Itoa(resp.StatusCode)
+	case req.Context().Err() != nil:
+		return resultCancelled
+	case errors.Is(err, ErrCircuitOpen):
+		return resultCircuitOpen
+	default:
+		return resultError
+	}
+}
+
+// roundTripperFunc adapts a function to an http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req)
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBackendMetrics(t *testing.T) {
+	Convey("Given backend metrics around an upstream", t, func() {
+		m := NewBackendMetrics(metrics.NewRegistry())
+		var status int
+		var err error
+		upstream := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+		})
+		transport := m.transport("babbage", upstream)
+		roundTrip := func(req *http.Request) {
+			if resp, err := transport.RoundTrip(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+
+		Convey("When the upstream responds", func() {
+			status = http.StatusOK
+			roundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+			status = http.StatusInternalServerError
+			roundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+			Convey("Then requests are counted by status code", func() {
+				So(m.requests.Value("babbage", "200"), ShouldEqual, 1)
+				So(m.requests.Value("babbage", "500"), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the upstream cannot be reached", func() {
+			err = errors.New("connection refused")
+			roundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+			Convey("Then the request is counted as an error", func() {
+				So(m.requests.Value("babbage", resultError), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the circuit breaker is open", func() {
+			err = ErrCircuitOpen
+			roundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+			Convey("Then the request is counted as circuit open", func() {
+				So(m.requests.Value("babbage", resultCircuitOpen), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the client cancels the request", func() {
+			err = context.Canceled
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			roundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody).WithContext(ctx))
+
+			Convey("Then the request is counted as cancelled rather than as an error", func() {
+				So(m.requests.Value("babbage", resultCancelled), ShouldEqual, 1)
+				So(m.requests.Value("babbage", resultError), ShouldEqual, 0)
+			})
+		})
+	})
+}
@@ -0,0 +1,39 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// otelErrors returns a RoundTripper counting the requests made through next that fail, or get a 5xx response, as an
+// OpenTelemetry counter of meter. Requests cancelled by the client are not counted.
+func otelErrors(backend string, meter metric.Meter, next http.RoundTripper) http.RoundTripper {
+	backendErrors, err := meter.Int64Counter("router.backend.errors",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Requests proxied to a backend that failed or got a 5xx response"))
+	if err != nil {
+		log.Warn(context.Background(), "error creating backend errors instrument, backend errors are not counted",
+			log.Data{"error": err.Error(), "proxy_name": backend})
+		return next
+	}
+
+	backendAttr := attribute.String("backend", backend)
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if res := result(req, resp, err); isBackendError(res, resp, err) {
+			backendErrors.Add(req.Context(), 1, metric.WithAttributes(backendAttr, attribute.String("error.type", res)))
+		}
+		return resp, err
+	})
+}
+
+func isBackendError(res string, resp *http.Response, err error) bool {
+	if err != nil {
+		return res != resultCancelled
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeMeter records the attribute sets added to its counters
+type fakeMeter struct {
+	noop.Meter
+	added []attribute.Set
+}
+
+type fakeCounter struct {
+	noop.Int64Counter
+	meter *fakeMeter
+}
+
+func (m *fakeMeter) Int64Counter(name string, options ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return fakeCounter{meter: m}, nil
+}
+
+func (c fakeCounter) Add(ctx context.Context, incr int64, options ...metric.AddOption) {
+	c.meter.added = append(c.meter.added, metric.NewAddConfig(options).Attributes())
+}
+
+func TestOtelErrors(t *testing.T) {
+	Convey("Given an OpenTelemetry backend error counter around an upstream", t, func() {
+		meter := &fakeMeter{}
+		var status int
+		var err error
+		upstream := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+		})
+		transport := otelErrors("search", meter, upstream)
+		roundTrip := func(req *http.Request) {
+			if resp, err := transport.RoundTrip(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+
+		Convey("When the upstream responds successfully or with a client error", func() {
+			status = http.StatusOK
+			roundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+			status = http.StatusNotFound
+			roundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+			Convey("Then no errors are counted", func() {
+				So(meter.added, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the upstream responds with a server error", func() {
+			status = http.StatusServiceUnavailable
+			roundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+			Convey("Then an error is counted against the backend with the status code", func() {
+				So(meter.added, ShouldHaveLength, 1)
+				So(meter.added[0].Equals(ptr(attribute.NewSet(
+					attribute.String("backend", "search"), attribute.String("error.type", "503")))), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the upstream cannot be reached", func() {
+			err = errors.New("connection refused")
+			roundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+			Convey("Then an error is counted", func() {
+				So(meter.added, ShouldHaveLength, 1)
+				errorType, _ := meter.added[0].Value("error.type")
+				So(errorType.AsString(), ShouldEqual, resultError)
+			})
+		})
+
+		Convey("When the client cancels the request", func() {
+			err = context.Canceled
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			roundTrip(httptest.NewRequest(http.MethodGet, "/", http.NoBody).WithContext(ctx))
+
+			Convey("Then no error is counted", func() {
+				So(meter.added, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/requestmetrics"
+	"github.com/ONSdigital/dp-frontend-router/useragent"
+	"github.com/ONSdigital/log.go/v2/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Options configures the optional behaviour of a reverse proxy
+type Options struct {
+	// RewriteHost sets the outbound Host header to the upstream host instead of the public host of the incoming request
+	RewriteHost bool
+	// ForwardedHeaders sets X-Forwarded-Host and X-Forwarded-Proto on the outbound request
+	ForwardedHeaders bool
+	// UpstreamCacheHeader surfaces the upstream's cache status in a normalised X-Router-Upstream-Cache response header
+	UpstreamCacheHeader bool
+	// UserAgent identifies outbound requests as sent by the router, appended to the browser's User-Agent if it has one
+	UserAgent string
+	// CircuitBreakerThreshold is the number of consecutive failures after which requests to the upstream are refused
+	// with 503 Service Unavailable for CircuitBreakerCooldown. Zero disables the circuit breaker.
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+	// RetryMaxAttempts is the number of times a GET or HEAD request that fails with a connection error, or a 502 or 503,
+	// is retried, waiting RetryBackoff before the first retry and doubling the wait for each retry after. Retries are
+	// limited to a ratio of the proxy's requests to each route: that of the longest matching path prefix in
+	// RetryBudgetRoutes, each of which has a budget of its own, or else RetryBudgetRatio, from a budget shared by the
+	// requests that match no prefix. Zero disables retries.
+	RetryMaxAttempts  int
+	RetryBackoff      time.Duration
+	RetryBudgetRatio  float64
+	RetryBudgetRoutes map[string]float64
+	// Metrics counts the requests proxied to the upstream by result, if not nil
+	Metrics *BackendMetrics
+	// Meter counts the requests proxied to the upstream that fail as an OpenTelemetry metric, if not nil
+	Meter metric.Meter
+}
+
+// NewReverseProxy creates a reverse proxy to proxyURL, logging each proxied request against proxyName
+func NewReverseProxy(proxyName string, proxyURL *url.URL, opts Options) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(proxyURL)
+	director := proxy.Director
+	proxy.Transport = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       180 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	// retries happen within the circuit breaker, so a request that succeeds on retry is not counted as a failure
+	if opts.RetryMaxAttempts > 0 {
+		proxy.Transport = newRetrier(proxyName, proxyURL.Path, opts, proxy.Transport)
+	}
+	// each proxy has its own circuit breaker, so that one struggling upstream does not stop traffic to the others
+	if opts.CircuitBreakerThreshold > 0 {
+		breaker := newCircuitBreaker(proxyName, opts.CircuitBreakerThreshold, opts.CircuitBreakerCooldown, proxy.Transport)
+		proxy.Transport = breaker
+		proxy.ErrorHandler = circuitOpenErrorHandler(proxyName, breaker)
+	}
+	// requests are counted outside retries and the circuit breaker, so that each proxied request is counted once
+	if opts.Metrics != nil {
+		proxy.Transport = opts.Metrics.transport(proxyName, proxy.Transport)
+	}
+	if opts.Meter != nil {
+		proxy.Transport = otelErrors(proxyName, opts.Meter, proxy.Transport)
+	}
+	proxy.Director = func(req *http.Request) {
+		log.Info(req.Context(), "proxying request", log.HTTP(req, 0, 0, nil, nil), log.Data{
+			"destination": proxyURL,
+			"proxy_name":  proxyName,
+		})
+		otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+		requestmetrics.SetBackend(req.Context(), proxyName)
+		if opts.ForwardedHeaders {
+			setForwardedHeaders(req)
+		}
+		useragent.Set(req, opts.UserAgent)
+		director(req)
+
+		// the default director leaves the Host untouched, so the upstream receives the public host unless rewritten
+		if opts.RewriteHost {
+			req.Host = proxyURL.Host
+		}
+	}
+	if opts.UpstreamCacheHeader {
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			setUpstreamCacheHeader(resp, proxyName)
+			return nil
+		}
+	}
+	return proxy
+}
+
+// setForwardedHeaders sets the public host and scheme of the incoming request as forwarded headers, keeping any values
+// already provided by a proxy in front of the router
+func setForwardedHeaders(req *http.Request) {
+	if req.Header.Get("X-Forwarded-Host") == "" {
+		req.Header.Set("X-Forwarded-Host", req.Host)
+	}
+
+	if req.Header.Get("X-Forwarded-Proto") == "" {
+		proto := "http"
+		if req.TLS != nil {
+			proto = "https"
+		}
+		req.Header.Set("X-Forwarded-Proto", proto)
+	}
+}
@@ -0,0 +1,164 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/ONSdigital/dp-frontend-router/middleware/requestmetrics"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewReverseProxy(t *testing.T) {
+	Convey("Given an upstream server that records the Host header it receives", t, func() {
+		var receivedHost string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			receivedHost = req.Host
+		}))
+		defer upstream.Close()
+
+		upstreamURL, err := url.Parse(upstream.URL)
+		So(err, ShouldBeNil)
+
+		req := httptest.NewRequest(http.MethodGet, "http://www.ons.gov.uk/economy", http.NoBody)
+
+		Convey("When the proxy is configured to keep the public host", func() {
+			proxy := NewReverseProxy("babbage", upstreamURL, Options{RewriteHost: false})
+			proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+			Convey("Then the upstream receives the public host", func() {
+				So(receivedHost, ShouldEqual, "www.ons.gov.uk")
+			})
+		})
+
+		Convey("When the proxy is configured to rewrite the host", func() {
+			proxy := NewReverseProxy("babbage", upstreamURL, Options{RewriteHost: true})
+			proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+			Convey("Then the upstream receives its own host", func() {
+				So(receivedHost, ShouldEqual, upstreamURL.Host)
+			})
+		})
+	})
+}
+
+func TestForwardedHeaders(t *testing.T) {
+	Convey("Given an upstream server that records the forwarded headers it receives", t, func() {
+		var receivedHeaders http.Header
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			receivedHeaders = req.Header
+		}))
+		defer upstream.Close()
+
+		upstreamURL, err := url.Parse(upstream.URL)
+		So(err, ShouldBeNil)
+
+		Convey("When forwarded headers are disabled", func() {
+			proxy := NewReverseProxy("babbage", upstreamURL, Options{})
+			req := httptest.NewRequest(http.MethodGet, "http://www.ons.gov.uk/economy", http.NoBody)
+			proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+			Convey("Then no forwarded host or proto headers are sent", func() {
+				So(receivedHeaders.Get("X-Forwarded-Host"), ShouldBeEmpty)
+				So(receivedHeaders.Get("X-Forwarded-Proto"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When forwarded headers are enabled", func() {
+			proxy := NewReverseProxy("babbage", upstreamURL, Options{ForwardedHeaders: true})
+
+			Convey("And a plain http request is made", func() {
+				req := httptest.NewRequest(http.MethodGet, "http://www.ons.gov.uk/economy", http.NoBody)
+				proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+				Convey("Then the public host and http scheme are forwarded", func() {
+					So(receivedHeaders.Get("X-Forwarded-Host"), ShouldEqual, "www.ons.gov.uk")
+					So(receivedHeaders.Get("X-Forwarded-Proto"), ShouldEqual, "http")
+				})
+			})
+
+			Convey("And a TLS request is made", func() {
+				req := httptest.NewRequest(http.MethodGet, "https://www.ons.gov.uk/economy", http.NoBody)
+				req.TLS = &tls.ConnectionState{}
+				proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+				Convey("Then the https scheme is forwarded", func() {
+					So(receivedHeaders.Get("X-Forwarded-Proto"), ShouldEqual, "https")
+				})
+			})
+
+			Convey("And the request already carries forwarded headers from a load balancer", func() {
+				req := httptest.NewRequest(http.MethodGet, "http://internal-lb/economy", http.NoBody)
+				req.Header.Set("X-Forwarded-Host", "www.ons.gov.uk")
+				req.Header.Set("X-Forwarded-Proto", "https")
+				proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+				Convey("Then the incoming values are kept", func() {
+					So(receivedHeaders.Get("X-Forwarded-Host"), ShouldEqual, "www.ons.gov.uk")
+					So(receivedHeaders.Get("X-Forwarded-Proto"), ShouldEqual, "https")
+				})
+			})
+		})
+	})
+}
+
+func TestUserAgent(t *testing.T) {
+	Convey("Given an upstream server that records the User-Agent it receives", t, func() {
+		var receivedUserAgent string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			receivedUserAgent = req.Header.Get("User-Agent")
+		}))
+		defer upstream.Close()
+
+		upstreamURL, err := url.Parse(upstream.URL)
+		So(err, ShouldBeNil)
+
+		req := httptest.NewRequest(http.MethodGet, "http://www.ons.gov.uk/economy", http.NoBody)
+		req.Header.Set("User-Agent", "Mozilla/5.0")
+
+		Convey("When the proxy is configured with a User-Agent", func() {
+			proxy := NewReverseProxy("babbage", upstreamURL, Options{UserAgent: "dp-frontend-router/v1.2.3"})
+			proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+			Convey("Then the upstream receives it after the browser's User-Agent", func() {
+				So(receivedUserAgent, ShouldEqual, "Mozilla/5.0 dp-frontend-router/v1.2.3")
+			})
+		})
+
+		Convey("When the proxy is configured without a User-Agent", func() {
+			proxy := NewReverseProxy("babbage", upstreamURL, Options{})
+			proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+			Convey("Then the upstream receives the browser's User-Agent", func() {
+				So(receivedUserAgent, ShouldEqual, "Mozilla/5.0")
+			})
+		})
+	})
+}
+
+func TestRequestMetricsBackend(t *testing.T) {
+	Convey("Given a proxy behind the request metrics recorder", t, func() {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+		defer upstream.Close()
+
+		upstreamURL, err := url.Parse(upstream.URL)
+		So(err, ShouldBeNil)
+
+		registry := metrics.NewRegistry()
+		handler := requestmetrics.NewRecorder(registry).Handler(NewReverseProxy("search", upstreamURL, Options{}))
+
+		Convey("When a request is proxied", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://www.ons.gov.uk/search", http.NoBody))
+
+			Convey("Then it is counted against the proxy's backend", func() {
+				var buf strings.Builder
+				So(registry.Write(&buf), ShouldBeNil)
+				So(buf.String(), ShouldContainSubstring, `backend="search",method="GET",code="200"} 1`)
+			})
+		})
+	})
+}
@@ -0,0 +1,159 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/helpers"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// maxRetryBudget is the most retries a budget can save up, so that a quiet upstream can still be retried
+const maxRetryBudget = 10
+
+// ParseRetryBudgets converts a map of path prefix to retry budget ratio, as read from config, into ratios
+func ParseRetryBudgets(budgets map[string]string) (map[string]float64, error) {
+	parsed := make(map[string]float64, len(budgets))
+	for prefix, value := range budgets {
+		ratio, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || ratio < 0 {
+			return nil, fmt.Errorf("invalid retry budget %q for prefix %q, must be a ratio of zero or more", value, prefix)
+		}
+		parsed[prefix] = ratio
+	}
+	return parsed, nil
+}
+
+// retrier retries idempotent requests that fail with a connection error, or a 502 or 503 from a restarting upstream,
+// backing off exponentially between attempts. Requests are retried from the budget of the longest matching route
+// prefix, so that failures on one route cannot use up the retries of the others, or from a budget shared by the
+// requests that match no prefix.
+type retrier struct {
+	name       string
+	maxRetries int
+	backoff    time.Duration
+	basePath   string
+	budget     *retryBudget
+	budgets    helpers.PrefixMap[*retryBudget]
+	transport  http.RoundTripper
+}
+
+// newRetrier creates a retrier of the requests proxied to an upstream at basePath, which is trimmed from the outbound
+// path so that requests are matched to routes by the path they were made to the router with
+func newRetrier(name, basePath string, opts Options, transport http.RoundTripper) *retrier {
+	budgets := make(map[string]*retryBudget, len(opts.RetryBudgetRoutes))
+	for prefix, ratio := range opts.RetryBudgetRoutes {
+		budgets[prefix] = newRetryBudget(ratio)
+	}
+	return &retrier{
+		name:       name,
+		maxRetries: opts.RetryMaxAttempts,
+		backoff:    opts.RetryBackoff,
+		basePath:   strings.TrimSuffix(basePath, "/"),
+		budget:     newRetryBudget(opts.RetryBudgetRatio),
+		budgets:    helpers.NewPrefixMap(budgets),
+		transport:  transport,
+	}
+}
+
+// budgetFor returns the retry budget of the route that req was made to
+func (r *retrier) budgetFor(req *http.Request) *retryBudget {
+	if _, budget, ok := r.budgets.Match(strings.TrimPrefix(req.URL.Path, r.basePath)); ok {
+		return budget
+	}
+	return r.budget
+}
+
+func (r *retrier) RoundTrip(req *http.Request) (*http.Response, error) {
+	budget := r.budgetFor(req)
+	budget.deposit()
+	resp, err := r.transport.RoundTrip(req)
+	if !isRetryable(req) {
+		return resp, err
+	}
+
+	for attempt := 0; attempt < r.maxRetries && shouldRetry(req, resp, err); attempt++ {
+		if !budget.withdraw() {
+			log.Warn(req.Context(), "retry budget exhausted, not retrying proxied request",
+				log.Data{"proxy_name": r.name, "path": req.URL.Path})
+			break
+		}
+		if !sleep(req.Context(), r.backoff<<attempt) {
+			break
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		log.Info(req.Context(), "retrying proxied request", log.Data{"proxy_name": r.name, "attempt": attempt + 1})
+		resp, err = r.transport.RoundTrip(req)
+	}
+	return resp, err
+}
+
+// isRetryable is true for requests that can safely be sent again: idempotent methods without a body
+func isRetryable(req *http.Request) bool {
+	return (req.Method == http.MethodGet || req.Method == http.MethodHead) && (req.Body == nil || req.Body == http.NoBody)
+}
+
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// sleep waits for d, returning false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retryBudget limits retries to a ratio of requests, so that retries cannot multiply the load on a struggling upstream.
+// Each request adds ratio to the balance, up to maxRetryBudget, and each retry takes one.
+type retryBudget struct {
+	mu      sync.Mutex
+	ratio   float64
+	balance float64
+}
+
+// newRetryBudget creates a retryBudget of ratio retries per request, starting full
+func newRetryBudget(ratio float64) *retryBudget {
+	return &retryBudget{ratio: ratio, balance: maxRetryBudget}
+}
+
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.balance += b.ratio
+	if b.balance > maxRetryBudget {
+		b.balance = maxRetryBudget
+	}
+}
+
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.balance < 1 {
+		return false
+	}
+	b.balance--
+	return true
+}
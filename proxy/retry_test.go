@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetrier(t *testing.T) {
+	Convey("Given a retrier of up to 2 retries, to an upstream that fails before it succeeds", t, func() {
+		var results []func() (*http.Response, error)
+		calls := 0
+		upstream := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			result := results[calls]
+			calls++
+			return result()
+		})
+		status := func(code int) func() (*http.Response, error) {
+			return func() (*http.Response, error) { return &http.Response{StatusCode: code, Body: http.NoBody}, nil }
+		}
+		connErr := func() (*http.Response, error) { return nil, errors.New("connection refused") }
+		r := newRetrier("babbage", "", Options{RetryMaxAttempts: 2, RetryBackoff: time.Millisecond, RetryBudgetRatio: 0.25}, upstream)
+
+		Convey("When a GET fails with a connection error and then a 503", func() {
+			results = append(results, connErr, status(http.StatusServiceUnavailable), status(http.StatusOK))
+			resp, err := r.RoundTrip(httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then it is retried until it succeeds", func() {
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(calls, ShouldEqual, 3)
+			})
+		})
+
+		Convey("When a GET keeps failing", func() {
+			results = append(results, status(http.StatusBadGateway), status(http.StatusBadGateway), status(http.StatusBadGateway))
+			resp, err := r.RoundTrip(httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then the last failure is returned once the retries are used up", func() {
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusBadGateway)
+				So(calls, ShouldEqual, 3)
+			})
+		})
+
+		Convey("When a GET fails with an error that is not transient", func() {
+			results = append(results, status(http.StatusInternalServerError))
+			resp, _ := r.RoundTrip(httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+
+			Convey("Then it is not retried", func() {
+				So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+				So(calls, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When a POST fails with a connection error", func() {
+			results = append(results, connErr)
+			_, err := r.RoundTrip(httptest.NewRequest(http.MethodPost, "/feedback", strings.NewReader("a=b")))
+
+			Convey("Then it is not retried", func() {
+				So(err, ShouldNotBeNil)
+				So(calls, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When more requests fail than the retry budget allows", func() {
+			for i := 0; i < 40; i++ {
+				results = append(results, connErr)
+			}
+			for i := 0; i < 8; i++ {
+				_, _ = r.RoundTrip(httptest.NewRequest(http.MethodGet, "/economy", http.NoBody))
+			}
+
+			Convey("Then retries stop once the budget is spent", func() {
+				// the first 5 requests are retried twice from the 10 saved retries, and the 6th once from the quarter of a
+				// retry that each request adds
+				So(calls, ShouldEqual, 8+11)
+			})
+		})
+	})
+}
+
+func TestRetrierRouteBudgets(t *testing.T) {
+	Convey("Given a retrier to an upstream under /babbage that always fails, with a budget of its own for search", t, func() {
+		calls := map[string]int{}
+		upstream := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls[req.URL.Path]++
+			return nil, errors.New("connection refused")
+		})
+		opts := Options{RetryMaxAttempts: 1, RetryBackoff: time.Millisecond, RetryBudgetRoutes: map[string]float64{"/search": 0}}
+		r := newRetrier("babbage", "/babbage/", opts, upstream)
+		get := func(path string) {
+			_, _ = r.RoundTrip(httptest.NewRequest(http.MethodGet, "/babbage"+path, http.NoBody))
+		}
+
+		Convey("When search uses up its retry budget", func() {
+			for i := 0; i < 20; i++ {
+				get("/search")
+			}
+			get("/economy")
+
+			Convey("Then search is no longer retried, while other routes still are", func() {
+				So(calls["/babbage/search"], ShouldEqual, 20+maxRetryBudget)
+				So(calls["/babbage/economy"], ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestParseRetryBudgets(t *testing.T) {
+	Convey("Retry budgets by path prefix are parsed into ratios", t, func() {
+		budgets, err := ParseRetryBudgets(map[string]string{"/search": "0.1", "/economy": " 0 "})
+		So(err, ShouldBeNil)
+		So(budgets, ShouldResemble, map[string]float64{"/search": 0.1, "/economy": 0})
+	})
+
+	Convey("A negative retry budget is an error", t, func() {
+		_, err := ParseRetryBudgets(map[string]string{"/search": "-1"})
+		So(err, ShouldBeError, `invalid retry budget "-1" for prefix "/search", must be a ratio of zero or more`)
+	})
+}
+
+func TestRetryBudget(t *testing.T) {
+	Convey("Given an empty retry budget of one retry per 4 requests", t, func() {
+		b := &retryBudget{ratio: 0.25}
+
+		Convey("Then a retry is allowed only once 4 requests have been made", func() {
+			for i := 0; i < 3; i++ {
+				b.deposit()
+				So(b.withdraw(), ShouldBeFalse)
+			}
+			b.deposit()
+			So(b.withdraw(), ShouldBeTrue)
+			So(b.withdraw(), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given a retry budget that has been saved up for a long time", t, func() {
+		b := &retryBudget{ratio: 1}
+		for i := 0; i < 100; i++ {
+			b.deposit()
+		}
+
+		Convey("Then no more than the maximum retries can be made in a burst", func() {
+			allowed := 0
+			for b.withdraw() {
+				allowed++
+			}
+			So(allowed, ShouldEqual, maxRetryBudget)
+		})
+	})
+}
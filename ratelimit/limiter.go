@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/cache"
+)
+
+// idleTTL is how long the bucket of a key that has stopped making requests is kept. A bucket left idle this long
+// would have refilled anyway, so dropping it doesn't change the limit.
+const idleTTL = 10 * time.Minute
+
+// Limiter limits the rate of events per key, such as per client IP, using a token bucket for each key
+type Limiter struct {
+	rate    float64
+	burst   float64
+	buckets *cache.Cache[*bucket]
+	mu      sync.Mutex
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New creates a Limiter allowing each key rate events per second on average, in bursts of up to burst events. A rate of
+// zero allows every event. At most maxKeys keys are tracked at once, with the least recently seen dropped first.
+func New(rate float64, burst, maxKeys int) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: cache.New[*bucket](maxKeys, idleTTL),
+		now:     time.Now,
+	}
+}
+
+// Allow reports whether an event for key is allowed now, consuming a token from its bucket if so
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true
+	}
+
+	now := l.now()
+	b, ok := l.buckets.Get(key)
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	l.buckets.Set(key, b)
+	return allowed
+}
+
+// SetLimit changes the rate and burst of the limiter, taking effect from the next event. Buckets already holding more
+// tokens than the new burst are capped to it as they are next used.
+func (l *Limiter) SetLimit(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = rate
+	l.burst = float64(burst)
+}
+
+// Rate returns the number of events per second the limiter allows each key on average
+func (l *Limiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rate
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLimiter(t *testing.T) {
+	Convey("Given a limiter allowing 1 event a second in bursts of 2", t, func() {
+		now := time.Now()
+		l := New(1, 2, 100)
+		l.now = func() time.Time { return now }
+
+		Convey("When a key makes a burst of events", func() {
+			first, second, third := l.Allow("a"), l.Allow("a"), l.Allow("a")
+
+			Convey("Then events up to the burst are allowed and the rest are not", func() {
+				So(first, ShouldBeTrue)
+				So(second, ShouldBeTrue)
+				So(third, ShouldBeFalse)
+			})
+
+			Convey("Then other keys are limited separately", func() {
+				So(l.Allow("b"), ShouldBeTrue)
+			})
+
+			Convey("And when time passes, the bucket refills at the rate", func() {
+				now = now.Add(time.Second)
+				So(l.Allow("a"), ShouldBeTrue)
+				So(l.Allow("a"), ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a limiter with a rate of zero", t, func() {
+		l := New(0, 0, 100)
+
+		Convey("Then every event is allowed", func() {
+			for i := 0; i < 10; i++ {
+				So(l.Allow("a"), ShouldBeTrue)
+			}
+		})
+	})
+
+	Convey("Given a limiter allowing 1 event a second in bursts of 1", t, func() {
+		now := time.Now()
+		l := New(1, 1, 100)
+		l.now = func() time.Time { return now }
+		So(l.Allow("a"), ShouldBeTrue)
+		So(l.Allow("a"), ShouldBeFalse)
+
+		Convey("When the limit is raised", func() {
+			l.SetLimit(10, 3)
+			now = now.Add(time.Second)
+
+			Convey("Then the new rate and burst take effect", func() {
+				So(l.Rate(), ShouldEqual, 10)
+				So(l.Allow("a"), ShouldBeTrue)
+				So(l.Allow("a"), ShouldBeTrue)
+				So(l.Allow("a"), ShouldBeTrue)
+				So(l.Allow("a"), ShouldBeFalse)
+			})
+		})
+
+		Convey("When the limit is removed", func() {
+			l.SetLimit(0, 0)
+
+			Convey("Then every event is allowed", func() {
+				So(l.Allow("a"), ShouldBeTrue)
+			})
+		})
+	})
+}
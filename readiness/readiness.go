@@ -0,0 +1,122 @@
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Check reports whether a dependency of the router is ready to serve traffic, returning an error describing why not
+type Check func(ctx context.Context) error
+
+// Readiness runs a set of named checks to decide whether the router is ready to be sent traffic
+type Readiness struct {
+	mu        sync.RWMutex
+	names     []string
+	checks    map[string]Check
+	selection bool
+}
+
+// Response is the body of a readiness response, giving the result of each check by name
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Possible readiness statuses
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	checkOK        = "ok"
+)
+
+// New creates a Readiness with no checks, which is always ready
+func New() *Readiness {
+	return &Readiness{checks: make(map[string]Check)}
+}
+
+// AddCheck adds a named check, replacing any existing check of the same name
+func (r *Readiness) AddCheck(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.checks[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.checks[name] = check
+}
+
+// EnableCheckSelection allows requests to run only the checks named by check query parameters, such as
+// ?check=zebedee&check=cache or ?check=zebedee,cache, rather than every check
+func (r *Readiness) EnableCheckSelection() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.selection = true
+}
+
+// ServeHTTP runs every check, or those selected by the request, responding 200 if all of them pass and 503 otherwise.
+// Selecting a check that does not exist is responded to with a 400.
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := r.names
+	if r.selection {
+		selected, unknown := r.selectedChecks(req)
+		if len(unknown) > 0 {
+			http.Error(w, "unknown readiness checks: "+strings.Join(unknown, ", "), http.StatusBadRequest)
+			return
+		}
+		if len(selected) > 0 {
+			names = selected
+		}
+	}
+
+	resp := Response{Status: StatusReady, Checks: make(map[string]string, len(names))}
+	for _, name := range names {
+		if err := r.checks[name](req.Context()); err != nil {
+			resp.Status = StatusNotReady
+			resp.Checks[name] = err.Error()
+			continue
+		}
+		resp.Checks[name] = checkOK
+	}
+
+	status := http.StatusOK
+	if resp.Status != StatusReady {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Error(req.Context(), "error writing readiness response", err)
+	}
+}
+
+// selectedChecks returns the names of the checks selected by the check query parameters of req, without duplicates,
+// and any selected names that are not checks. The read lock must be held.
+func (r *Readiness) selectedChecks(req *http.Request) (selected, unknown []string) {
+	seen := make(map[string]bool)
+	for _, param := range req.URL.Query()["check"] {
+		for _, name := range strings.Split(param, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			if _, ok := r.checks[name]; !ok {
+				unknown = append(unknown, name)
+				continue
+			}
+			selected = append(selected, name)
+		}
+	}
+	return selected, unknown
+}
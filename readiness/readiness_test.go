@@ -0,0 +1,123 @@
+package readiness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadiness(t *testing.T) {
+	Convey("Given readiness with a passing and a failing check", t, func() {
+		r := New()
+		r.AddCheck("passing", func(ctx context.Context) error { return nil })
+		r.AddCheck("failing", func(ctx context.Context) error { return errors.New("not yet") })
+
+		Convey("When readiness is requested", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", http.NoBody))
+
+			Convey("Then 503 is returned with the result of each check", func() {
+				So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+				var resp Response
+				So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+				So(resp.Status, ShouldEqual, StatusNotReady)
+				So(resp.Checks, ShouldResemble, map[string]string{"passing": "ok", "failing": "not yet"})
+			})
+		})
+
+		Convey("When the failing check is replaced with a passing one", func() {
+			r.AddCheck("failing", func(ctx context.Context) error { return nil })
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", http.NoBody))
+
+			Convey("Then 200 is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+	})
+
+	Convey("Given readiness with check selection enabled", t, func() {
+		var cacheRuns int
+		r := New()
+		r.AddCheck("zebedee", func(ctx context.Context) error { return nil })
+		r.AddCheck("search", func(ctx context.Context) error { return errors.New("unavailable") })
+		r.AddCheck("cache", func(ctx context.Context) error {
+			cacheRuns++
+			return nil
+		})
+		r.EnableCheckSelection()
+
+		serve := func(target string) (*httptest.ResponseRecorder, Response) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, http.NoBody))
+			var resp Response
+			if w.Code != http.StatusBadRequest {
+				So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+			}
+			return w, resp
+		}
+
+		Convey("When a single check is named", func() {
+			w, resp := serve("/health/ready?check=zebedee")
+
+			Convey("Then only that check is run and reported", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(resp.Checks, ShouldResemble, map[string]string{"zebedee": "ok"})
+				So(cacheRuns, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When multiple checks are named, as repeated and comma separated parameters", func() {
+			w, resp := serve("/health/ready?check=zebedee,search&check=search")
+
+			Convey("Then each named check is run once, and the result reflects only those checks", func() {
+				So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(resp.Status, ShouldEqual, StatusNotReady)
+				So(resp.Checks, ShouldResemble, map[string]string{"zebedee": "ok", "search": "unavailable"})
+				So(cacheRuns, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When an unknown check is named", func() {
+			w, _ := serve("/health/ready?check=zebedee&check=missing")
+
+			Convey("Then 400 is returned naming the unknown check, without running any checks", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, "missing")
+				So(cacheRuns, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When no check is named", func() {
+			_, resp := serve("/health/ready")
+
+			Convey("Then every check is run", func() {
+				So(resp.Checks, ShouldHaveLength, 3)
+				So(cacheRuns, ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given readiness without check selection enabled", t, func() {
+		r := New()
+		r.AddCheck("zebedee", func(ctx context.Context) error { return nil })
+		r.AddCheck("cache", func(ctx context.Context) error { return nil })
+
+		Convey("When a check is named", func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready?check=missing", http.NoBody))
+
+			Convey("Then the parameter is ignored and every check is run", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				var resp Response
+				So(json.Unmarshal(w.Body.Bytes(), &resp), ShouldBeNil)
+				So(resp.Checks, ShouldHaveLength, 2)
+			})
+		})
+	})
+}
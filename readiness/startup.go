@@ -0,0 +1,59 @@
+package readiness
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// Gate is a check that fails until it is opened, for a startup step, such as building the routes, that must complete
+// before the router is ready. It can be closed again, such as when the router is shutting down.
+type Gate struct {
+	open   atomic.Bool
+	reason string
+}
+
+// NewGate creates a closed Gate, whose check fails with reason until it is opened
+func NewGate(reason string) *Gate {
+	return &Gate{reason: reason}
+}
+
+// Open passes the gate's check from now on
+func (g *Gate) Open() {
+	g.open.Store(true)
+}
+
+// Close fails the gate's check from now on, until it is opened again
+func (g *Gate) Close() {
+	g.open.Store(false)
+}
+
+// Check fails until the gate is opened
+func (g *Gate) Check(ctx context.Context) error {
+	if g.open.Load() {
+		return nil
+	}
+	return errors.New(g.reason)
+}
+
+// Once returns a check that runs check until it first passes, and passes from then on without running it again, so
+// that a dependency verified at startup, such as a backend, is not requested by every readiness probe and readiness
+// doesn't flap
+func Once(check Check) Check {
+	var mu sync.Mutex
+	var passed bool
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if passed {
+			return nil
+		}
+		if err := check(ctx); err != nil {
+			return err
+		}
+		passed = true
+		return nil
+	}
+}
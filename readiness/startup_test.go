@@ -0,0 +1,66 @@
+package readiness
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGate(t *testing.T) {
+	Convey("Given a closed gate", t, func() {
+		gate := NewGate("routes have not been built")
+
+		Convey("Then its check fails with its reason", func() {
+			So(gate.Check(context.Background()), ShouldBeError, "routes have not been built")
+		})
+
+		Convey("When it is opened", func() {
+			gate.Open()
+
+			Convey("Then its check passes", func() {
+				So(gate.Check(context.Background()), ShouldBeNil)
+			})
+
+			Convey("And when it is closed again", func() {
+				gate.Close()
+
+				Convey("Then its check fails", func() {
+					So(gate.Check(context.Background()), ShouldNotBeNil)
+				})
+			})
+		})
+	})
+}
+
+func TestOnce(t *testing.T) {
+	Convey("Given a check of a backend that is unreachable", t, func() {
+		calls := 0
+		reachable := false
+		check := Once(func(ctx context.Context) error {
+			calls++
+			if !reachable {
+				return errors.New("search is unreachable")
+			}
+			return nil
+		})
+
+		Convey("Then the check fails each time it is run", func() {
+			So(check(context.Background()), ShouldNotBeNil)
+			So(check(context.Background()), ShouldNotBeNil)
+			So(calls, ShouldEqual, 2)
+		})
+
+		Convey("When the backend becomes reachable", func() {
+			reachable = true
+			So(check(context.Background()), ShouldBeNil)
+
+			Convey("Then the check keeps passing without checking the backend again", func() {
+				reachable = false
+				So(check(context.Background()), ShouldBeNil)
+				So(calls, ShouldEqual, 1)
+			})
+		})
+	})
+}
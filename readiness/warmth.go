@@ -0,0 +1,35 @@
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/cache"
+)
+
+// CacheWarmth returns a check that passes once the named cache in registry holds at least minEntries entries, or once
+// the grace period since started has elapsed, whichever comes first. Once passed, the check stays passed, so that
+// readiness doesn't flap as entries are evicted.
+func CacheWarmth(registry *cache.Registry, cacheName string, minEntries int, grace time.Duration, started time.Time) Check {
+	var warm atomic.Bool
+	return func(ctx context.Context) error {
+		if warm.Load() {
+			return nil
+		}
+
+		if time.Since(started) >= grace {
+			warm.Store(true)
+			return nil
+		}
+
+		size := registry.Stats()[cacheName].Size
+		if size >= minEntries {
+			warm.Store(true)
+			return nil
+		}
+
+		return fmt.Errorf("cache %q is cold: %d of %d entries", cacheName, size, minEntries)
+	}
+}
@@ -0,0 +1,56 @@
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/cache"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCacheWarmth(t *testing.T) {
+	Convey("Given a cache warmth check requiring three entries, within its grace period", t, func() {
+		registry := cache.NewRegistry()
+		pageTypes := cache.New[string](10, time.Minute)
+		registry.Register("page-type", pageTypes)
+		check := CacheWarmth(registry, "page-type", 3, time.Hour, time.Now())
+
+		Convey("When the cache is cold", func() {
+			pageTypes.Set("/a", "article")
+			err := check(context.Background())
+
+			Convey("Then the check fails", func() {
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("And when the cache warms up", func() {
+				for i := 0; i < 3; i++ {
+					pageTypes.Set(fmt.Sprintf("/%d", i), "article")
+				}
+
+				Convey("Then the check passes, and keeps passing once the cache is emptied", func() {
+					So(check(context.Background()), ShouldBeNil)
+					for i := 0; i < 3; i++ {
+						pageTypes.Delete(fmt.Sprintf("/%d", i))
+					}
+					So(check(context.Background()), ShouldBeNil)
+				})
+			})
+		})
+	})
+
+	Convey("Given a cache warmth check whose grace period has elapsed", t, func() {
+		registry := cache.NewRegistry()
+		check := CacheWarmth(registry, "page-type", 3, time.Minute, time.Now().Add(-2*time.Minute))
+
+		Convey("When the cache is cold, or not registered at all", func() {
+			err := check(context.Background())
+
+			Convey("Then the check passes", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
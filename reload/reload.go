@@ -0,0 +1,149 @@
+package reload
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sync"
+	"syscall"
+
+	"github.com/ONSdigital/dp-frontend-router/config"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// Apply applies the hot reloadable values of a reloaded config to the component that consumes them
+type Apply func(ctx context.Context, cfg *config.Config)
+
+type applier struct {
+	fields []string
+	apply  Apply
+}
+
+// Reloader reloads the config when asked, applying the changes to the settings that can be changed while running.
+// Changes to any other setting are ignored until the router is restarted.
+type Reloader struct {
+	mu       sync.Mutex
+	load     func() (*config.Config, error)
+	current  *config.Config
+	appliers []applier
+}
+
+// New creates a Reloader for the running config, current, using load to read the config afresh
+func New(current *config.Config, load func() (*config.Config, error)) *Reloader {
+	return &Reloader{
+		load:    load,
+		current: current,
+	}
+}
+
+// OnReload registers apply to be called with each reloaded config that changes any of the named Config fields, making
+// them hot reloadable
+func (r *Reloader) OnReload(fields []string, apply Apply) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.appliers = append(r.appliers, applier{fields: fields, apply: apply})
+}
+
+// Reload loads the config and applies it. Changes to settings that are not hot reloadable are logged and ignored. If
+// the config cannot be loaded, the running config is left as it is.
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := r.load()
+	if err != nil {
+		log.Error(ctx, "error reloading config, keeping the running config", err)
+		return err
+	}
+
+	reloadable := make(map[string]bool)
+	for _, a := range r.appliers {
+		for _, field := range a.fields {
+			reloadable[field] = true
+		}
+	}
+
+	changed, ignored := diff(r.current, loaded, reloadable)
+	if len(ignored) > 0 {
+		log.Warn(ctx, "ignoring changes to config that require a restart", log.Data{"settings": ignored})
+	}
+
+	// only apply the settings that changed, so that components are not rebuilt, and lose their state, for no reason
+	for _, a := range r.appliers {
+		if slices.ContainsFunc(a.fields, func(field string) bool { return slices.Contains(changed, field) }) {
+			a.apply(ctx, loaded)
+		}
+	}
+
+	// keep the running values of the settings that were not applied, so their changes are reported until restarted
+	applied := *r.current
+	copyFields(&applied, loaded, changed)
+	r.current = &applied
+
+	log.Info(ctx, "config reloaded", log.Data{"changed": changed})
+	return nil
+}
+
+// Current returns the running config, with the reloaded values of the settings that have been applied
+func (r *Reloader) Current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current
+}
+
+// Listen reloads the config whenever the process receives a SIGHUP, until the returned stop function is called
+func (r *Reloader) Listen(ctx context.Context) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				log.Info(ctx, "SIGHUP received, reloading config")
+				_ = r.Reload(ctx)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// diff returns the names of the top level Config fields that differ between current and loaded, split into those that
+// are reloadable and those that are not
+func diff(current, loaded *config.Config, reloadable map[string]bool) (changed, ignored []string) {
+	cv, lv := reflect.ValueOf(current).Elem(), reflect.ValueOf(loaded).Elem()
+	for i := 0; i < cv.NumField(); i++ {
+		if reflect.DeepEqual(cv.Field(i).Interface(), lv.Field(i).Interface()) {
+			continue
+		}
+		name := cv.Type().Field(i).Name
+		if reloadable[name] {
+			changed = append(changed, name)
+		} else {
+			ignored = append(ignored, name)
+		}
+	}
+	return changed, ignored
+}
+
+// copyFields copies the named top level fields from src to dst
+func copyFields(dst, src *config.Config, fields []string) {
+	dv, sv := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for _, field := range fields {
+		dv.FieldByName(field).Set(sv.FieldByName(field))
+	}
+}
@@ -0,0 +1,89 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/config"
+	"github.com/ONSdigital/dp-frontend-router/ratelimit"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReload(t *testing.T) {
+	Convey("Given a reloader with the analytics rate limit registered as hot reloadable", t, func() {
+		ctx := context.Background()
+		current := &config.Config{BindAddr: ":20000", AnalyticsRateLimit: 1, AnalyticsRateLimitBurst: 1}
+		next := *current
+		var loadErr error
+		reloader := New(current, func() (*config.Config, error) {
+			loaded := next
+			return &loaded, loadErr
+		})
+
+		limiter := ratelimit.New(current.AnalyticsRateLimit, current.AnalyticsRateLimitBurst, 10)
+		reloader.OnReload([]string{"AnalyticsRateLimit", "AnalyticsRateLimitBurst"}, func(ctx context.Context, cfg *config.Config) {
+			limiter.SetLimit(cfg.AnalyticsRateLimit, cfg.AnalyticsRateLimitBurst)
+		})
+		botReloads := 0
+		reloader.OnReload([]string{"BotRateLimit"}, func(ctx context.Context, cfg *config.Config) {
+			botReloads++
+		})
+		So(limiter.Allow("client"), ShouldBeTrue)
+		So(limiter.Allow("client"), ShouldBeFalse)
+
+		Convey("When a reload changes the rate limit", func() {
+			next.AnalyticsRateLimit = 0
+			So(reloader.Reload(ctx), ShouldBeNil)
+
+			Convey("Then the new limit takes effect", func() {
+				So(limiter.Allow("client"), ShouldBeTrue)
+				So(reloader.Current().AnalyticsRateLimit, ShouldEqual, 0)
+			})
+
+			Convey("Then settings that did not change are not applied again", func() {
+				So(botReloads, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a reload changes a setting that requires a restart", func() {
+			next.BindAddr = ":30000"
+			next.AnalyticsRateLimitBurst = 5
+			So(reloader.Reload(ctx), ShouldBeNil)
+
+			Convey("Then the change is ignored, while reloadable changes are applied", func() {
+				So(reloader.current.BindAddr, ShouldEqual, ":20000")
+				So(reloader.current.AnalyticsRateLimitBurst, ShouldEqual, 5)
+			})
+		})
+
+		Convey("When the config cannot be loaded", func() {
+			next.AnalyticsRateLimit = 0
+			loadErr = errors.New("invalid config")
+
+			Convey("Then an error is returned and the running limit is kept", func() {
+				So(reloader.Reload(ctx), ShouldEqual, loadErr)
+				So(limiter.Allow("client"), ShouldBeFalse)
+				So(reloader.current.AnalyticsRateLimit, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the process receives a SIGHUP while listening", func() {
+			next.AnalyticsRateLimit = 0
+			stop := reloader.Listen(ctx)
+			defer stop()
+			So(syscall.Kill(os.Getpid(), syscall.SIGHUP), ShouldBeNil)
+
+			Convey("Then the config is reloaded", func() {
+				deadline := time.Now().Add(time.Second)
+				for !limiter.Allow("client") && time.Now().Before(deadline) {
+					time.Sleep(10 * time.Millisecond)
+				}
+				So(limiter.Allow("client"), ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,124 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// openAPIVersion is the version of the OpenAPI specification that the description conforms to
+const openAPIVersion = "3.0.3"
+
+type apiDescription struct {
+	OpenAPI string                      `json:"openapi"`
+	Info    apiInfo                     `json:"info"`
+	Paths   map[string]map[string]apiOp `json:"paths"`
+}
+
+type apiInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type apiOp struct {
+	Summary    string                 `json:"summary"`
+	Parameters []apiParam             `json:"parameters,omitempty"`
+	Responses  map[string]apiResponse `json:"responses"`
+}
+
+type apiParam struct {
+	Name     string            `json:"name"`
+	In       string            `json:"in"`
+	Required bool              `json:"required"`
+	Schema   map[string]string `json:"schema"`
+}
+
+type apiResponse struct {
+	Description string `json:"description"`
+}
+
+// Description returns an OpenAPI description of the endpoints that the router serves itself for cfg, rather than
+// proxies to other services
+func Description(cfg Config) ([]byte, error) {
+	version := cfg.APIDescriptionVersion
+	if version == "" {
+		version = "unknown"
+	}
+
+	paths := map[string]map[string]apiOp{
+		"/health": {http.MethodGet: {
+			Summary: "Health of the router and the services it depends on",
+			Responses: map[string]apiResponse{
+				"200": {Description: "The router and its dependencies are healthy"},
+				"429": {Description: "A dependency is failing, but the router can still serve requests"},
+				"500": {Description: "A critical dependency is failing"},
+			},
+		}},
+		"/redir/{data}": {http.MethodGet: {
+			Summary:    "Records a search analytics event and redirects to the URL it holds",
+			Parameters: []apiParam{{Name: "data", In: "path", Required: true, Schema: map[string]string{"type": "string"}}},
+			Responses: map[string]apiResponse{
+				"307": {Description: "The event was recorded, or dropped, and the client is redirected to its URL"},
+				"400": {Description: "The data is not a valid signed analytics token"},
+				"429": {Description: "The client is rate limited, if rate limited requests are rejected"},
+			},
+		}},
+	}
+
+	if cfg.ReadinessHandler != nil {
+		paths["/health/ready"] = map[string]apiOp{http.MethodGet: {
+			Summary: "Readiness of the router to receive traffic",
+			Responses: map[string]apiResponse{
+				"200": {Description: "All readiness checks pass"},
+				"503": {Description: "One or more readiness checks fail"},
+			},
+		}}
+	}
+
+	if cfg.CacheStatsHandler != nil {
+		paths["/status"] = map[string]apiOp{http.MethodGet: {
+			Summary:   "Statistics of the router's caches",
+			Responses: map[string]apiResponse{"200": {Description: "The statistics of each cache"}},
+		}}
+	}
+
+	if cfg.MetricsHandler != nil {
+		paths["/metrics"] = map[string]apiOp{http.MethodGet: {
+			Summary:   "Metrics of the router in the Prometheus text exposition format",
+			Responses: map[string]apiResponse{"200": {Description: "The router's metrics"}},
+		}}
+	}
+
+	paths[cfg.APIDescriptionPath] = map[string]apiOp{http.MethodGet: {
+		Summary:   "This description of the endpoints the router serves itself",
+		Responses: map[string]apiResponse{"200": {Description: "The OpenAPI description"}},
+	}}
+
+	return json.Marshal(apiDescription{
+		OpenAPI: openAPIVersion,
+		Info: apiInfo{
+			Title:       "dp-frontend-router",
+			Description: "Endpoints served by the router itself. Requests for any other path are proxied to the frontend services.",
+			Version:     version,
+		},
+		Paths: paths,
+	})
+}
+
+// descriptionHandler serves the description of the router's own endpoints, which is built once as the config is fixed
+func descriptionHandler(cfg Config) http.Handler {
+	b, err := Description(cfg)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err != nil {
+			log.Error(req.Context(), "error building api description", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(b); err != nil {
+			log.Error(req.Context(), "error writing api description", err)
+		}
+	})
+}
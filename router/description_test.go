@@ -0,0 +1,76 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/middleware/internalonly"
+	"github.com/ONSdigital/dp-frontend-router/router"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type description struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func describedPaths(d description) []string {
+	paths := make([]string, 0, len(d.Paths))
+	for path, ops := range d.Paths {
+		So(ops, ShouldContainKey, http.MethodGet)
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestDescription(t *testing.T) {
+	Convey("Given a router config with only the endpoints that are always served", t, func() {
+		cfg := router.Config{APIDescriptionPath: "/router/openapi.json"}
+
+		Convey("Then the description lists the router's own endpoints and no proxied ones", func() {
+			b, err := router.Description(cfg)
+			So(err, ShouldBeNil)
+
+			var d description
+			So(json.Unmarshal(b, &d), ShouldBeNil)
+			So(d.OpenAPI, ShouldEqual, "3.0.3")
+			So(d.Info.Version, ShouldEqual, "unknown")
+			So(describedPaths(d), ShouldResemble, []string{"/health", "/redir/{data}", "/router/openapi.json"})
+		})
+	})
+
+	Convey("Given a router config with the optional endpoints enabled", t, func() {
+		cfg := router.Config{
+			APIDescriptionPath:    "/router/openapi.json",
+			APIDescriptionVersion: "v1.2.3",
+			ReadinessHandler:      http.NotFoundHandler(),
+			CacheStatsHandler:     http.NotFound,
+			MetricsHandler:        http.NotFoundHandler(),
+			AdminGuard:            internalonly.Guard{SecretHeader: "X-Admin-Secret", Secret: "s3cret"},
+		}
+
+		Convey("When the description is requested from the router", func() {
+			res := httptest.NewRecorder()
+			router.New(cfg).ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/router/openapi.json", http.NoBody))
+
+			Convey("Then it lists the optional endpoints as well", func() {
+				So(res.Code, ShouldEqual, http.StatusOK)
+				So(res.Header().Get("Content-Type"), ShouldEqual, "application/json")
+
+				var d description
+				So(json.Unmarshal(res.Body.Bytes(), &d), ShouldBeNil)
+				So(d.Info.Version, ShouldEqual, "v1.2.3")
+				So(describedPaths(d), ShouldResemble, []string{
+					"/health", "/health/ready", "/metrics", "/redir/{data}", "/router/openapi.json", "/status",
+				})
+			})
+		})
+	})
+}
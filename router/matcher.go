@@ -68,3 +68,15 @@ func HasFileExt(path string) bool {
 func hasFileExtMatcher(request *http.Request, _ *mux.RouteMatch) bool {
 	return HasFileExt(request.URL.Path)
 }
+
+// hasPathPrefixMatcher returns a mux MatcherFunc, allowing routes to be matched on the path having any of prefixes
+func hasPathPrefixMatcher(prefixes []string) mux.MatcherFunc {
+	return func(request *http.Request, _ *mux.RouteMatch) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(request.URL.Path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
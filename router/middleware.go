@@ -0,0 +1,270 @@
+package router
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ONSdigital/dp-frontend-router/config"
+	"github.com/ONSdigital/dp-frontend-router/middleware/accesslog"
+	"github.com/ONSdigital/dp-frontend-router/middleware/bodylimit"
+	"github.com/ONSdigital/dp-frontend-router/middleware/bots"
+	"github.com/ONSdigital/dp-frontend-router/middleware/cachecontrol"
+	"github.com/ONSdigital/dp-frontend-router/middleware/compression"
+	"github.com/ONSdigital/dp-frontend-router/middleware/cors"
+	"github.com/ONSdigital/dp-frontend-router/middleware/experiments"
+	"github.com/ONSdigital/dp-frontend-router/middleware/forwardedproto"
+	"github.com/ONSdigital/dp-frontend-router/middleware/georouting"
+	"github.com/ONSdigital/dp-frontend-router/middleware/gone"
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+	"github.com/ONSdigital/dp-frontend-router/middleware/otelmetrics"
+	"github.com/ONSdigital/dp-frontend-router/middleware/preconnect"
+	"github.com/ONSdigital/dp-frontend-router/middleware/probelog"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectchain"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectrules"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirects"
+	"github.com/ONSdigital/dp-frontend-router/middleware/securecookies"
+	"github.com/ONSdigital/dp-frontend-router/middleware/securityheaders"
+	"github.com/ONSdigital/dp-frontend-router/middleware/timeout"
+	"github.com/ONSdigital/dp-frontend-router/middleware/trailingslash"
+	"github.com/ONSdigital/dp-frontend-router/middleware/traversal"
+	dprequest "github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/justinas/alice"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+)
+
+// Names of the middleware in the router's middleware chain
+const (
+	RequestIDMiddleware       = "request-id"
+	AccessLogMiddleware       = "access-log"
+	SecurityMiddleware        = "security"
+	HealthcheckMiddleware     = "healthcheck"
+	ReadinessMiddleware       = "readiness"
+	MetricsMiddleware         = "metrics"
+	CompressionMiddleware     = "compression"
+	IPFilterMiddleware        = "ip-filter"
+	MaintenanceMiddleware     = "maintenance"
+	BotsMiddleware            = "bots"
+	BotBackendMiddleware      = "bot-backend"
+	RateLimitMiddleware       = "rate-limit"
+	CORSMiddleware            = "cors"
+	BodyLimitMiddleware       = "body-limit"
+	BackendSetMiddleware      = "backend-set"
+	ForwardedProtoMiddleware  = "forwarded-proto"
+	PathTraversalMiddleware   = "path-traversal"
+	RedirectMissesMiddleware  = "redirect-misses"
+	RedirectChainMiddleware   = "redirect-chain"
+	RedirectsMiddleware       = "redirects"
+	RedirectMapMiddleware     = "redirect-map"
+	RedirectRulesMiddleware   = "redirect-rules"
+	TrailingSlashMiddleware   = "trailing-slash"
+	GoneMiddleware            = "gone"
+	TimeoutMiddleware         = "timeout"
+	StreamingMiddleware       = "streaming"
+	PreconnectMiddleware      = "preconnect"
+	ExperimentsMiddleware     = "experiments"
+	GeoRoutingMiddleware      = "geo-routing"
+	CacheControlMiddleware    = "cache-control"
+	SecureCookiesMiddleware   = "secure-cookies"
+	SecurityHeadersMiddleware = "security-headers"
+	OtelMiddleware            = "otel"
+	OtelMetricsMiddleware     = "otel-metrics"
+)
+
+// Middleware is a named constructor in the router's middleware chain
+type Middleware struct {
+	Name        string
+	Constructor alice.Constructor
+}
+
+// MiddlewareChain returns the middleware that New wraps the router in for cfg, in the order that requests pass through
+// it, so the composition of the chain can be checked without building the router
+func MiddlewareChain(cfg Config) []Middleware {
+	middleware := []Middleware{
+		{RequestIDMiddleware, dprequest.HandlerRequestID(16)},
+		{AccessLogMiddleware, probelog.Handler(cfg.ProbeLogPaths, cfg.ProbeLogMode, accesslog.Handler(cfg.AccessLogSampleRate))},
+		{SecurityMiddleware, securityHandler(cfg.SecurityHeaders)},
+		{HealthcheckMiddleware, healthcheckHandler(cfg.HealthCheckHandler)},
+	}
+
+	if cfg.ReadinessHandler != nil {
+		middleware = append(middleware, Middleware{ReadinessMiddleware, readinessHandler(cfg.ReadinessHandler)})
+	}
+
+	// requests are measured once health probes have been answered, so that probes do not skew the request metrics
+	if cfg.RequestMetrics != nil {
+		middleware = append(middleware, Middleware{MetricsMiddleware, cfg.RequestMetrics.Handler})
+	}
+
+	// compress responses before anything else writes them, so that error pages from the router are compressed too
+	if cfg.Compression.Enabled() {
+		middleware = append(middleware, Middleware{CompressionMiddleware, compression.Handler(cfg.Compression)})
+	}
+
+	middleware = append(middleware, clientMiddleware(cfg)...)
+
+	if cfg.ForwardedProtoCheckEnabled {
+		middleware = append(middleware, Middleware{ForwardedProtoMiddleware, forwardedproto.Handler(cfg.ForwardedProtoReject)})
+	}
+
+	// reject traversal before any redirects or routing act on the path
+	if cfg.PathTraversalBlockEnabled {
+		middleware = append(middleware, Middleware{PathTraversalMiddleware, traversal.Handler})
+	}
+
+	middleware = append(middleware, redirectMiddleware(cfg)...)
+
+	if len(cfg.RetiredPaths) > 0 {
+		middleware = append(middleware, Middleware{GoneMiddleware, gone.Handler(cfg.RetiredPaths, cfg.RetiredPathsBody)})
+	}
+
+	// divert crawlers only once redirects and retired paths have been answered, so that they see the same as everyone else
+	if cfg.BotRules.Backend != nil {
+		middleware = append(middleware, Middleware{BotBackendMiddleware, bots.Divert(cfg.BotRules)})
+	}
+
+	if len(cfg.RouteTimeouts) > 0 {
+		middleware = append(middleware, Middleware{TimeoutMiddleware, timeout.Handler(cfg.RouteTimeouts, cfg.RouteTimeoutBody)})
+	}
+
+	if cfg.Streaming != nil {
+		middleware = append(middleware, Middleware{StreamingMiddleware, cfg.Streaming.Handler})
+	}
+
+	if cfg.PreconnectOrigin != "" && len(cfg.PreconnectPaths) > 0 {
+		middleware = append(middleware, Middleware{PreconnectMiddleware, preconnect.Handler(cfg.PreconnectOrigin, cfg.PreconnectPaths)})
+	}
+
+	if len(cfg.Experiments) > 0 {
+		experimentsHandler := experiments.Handler(cfg.Experiments, cfg.ExperimentIDCookie, cfg.IPFilterRules.TrustedProxies)
+		middleware = append(middleware, Middleware{ExperimentsMiddleware, experimentsHandler})
+	}
+
+	if cfg.GeoRules.Enabled() {
+		middleware = append(middleware, Middleware{GeoRoutingMiddleware, georouting.Handler(cfg.GeoRules)})
+	}
+
+	middleware = append(middleware, headerMiddleware(cfg)...)
+
+	return append(middleware, otelMiddleware()...)
+}
+
+// headerMiddleware returns the middleware that sets or enforces response headers in place of those set by backends
+func headerMiddleware(cfg Config) []Middleware {
+	var middleware []Middleware
+
+	if len(cfg.CachePolicies) > 0 {
+		middleware = append(middleware, Middleware{CacheControlMiddleware, cachecontrol.Handler(cfg.CachePolicies)})
+	}
+
+	if cfg.CookiePolicy.Enabled() {
+		middleware = append(middleware, Middleware{SecureCookiesMiddleware, securecookies.Handler(cfg.CookiePolicy)})
+	}
+
+	if cfg.SecurityHeaderProfiles {
+		profiles := securityheaders.DefaultProfiles(securityheaders.Policy{
+			Default:    cfg.ContentSecurityPolicy,
+			Overrides:  cfg.CSPOverrides,
+			ReportOnly: cfg.CSPReportOnly,
+		})
+		middleware = append(middleware, Middleware{SecurityHeadersMiddleware, securityheaders.Handler(profiles)})
+	}
+	return middleware
+}
+
+// clientMiddleware returns the middleware that filters, limits and allows requests by the client making them,
+// applied once health probes have been answered so that probes are never blocked or limited
+func clientMiddleware(cfg Config) []Middleware {
+	var middleware []Middleware
+
+	// block denied clients before anything else is done for them
+	if cfg.IPFilterRules.Enabled() {
+		middleware = append(middleware, Middleware{IPFilterMiddleware, ipfilter.Handler(cfg.IPFilterRules)})
+	}
+
+	// in maintenance mode, every request other than the health probes gets the maintenance page
+	if cfg.Maintenance != nil {
+		maintenanceHandler := cfg.Maintenance.Handler(cfg.MaintenanceBody, cfg.MaintenanceRetryAfter)
+		middleware = append(middleware, Middleware{MaintenanceMiddleware, maintenanceHandler})
+	}
+
+	// limit crawlers before the general rate limits, so that crawl storms do not use up other clients' limits
+	if cfg.BotRules.Limiter != nil {
+		middleware = append(middleware, Middleware{BotsMiddleware, bots.Limit(cfg.BotRules)})
+	}
+
+	if cfg.RateLimiter != nil {
+		middleware = append(middleware, Middleware{RateLimitMiddleware, cfg.RateLimiter.Handler})
+	}
+
+	// answer preflight requests from allowed origins before anything else is done for them
+	if len(cfg.CORSRules) > 0 {
+		middleware = append(middleware, Middleware{CORSMiddleware, cors.Handler(cfg.CORSRules)})
+	}
+
+	if cfg.BodyLimits.Enabled() {
+		middleware = append(middleware, Middleware{BodyLimitMiddleware, bodylimit.Handler(cfg.BodyLimits)})
+	}
+
+	// only clients that have been let through can choose the backend set that serves them
+	if cfg.BackendSets.Enabled() {
+		middleware = append(middleware, Middleware{BackendSetMiddleware, cfg.BackendSets.Handler})
+	}
+	return middleware
+}
+
+// otelMiddleware returns the OpenTelemetry tracing and metrics middleware, if enabled
+func otelMiddleware() []Middleware {
+	appConfig, err := config.Get()
+	if err != nil {
+		log.Error(context.Background(), "error getting config", err)
+	}
+
+	if !appConfig.OtelEnabled {
+		return nil
+	}
+	return []Middleware{
+		{OtelMiddleware, otelhttp.NewMiddleware("dp-frontend-router")},
+		{OtelMetricsMiddleware, otelmetrics.Handler(otel.Meter(otelmetrics.MeterName))},
+	}
+}
+
+// redirectMiddleware returns the redirect stages, or a single redirect chain resolving them internally if enabled so
+// that visitors get a single redirect, counting the redirects they serve and the legacy URLs they miss if enabled
+func redirectMiddleware(cfg Config) []Middleware {
+	// the redirects served by each stage are counted by rule, which is the path for the redirects keyed by path
+	stage := func(name string, rule func(*http.Request) string, constructor func(http.Handler) http.Handler) Middleware {
+		return Middleware{name, cfg.RedirectUsage.Stage(name, rule, constructor)}
+	}
+	byPath := func(req *http.Request) string { return req.URL.Path }
+	byRule := func(req *http.Request) string {
+		rule, _, _ := redirectrules.Match(cfg.RedirectRules, req.URL.Path)
+		return rule.String()
+	}
+
+	redirectStages := []Middleware{stage(RedirectsMiddleware, byPath, redirects.Handler)}
+	if cfg.RedirectMap != nil {
+		redirectStages = append(redirectStages, stage(RedirectMapMiddleware, byPath, cfg.RedirectMap.Handler))
+	}
+	if len(cfg.RedirectRules) > 0 {
+		redirectStages = append(redirectStages, stage(RedirectRulesMiddleware, byRule, redirectrules.Handler(cfg.RedirectRules)))
+	}
+	if len(cfg.TrailingSlashPolicies) > 0 {
+		redirectStages = append(redirectStages, Middleware{TrailingSlashMiddleware, trailingslash.Handler(cfg.TrailingSlashPolicies)})
+	}
+
+	if cfg.RedirectMaxHops > 0 {
+		stages := make([]func(http.Handler) http.Handler, 0, len(redirectStages))
+		for _, stage := range redirectStages {
+			stages = append(stages, stage.Constructor)
+		}
+		redirectStages = []Middleware{{RedirectChainMiddleware, redirectchain.Handler(cfg.RedirectMaxHops, stages...)}}
+	}
+
+	// legacy URLs that none of the stages redirect are logged once the request has been served
+	if cfg.RedirectUsage != nil {
+		return append([]Middleware{{RedirectMissesMiddleware, cfg.RedirectUsage.Misses}}, redirectStages...)
+	}
+	return redirectStages
+}
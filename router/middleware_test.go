@@ -0,0 +1,197 @@
+package router_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/metrics"
+	"github.com/ONSdigital/dp-frontend-router/middleware/backendset"
+	"github.com/ONSdigital/dp-frontend-router/middleware/bodylimit"
+	"github.com/ONSdigital/dp-frontend-router/middleware/bots"
+	"github.com/ONSdigital/dp-frontend-router/middleware/cachecontrol"
+	"github.com/ONSdigital/dp-frontend-router/middleware/compression"
+	"github.com/ONSdigital/dp-frontend-router/middleware/cors"
+	"github.com/ONSdigital/dp-frontend-router/middleware/georouting"
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+	"github.com/ONSdigital/dp-frontend-router/middleware/maintenance"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectmap"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectrules"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectusage"
+	"github.com/ONSdigital/dp-frontend-router/middleware/requestmetrics"
+	"github.com/ONSdigital/dp-frontend-router/middleware/securecookies"
+	"github.com/ONSdigital/dp-frontend-router/middleware/streaming"
+	"github.com/ONSdigital/dp-frontend-router/middleware/throttle"
+	"github.com/ONSdigital/dp-frontend-router/middleware/trailingslash"
+	"github.com/ONSdigital/dp-frontend-router/ratelimit"
+	"github.com/ONSdigital/dp-frontend-router/router"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func middlewareNames(chain []router.Middleware) []string {
+	names := make([]string, 0, len(chain))
+	for _, m := range chain {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+// countryLocator locates every client in the same country
+type countryLocator string
+
+func (c countryLocator) Country(ip netip.Addr) (string, error) {
+	return string(c), nil
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	redirectMapFile := filepath.Join(t.TempDir(), "redirects.csv")
+	if err := os.WriteFile(redirectMapFile, []byte("/old,/new\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	redirectMap, err := redirectmap.New(context.Background(), redirectmap.NewFileSource(redirectMapFile), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirectRules, err := redirectrules.ParseRules(`[{"pattern":"/ons/rel/(.*)","to":"/timeseries/$1"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("Given a config with no optional middleware enabled", t, func() {
+		cfg := router.Config{}
+
+		Convey("Then the chain holds only the middleware that is always applied", func() {
+			So(middlewareNames(router.MiddlewareChain(cfg)), ShouldResemble, []string{
+				router.RequestIDMiddleware,
+				router.AccessLogMiddleware,
+				router.SecurityMiddleware,
+				router.HealthcheckMiddleware,
+				router.RedirectsMiddleware,
+			})
+		})
+	})
+
+	Convey("Given a config with optional middleware enabled", t, func() {
+		botLimiter := ratelimit.New(1, 10, 100)
+		cfg := router.Config{
+			ReadinessHandler:           http.NotFoundHandler(),
+			RateLimiter:                throttle.New(map[string]throttle.Limit{"/": {Rate: 10, Burst: 20}}, 100, nil),
+			CORSRules:                  []cors.Rule{{PathPrefix: "/feedback", Origins: []string{"https://census.gov.uk"}}},
+			BodyLimits:                 bodylimit.Limits{Default: 1 << 20},
+			ForwardedProtoCheckEnabled: true,
+			PathTraversalBlockEnabled:  true,
+			RedirectMap:                redirectMap,
+			RedirectRules:              redirectRules,
+			TrailingSlashPolicies:      map[string]trailingslash.Policy{"/": trailingslash.Forbid},
+			RetiredPaths:               []string{"/retired"},
+			RouteTimeouts:              map[string]time.Duration{"/search": 5 * time.Second},
+			Streaming:                  streaming.New(10, nil),
+			PreconnectOrigin:           "https://cdn.ons.gov.uk",
+			PreconnectPaths:            []string{"/"},
+			SecurityHeaderProfiles:     true,
+			CachePolicies:              []cachecontrol.Policy{{PathPrefix: "/", CacheControl: "public, max-age=300"}},
+			CookiePolicy:               securecookies.Policy{Secure: true},
+			Compression:                compression.Options{MinSize: 1024, ContentTypes: []string{"text/html"}},
+			RequestMetrics:             requestmetrics.NewRecorder(metrics.NewRegistry()),
+			IPFilterRules:              ipfilter.Rules{Deny: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}},
+			Maintenance:                maintenance.New(false),
+			BotRules:                   bots.Rules{UserAgents: bots.DefaultUserAgents, Backend: http.NotFoundHandler(), Limiter: botLimiter},
+			GeoRules:                   georouting.Rules{Locator: countryLocator("GB"), CountryHeader: "X-Country-Code"},
+			RedirectUsage:              redirectusage.NewRecorder(metrics.NewRegistry(), nil),
+			BackendSets:                backendset.Selector{Header: "X-Router-Backend", Secret: "s3cret", Sets: []string{"green"}},
+		}
+
+		Convey("Then the redirect stages are applied separately, in order", func() {
+			So(middlewareNames(router.MiddlewareChain(cfg)), ShouldResemble, []string{
+				router.RequestIDMiddleware,
+				router.AccessLogMiddleware,
+				router.SecurityMiddleware,
+				router.HealthcheckMiddleware,
+				router.ReadinessMiddleware,
+				router.MetricsMiddleware,
+				router.CompressionMiddleware,
+				router.IPFilterMiddleware,
+				router.MaintenanceMiddleware,
+				router.BotsMiddleware,
+				router.RateLimitMiddleware,
+				router.CORSMiddleware,
+				router.BodyLimitMiddleware,
+				router.BackendSetMiddleware,
+				router.ForwardedProtoMiddleware,
+				router.PathTraversalMiddleware,
+				router.RedirectMissesMiddleware,
+				router.RedirectsMiddleware,
+				router.RedirectMapMiddleware,
+				router.RedirectRulesMiddleware,
+				router.TrailingSlashMiddleware,
+				router.GoneMiddleware,
+				router.BotBackendMiddleware,
+				router.TimeoutMiddleware,
+				router.StreamingMiddleware,
+				router.PreconnectMiddleware,
+				router.GeoRoutingMiddleware,
+				router.CacheControlMiddleware,
+				router.SecureCookiesMiddleware,
+				router.SecurityHeadersMiddleware,
+			})
+		})
+
+		Convey("When a path in the redirect map is requested through the chain", func() {
+			var h http.Handler = http.NotFoundHandler()
+			chain := router.MiddlewareChain(cfg)
+			for i := len(chain) - 1; i >= 0; i-- {
+				h = chain[i].Constructor(h)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/old", http.NoBody)
+			req.RemoteAddr = "203.0.113.1:1234"
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			Convey("Then the redirect is counted against the redirect map", func() {
+				So(w.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(cfg.RedirectUsage.Value(router.RedirectMapMiddleware, "/old"), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When redirect chains are resolved internally", func() {
+			cfg.RedirectMaxHops = 3
+
+			Convey("Then the redirect stages are replaced by the redirect chain", func() {
+				So(middlewareNames(router.MiddlewareChain(cfg)), ShouldResemble, []string{
+					router.RequestIDMiddleware,
+					router.AccessLogMiddleware,
+					router.SecurityMiddleware,
+					router.HealthcheckMiddleware,
+					router.ReadinessMiddleware,
+					router.MetricsMiddleware,
+					router.CompressionMiddleware,
+					router.IPFilterMiddleware,
+					router.MaintenanceMiddleware,
+					router.BotsMiddleware,
+					router.RateLimitMiddleware,
+					router.CORSMiddleware,
+					router.BodyLimitMiddleware,
+					router.BackendSetMiddleware,
+					router.ForwardedProtoMiddleware,
+					router.PathTraversalMiddleware,
+					router.RedirectMissesMiddleware,
+					router.RedirectChainMiddleware,
+					router.GoneMiddleware,
+					router.BotBackendMiddleware,
+					router.TimeoutMiddleware,
+					router.StreamingMiddleware,
+					router.PreconnectMiddleware,
+					router.GeoRoutingMiddleware,
+					router.CacheControlMiddleware,
+					router.SecureCookiesMiddleware,
+					router.SecurityHeadersMiddleware,
+				})
+			})
+		})
+	})
+}
@@ -2,30 +2,78 @@ package router
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"os"
 	"strings"
+	"time"
 
-	"github.com/ONSdigital/dp-frontend-router/config"
+	"github.com/ONSdigital/dp-frontend-router/handlers/canary"
+	"github.com/ONSdigital/dp-frontend-router/handlers/cdn"
 	"github.com/ONSdigital/dp-frontend-router/handlers/relcal"
+	"github.com/ONSdigital/dp-frontend-router/handlers/shadow"
 	"github.com/ONSdigital/dp-frontend-router/middleware/allRoutes"
+	"github.com/ONSdigital/dp-frontend-router/middleware/backendset"
+	"github.com/ONSdigital/dp-frontend-router/middleware/bodylimit"
+	"github.com/ONSdigital/dp-frontend-router/middleware/bots"
+	"github.com/ONSdigital/dp-frontend-router/middleware/cachecontrol"
+	"github.com/ONSdigital/dp-frontend-router/middleware/compression"
+	"github.com/ONSdigital/dp-frontend-router/middleware/cors"
 	"github.com/ONSdigital/dp-frontend-router/middleware/datasetType"
+	"github.com/ONSdigital/dp-frontend-router/middleware/experiments"
+	"github.com/ONSdigital/dp-frontend-router/middleware/flagusage"
+	"github.com/ONSdigital/dp-frontend-router/middleware/georouting"
+	"github.com/ONSdigital/dp-frontend-router/middleware/internalonly"
+	"github.com/ONSdigital/dp-frontend-router/middleware/ipfilter"
+	"github.com/ONSdigital/dp-frontend-router/middleware/maintenance"
+	"github.com/ONSdigital/dp-frontend-router/middleware/probelog"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectmap"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectrules"
 	"github.com/ONSdigital/dp-frontend-router/middleware/redirects"
-	dprequest "github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/dp-frontend-router/middleware/redirectusage"
+	"github.com/ONSdigital/dp-frontend-router/middleware/requestmetrics"
+	"github.com/ONSdigital/dp-frontend-router/middleware/securecookies"
+	"github.com/ONSdigital/dp-frontend-router/middleware/securityheaders"
+	"github.com/ONSdigital/dp-frontend-router/middleware/streaming"
+	"github.com/ONSdigital/dp-frontend-router/middleware/throttle"
+	"github.com/ONSdigital/dp-frontend-router/middleware/trailingslash"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gorilla/mux"
 	"github.com/justinas/alice"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 const (
 	HTTPHeaderKeyXFrameOptions = "X-Frame-Options"
 )
 
+// dataAggregationPaths are the data aggregation pages served by the search controller
+var dataAggregationPaths = []string{
+	"/alladhocs",
+	"/datalist",
+	"/allmethodologies",
+	"/publishedrequests",
+	"/staticlist",
+	"/publications",
+	"/topicspecificmethodology",
+	"/timeseriestool",
+}
+
 //go:generate moq -out routertest/handler.go -pkg routertest . Handler
 type Handler http.Handler
 
 type Config struct {
 	HealthCheckHandler           func(w http.ResponseWriter, req *http.Request)
+	ReadinessHandler             http.Handler
+	CacheStatsHandler            func(w http.ResponseWriter, req *http.Request)
+	MetricsHandler               http.Handler
+	AdminGuard                   internalonly.Guard
+	SLOMiddleware                func(http.Handler) http.Handler
+	RequestMetrics               *requestmetrics.Recorder
+	IPFilterRules                ipfilter.Rules
+	BotRules                     bots.Rules
+	Maintenance                  *maintenance.Mode
+	MaintenanceBody              string
+	MaintenanceRetryAfter        time.Duration
 	AnalyticsHandler             http.Handler
 	AreaProfileEnabled           bool
 	AreaProfileHandler           http.Handler
@@ -41,7 +89,11 @@ type Config struct {
 	FeedbackHandler              http.Handler
 	ContentTypeByteLimit         int
 	ZebedeeClient                allRoutes.ZebedeeClient
+	PageTypeCache                allRoutes.PageTypeCache
+	PageTypeCacheBypassCookies   []string
 	LegacySearchRedirectsEnabled bool
+	LegacySearchRedirectStatus   int
+	LegacySearchQueryParams      map[string]string
 	DataAggregationPagesEnabled  bool
 	SearchRoutesEnabled          bool
 	SiteDomain                   string
@@ -55,88 +107,221 @@ type Config struct {
 	CensusAtlasHandler           http.Handler
 	CensusAtlasEnabled           bool
 	DatasetFinderEnabled         bool
+	RetiredPaths                 []string
+	RetiredPathsBody             string
+	Streaming                    *streaming.Limiter
+	URIValidationEnabled         bool
+	SecurityHeaders              securityheaders.Headers
+	CachePolicies                []cachecontrol.Policy
+	CookiePolicy                 securecookies.Policy
+	SecurityHeaderProfiles       bool
+	ContentSecurityPolicy        string
+	CSPOverrides                 map[string]string
+	CSPReportOnly                bool
+	TrailingSlashPolicies        map[string]trailingslash.Policy
+	PathTraversalBlockEnabled    bool
+	RoutingTableLogEnabled       bool
+	RoutingTableFile             string
+	AccessLogSampleRate          float64
+	ProbeLogMode                 probelog.Mode
+	ProbeLogPaths                []string
+	Experiments                  []experiments.Experiment
+	ExperimentIDCookie           string
+	GeoRules                     georouting.Rules
+	PreconnectOrigin             string
+	PreconnectPaths              []string
+	RedirectMaxHops              int
+	RedirectMap                  *redirectmap.Map
+	RedirectRules                []redirectrules.Rule
+	CensusAtlasEmptyURIRedirect  bool
+	FeatureFlagUsage             *flagusage.Recorder
+	RedirectUsage                *redirectusage.Recorder
+	CDNAssetBaseURL              string
+	CDNAssetPrefixes             []string
+	CDNAssetRedirectStatus       int
+	ForwardedProtoCheckEnabled   bool
+	ForwardedProtoReject         bool
+	APIDescriptionPath           string
+	APIDescriptionVersion        string
+	RouteTable                   *RouteTable
+	Backends                     map[string]http.Handler
+	Canaries                     []canary.Canary
+	Shadows                      []shadow.Shadow
+	ShadowTimeout                time.Duration
+	RouteTimeouts                map[string]time.Duration
+	RouteTimeoutBody             string
+	RateLimiter                  *throttle.Limiter
+	CORSRules                    []cors.Rule
+	BodyLimits                   bodylimit.Limits
+	BackendSets                  backendset.Selector
+	Compression                  compression.Options
+}
+
+// Validate returns an error if the config enables a route without providing the handler for it
+func (cfg Config) Validate() error {
+	if cfg.CensusAtlasEnabled && cfg.CensusAtlasHandler == nil {
+		return errors.New("census atlas routes are enabled but no census atlas handler is provided")
+	}
+	if cfg.RouteTable != nil {
+		return cfg.RouteTable.Validate(cfg.Backends)
+	}
+	return nil
 }
 
-func New(cfg Config) http.Handler {
+// New creates the router for cfg, wrapped in the middleware chain
+func New(cfg Config) *Router {
+	cfg = guardAdminHandlers(cfg)
 	router := mux.NewRouter()
-	middleware := []alice.Constructor{
-		dprequest.HandlerRequestID(16),
-		log.Middleware,
-		SecurityHandler,
-		healthcheckHandler(cfg.HealthCheckHandler),
-		redirects.Handler,
+	chain := MiddlewareChain(cfg)
+	middleware := make([]alice.Constructor, 0, len(chain))
+	for _, m := range chain {
+		middleware = append(middleware, m.Constructor)
 	}
 
-	appConfig, err := config.Get()
-	if err != nil {
-		log.Error(context.Background(), "error getting config", err)
+	newAlice := alice.New(middleware...).Then(router)
+
+	// mux middleware runs once the route is matched, so the SLO counters can be labelled by route
+	if cfg.SLOMiddleware != nil {
+		router.Use(cfg.SLOMiddleware)
 	}
 
-	if appConfig.OtelEnabled {
-		middleware = append(middleware, otelhttp.NewMiddleware("dp-frontend-router"))
+	// request metrics are labelled by the route matched, which is only known once mux has matched it
+	if cfg.RequestMetrics != nil {
+		router.Use(requestmetrics.RouteHandler)
 	}
 
-	newAlice := alice.New(middleware...).Then(router)
+	// feature-flag-gated routes are registered through the usage recorder, so flag lifecycle can be decided on usage
+	r := &routes{
+		router:        router,
+		flagged:       cfg.FeatureFlagUsage,
+		canaries:      cfg.Canaries,
+		idCookie:      cfg.ExperimentIDCookie,
+		proxies:       cfg.IPFilterRules.TrustedProxies,
+		shadows:       cfg.Shadows,
+		shadowTimeout: cfg.ShadowTimeout,
+	}
+	addRoutes(r, cfg)
+	addFallbackRoutes(r, cfg)
+	r.warnUnusedCanaries()
+	r.warnUnusedShadows()
+
+	if cfg.RoutingTableLogEnabled || cfg.RoutingTableFile != "" {
+		exposeRoutingTable(router, cfg.RoutingTableLogEnabled, cfg.RoutingTableFile)
+	}
+
+	return &Router{Handler: newAlice, routes: r.info}
+}
 
-	router.Handle("/", cfg.HomepageHandler)
+// guardAdminHandlers returns cfg with its admin handlers, the cache stats and metrics, restricted to internal clients by
+// its admin guard. If the guard is not enabled they are removed, so that they are never served publicly to every client.
+func guardAdminHandlers(cfg Config) Config {
+	if cfg.CacheStatsHandler == nil && cfg.MetricsHandler == nil {
+		return cfg
+	}
+	if !cfg.AdminGuard.Enabled() {
+		log.Warn(context.Background(), "admin endpoints are not served publicly, as no admin allowed ranges or secret are configured",
+			log.Data{"cache_stats": cfg.CacheStatsHandler != nil, "metrics": cfg.MetricsHandler != nil})
+		cfg.CacheStatsHandler = nil
+		cfg.MetricsHandler = nil
+		return cfg
+	}
 
-	if cfg.CensusAtlasEnabled {
-		router.Handle("/census/maps{uri:.*}", cfg.CensusAtlasHandler)
+	if cfg.CacheStatsHandler != nil {
+		cfg.CacheStatsHandler = cfg.AdminGuard.Handler(http.HandlerFunc(cfg.CacheStatsHandler)).ServeHTTP
 	}
+	if cfg.MetricsHandler != nil {
+		cfg.MetricsHandler = cfg.AdminGuard.Handler(cfg.MetricsHandler)
+	}
+	return cfg
+}
 
-	router.Handle("/census", cfg.HomepageHandler)
+// addRoutes registers the routes that are matched on path
+func addRoutes(r *routes, cfg Config) {
+	r.handle("/", "homepage", cfg.HomepageHandler)
 
-	if cfg.DatasetFinderEnabled {
-		router.Handle("/census/find-a-dataset", cfg.SearchHandler)
+	if cfg.CacheStatsHandler != nil {
+		r.handle("/status", "cache stats", http.HandlerFunc(cfg.CacheStatsHandler))
 	}
 
-	router.Handle("/redir/{data:.*}", cfg.AnalyticsHandler)
-	router.Handle("/download/{uri:.*}", cfg.DownloadHandler)
-	router.Handle("/cookies{uri:.*}", cfg.CookieHandler)
-	router.Handle("/datasets/{uri:.*}", cfg.DatasetHandler)
-	router.Handle("/filters/{uri:.*}", datasetType.Handler(cfg.FilterClient, cfg.DatasetClient)(cfg.FilterHandler, cfg.FilterFlexHandler))
-	router.Handle("/filter-outputs/{uri:.*}", cfg.FilterHandler)
-	router.Handle("/feedback{uri:.*}", cfg.FeedbackHandler)
+	if cfg.MetricsHandler != nil {
+		r.handle("/metrics", "metrics", cfg.MetricsHandler)
+	}
 
-	if cfg.LegacySearchRedirectsEnabled {
-		searchDataHandler := redirects.DynamicRedirectHandler("/searchdata", "/search")
-		searchPublicationHandler := redirects.DynamicRedirectHandler("/searchpublication", "/search")
+	if cfg.APIDescriptionPath != "" {
+		r.handle(cfg.APIDescriptionPath, "api description", descriptionHandler(cfg))
+	}
 
-		router.Handle("/searchdata", searchDataHandler)
-		router.Handle("/searchpublication", searchPublicationHandler)
+	// routes from the route table take precedence, so that ops can change the backend of a built in route
+	if cfg.RouteTable != nil {
+		cfg.RouteTable.register(r, cfg.Backends)
 	}
 
-	if cfg.SearchRoutesEnabled {
-		// needs both the SearchRoutesEnabled and DataAggregationPagesEnabled since it relies on the SearchHandler
-		if cfg.DataAggregationPagesEnabled {
-			router.Handle("/alladhocs", cfg.SearchHandler)
-			router.Handle("/datalist", cfg.SearchHandler)
-			router.Handle("/allmethodologies", cfg.SearchHandler)
-			router.Handle("/publishedrequests", cfg.SearchHandler)
-			router.Handle("/staticlist", cfg.SearchHandler)
-			router.Handle("/publications", cfg.SearchHandler)
-			router.Handle("/topicspecificmethodology", cfg.SearchHandler)
-			router.Handle("/timeseriestool", cfg.SearchHandler)
-		}
-		router.Handle("/search", cfg.SearchHandler)
+	if cfg.CensusAtlasEnabled && cfg.CensusAtlasEmptyURIRedirect {
+		r.handle("/census/maps", "redirect", http.RedirectHandler("/census/maps/", http.StatusMovedPermanently))
 	}
+	// the uri must be empty or a sub path, so that paths like /census/mapsfoo are not sent to the atlas
+	r.handleFlagged("census_atlas", cfg.CensusAtlasEnabled, "/census/maps{uri:(?:/.*)?}", "censusAtlas", cfg.CensusAtlasHandler)
 
-	if cfg.RelCalEnabled {
-		if cfg.UseNewReleaseCalendar {
-			router.Handle(cfg.RelCalRoutePrefix+"/releasecalendar", relcal.Handler(cfg.RelCalHandler))
-			router.Handle(cfg.RelCalRoutePrefix+"/releases/{uri:.*}", relcal.Handler(cfg.RelCalHandler))
-		} else {
-			router.Handle(cfg.RelCalRoutePrefix+"/releasecalendar", relcal.Handler(cfg.BabbageHandler))
-			router.Handle(cfg.RelCalRoutePrefix+"/releases/{uri:.*}", relcal.Handler(cfg.BabbageHandler))
-		}
-		router.Handle(cfg.RelCalRoutePrefix+"/calendar/releasecalendar", cfg.RelCalHandler)
+	r.handle("/census", "homepage", cfg.HomepageHandler)
+
+	r.handleFlagged("dataset_finder", cfg.DatasetFinderEnabled, "/census/find-a-dataset", "search", cfg.SearchHandler)
+
+	r.handle("/redir/{data:.*}", "analytics", cfg.AnalyticsHandler)
+	r.handle("/download/{uri:.*}", "download", cfg.DownloadHandler)
+	r.handle("/cookies{uri:.*}", "cookies", cfg.CookieHandler)
+	datasetHandler := cfg.DatasetHandler
+	filterHandler := datasetType.Handler(cfg.FilterClient, cfg.DatasetClient)(cfg.FilterHandler, cfg.FilterFlexHandler)
+	filterOutputsHandler := cfg.FilterHandler
+	if cfg.URIValidationEnabled {
+		datasetHandler = datasetType.ValidateURI(datasetHandler)
+		filterHandler = datasetType.ValidateURI(filterHandler)
+		filterOutputsHandler = datasetType.ValidateURI(filterOutputsHandler)
+	}
+	r.handle("/datasets/{uri:.*}", "datasets", datasetHandler)
+	r.handle("/filters/{uri:.*}", "filters or flex, by dataset type", filterHandler)
+	r.handle("/filter-outputs/{uri:.*}", "filters", filterOutputsHandler)
+	r.handle("/feedback{uri:.*}", "feedback", cfg.FeedbackHandler)
+
+	legacySearchRedirectStatus := cfg.LegacySearchRedirectStatus
+	if legacySearchRedirectStatus == 0 {
+		legacySearchRedirectStatus = http.StatusMovedPermanently
+	}
+	for _, path := range []string{"/searchdata", "/searchpublication"} {
+		redirect := redirects.DynamicRedirectHandler(path, "/search", legacySearchRedirectStatus, cfg.LegacySearchQueryParams)
+		redirect = cfg.RedirectUsage.Redirect("legacy-search", path, redirect)
+		r.handleFlagged("legacy_search_redirects", cfg.LegacySearchRedirectsEnabled, path, "redirect", redirect)
+	}
+
+	// needs both the SearchRoutesEnabled and DataAggregationPagesEnabled since it relies on the SearchHandler
+	dataAggregation := cfg.SearchRoutesEnabled && cfg.DataAggregationPagesEnabled
+	for _, path := range dataAggregationPaths {
+		r.handleFlagged("data_aggregation_pages", dataAggregation, path, "search", cfg.SearchHandler)
+	}
+	r.handleFlagged("search_routes", cfg.SearchRoutesEnabled, "/search", "search", cfg.SearchHandler)
+
+	relCalHandler, relCalBackend := cfg.BabbageHandler, "babbage"
+	if cfg.UseNewReleaseCalendar {
+		relCalHandler, relCalBackend = cfg.RelCalHandler, "relcal"
+	}
+	prefix := cfg.RelCalRoutePrefix
+	r.handleFlagged("release_calendar", cfg.RelCalEnabled, prefix+"/releasecalendar", relCalBackend, relcal.Handler(relCalHandler))
+	r.handleFlagged("release_calendar", cfg.RelCalEnabled, prefix+"/releases/{uri:.*}", relCalBackend, relcal.Handler(relCalHandler))
+	r.handleFlagged("release_calendar", cfg.RelCalEnabled, prefix+"/calendar/releasecalendar", "relcal", cfg.RelCalHandler)
+}
+
+// addFallbackRoutes registers the routes for requests that no path has matched, ending with the page type lookup
+func addFallbackRoutes(r *routes, cfg Config) {
+	// redirect known static assets to the CDN before the file extension matcher would proxy them through babbage
+	if cfg.CDNAssetBaseURL != "" && len(cfg.CDNAssetPrefixes) > 0 {
+		cdnRedirect := cdn.Redirect(cfg.CDNAssetBaseURL, cfg.CDNAssetRedirectStatus)
+		r.match(cdnAssetRouteName, hasPathPrefixMatcher(cfg.CDNAssetPrefixes), "cdn redirect", cdnRedirect)
 	}
 
 	// if the request is for a file go directly to babbage instead of using the allRoutesMiddleware
-	router.MatcherFunc(hasFileExtMatcher).Handler(cfg.BabbageHandler)
+	r.match(fileExtRouteName, hasFileExtMatcher, "babbage", cfg.BabbageHandler)
 
 	// If it is a known babbage endpoint go directly to babbage instead of using the allRoutesMiddleware
-	router.MatcherFunc(isKnownBabbageEndpointMatcher).Handler(cfg.BabbageHandler)
+	r.match(knownBabbageEndpointRouteName, isKnownBabbageEndpointMatcher, "babbage", cfg.BabbageHandler)
 
 	// all other requests go through the allRoutesMiddleware to check the page type first
 	handlers := map[string]http.Handler{
@@ -145,13 +330,33 @@ func New(cfg Config) http.Handler {
 	if cfg.NewDatasetRoutingEnabled {
 		handlers["dataset"] = cfg.PrefixDatasetHandler
 	}
-	allRoutesMiddleware := allRoutes.Handler(handlers, cfg.ZebedeeClient, cfg.ContentTypeByteLimit)
+	allRoutesMiddleware := allRoutes.Handler(handlers, cfg.ZebedeeClient, cfg.ContentTypeByteLimit, cfg.PageTypeCache,
+		cfg.PageTypeCacheBypassCookies)
 
-	babbageRouter := router.PathPrefix("/").Subrouter()
+	babbageRouter := r.router.PathPrefix("/").Subrouter()
 	babbageRouter.Use(allRoutesMiddleware)
 	babbageRouter.PathPrefix("/").Handler(cfg.BabbageHandler)
+	r.info = append(r.info, RouteInfo{Path: "/", Backend: "datasets or babbage, by zebedee page type", Enabled: true})
+}
 
-	return newAlice
+// exposeRoutingTable logs the routing table and/or writes it to file, so that the precedence of routes can be checked
+func exposeRoutingTable(router *mux.Router, logEnabled bool, file string) {
+	ctx := context.Background()
+	table, err := RoutingTable(router)
+	if err != nil {
+		log.Error(ctx, "error building routing table", err)
+		return
+	}
+
+	if logEnabled {
+		log.Info(ctx, "routing table in precedence order", log.Data{"routes": table})
+	}
+
+	if file != "" {
+		if err := os.WriteFile(file, []byte(strings.Join(table, "\n")+"\n"), 0o600); err != nil {
+			log.Error(ctx, "error writing routing table to file", err, log.Data{"file": file})
+		}
+	}
 }
 
 // SecurityHandler is the custom handler for for setting frame options
@@ -166,13 +371,50 @@ func SecurityHandler(h http.Handler) http.Handler {
 	})
 }
 
-// healthcheckHandler uses the provided handler for /health endpoint, and serves any other traffic to the next handler in chain
+// securityHandler sets the frame options as SecurityHandler does, and the configured security headers on every response
+func securityHandler(headers securityheaders.Headers) func(h http.Handler) http.Handler {
+	profile := headers.Profile()
+	if len(profile) == 0 {
+		return SecurityHandler
+	}
+	always := securityheaders.Always(profile)
+	return func(h http.Handler) http.Handler {
+		return SecurityHandler(always(h))
+	}
+}
+
+// healthcheckHandler uses the provided handler for /health endpoint, answers /health/live itself, and serves any other
+// traffic to the next handler in chain
 func healthcheckHandler(hc func(w http.ResponseWriter, req *http.Request)) func(h http.Handler) http.Handler {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			if req.URL.Path == "/health" {
+			switch req.URL.Path {
+			case "/health":
 				hc(w, req)
 				return
+			case "/health/live":
+				live(w, req)
+				return
+			}
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
+// live reports that the router is up. Unlike /health, it does not depend on the router's dependencies, so that the router
+// is not restarted when a backend is unavailable.
+func live(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// readinessHandler uses the provided handler for /health/ready endpoint, and serves any other traffic to the next handler in chain
+func readinessHandler(ready http.Handler) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/health/ready" {
+				ready.ServeHTTP(w, req)
+				return
 			}
 			h.ServeHTTP(w, req)
 		})
@@ -3,17 +3,28 @@ package router_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
 	"github.com/ONSdigital/dp-api-clients-go/v2/filter"
+	"github.com/ONSdigital/dp-frontend-router/handlers/canary"
+	"github.com/ONSdigital/dp-frontend-router/handlers/shadow"
+	"github.com/ONSdigital/dp-frontend-router/metrics"
 	"github.com/ONSdigital/dp-frontend-router/middleware/allRoutes"
 	"github.com/ONSdigital/dp-frontend-router/middleware/allRoutes/allroutestest"
+	"github.com/ONSdigital/dp-frontend-router/middleware/bots"
 	"github.com/ONSdigital/dp-frontend-router/middleware/datasetType/mocks"
+	"github.com/ONSdigital/dp-frontend-router/middleware/experiments"
+	"github.com/ONSdigital/dp-frontend-router/middleware/flagusage"
+	"github.com/ONSdigital/dp-frontend-router/middleware/internalonly"
+	"github.com/ONSdigital/dp-frontend-router/middleware/trailingslash"
 	"github.com/ONSdigital/dp-frontend-router/router"
 	"github.com/ONSdigital/dp-frontend-router/router/routertest"
+	"github.com/gorilla/mux"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -672,5 +683,552 @@ func TestRouter(t *testing.T) {
 				So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 0)
 			})
 		})
+
+		Convey("When a request is made for a retired path", func() {
+			url := "/economy/retiredpage"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			config.RetiredPaths = []string{url}
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then 410 Gone is returned", func() {
+				So(res.Code, ShouldEqual, http.StatusGone)
+			})
+			Convey("Then no requests are sent to Zebedee", func() {
+				So(len(zebedeeClient.GetWithHeadersCalls()), ShouldEqual, 0)
+			})
+			Convey("Then no request is sent to Babbage", func() {
+				So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a crawler requests a retired path, and crawlers are served by a crawler backend", func() {
+			url := "/economy/retiredpage"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			req.Header.Set("User-Agent", "Googlebot/2.1")
+			res := httptest.NewRecorder()
+
+			var crawlerBackend bool
+			config.RetiredPaths = []string{url}
+			config.BotRules = bots.Rules{
+				UserAgents: bots.DefaultUserAgents,
+				Backend:    http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { crawlerBackend = true }),
+			}
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then 410 Gone is returned", func() {
+				So(res.Code, ShouldEqual, http.StatusGone)
+			})
+			Convey("Then the request is not sent to the crawler backend", func() {
+				So(crawlerBackend, ShouldBeFalse)
+			})
+		})
+
+		Convey("When a filter request is made with control characters in the uri, and uri validation is enabled", func() {
+			url := "/filters/123%00"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			config.URIValidationEnabled = true
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then 400 is returned", func() {
+				So(res.Code, ShouldEqual, http.StatusBadRequest)
+			})
+			Convey("Then no requests are sent to the filter API or filter handler", func() {
+				So(len(filterClient.GetJobStateCalls()), ShouldEqual, 0)
+				So(len(filterHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When filter requests are made with a traversal or repeated slashes in the uri, and uri validation is enabled", func() {
+			config.URIValidationEnabled = true
+			r := router.New(config)
+			traversal := httptest.NewRecorder()
+			r.ServeHTTP(traversal, httptest.NewRequest("GET", "/filters/%2e%2e/%2e%2e/admin", http.NoBody))
+			slashes := httptest.NewRecorder()
+			r.ServeHTTP(slashes, httptest.NewRequest("GET", "/filters/abc-123//dimensions", http.NoBody))
+
+			Convey("Then they are redirected to the cleaned path by mux, before the uri is validated", func() {
+				So(traversal.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(traversal.Header().Get("Location"), ShouldEqual, "/admin")
+				So(slashes.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(slashes.Header().Get("Location"), ShouldEqual, "/filters/abc-123/dimensions")
+			})
+			Convey("Then no requests are sent to the filter API or filter handler", func() {
+				So(len(filterClient.GetJobStateCalls()), ShouldEqual, 0)
+				So(len(filterHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a status request is made by an internal client, and the cache stats handler is configured", func() {
+			url := "/status"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			req.Header.Set("X-Admin-Secret", "s3cret")
+			res := httptest.NewRecorder()
+
+			var cacheStatsCalled bool
+			config.CacheStatsHandler = func(w http.ResponseWriter, req *http.Request) {
+				cacheStatsCalled = true
+			}
+			config.AdminGuard = internalonly.Guard{SecretHeader: "X-Admin-Secret", Secret: "s3cret"}
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then the request is sent to the cache stats handler", func() {
+				So(cacheStatsCalled, ShouldBeTrue)
+			})
+
+			Convey("Then no requests are sent to Zebedee", func() {
+				So(len(zebedeeClient.GetWithHeadersCalls()), ShouldEqual, 0)
+			})
+			Convey("Then no request is sent to Babbage", func() {
+				So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a request is made for a path under a prefix that requires a trailing slash", func() {
+			url := "/economy/inflationandpriceindices"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			config.TrailingSlashPolicies = map[string]trailingslash.Policy{"/economy/": trailingslash.Require}
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then the request is redirected to the canonical path", func() {
+				So(res.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(res.Header().Get("Location"), ShouldEqual, "/economy/inflationandpriceindices/")
+			})
+			Convey("Then no request is sent to Babbage", func() {
+				So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a request is made with an encoded path traversal, and path traversal blocking is enabled", func() {
+			url := "/datasets/%2e%2e/%2e%2e/secret"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			config.PathTraversalBlockEnabled = true
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then 400 is returned", func() {
+				So(res.Code, ShouldEqual, http.StatusBadRequest)
+			})
+			Convey("Then no request is sent to the dataset handler or Babbage", func() {
+				So(len(datasetHandler.ServeHTTPCalls()), ShouldEqual, 0)
+				So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a readiness request is made, and the readiness handler is configured", func() {
+			url := "/health/ready"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			readinessHandler := NewHandlerMock()
+			config.ReadinessHandler = readinessHandler
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then the request is sent to the readiness handler", func() {
+				So(len(readinessHandler.ServeHTTPCalls()), ShouldEqual, 1)
+			})
+			Convey("Then no requests are sent to Zebedee or Babbage", func() {
+				So(len(zebedeeClient.GetWithHeadersCalls()), ShouldEqual, 0)
+				So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a liveness request is made", func() {
+			url := "/health/live"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then 200 is returned without checking the router's dependencies", func() {
+				So(res.Code, ShouldEqual, http.StatusOK)
+			})
+			Convey("Then no requests are sent to Zebedee or Babbage", func() {
+				So(len(zebedeeClient.GetWithHeadersCalls()), ShouldEqual, 0)
+				So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a request is made for a path in an experiment, from a visitor in a variant bucket", func() {
+			url := "/search"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			req.AddCookie(&http.Cookie{Name: "exp_search", Value: "new"})
+			res := httptest.NewRecorder()
+
+			variantHandler := NewHandlerMock()
+			config.SearchRoutesEnabled = true
+			config.Experiments = []experiments.Experiment{{
+				Name:         "search",
+				Cookie:       "exp_search",
+				PathPrefixes: []string{"/search"},
+				Variants:     map[string]http.Handler{"control": nil, "new": variantHandler},
+			}}
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then the request is sent to the variant handler", func() {
+				So(len(variantHandler.ServeHTTPCalls()), ShouldEqual, 1)
+				So(len(searchHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a request is made for a page configured to preconnect to the download origin", func() {
+			url := "/datasets/cpih01"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			config.PreconnectOrigin = "https://download.ons.gov.uk"
+			config.PreconnectPaths = []string{"/datasets/"}
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then the preconnect header is set", func() {
+				So(res.Header().Get("Link"), ShouldEqual, "<https://download.ons.gov.uk>; rel=\"preconnect\"")
+			})
+			Convey("Then the request is sent to the dataset handler", func() {
+				So(len(datasetHandler.ServeHTTPCalls()), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When a request is redirected internally more times than the maximum hops", func() {
+			url := "/economy"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			// conflicting policies redirect back and forth between the forms of the path
+			config.TrailingSlashPolicies = map[string]trailingslash.Policy{"/economy": trailingslash.Require, "/economy/": trailingslash.Forbid}
+			config.RedirectMaxHops = 3
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then 500 is returned", func() {
+				So(res.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+			Convey("Then no request is sent to Babbage", func() {
+				So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a request is made, and SLO middleware and the metrics handler are configured", func() {
+			url := "/search"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			var sloRoutes []string
+			config.SearchRoutesEnabled = true
+			config.SLOMiddleware = func(h http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					tpl, _ := mux.CurrentRoute(req).GetPathTemplate()
+					sloRoutes = append(sloRoutes, tpl)
+					h.ServeHTTP(w, req)
+				})
+			}
+			metricsHandler := NewHandlerMock()
+			config.MetricsHandler = metricsHandler
+			config.AdminGuard = internalonly.Guard{SecretHeader: "X-Admin-Secret", Secret: "s3cret"}
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+			metricsReq := httptest.NewRequest("GET", "/metrics", http.NoBody)
+			metricsReq.Header.Set("X-Admin-Secret", "s3cret")
+			r.ServeHTTP(httptest.NewRecorder(), metricsReq)
+
+			Convey("Then the SLO middleware sees the matched route", func() {
+				So(sloRoutes, ShouldResemble, []string{"/search", "/metrics"})
+				So(len(searchHandler.ServeHTTPCalls()), ShouldEqual, 1)
+			})
+			Convey("Then metrics requests are sent to the metrics handler", func() {
+				So(len(metricsHandler.ServeHTTPCalls()), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When a request is made for a path that only starts with the census atlas prefix", func() {
+			url := "/census/mapsfoo"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			config.CensusAtlasEnabled = true
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then no request is sent to the census atlas handler", func() {
+				So(len(censusAtlasHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a census atlas request is made with an empty uri, and empty uris are redirected", func() {
+			url := "/census/maps"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			config.CensusAtlasEnabled = true
+			config.CensusAtlasEmptyURIRedirect = true
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then the request is redirected to the census atlas root", func() {
+				So(res.Code, ShouldEqual, http.StatusMovedPermanently)
+				So(res.Header().Get("Location"), ShouldEqual, "/census/maps/")
+			})
+			Convey("Then no request is sent to the census atlas handler", func() {
+				So(len(censusAtlasHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the census atlas root is requested, and empty uris are redirected", func() {
+			url := "/census/maps/"
+			req := httptest.NewRequest("GET", url, http.NoBody)
+			res := httptest.NewRecorder()
+
+			config.CensusAtlasEnabled = true
+			config.CensusAtlasEmptyURIRedirect = true
+			r := router.New(config)
+			r.ServeHTTP(res, req)
+
+			Convey("Then the request is sent to the census atlas handler", func() {
+				So(len(censusAtlasHandler.ServeHTTPCalls()), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When feature flag usage is recorded", func() {
+			config.FeatureFlagUsage = flagusage.NewRecorder(metrics.NewRegistry())
+
+			Convey("And a request is made for an enabled flag-gated route", func() {
+				config.SearchRoutesEnabled = true
+				r := router.New(config)
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/search", http.NoBody))
+
+				Convey("Then the route is counted as served", func() {
+					So(config.FeatureFlagUsage.Value("search_routes", "/search", flagusage.Served), ShouldEqual, 1)
+					So(config.FeatureFlagUsage.Value("search_routes", "/search", flagusage.Bypassed), ShouldEqual, 0)
+					So(len(searchHandler.ServeHTTPCalls()), ShouldEqual, 1)
+				})
+			})
+
+			Convey("And a request is made for a disabled flag-gated route", func() {
+				config.SearchRoutesEnabled = false
+				r := router.New(config)
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/search", http.NoBody))
+
+				Convey("Then the route is not counted as served, but as bypassed", func() {
+					So(config.FeatureFlagUsage.Value("search_routes", "/search", flagusage.Served), ShouldEqual, 0)
+					So(config.FeatureFlagUsage.Value("search_routes", "/search", flagusage.Bypassed), ShouldEqual, 1)
+				})
+				Convey("Then the request still falls through to Babbage", func() {
+					So(len(searchHandler.ServeHTTPCalls()), ShouldEqual, 0)
+					So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 1)
+				})
+			})
+
+			Convey("And a request is made for a route that is not flag-gated", func() {
+				r := router.New(config)
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/redir/123", http.NoBody))
+
+				Convey("Then no flag-gated route is counted", func() {
+					So(config.FeatureFlagUsage.Value("search_routes", "/search", flagusage.Served), ShouldEqual, 0)
+					So(config.FeatureFlagUsage.Value("search_routes", "/search", flagusage.Bypassed), ShouldEqual, 0)
+				})
+			})
+		})
+
+		Convey("When static assets are redirected to the CDN", func() {
+			config.CDNAssetBaseURL = "https://cdn.ons.gov.uk"
+			config.CDNAssetPrefixes = []string{"/img/", "/fonts/"}
+			config.CDNAssetRedirectStatus = http.StatusFound
+			r := router.New(config)
+
+			Convey("And a configured asset path is requested", func() {
+				res := httptest.NewRecorder()
+				r.ServeHTTP(res, httptest.NewRequest("GET", "/img/logo.svg", http.NoBody))
+
+				Convey("Then the request is redirected to the CDN before the file extension matcher sends it to Babbage", func() {
+					So(res.Code, ShouldEqual, http.StatusFound)
+					So(res.Header().Get("Location"), ShouldEqual, "https://cdn.ons.gov.uk/img/logo.svg")
+					So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 0)
+				})
+			})
+
+			Convey("And a file outside the configured asset paths is requested", func() {
+				res := httptest.NewRecorder()
+				r.ServeHTTP(res, httptest.NewRequest("GET", "/file.xlsx", http.NoBody))
+
+				Convey("Then the request is proxied to Babbage", func() {
+					So(res.Header().Get("Location"), ShouldBeEmpty)
+					So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 1)
+				})
+			})
+		})
+		Convey("When a route table is configured", func() {
+			config.Backends = map[string]http.Handler{"search": searchHandler}
+			config.RouteTable = &router.RouteTable{
+				FeatureFlags: map[string]bool{"new_topic_pages": false},
+				Routes: []router.TableRoute{
+					{Path: "/datasets/{uri:.*}", Backend: "search"},
+					{Path: "/topics/{uri:.*}", Backend: "search", FeatureFlag: "new_topic_pages"},
+				},
+			}
+			r := router.New(config)
+
+			Convey("And a request is made for a built in route that the table overrides", func() {
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/datasets/cpih", http.NoBody))
+
+				Convey("Then the request is sent to the backend from the table", func() {
+					So(len(searchHandler.ServeHTTPCalls()), ShouldEqual, 1)
+					So(len(datasetHandler.ServeHTTPCalls()), ShouldEqual, 0)
+				})
+			})
+
+			Convey("And a request is made for a table route whose feature flag is disabled", func() {
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/topics/economy", http.NoBody))
+
+				Convey("Then the request falls through to Babbage", func() {
+					So(len(searchHandler.ServeHTTPCalls()), ShouldEqual, 0)
+					So(len(babbageHandler.ServeHTTPCalls()), ShouldEqual, 1)
+				})
+			})
+		})
+		Convey("When a route has a canary for every visitor", func() {
+			canaryHandler := NewHandlerMock()
+			config.Canaries = []canary.Canary{{Name: "new-datasets", Path: "/datasets/{uri:.*}", Handler: canaryHandler, Percent: 100}}
+			r := router.New(config)
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/datasets/cpih", http.NoBody))
+
+			Convey("Then the request is sent to the canary instead of the route's handler", func() {
+				So(len(canaryHandler.ServeHTTPCalls()), ShouldEqual, 1)
+				So(len(datasetHandler.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+
+			Convey("Then the route is listed with its canary", func() {
+				So(r.Routes(), ShouldContain, router.RouteInfo{
+					Path: "/datasets/{uri:.*}", Backend: "datasets", Enabled: true, Canary: "new-datasets", CanaryPercent: 100,
+				})
+			})
+		})
+		Convey("When a route has a shadow for every request", func() {
+			mirrored := make(chan *http.Request, 1)
+			shadowHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { mirrored <- req })
+			config.Shadows = []shadow.Shadow{{Name: "new-datasets", Path: "/datasets/{uri:.*}", Handler: shadowHandler, Percent: 100}}
+			config.ShadowTimeout = time.Second
+			r := router.New(config)
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/datasets/cpih", http.NoBody))
+
+			Convey("Then the request is served by the route's handler and mirrored to the shadow", func() {
+				So(len(datasetHandler.ServeHTTPCalls()), ShouldEqual, 1)
+				select {
+				case req := <-mirrored:
+					So(req.URL.Path, ShouldEqual, "/datasets/cpih")
+				case <-time.After(time.Second):
+					t.Error("request was not mirrored to the shadow")
+				}
+			})
+
+			Convey("Then the route is listed with its shadow", func() {
+				So(r.Routes(), ShouldContain, router.RouteInfo{
+					Path: "/datasets/{uri:.*}", Backend: "datasets", Enabled: true, Shadow: "new-datasets", ShadowPercent: 100,
+				})
+			})
+		})
+	})
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	Convey("Given a router with the cache stats and metrics handlers", t, func() {
+		cacheStats, metricsHandler, babbage := NewHandlerMock(), NewHandlerMock(), NewHandlerMock()
+		zebedeeClient := &allroutestest.ZebedeeClientMock{
+			GetWithHeadersFunc: func(ctx context.Context, userAccessToken, path string) ([]byte, http.Header, error) {
+				return nil, nil, errors.New("not found")
+			},
+		}
+		cfg := router.Config{
+			CacheStatsHandler: cacheStats.ServeHTTP,
+			MetricsHandler:    metricsHandler,
+			BabbageHandler:    babbage,
+			ZebedeeClient:     zebedeeClient,
+		}
+		serve := func(path, secret string) int {
+			req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+			if secret != "" {
+				req.Header.Set("X-Admin-Secret", secret)
+			}
+			res := httptest.NewRecorder()
+			router.New(cfg).ServeHTTP(res, req)
+			return res.Code
+		}
+
+		Convey("When no admin guard is configured", func() {
+			serve("/status", "")
+			serve("/metrics", "")
+
+			Convey("Then they are not served publicly", func() {
+				So(cacheStats.ServeHTTPCalls(), ShouldBeEmpty)
+				So(metricsHandler.ServeHTTPCalls(), ShouldBeEmpty)
+				So(babbage.ServeHTTPCalls(), ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When an admin guard is configured", func() {
+			cfg.AdminGuard = internalonly.Guard{SecretHeader: "X-Admin-Secret", Secret: "s3cret"}
+
+			Convey("Then clients that are not internal get 403 Forbidden", func() {
+				So(serve("/status", ""), ShouldEqual, http.StatusForbidden)
+				So(serve("/metrics", "guess"), ShouldEqual, http.StatusForbidden)
+				So(cacheStats.ServeHTTPCalls(), ShouldBeEmpty)
+				So(metricsHandler.ServeHTTPCalls(), ShouldBeEmpty)
+			})
+
+			Convey("Then internal clients are served", func() {
+				So(serve("/status", "s3cret"), ShouldEqual, http.StatusOK)
+				So(serve("/metrics", "s3cret"), ShouldEqual, http.StatusOK)
+				So(cacheStats.ServeHTTPCalls(), ShouldHaveLength, 1)
+				So(metricsHandler.ServeHTTPCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	Convey("Given census atlas routes are enabled without a census atlas handler", t, func() {
+		cfg := router.Config{CensusAtlasEnabled: true}
+
+		Convey("Then validation returns an error", func() {
+			So(cfg.Validate(), ShouldBeError, "census atlas routes are enabled but no census atlas handler is provided")
+		})
+	})
+
+	Convey("Given census atlas routes are enabled with a census atlas handler", t, func() {
+		cfg := router.Config{CensusAtlasEnabled: true, CensusAtlasHandler: NewHandlerMock()}
+
+		Convey("Then validation succeeds", func() {
+			So(cfg.Validate(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given census atlas routes are disabled without a census atlas handler", t, func() {
+		cfg := router.Config{}
+
+		Convey("Then validation succeeds", func() {
+			So(cfg.Validate(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a route table that refers to a backend that is not provided", t, func() {
+		cfg := router.Config{RouteTable: &router.RouteTable{Routes: []router.TableRoute{{Path: "/economy", Backend: "search"}}}}
+
+		Convey("Then validation returns an error", func() {
+			So(cfg.Validate(), ShouldBeError, `route table entry 1 for "/economy" has an unknown backend "search"`)
+		})
 	})
 }
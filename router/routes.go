@@ -0,0 +1,177 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-frontend-router/handlers/canary"
+	"github.com/ONSdigital/dp-frontend-router/handlers/shadow"
+	"github.com/ONSdigital/dp-frontend-router/middleware/flagusage"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+// Names given to routes that have no path template
+const (
+	fileExtRouteName              = "file extension matcher"
+	knownBabbageEndpointRouteName = "known babbage endpoint matcher"
+	cdnAssetRouteName             = "cdn asset prefix matcher"
+)
+
+// RoutingTable describes the routes of r in the order that they are matched, one line per route. Routes within a
+// subrouter are indented below the route that holds it. Paths handled by middleware, such as /health, are not included.
+func RoutingTable(r *mux.Router) ([]string, error) {
+	var table []string
+	err := r.Walk(func(route *mux.Route, _ *mux.Router, ancestors []*mux.Route) error {
+		table = append(table, fmt.Sprintf("%3d %s%s", len(table)+1, strings.Repeat("  ", len(ancestors)), describeRoute(route)))
+		return nil
+	})
+	return table, err
+}
+
+func describeRoute(route *mux.Route) string {
+	var parts []string
+	if tpl, err := route.GetPathTemplate(); err == nil {
+		parts = append(parts, tpl)
+	}
+	if name := route.GetName(); name != "" {
+		parts = append(parts, "("+name+")")
+	}
+	if len(parts) == 0 {
+		return "(unnamed matcher)"
+	}
+	return strings.Join(parts, " ")
+}
+
+// RouteInfo describes a route registered by New, and the backend that serves it
+type RouteInfo struct {
+	Path        string `json:"path"`
+	Backend     string `json:"backend"`
+	FeatureFlag string `json:"feature_flag,omitempty"`
+	Enabled     bool   `json:"enabled"`
+	// Canary is the name of the canary serving a share of the route's visitors, if any
+	Canary        string `json:"canary,omitempty"`
+	CanaryPercent int    `json:"canary_percent,omitempty"`
+	// Shadow is the name of the shadow that a share of the route's requests are mirrored to, if any
+	Shadow        string `json:"shadow,omitempty"`
+	ShadowPercent int    `json:"shadow_percent,omitempty"`
+}
+
+// Router is the handler created by New. It can describe the routes that it was created with.
+type Router struct {
+	http.Handler
+	routes []RouteInfo
+}
+
+// Routes returns the routes of r in the order that they are matched. Routes behind a disabled feature flag are
+// included, so that it is clear why their requests fall through to a later route.
+func (r *Router) Routes() []RouteInfo {
+	return r.routes
+}
+
+// RoutesHandler serves the routes of the router that s is currently serving requests with, as JSON
+func RoutesHandler(s *SwapHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		b, err := json.Marshal(map[string]interface{}{"routes": s.Router().Routes()})
+		if err != nil {
+			log.Error(req.Context(), "error marshalling routes", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(b); err != nil {
+			log.Error(req.Context(), "error writing response", err)
+		}
+	}
+}
+
+// routes registers routes on a mux router, recording the backend and feature flag of each. A route that has a canary
+// is served by the canary for the canary's share of visitors. A route that has a shadow mirrors the shadow's share of
+// its requests to it.
+type routes struct {
+	router        *mux.Router
+	flagged       *flagusage.Recorder
+	canaries      []canary.Canary
+	idCookie      string
+	proxies       []netip.Prefix
+	shadows       []shadow.Shadow
+	shadowTimeout time.Duration
+	used          map[string]bool
+	shadowed      map[string]bool
+	info          []RouteInfo
+}
+
+func (r *routes) handle(path, backend string, h http.Handler) {
+	info := RouteInfo{Path: path, Backend: backend, Enabled: true}
+	r.router.Handle(path, r.withShadow(&info, r.withCanary(&info, h)))
+	r.info = append(r.info, info)
+}
+
+func (r *routes) handleFlagged(flag string, enabled bool, path, backend string, h http.Handler) {
+	info := RouteInfo{Path: path, Backend: backend, FeatureFlag: flag, Enabled: enabled}
+	r.flagged.Handle(r.router, flag, enabled, path, r.withShadow(&info, r.withCanary(&info, h)))
+	r.info = append(r.info, info)
+}
+
+// withCanary wraps h in the canary for the route described by info, if it has one
+func (r *routes) withCanary(info *RouteInfo, h http.Handler) http.Handler {
+	for _, c := range r.canaries {
+		if c.Path != info.Path {
+			continue
+		}
+		if r.used == nil {
+			r.used = make(map[string]bool)
+		}
+		r.used[c.Name] = true
+		info.Canary, info.CanaryPercent = c.Name, c.Percent
+		return canary.Handler(c, h, r.idCookie, r.proxies)
+	}
+	return h
+}
+
+// withShadow wraps h in the shadow for the route described by info, if it has one, so that requests are mirrored
+// whichever of the route's handler or its canary serves them
+func (r *routes) withShadow(info *RouteInfo, h http.Handler) http.Handler {
+	for _, s := range r.shadows {
+		if s.Path != info.Path {
+			continue
+		}
+		if r.shadowed == nil {
+			r.shadowed = make(map[string]bool)
+		}
+		r.shadowed[s.Name] = true
+		info.Shadow, info.ShadowPercent = s.Name, s.Percent
+		return shadow.Handler(s, h, r.shadowTimeout)
+	}
+	return h
+}
+
+// warnUnusedCanaries logs the canaries whose path is not the path template of any route, as they will never be served
+func (r *routes) warnUnusedCanaries() {
+	for _, c := range r.canaries {
+		if !r.used[c.Name] {
+			log.Warn(context.Background(), "canary does not match any route", log.Data{"canary": c.Name, "path": c.Path})
+		}
+	}
+}
+
+// warnUnusedShadows logs the shadows whose path is not the path template of any route, as they will never be mirrored to
+func (r *routes) warnUnusedShadows() {
+	for _, s := range r.shadows {
+		if !r.shadowed[s.Name] {
+			log.Warn(context.Background(), "shadow does not match any route", log.Data{"shadow": s.Name, "path": s.Path})
+		}
+	}
+}
+
+// match registers a route without a path template, described by its name
+func (r *routes) match(name string, matcher mux.MatcherFunc, backend string, h http.Handler) {
+	r.router.MatcherFunc(matcher).Handler(h).Name(name)
+	r.info = append(r.info, RouteInfo{Path: "(" + name + ")", Backend: backend, Enabled: true})
+}
@@ -0,0 +1,114 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/router"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRoutingTable(t *testing.T) {
+	Convey("Given a mux router with routes, matchers and a subrouter", t, func() {
+		r := mux.NewRouter()
+		r.Handle("/", NewHandlerMock())
+		r.Handle("/datasets/{uri:.*}", NewHandlerMock())
+		r.MatcherFunc(func(*http.Request, *mux.RouteMatch) bool { return false }).Handler(NewHandlerMock()).Name("a matcher")
+		sub := r.PathPrefix("/").Subrouter()
+		sub.PathPrefix("/").Handler(NewHandlerMock())
+
+		Convey("When the routing table is built", func() {
+			table, err := router.RoutingTable(r)
+
+			Convey("Then the routes are listed in the order they are matched, with subrouter routes indented", func() {
+				So(err, ShouldBeNil)
+				So(table, ShouldResemble, []string{
+					"  1 /",
+					"  2 /datasets/{uri:.*}",
+					"  3 (a matcher)",
+					"  4 /",
+					"  5   /",
+				})
+			})
+		})
+	})
+
+	Convey("Given the router is configured to write its routing table to file", t, func() {
+		file := filepath.Join(t.TempDir(), "routes.txt")
+		router.New(router.Config{
+			SearchRoutesEnabled: true,
+			RoutingTableFile:    file,
+		})
+
+		Convey("When the file is read", func() {
+			b, err := os.ReadFile(file)
+			So(err, ShouldBeNil)
+			table := string(b)
+
+			Convey("Then feature routes precede the file extension matcher, which precedes the known Babbage matcher and the catch-all", func() {
+				search := strings.Index(table, "/search\n")
+				fileExt := strings.Index(table, "(file extension matcher)")
+				knownBabbage := strings.Index(table, "(known babbage endpoint matcher)")
+				catchAll := strings.LastIndex(table, "/\n")
+
+				So(strings.HasPrefix(table, "  1 /\n"), ShouldBeTrue)
+				So(search, ShouldBeGreaterThan, 0)
+				So(fileExt, ShouldBeGreaterThan, search)
+				So(knownBabbage, ShouldBeGreaterThan, fileExt)
+				So(catchAll, ShouldBeGreaterThan, knownBabbage)
+			})
+		})
+	})
+}
+
+func TestRoutes(t *testing.T) {
+	Convey("Given a router with a route table, an enabled and a disabled feature flag", t, func() {
+		r := router.New(router.Config{
+			SearchRoutesEnabled: true,
+			RouteTable: &router.RouteTable{
+				FeatureFlags: map[string]bool{"new_topic_pages": false},
+				Routes:       []router.TableRoute{{Path: "/topics/{uri:.*}", Backend: "search", FeatureFlag: "new_topic_pages"}},
+			},
+			Backends: map[string]http.Handler{"search": NewHandlerMock()},
+		})
+
+		Convey("When its routes are listed", func() {
+			routes := r.Routes()
+
+			Convey("Then each route is listed in the order it is matched, with its backend and feature flag", func() {
+				So(routes[0], ShouldResemble, router.RouteInfo{Path: "/", Backend: "homepage", Enabled: true})
+				So(routes[1], ShouldResemble, router.RouteInfo{
+					Path: "/topics/{uri:.*}", Backend: "search", FeatureFlag: "new_topic_pages", Enabled: false,
+				})
+				So(routes, ShouldContain, router.RouteInfo{Path: "/search", Backend: "search", FeatureFlag: "search_routes", Enabled: true})
+				So(routes, ShouldContain, router.RouteInfo{
+					Path: "/census/maps{uri:(?:/.*)?}", Backend: "censusAtlas", FeatureFlag: "census_atlas", Enabled: false,
+				})
+				So(routes, ShouldContain, router.RouteInfo{Path: "(file extension matcher)", Backend: "babbage", Enabled: true})
+				So(routes[len(routes)-1].Path, ShouldEqual, "/")
+			})
+		})
+
+		Convey("When the routes endpoint is requested", func() {
+			res := httptest.NewRecorder()
+			router.RoutesHandler(router.NewSwapHandler(r))(res, httptest.NewRequest("GET", "/routes", http.NoBody))
+
+			Convey("Then the routes are returned as JSON", func() {
+				So(res.Code, ShouldEqual, http.StatusOK)
+				So(res.Header().Get("Content-Type"), ShouldEqual, "application/json")
+
+				var body struct {
+					Routes []router.RouteInfo `json:"routes"`
+				}
+				So(json.Unmarshal(res.Body.Bytes(), &body), ShouldBeNil)
+				So(body.Routes, ShouldResemble, r.Routes())
+			})
+		})
+	})
+}
@@ -0,0 +1,33 @@
+package router
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// SwapHandler serves requests with a router that can be replaced while running, e.g. with a router rebuilt from a
+// reloaded config. Requests that are in flight when the router is swapped complete on the router they started with.
+type SwapHandler struct {
+	current atomic.Pointer[Router]
+}
+
+// NewSwapHandler creates a SwapHandler that serves requests with r until it is swapped
+func NewSwapHandler(r *Router) *SwapHandler {
+	s := &SwapHandler{}
+	s.Swap(r)
+	return s
+}
+
+// Swap replaces the router that new requests are served with
+func (s *SwapHandler) Swap(r *Router) {
+	s.current.Store(r)
+}
+
+// Router returns the router that new requests are served with
+func (s *SwapHandler) Router() *Router {
+	return s.current.Load()
+}
+
+func (s *SwapHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.current.Load().ServeHTTP(w, req)
+}
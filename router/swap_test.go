@@ -0,0 +1,62 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/router"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSwapHandler(t *testing.T) {
+	Convey("Given a swap handler", t, func() {
+		first, second := NewHandlerMock(), NewHandlerMock()
+		swap := router.NewSwapHandler(&router.Router{Handler: first})
+
+		Convey("When a request is made", func() {
+			swap.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/economy", http.NoBody))
+
+			Convey("Then it is served by the initial handler", func() {
+				So(len(first.ServeHTTPCalls()), ShouldEqual, 1)
+				So(len(second.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the handler is swapped and a request is made", func() {
+			swap.Swap(&router.Router{Handler: second})
+			swap.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/economy", http.NoBody))
+
+			Convey("Then it is served by the new handler", func() {
+				So(len(first.ServeHTTPCalls()), ShouldEqual, 0)
+				So(len(second.ServeHTTPCalls()), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the handler is swapped while a request is in flight", func() {
+			started, release := make(chan struct{}), make(chan struct{})
+			blocking := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				close(started)
+				<-release
+				w.WriteHeader(http.StatusTeapot)
+			})
+			swap.Swap(&router.Router{Handler: blocking})
+
+			res := httptest.NewRecorder()
+			done := make(chan struct{})
+			go func() {
+				swap.ServeHTTP(res, httptest.NewRequest("GET", "/economy", http.NoBody))
+				close(done)
+			}()
+			<-started
+			swap.Swap(&router.Router{Handler: second})
+			close(release)
+			<-done
+
+			Convey("Then the in-flight request completes on the handler it started with", func() {
+				So(res.Code, ShouldEqual, http.StatusTeapot)
+				So(len(second.ServeHTTPCalls()), ShouldEqual, 0)
+			})
+		})
+	})
+}
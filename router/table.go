@@ -0,0 +1,79 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v3"
+)
+
+// RouteTable is a set of routes loaded from file, so that routes can be added or changed without a code change. e.g.
+//
+//	feature_flags:
+//	  new_topic_pages: true
+//	routes:
+//	  - path: /topics/{uri:.*}
+//	    backend: search
+//	    feature_flag: new_topic_pages
+type RouteTable struct {
+	FeatureFlags map[string]bool `yaml:"feature_flags"`
+	Routes       []TableRoute    `yaml:"routes"`
+}
+
+// TableRoute sends requests matching the mux path template to the named backend. A route with a feature flag is only
+// registered while the flag is enabled.
+type TableRoute struct {
+	Path        string `yaml:"path"`
+	Backend     string `yaml:"backend"`
+	FeatureFlag string `yaml:"feature_flag"`
+}
+
+// LoadRouteTable reads a route table from a YAML file. Unknown keys are rejected so that typos are not silently ignored.
+func LoadRouteTable(file string) (*RouteTable, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var table RouteTable
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&table); err != nil {
+		return nil, fmt.Errorf("invalid route table %s: %w", file, err)
+	}
+	return &table, nil
+}
+
+// Validate returns an error if a route has an invalid path template, or refers to a backend or feature flag that does
+// not exist
+func (t *RouteTable) Validate(backends map[string]http.Handler) error {
+	for i, route := range t.Routes {
+		if route.Path == "" {
+			return fmt.Errorf("route table entry %d has no path", i+1)
+		}
+		if err := mux.NewRouter().Path(route.Path).GetError(); err != nil {
+			return fmt.Errorf("route table entry %d has an invalid path %q: %w", i+1, route.Path, err)
+		}
+		if backends[route.Backend] == nil {
+			return fmt.Errorf("route table entry %d for %q has an unknown backend %q", i+1, route.Path, route.Backend)
+		}
+		if _, ok := t.FeatureFlags[route.FeatureFlag]; route.FeatureFlag != "" && !ok {
+			return fmt.Errorf("route table entry %d for %q has an unknown feature flag %q", i+1, route.Path, route.FeatureFlag)
+		}
+	}
+	return nil
+}
+
+// register adds the routes of the table to r, through the usage recorder for routes behind a feature flag
+func (t *RouteTable) register(r *routes, backends map[string]http.Handler) {
+	for _, route := range t.Routes {
+		if route.FeatureFlag != "" {
+			r.handleFlagged(route.FeatureFlag, t.FeatureFlags[route.FeatureFlag], route.Path, route.Backend, backends[route.Backend])
+			continue
+		}
+		r.handle(route.Path, route.Backend, backends[route.Backend])
+	}
+}
@@ -0,0 +1,113 @@
+package router_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ONSdigital/dp-frontend-router/router"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func writeRouteTable(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "routes.yaml")
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadRouteTable(t *testing.T) {
+	Convey("Given a route table file", t, func() {
+		file := writeRouteTable(t, `
+feature_flags:
+  new_topic_pages: true
+routes:
+  - path: /topics/{uri:.*}
+    backend: search
+    feature_flag: new_topic_pages
+  - path: /economy
+    backend: babbage
+`)
+
+		Convey("When it is loaded", func() {
+			table, err := router.LoadRouteTable(file)
+
+			Convey("Then the feature flags and routes are returned in order", func() {
+				So(err, ShouldBeNil)
+				So(table.FeatureFlags, ShouldResemble, map[string]bool{"new_topic_pages": true})
+				So(table.Routes, ShouldResemble, []router.TableRoute{
+					{Path: "/topics/{uri:.*}", Backend: "search", FeatureFlag: "new_topic_pages"},
+					{Path: "/economy", Backend: "babbage"},
+				})
+			})
+		})
+	})
+
+	Convey("Given a route table file with a misspelt key", t, func() {
+		file := writeRouteTable(t, "routes:\n  - path: /economy\n    backnd: babbage\n")
+
+		Convey("Then loading it returns an error", func() {
+			_, err := router.LoadRouteTable(file)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a route table file that does not exist", t, func() {
+		Convey("Then loading it returns an error", func() {
+			_, err := router.LoadRouteTable(filepath.Join(t.TempDir(), "missing.yaml"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestRouteTableValidate(t *testing.T) {
+	backends := map[string]http.Handler{"search": NewHandlerMock()}
+
+	Convey("Given a route table of known backends and feature flags", t, func() {
+		table := router.RouteTable{
+			FeatureFlags: map[string]bool{"new_topic_pages": false},
+			Routes: []router.TableRoute{
+				{Path: "/topics/{uri:.*}", Backend: "search", FeatureFlag: "new_topic_pages"},
+				{Path: "/economy", Backend: "search"},
+			},
+		}
+
+		Convey("Then validation succeeds", func() {
+			So(table.Validate(backends), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a route table with an unknown backend", t, func() {
+		table := router.RouteTable{Routes: []router.TableRoute{{Path: "/economy", Backend: "zebedee"}}}
+
+		Convey("Then validation returns an error", func() {
+			So(table.Validate(backends), ShouldBeError, `route table entry 1 for "/economy" has an unknown backend "zebedee"`)
+		})
+	})
+
+	Convey("Given a route table with an unknown feature flag", t, func() {
+		table := router.RouteTable{Routes: []router.TableRoute{{Path: "/economy", Backend: "search", FeatureFlag: "economy"}}}
+
+		Convey("Then validation returns an error", func() {
+			So(table.Validate(backends), ShouldBeError, `route table entry 1 for "/economy" has an unknown feature flag "economy"`)
+		})
+	})
+
+	Convey("Given a route table with an invalid path template", t, func() {
+		table := router.RouteTable{Routes: []router.TableRoute{{Path: "/economy/{uri", Backend: "search"}}}
+
+		Convey("Then validation returns an error", func() {
+			So(table.Validate(backends), ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a route table with an entry that has no path", t, func() {
+		table := router.RouteTable{Routes: []router.TableRoute{{Backend: "search"}}}
+
+		Convey("Then validation returns an error", func() {
+			So(table.Validate(backends), ShouldBeError, "route table entry 1 has no path")
+		})
+	})
+}
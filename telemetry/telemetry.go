@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// Config is the OpenTelemetry set up. Zero batch sizes leave the SDK default in place.
+type Config struct {
+	ServiceName string
+	// Endpoint is the host and port of the OTLP gRPC collector
+	Endpoint string
+	// Headers are sent with every export, e.g. to authenticate with a hosted collector
+	Headers map[string]string
+	// Insecure exports without TLS, as to a collector running alongside the router
+	Insecure bool
+	// SampleRatio is the fraction of traces started by the router that are sampled, from 0 to 1. Traces started
+	// upstream keep the sampling decision of their parent.
+	SampleRatio        float64
+	BatchTimeout       time.Duration
+	MaxQueueSize       int
+	MaxExportBatchSize int
+}
+
+// Setup exports traces to the configured collector, and installs the tracer provider and propagators globally. The
+// returned function flushes any spans still queued and must be called on shutdown.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	exporter, err := otlptracegrpc.New(ctx, exporterOptions(cfg)...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(Sampler(cfg.SampleRatio)),
+		sdktrace.WithBatcher(exporter, batchOptions(cfg)...),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return tracerProvider.Shutdown, nil
+}
+
+// Sampler samples ratio of the traces that start at the router, and follows the decision of the parent span for the
+// rest. A ratio of 1 or more samples every trace, and 0 or less none.
+func Sampler(ratio float64) sdktrace.Sampler {
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+func exporterOptions(cfg Config) []otlptracegrpc.Option {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+	}
+	return opts
+}
+
+func batchOptions(cfg Config) []sdktrace.BatchSpanProcessorOption {
+	var opts []sdktrace.BatchSpanProcessorOption
+	if cfg.BatchTimeout > 0 {
+		opts = append(opts, sdktrace.WithBatchTimeout(cfg.BatchTimeout))
+	}
+	if cfg.MaxQueueSize > 0 {
+		opts = append(opts, sdktrace.WithMaxQueueSize(cfg.MaxQueueSize))
+	}
+	if cfg.MaxExportBatchSize > 0 {
+		opts = append(opts, sdktrace.WithMaxExportBatchSize(cfg.MaxExportBatchSize))
+	}
+	return opts
+}
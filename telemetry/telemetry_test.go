@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSampler(t *testing.T) {
+	traceID := trace.TraceID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	parent := func(flags trace.TraceFlags) context.Context {
+		return trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     trace.SpanID{1},
+			TraceFlags: flags,
+			Remote:     true,
+		}))
+	}
+	decision := func(sampler sdktrace.Sampler, ctx context.Context) sdktrace.SamplingDecision {
+		return sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: ctx, TraceID: traceID, Name: "/"}).Decision
+	}
+
+	Convey("Given a sampler for none of the traces started by the router", t, func() {
+		sampler := Sampler(0)
+
+		Convey("Then a trace started by the router is not sampled", func() {
+			So(decision(sampler, context.Background()), ShouldEqual, sdktrace.Drop)
+		})
+
+		Convey("Then a trace whose parent was sampled upstream is sampled", func() {
+			So(decision(sampler, parent(trace.FlagsSampled)), ShouldEqual, sdktrace.RecordAndSample)
+		})
+	})
+
+	Convey("Given a sampler for all of the traces started by the router", t, func() {
+		sampler := Sampler(1)
+
+		Convey("Then a trace started by the router is sampled", func() {
+			So(decision(sampler, context.Background()), ShouldEqual, sdktrace.RecordAndSample)
+		})
+
+		Convey("Then a trace whose parent was not sampled upstream is not sampled", func() {
+			So(decision(sampler, parent(0)), ShouldEqual, sdktrace.Drop)
+		})
+	})
+}
+
+func TestBatchOptions(t *testing.T) {
+	Convey("Given no batch settings", t, func() {
+		Convey("Then the SDK defaults are left in place", func() {
+			So(batchOptions(Config{}), ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a batch timeout, queue size and export batch size", t, func() {
+		cfg := Config{BatchTimeout: time.Second, MaxQueueSize: 4096, MaxExportBatchSize: 1024}
+
+		Convey("Then each of them is applied", func() {
+			So(batchOptions(cfg), ShouldHaveLength, 3)
+		})
+	})
+}
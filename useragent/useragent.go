@@ -0,0 +1,52 @@
+package useragent
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+)
+
+// Header is the name of the User-Agent header
+const Header = "User-Agent"
+
+// Set identifies req as sent by the router with userAgent. If req already has a User-Agent, such as that of the browser
+// a proxied request came from, or one set by a client for a specific reason, userAgent is appended to it as a further
+// product rather than replacing it.
+func Set(req *http.Request, userAgent string) {
+	if userAgent == "" {
+		return
+	}
+
+	existing := req.Header.Get(Header)
+	switch {
+	case existing == "":
+		req.Header.Set(Header, userAgent)
+	case !strings.Contains(existing, userAgent):
+		req.Header.Set(Header, existing+" "+userAgent)
+	}
+}
+
+var _ dphttp.Clienter = &Clienter{}
+
+// Clienter wraps a dp-net Clienter, identifying each request that it sends by a User-Agent
+type Clienter struct {
+	dphttp.Clienter
+	userAgent string
+}
+
+// NewClienter wraps clienter so that the requests it sends are identified by userAgent. If userAgent is empty,
+// clienter is returned as it is.
+func NewClienter(clienter dphttp.Clienter, userAgent string) dphttp.Clienter {
+	if userAgent == "" {
+		return clienter
+	}
+	return &Clienter{Clienter: clienter, userAgent: userAgent}
+}
+
+// Do sets the User-Agent of req and sends it with the wrapped Clienter
+func (c *Clienter) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	Set(req, c.userAgent)
+	return c.Clienter.Do(ctx, req)
+}
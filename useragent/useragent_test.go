@@ -0,0 +1,81 @@
+package useragent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dphttp "github.com/ONSdigital/dp-net/v2/http"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const routerUserAgent = "dp-frontend-router/v1.2.3"
+
+func newClienterMock() *dphttp.ClienterMock {
+	return &dphttp.ClienterMock{
+		DoFunc: func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		},
+		SetTimeoutFunc: func(timeout time.Duration) {},
+	}
+}
+
+func TestSet(t *testing.T) {
+	Convey("Given a request without a User-Agent", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+		Convey("Then the router's User-Agent is set", func() {
+			Set(req, routerUserAgent)
+			So(req.Header.Get(Header), ShouldEqual, routerUserAgent)
+		})
+	})
+
+	Convey("Given a request with a browser's User-Agent", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.Header.Set(Header, "Mozilla/5.0")
+
+		Convey("Then the router's User-Agent is appended, once, without clobbering the existing one", func() {
+			Set(req, routerUserAgent)
+			Set(req, routerUserAgent)
+			So(req.Header.Get(Header), ShouldEqual, "Mozilla/5.0 "+routerUserAgent)
+		})
+	})
+
+	Convey("Given no User-Agent is configured", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+		Convey("Then the request is left as it is", func() {
+			Set(req, "")
+			So(req.Header.Values(Header), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestClienter(t *testing.T) {
+	Convey("Given a Clienter wrapped with the router's User-Agent", t, func() {
+		inner := newClienterMock()
+		clienter := NewClienter(inner, routerUserAgent)
+
+		Convey("When requests are sent through it", func() {
+			clienter.SetTimeout(time.Second)
+			_, err := clienter.Do(context.Background(), httptest.NewRequest(http.MethodGet, "/data?uri=/", http.NoBody))
+			So(err, ShouldBeNil)
+
+			Convey("Then each outbound request has the User-Agent, and other calls reach the wrapped Clienter", func() {
+				So(inner.DoCalls(), ShouldHaveLength, 1)
+				So(inner.DoCalls()[0].Req.Header.Get(Header), ShouldEqual, routerUserAgent)
+				So(inner.SetTimeoutCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a Clienter wrapped without a User-Agent", t, func() {
+		inner := newClienterMock()
+
+		Convey("Then the Clienter is returned as it is", func() {
+			So(NewClienter(inner, ""), ShouldEqual, inner)
+		})
+	})
+}